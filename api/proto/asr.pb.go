@@ -172,14 +172,15 @@ func (x *StreamingConfig) GetLanguageCodes() []string {
 }
 
 type ASRResponse struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	SessionId        string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	Transcript       string                 `protobuf:"bytes,2,opt,name=transcript,proto3" json:"transcript,omitempty"`
-	IsFinal          bool                   `protobuf:"varint,3,opt,name=is_final,json=isFinal,proto3" json:"is_final,omitempty"`
-	Stability        float32                `protobuf:"fixed32,4,opt,name=stability,proto3" json:"stability,omitempty"`
-	DetectedLanguage string                 `protobuf:"bytes,5,opt,name=detected_language,json=detectedLanguage,proto3" json:"detected_language,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	SessionId         string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Transcript        string                 `protobuf:"bytes,2,opt,name=transcript,proto3" json:"transcript,omitempty"`
+	IsFinal           bool                   `protobuf:"varint,3,opt,name=is_final,json=isFinal,proto3" json:"is_final,omitempty"`
+	Stability         float32                `protobuf:"fixed32,4,opt,name=stability,proto3" json:"stability,omitempty"`
+	DetectedLanguage  string                 `protobuf:"bytes,5,opt,name=detected_language,json=detectedLanguage,proto3" json:"detected_language,omitempty"`
+	ResultEndOffsetMs int64                  `protobuf:"varint,6,opt,name=result_end_offset_ms,json=resultEndOffsetMs,proto3" json:"result_end_offset_ms,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
 }
 
 func (x *ASRResponse) Reset() {
@@ -247,6 +248,13 @@ func (x *ASRResponse) GetDetectedLanguage() string {
 	return ""
 }
 
+func (x *ASRResponse) GetResultEndOffsetMs() int64 {
+	if x != nil {
+		return x.ResultEndOffsetMs
+	}
+	return 0
+}
+
 var File_asr_proto protoreflect.FileDescriptor
 
 const file_asr_proto_rawDesc = "" +
@@ -261,7 +269,7 @@ const file_asr_proto_rawDesc = "" +
 	"\asession\x18\x01 \x01(\v2\x16.api.proto.SessionInfoR\asession\x12@\n" +
 	"\x1cenable_automatic_punctuation\x18\x02 \x01(\bR\x1aenableAutomaticPunctuation\x12:\n" +
 	"\x19enable_language_detection\x18\x03 \x01(\bR\x17enableLanguageDetection\x12%\n" +
-	"\x0elanguage_codes\x18\x04 \x03(\tR\rlanguageCodes\"\xb2\x01\n" +
+	"\x0elanguage_codes\x18\x04 \x03(\tR\rlanguageCodes\"\xe3\x01\n" +
 	"\vASRResponse\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1e\n" +
@@ -270,7 +278,8 @@ const file_asr_proto_rawDesc = "" +
 	"transcript\x12\x19\n" +
 	"\bis_final\x18\x03 \x01(\bR\aisFinal\x12\x1c\n" +
 	"\tstability\x18\x04 \x01(\x02R\tstability\x12+\n" +
-	"\x11detected_language\x18\x05 \x01(\tR\x10detectedLanguage2U\n" +
+	"\x11detected_language\x18\x05 \x01(\tR\x10detectedLanguage\x12/\n" +
+	"\x14result_end_offset_ms\x18\x06 \x01(\x03R\x11resultEndOffsetMs2U\n" +
 	"\n" +
 	"ASRService\x12G\n" +
 	"\x12StreamingRecognize\x12\x15.api.proto.ASRRequest\x1a\x16.api.proto.ASRResponse(\x010\x01B\x1fZ\x1dai-translator/api/proto;protob\x06proto3"
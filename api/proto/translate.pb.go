@@ -21,6 +21,110 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type Formality int32
+
+const (
+	Formality_FORMALITY_AUTO     Formality = 0
+	Formality_FORMALITY_FORMAL   Formality = 1
+	Formality_FORMALITY_INFORMAL Formality = 2
+)
+
+// Enum value maps for Formality.
+var (
+	Formality_name = map[int32]string{
+		0: "FORMALITY_AUTO",
+		1: "FORMALITY_FORMAL",
+		2: "FORMALITY_INFORMAL",
+	}
+	Formality_value = map[string]int32{
+		"FORMALITY_AUTO":     0,
+		"FORMALITY_FORMAL":   1,
+		"FORMALITY_INFORMAL": 2,
+	}
+)
+
+func (x Formality) Enum() *Formality {
+	p := new(Formality)
+	*p = x
+	return p
+}
+
+func (x Formality) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Formality) Descriptor() protoreflect.EnumDescriptor {
+	return file_translate_proto_enumTypes[0].Descriptor()
+}
+
+func (Formality) Type() protoreflect.EnumType {
+	return &file_translate_proto_enumTypes[0]
+}
+
+func (x Formality) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Formality.Descriptor instead.
+func (Formality) EnumDescriptor() ([]byte, []int) {
+	return file_translate_proto_rawDescGZIP(), []int{0}
+}
+
+type Domain int32
+
+const (
+	Domain_DOMAIN_GENERAL          Domain = 0
+	Domain_DOMAIN_MEDICAL          Domain = 1
+	Domain_DOMAIN_LEGAL            Domain = 2
+	Domain_DOMAIN_CUSTOMER_SUPPORT Domain = 3
+	Domain_DOMAIN_TECHNICAL        Domain = 4
+)
+
+// Enum value maps for Domain.
+var (
+	Domain_name = map[int32]string{
+		0: "DOMAIN_GENERAL",
+		1: "DOMAIN_MEDICAL",
+		2: "DOMAIN_LEGAL",
+		3: "DOMAIN_CUSTOMER_SUPPORT",
+		4: "DOMAIN_TECHNICAL",
+	}
+	Domain_value = map[string]int32{
+		"DOMAIN_GENERAL":          0,
+		"DOMAIN_MEDICAL":          1,
+		"DOMAIN_LEGAL":            2,
+		"DOMAIN_CUSTOMER_SUPPORT": 3,
+		"DOMAIN_TECHNICAL":        4,
+	}
+)
+
+func (x Domain) Enum() *Domain {
+	p := new(Domain)
+	*p = x
+	return p
+}
+
+func (x Domain) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Domain) Descriptor() protoreflect.EnumDescriptor {
+	return file_translate_proto_enumTypes[1].Descriptor()
+}
+
+func (Domain) Type() protoreflect.EnumType {
+	return &file_translate_proto_enumTypes[1]
+}
+
+func (x Domain) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Domain.Descriptor instead.
+func (Domain) EnumDescriptor() ([]byte, []int) {
+	return file_translate_proto_rawDescGZIP(), []int{1}
+}
+
 type TranslateRequest struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	SessionId      string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
@@ -28,6 +132,7 @@ type TranslateRequest struct {
 	SourceLanguage string                 `protobuf:"bytes,3,opt,name=source_language,json=sourceLanguage,proto3" json:"source_language,omitempty"`
 	TargetLanguage string                 `protobuf:"bytes,4,opt,name=target_language,json=targetLanguage,proto3" json:"target_language,omitempty"`
 	IsFinal        bool                   `protobuf:"varint,5,opt,name=is_final,json=isFinal,proto3" json:"is_final,omitempty"`
+	Style          *TranslationStyle      `protobuf:"bytes,6,opt,name=style,proto3" json:"style,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -97,6 +202,73 @@ func (x *TranslateRequest) GetIsFinal() bool {
 	return false
 }
 
+func (x *TranslateRequest) GetStyle() *TranslationStyle {
+	if x != nil {
+		return x.Style
+	}
+	return nil
+}
+
+type TranslationStyle struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Formality     Formality              `protobuf:"varint,1,opt,name=formality,proto3,enum=api.proto.Formality" json:"formality,omitempty"`
+	Domain        Domain                 `protobuf:"varint,2,opt,name=domain,proto3,enum=api.proto.Domain" json:"domain,omitempty"`
+	Audience      string                 `protobuf:"bytes,3,opt,name=audience,proto3" json:"audience,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TranslationStyle) Reset() {
+	*x = TranslationStyle{}
+	mi := &file_translate_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TranslationStyle) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TranslationStyle) ProtoMessage() {}
+
+func (x *TranslationStyle) ProtoReflect() protoreflect.Message {
+	mi := &file_translate_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TranslationStyle.ProtoReflect.Descriptor instead.
+func (*TranslationStyle) Descriptor() ([]byte, []int) {
+	return file_translate_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TranslationStyle) GetFormality() Formality {
+	if x != nil {
+		return x.Formality
+	}
+	return Formality_FORMALITY_AUTO
+}
+
+func (x *TranslationStyle) GetDomain() Domain {
+	if x != nil {
+		return x.Domain
+	}
+	return Domain_DOMAIN_GENERAL
+}
+
+func (x *TranslationStyle) GetAudience() string {
+	if x != nil {
+		return x.Audience
+	}
+	return ""
+}
+
 type TranslateResponse struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	SessionId      string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
@@ -104,13 +276,14 @@ type TranslateResponse struct {
 	SourceLanguage string                 `protobuf:"bytes,3,opt,name=source_language,json=sourceLanguage,proto3" json:"source_language,omitempty"`
 	TargetLanguage string                 `protobuf:"bytes,4,opt,name=target_language,json=targetLanguage,proto3" json:"target_language,omitempty"`
 	IsFinal        bool                   `protobuf:"varint,5,opt,name=is_final,json=isFinal,proto3" json:"is_final,omitempty"`
+	Engine         string                 `protobuf:"bytes,6,opt,name=engine,proto3" json:"engine,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
 
 func (x *TranslateResponse) Reset() {
 	*x = TranslateResponse{}
-	mi := &file_translate_proto_msgTypes[1]
+	mi := &file_translate_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -122,7 +295,7 @@ func (x *TranslateResponse) String() string {
 func (*TranslateResponse) ProtoMessage() {}
 
 func (x *TranslateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_translate_proto_msgTypes[1]
+	mi := &file_translate_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -135,7 +308,7 @@ func (x *TranslateResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TranslateResponse.ProtoReflect.Descriptor instead.
 func (*TranslateResponse) Descriptor() ([]byte, []int) {
-	return file_translate_proto_rawDescGZIP(), []int{1}
+	return file_translate_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *TranslateResponse) GetSessionId() string {
@@ -173,25 +346,48 @@ func (x *TranslateResponse) GetIsFinal() bool {
 	return false
 }
 
+func (x *TranslateResponse) GetEngine() string {
+	if x != nil {
+		return x.Engine
+	}
+	return ""
+}
+
 var File_translate_proto protoreflect.FileDescriptor
 
 const file_translate_proto_rawDesc = "" +
 	"\n" +
-	"\x0ftranslate.proto\x12\tapi.proto\x1a\fcommon.proto\"\xb2\x01\n" +
+	"\x0ftranslate.proto\x12\tapi.proto\x1a\fcommon.proto\"\xe5\x01\n" +
 	"\x10TranslateRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x12\n" +
 	"\x04text\x18\x02 \x01(\tR\x04text\x12'\n" +
 	"\x0fsource_language\x18\x03 \x01(\tR\x0esourceLanguage\x12'\n" +
 	"\x0ftarget_language\x18\x04 \x01(\tR\x0etargetLanguage\x12\x19\n" +
-	"\bis_final\x18\x05 \x01(\bR\aisFinal\"\xc8\x01\n" +
+	"\bis_final\x18\x05 \x01(\bR\aisFinal\x121\n" +
+	"\x05style\x18\x06 \x01(\v2\x1b.api.proto.TranslationStyleR\x05style\"\x8d\x01\n" +
+	"\x10TranslationStyle\x122\n" +
+	"\tformality\x18\x01 \x01(\x0e2\x14.api.proto.FormalityR\tformality\x12)\n" +
+	"\x06domain\x18\x02 \x01(\x0e2\x11.api.proto.DomainR\x06domain\x12\x1a\n" +
+	"\baudience\x18\x03 \x01(\tR\baudience\"\xe0\x01\n" +
 	"\x11TranslateResponse\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12'\n" +
 	"\x0ftranslated_text\x18\x02 \x01(\tR\x0etranslatedText\x12'\n" +
 	"\x0fsource_language\x18\x03 \x01(\tR\x0esourceLanguage\x12'\n" +
 	"\x0ftarget_language\x18\x04 \x01(\tR\x0etargetLanguage\x12\x19\n" +
-	"\bis_final\x18\x05 \x01(\bR\aisFinal2\xad\x01\n" +
+	"\bis_final\x18\x05 \x01(\bR\aisFinal\x12\x16\n" +
+	"\x06engine\x18\x06 \x01(\tR\x06engine*M\n" +
+	"\tFormality\x12\x12\n" +
+	"\x0eFORMALITY_AUTO\x10\x00\x12\x14\n" +
+	"\x10FORMALITY_FORMAL\x10\x01\x12\x16\n" +
+	"\x12FORMALITY_INFORMAL\x10\x02*u\n" +
+	"\x06Domain\x12\x12\n" +
+	"\x0eDOMAIN_GENERAL\x10\x00\x12\x12\n" +
+	"\x0eDOMAIN_MEDICAL\x10\x01\x12\x10\n" +
+	"\fDOMAIN_LEGAL\x10\x02\x12\x1b\n" +
+	"\x17DOMAIN_CUSTOMER_SUPPORT\x10\x03\x12\x14\n" +
+	"\x10DOMAIN_TECHNICAL\x10\x042\xad\x01\n" +
 	"\x11TranslatorService\x12F\n" +
 	"\tTranslate\x12\x1b.api.proto.TranslateRequest\x1a\x1c.api.proto.TranslateResponse\x12P\n" +
 	"\x0fStreamTranslate\x12\x1b.api.proto.TranslateRequest\x1a\x1c.api.proto.TranslateResponse(\x010\x01B\x1fZ\x1dai-translator/api/proto;protob\x06proto3"
@@ -208,21 +404,28 @@ func file_translate_proto_rawDescGZIP() []byte {
 	return file_translate_proto_rawDescData
 }
 
-var file_translate_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_translate_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_translate_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_translate_proto_goTypes = []any{
-	(*TranslateRequest)(nil),  // 0: api.proto.TranslateRequest
-	(*TranslateResponse)(nil), // 1: api.proto.TranslateResponse
+	(Formality)(0),            // 0: api.proto.Formality
+	(Domain)(0),               // 1: api.proto.Domain
+	(*TranslateRequest)(nil),  // 2: api.proto.TranslateRequest
+	(*TranslationStyle)(nil),  // 3: api.proto.TranslationStyle
+	(*TranslateResponse)(nil), // 4: api.proto.TranslateResponse
 }
 var file_translate_proto_depIdxs = []int32{
-	0, // 0: api.proto.TranslatorService.Translate:input_type -> api.proto.TranslateRequest
-	0, // 1: api.proto.TranslatorService.StreamTranslate:input_type -> api.proto.TranslateRequest
-	1, // 2: api.proto.TranslatorService.Translate:output_type -> api.proto.TranslateResponse
-	1, // 3: api.proto.TranslatorService.StreamTranslate:output_type -> api.proto.TranslateResponse
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	3, // 0: api.proto.TranslateRequest.style:type_name -> api.proto.TranslationStyle
+	0, // 1: api.proto.TranslationStyle.formality:type_name -> api.proto.Formality
+	1, // 2: api.proto.TranslationStyle.domain:type_name -> api.proto.Domain
+	2, // 3: api.proto.TranslatorService.Translate:input_type -> api.proto.TranslateRequest
+	2, // 4: api.proto.TranslatorService.StreamTranslate:input_type -> api.proto.TranslateRequest
+	4, // 5: api.proto.TranslatorService.Translate:output_type -> api.proto.TranslateResponse
+	4, // 6: api.proto.TranslatorService.StreamTranslate:output_type -> api.proto.TranslateResponse
+	5, // [5:7] is the sub-list for method output_type
+	3, // [3:5] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_translate_proto_init() }
@@ -236,13 +439,14 @@ func file_translate_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_translate_proto_rawDesc), len(file_translate_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   2,
+			NumEnums:      2,
+			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_translate_proto_goTypes,
 		DependencyIndexes: file_translate_proto_depIdxs,
+		EnumInfos:         file_translate_proto_enumTypes,
 		MessageInfos:      file_translate_proto_msgTypes,
 	}.Build()
 	File_translate_proto = out.File
@@ -21,12 +21,62 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type AudioEncoding int32
+
+const (
+	AudioEncoding_AUDIO_ENCODING_LINEAR16 AudioEncoding = 0
+	AudioEncoding_AUDIO_ENCODING_OGG_OPUS AudioEncoding = 1
+	AudioEncoding_AUDIO_ENCODING_MP3      AudioEncoding = 2
+)
+
+// Enum value maps for AudioEncoding.
+var (
+	AudioEncoding_name = map[int32]string{
+		0: "AUDIO_ENCODING_LINEAR16",
+		1: "AUDIO_ENCODING_OGG_OPUS",
+		2: "AUDIO_ENCODING_MP3",
+	}
+	AudioEncoding_value = map[string]int32{
+		"AUDIO_ENCODING_LINEAR16": 0,
+		"AUDIO_ENCODING_OGG_OPUS": 1,
+		"AUDIO_ENCODING_MP3":      2,
+	}
+)
+
+func (x AudioEncoding) Enum() *AudioEncoding {
+	p := new(AudioEncoding)
+	*p = x
+	return p
+}
+
+func (x AudioEncoding) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AudioEncoding) Descriptor() protoreflect.EnumDescriptor {
+	return file_tts_proto_enumTypes[0].Descriptor()
+}
+
+func (AudioEncoding) Type() protoreflect.EnumType {
+	return &file_tts_proto_enumTypes[0]
+}
+
+func (x AudioEncoding) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AudioEncoding.Descriptor instead.
+func (AudioEncoding) EnumDescriptor() ([]byte, []int) {
+	return file_tts_proto_rawDescGZIP(), []int{0}
+}
+
 type TTSRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
 	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
 	LanguageCode  string                 `protobuf:"bytes,3,opt,name=language_code,json=languageCode,proto3" json:"language_code,omitempty"`
 	VoiceConfig   *VoiceConfig           `protobuf:"bytes,4,opt,name=voice_config,json=voiceConfig,proto3" json:"voice_config,omitempty"`
+	Encoding      AudioEncoding          `protobuf:"varint,5,opt,name=encoding,proto3,enum=api.proto.AudioEncoding" json:"encoding,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -89,6 +139,13 @@ func (x *TTSRequest) GetVoiceConfig() *VoiceConfig {
 	return nil
 }
 
+func (x *TTSRequest) GetEncoding() AudioEncoding {
+	if x != nil {
+		return x.Encoding
+	}
+	return AudioEncoding_AUDIO_ENCODING_LINEAR16
+}
+
 type VoiceConfig struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	VoiceName     string                 `protobuf:"bytes,1,opt,name=voice_name,json=voiceName,proto3" json:"voice_name,omitempty"`
@@ -154,6 +211,8 @@ type TTSResponse struct {
 	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
 	Audio         *AudioChunk            `protobuf:"bytes,2,opt,name=audio,proto3" json:"audio,omitempty"`
 	IsFinal       bool                   `protobuf:"varint,3,opt,name=is_final,json=isFinal,proto3" json:"is_final,omitempty"`
+	VoiceName     string                 `protobuf:"bytes,4,opt,name=voice_name,json=voiceName,proto3" json:"voice_name,omitempty"`
+	VoiceTier     string                 `protobuf:"bytes,5,opt,name=voice_tier,json=voiceTier,proto3" json:"voice_tier,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -209,28 +268,51 @@ func (x *TTSResponse) GetIsFinal() bool {
 	return false
 }
 
+func (x *TTSResponse) GetVoiceName() string {
+	if x != nil {
+		return x.VoiceName
+	}
+	return ""
+}
+
+func (x *TTSResponse) GetVoiceTier() string {
+	if x != nil {
+		return x.VoiceTier
+	}
+	return ""
+}
+
 var File_tts_proto protoreflect.FileDescriptor
 
 const file_tts_proto_rawDesc = "" +
 	"\n" +
-	"\ttts.proto\x12\tapi.proto\x1a\fcommon.proto\"\x9f\x01\n" +
+	"\ttts.proto\x12\tapi.proto\x1a\fcommon.proto\"\xd5\x01\n" +
 	"\n" +
 	"TTSRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x12\n" +
 	"\x04text\x18\x02 \x01(\tR\x04text\x12#\n" +
 	"\rlanguage_code\x18\x03 \x01(\tR\flanguageCode\x129\n" +
-	"\fvoice_config\x18\x04 \x01(\v2\x16.api.proto.VoiceConfigR\vvoiceConfig\"g\n" +
+	"\fvoice_config\x18\x04 \x01(\v2\x16.api.proto.VoiceConfigR\vvoiceConfig\x124\n" +
+	"\bencoding\x18\x05 \x01(\x0e2\x18.api.proto.AudioEncodingR\bencoding\"g\n" +
 	"\vVoiceConfig\x12\x1d\n" +
 	"\n" +
 	"voice_name\x18\x01 \x01(\tR\tvoiceName\x12#\n" +
 	"\rspeaking_rate\x18\x02 \x01(\x02R\fspeakingRate\x12\x14\n" +
-	"\x05pitch\x18\x03 \x01(\x02R\x05pitch\"t\n" +
+	"\x05pitch\x18\x03 \x01(\x02R\x05pitch\"\xb2\x01\n" +
 	"\vTTSResponse\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12+\n" +
 	"\x05audio\x18\x02 \x01(\v2\x15.api.proto.AudioChunkR\x05audio\x12\x19\n" +
-	"\bis_final\x18\x03 \x01(\bR\aisFinal2\x92\x01\n" +
+	"\bis_final\x18\x03 \x01(\bR\aisFinal\x12\x1d\n" +
+	"\n" +
+	"voice_name\x18\x04 \x01(\tR\tvoiceName\x12\x1d\n" +
+	"\n" +
+	"voice_tier\x18\x05 \x01(\tR\tvoiceTier*a\n" +
+	"\rAudioEncoding\x12\x1b\n" +
+	"\x17AUDIO_ENCODING_LINEAR16\x10\x00\x12\x1b\n" +
+	"\x17AUDIO_ENCODING_OGG_OPUS\x10\x01\x12\x16\n" +
+	"\x12AUDIO_ENCODING_MP3\x10\x022\x92\x01\n" +
 	"\n" +
 	"TTSService\x12=\n" +
 	"\n" +
@@ -249,25 +331,28 @@ func file_tts_proto_rawDescGZIP() []byte {
 	return file_tts_proto_rawDescData
 }
 
+var file_tts_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
 var file_tts_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
 var file_tts_proto_goTypes = []any{
-	(*TTSRequest)(nil),  // 0: api.proto.TTSRequest
-	(*VoiceConfig)(nil), // 1: api.proto.VoiceConfig
-	(*TTSResponse)(nil), // 2: api.proto.TTSResponse
-	(*AudioChunk)(nil),  // 3: api.proto.AudioChunk
+	(AudioEncoding)(0),  // 0: api.proto.AudioEncoding
+	(*TTSRequest)(nil),  // 1: api.proto.TTSRequest
+	(*VoiceConfig)(nil), // 2: api.proto.VoiceConfig
+	(*TTSResponse)(nil), // 3: api.proto.TTSResponse
+	(*AudioChunk)(nil),  // 4: api.proto.AudioChunk
 }
 var file_tts_proto_depIdxs = []int32{
-	1, // 0: api.proto.TTSRequest.voice_config:type_name -> api.proto.VoiceConfig
-	3, // 1: api.proto.TTSResponse.audio:type_name -> api.proto.AudioChunk
-	0, // 2: api.proto.TTSService.Synthesize:input_type -> api.proto.TTSRequest
-	0, // 3: api.proto.TTSService.StreamSynthesize:input_type -> api.proto.TTSRequest
-	2, // 4: api.proto.TTSService.Synthesize:output_type -> api.proto.TTSResponse
-	2, // 5: api.proto.TTSService.StreamSynthesize:output_type -> api.proto.TTSResponse
-	4, // [4:6] is the sub-list for method output_type
-	2, // [2:4] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	2, // 0: api.proto.TTSRequest.voice_config:type_name -> api.proto.VoiceConfig
+	0, // 1: api.proto.TTSRequest.encoding:type_name -> api.proto.AudioEncoding
+	4, // 2: api.proto.TTSResponse.audio:type_name -> api.proto.AudioChunk
+	1, // 3: api.proto.TTSService.Synthesize:input_type -> api.proto.TTSRequest
+	1, // 4: api.proto.TTSService.StreamSynthesize:input_type -> api.proto.TTSRequest
+	3, // 5: api.proto.TTSService.Synthesize:output_type -> api.proto.TTSResponse
+	3, // 6: api.proto.TTSService.StreamSynthesize:output_type -> api.proto.TTSResponse
+	5, // [5:7] is the sub-list for method output_type
+	3, // [3:5] is the sub-list for method input_type
+	3, // [3:3] is the sub-list for extension type_name
+	3, // [3:3] is the sub-list for extension extendee
+	0, // [0:3] is the sub-list for field type_name
 }
 
 func init() { file_tts_proto_init() }
@@ -281,13 +366,14 @@ func file_tts_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_tts_proto_rawDesc), len(file_tts_proto_rawDesc)),
-			NumEnums:      0,
+			NumEnums:      1,
 			NumMessages:   3,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_tts_proto_goTypes,
 		DependencyIndexes: file_tts_proto_depIdxs,
+		EnumInfos:         file_tts_proto_enumTypes,
 		MessageInfos:      file_tts_proto_msgTypes,
 	}.Build()
 	File_tts_proto = out.File
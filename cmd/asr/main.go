@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -13,6 +15,8 @@ import (
 	internalASR "ai-translator/internal/asr"
 	"ai-translator/internal/config"
 	"ai-translator/internal/logging"
+	"ai-translator/internal/metrics"
+	"ai-translator/internal/tracing"
 	"ai-translator/internal/transport"
 )
 
@@ -93,17 +97,19 @@ func (s *asrServer) StreamingRecognize(stream pb.ASRService_StreamingRecognizeSe
 
 		for result := range results {
 			resp := &pb.ASRResponse{
-				SessionId:        sessionID,
-				Transcript:       result.Transcript,
-				IsFinal:          result.IsFinal,
-				Stability:        result.Stability,
-				DetectedLanguage: result.DetectedLanguage,
+				SessionId:         sessionID,
+				Transcript:        result.Transcript,
+				IsFinal:           result.IsFinal,
+				Stability:         result.Stability,
+				DetectedLanguage:  result.DetectedLanguage,
+				ResultEndOffsetMs: result.ResultEndOffset.Milliseconds(),
 			}
 
 			if err := stream.Send(resp); err != nil {
 				errCh <- err
 				return
 			}
+			metrics.ASRResults.WithLabelValues(metrics.ResultType(result.IsFinal)).Inc()
 
 			if result.IsFinal {
 				logger.Debug("final transcript", "text", result.Transcript)
@@ -122,6 +128,17 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	shutdownTracing, err := tracing.Setup(ctx, "asr", tracing.Config{
+		Exporter:     cfg.TracingExporter,
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		OTLPInsecure: cfg.OTLPInsecure,
+		SampleRatio:  cfg.TracingSampleRatio,
+	}, logger)
+	if err != nil {
+		logger.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
 	asrClient, err := internalASR.NewClient(ctx, logger)
 	if err != nil {
 		logger.Error("failed to create ASR client", "error", err)
@@ -129,12 +146,36 @@ func main() {
 	}
 	defer asrClient.Close()
 
-	grpcServer := transport.NewGRPCServer(logger)
+	var serverTLS *tls.Config
+	if cfg.GRPCTLS {
+		certs, err := transport.NewCertReloader(cfg.GRPCTLSCertFile, cfg.GRPCTLSKeyFile, cfg.GRPCTLSCAFile, logger)
+		if err != nil {
+			logger.Error("failed to load TLS certificates", "error", err)
+			os.Exit(1)
+		}
+		serverTLS, err = certs.ServerConfig(cfg.GRPCTLSClientAuth)
+		if err != nil {
+			logger.Error("invalid TLS configuration", "error", err)
+			os.Exit(1)
+		}
+		go certs.Watch(ctx, cfg.TLSReloadInterval)
+	}
+
+	grpcServer := transport.NewGRPCServer(serverTLS, logger)
 	pb.RegisterASRServiceServer(grpcServer.Server(), &asrServer{
 		client: asrClient,
 		logger: logger,
 	})
 
+	grpcServer.WatchHealth(ctx, pb.ASRService_ServiceDesc.ServiceName, cfg.HealthCheckInterval, asrClient.Healthy)
+
+	metricsServer := metrics.NewServer(fmt.Sprintf(":%d", cfg.ASRMetricsPort), logger)
+	go func() {
+		if err := metricsServer.Start(); err != nil && err != http.ErrServerClosed {
+			logger.Error("metrics server error", "error", err)
+		}
+	}()
+
 	go func() {
 		addr := fmt.Sprintf(":%d", cfg.ASRPort)
 		if err := grpcServer.Start(addr); err != nil {
@@ -156,7 +197,9 @@ func main() {
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer shutdownCancel()
+	defer shutdownTracing(shutdownCtx)
 
 	grpcServer.Stop(shutdownCtx)
+	metricsServer.Stop(shutdownCtx)
 	logger.Info("ASR service stopped")
 }
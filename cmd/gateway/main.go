@@ -2,17 +2,29 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	pb "ai-translator/api/proto"
+	"ai-translator/internal/auth"
+	"ai-translator/internal/batch"
 	"ai-translator/internal/config"
 	"ai-translator/internal/gateway"
 	"ai-translator/internal/logging"
+	"ai-translator/internal/metering"
+	"ai-translator/internal/quota"
+	"ai-translator/internal/recording"
+	"ai-translator/internal/tracing"
+	"ai-translator/internal/transcript"
 	"ai-translator/internal/transport"
+	"ai-translator/internal/util"
 )
 
 func main() {
@@ -22,37 +34,176 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	asrConn, err := transport.NewGRPCClient(ctx, cfg.ASRAddress, logger)
+	shutdownTracing, err := tracing.Setup(ctx, "gateway", tracing.Config{
+		Exporter:     cfg.TracingExporter,
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		OTLPInsecure: cfg.OTLPInsecure,
+		SampleRatio:  cfg.TracingSampleRatio,
+	}, logger)
+	if err != nil {
+		logger.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	var clientTLS *tls.Config
+	if cfg.GRPCTLS {
+		certs, err := transport.NewCertReloader(cfg.GRPCTLSCertFile, cfg.GRPCTLSKeyFile, cfg.GRPCTLSCAFile, logger)
+		if err != nil {
+			logger.Error("failed to load TLS certificates", "error", err)
+			os.Exit(1)
+		}
+		go certs.Watch(ctx, cfg.TLSReloadInterval)
+		clientTLS = certs.ClientConfig(cfg.GRPCTLSServerName)
+	}
+
+	asrConn, err := transport.NewGRPCClient(ctx, cfg.ASRAddress, clientTLS, logger)
 	if err != nil {
 		logger.Error("failed to connect to ASR service", "error", err)
 		os.Exit(1)
 	}
 	defer asrConn.Close()
 
-	translatorConn, err := transport.NewGRPCClient(ctx, cfg.TranslatorAddr, logger)
+	translatorConn, err := transport.NewGRPCClient(ctx, cfg.TranslatorAddr, clientTLS, logger)
 	if err != nil {
 		logger.Error("failed to connect to Translator service", "error", err)
 		os.Exit(1)
 	}
 	defer translatorConn.Close()
 
-	ttsConn, err := transport.NewGRPCClient(ctx, cfg.TTSAddress, logger)
+	ttsConn, err := transport.NewGRPCClient(ctx, cfg.TTSAddress, clientTLS, logger)
 	if err != nil {
 		logger.Error("failed to connect to TTS service", "error", err)
 		os.Exit(1)
 	}
 	defer ttsConn.Close()
 
-	asrClient := pb.NewASRServiceClient(asrConn.Conn())
-	translatorClient := pb.NewTranslatorServiceClient(translatorConn.Conn())
-	ttsClient := pb.NewTTSServiceClient(ttsConn.Conn())
+	backendCfg := func(timeout time.Duration) gateway.BackendConfig {
+		retry := util.DefaultRetryConfig()
+		retry.MaxAttempts = cfg.BackendRetryAttempts
+		return gateway.BackendConfig{
+			Timeout: timeout,
+			Retry:   retry,
+			Breaker: util.BreakerConfig{
+				FailureThreshold: cfg.BackendBreakerFailures,
+				Cooldown:         cfg.BackendBreakerCooldown,
+			},
+		}
+	}
+
+	asrBackend := gateway.NewBackend("asr", pb.ASRService_ServiceDesc.ServiceName, asrConn.Conn(), backendCfg(0))
+	translatorBackend := gateway.NewBackend("translator", pb.TranslatorService_ServiceDesc.ServiceName, translatorConn.Conn(), backendCfg(cfg.TranslateTimeout))
+	ttsBackend := gateway.NewBackend("tts", pb.TTSService_ServiceDesc.ServiceName, ttsConn.Conn(), backendCfg(cfg.SynthesizeTimeout))
+
+	asrClient := gateway.NewResilientASRClient(pb.NewASRServiceClient(asrConn.Conn()), asrBackend)
+	translatorClient := gateway.NewResilientTranslatorClient(pb.NewTranslatorServiceClient(translatorConn.Conn()), translatorBackend)
+	ttsClient := gateway.NewResilientTTSClient(pb.NewTTSServiceClient(ttsConn.Conn()), ttsBackend)
+
+	authenticator, apiKeys, tokens, err := buildAuthenticator(cfg, logger)
+	if err != nil {
+		logger.Error("failed to configure authentication", "error", err)
+		os.Exit(1)
+	}
+
+	quotas := quota.NewManager(quota.Limits{
+		MaxSessions:            cfg.QuotaMaxSessions,
+		SessionsPerMinute:      cfg.QuotaSessionsPerMinute,
+		AudioMinutesPerDay:     cfg.QuotaAudioMinutesPerDay,
+		TranslationCharsPerDay: int64(cfg.QuotaTranslationChars),
+		TTSCharsPerDay:         int64(cfg.QuotaTTSChars),
+		CharsPerSecond:         cfg.QuotaCharsPerSecond,
+	})
+	go quotas.Run(ctx, time.Hour)
+
+	meter, err := buildMeter(cfg, logger)
+	if err != nil {
+		logger.Error("failed to configure metering", "error", err)
+		os.Exit(1)
+	}
+	if meter != nil {
+		go meter.Run(ctx, cfg.MeteringFlushInterval)
+	}
+
+	transcripts, err := buildTranscriptStore(cfg)
+	if err != nil {
+		logger.Error("failed to configure transcript store", "error", err)
+		os.Exit(1)
+	}
+
+	recordings, err := buildRecordings(cfg, logger)
+	if err != nil {
+		logger.Error("failed to configure recordings", "error", err)
+		os.Exit(1)
+	}
+	if recordings != nil {
+		go recordings.Run(ctx, time.Hour)
+	}
+
+	ingest, err := gateway.NewIngestConfig(cfg.IngestBuffer, cfg.IngestHighWatermarkPct, cfg.IngestLowWatermarkPct)
+	if err != nil {
+		logger.Error("failed to configure ingest buffer", "error", err)
+		os.Exit(1)
+	}
+
+	sessionManager := gateway.NewSessionManager(asrClient, translatorClient, ttsClient, quotas, meter, transcripts, recordings, cfg.SessionResumeGrace, ingest, logger)
+	if _, err := gateway.ParseSlowClientPolicy(cfg.WSSlowClientPolicy); err != nil {
+		logger.Error("failed to configure websocket send queue", "error", err)
+		os.Exit(1)
+	}
+	upgrader := transport.NewWSUpgrader(splitList(cfg.AllowedOrigins), transport.WSTimeouts{
+		PingInterval: cfg.WSPingInterval,
+		IdleTimeout:  cfg.WSIdleTimeout,
+		WriteTimeout: cfg.WSWriteTimeout,
+	})
+	sendQueue := gateway.SendQueueConfig{MaxBytes: cfg.WSSendQueueKB << 10, Policy: cfg.WSSlowClientPolicy}
+	wsAuthenticator := authenticator
+	if tokens != nil {
+		wsAuthenticator = auth.Chain{authenticator, auth.NewQueryTokenAuthenticator(tokens)}
+	}
+	wsHandler := gateway.NewWebSocketHandler(sessionManager, upgrader, wsAuthenticator, cfg.RecordingMode, sendQueue, logger)
+	router := gateway.NewRouter(wsHandler, []*gateway.Backend{asrBackend, translatorBackend, ttsBackend}, logger)
+	if apiKeys != nil && tokens != nil {
+		router.Handle("/v1/auth/token", gateway.RequireAuth(apiKeys, gateway.NewTokenHandler(tokens, logger)))
+	}
+	router.Handle("POST /v1/translate", gateway.RequireAuth(authenticator, gateway.NewTextHandler(translatorClient, ttsClient, quotas, meter, logger)))
+	router.Handle("POST /v1/speech", gateway.RequireAuth(authenticator, gateway.NewSpeechHandler(ttsClient, quotas, meter, logger)))
+	router.Handle("GET /v1/sessions/{id}/captions", gateway.RequireAuth(authenticator, gateway.NewCaptionHandler(sessionManager, logger)))
+	if transcripts != nil {
+		router.Handle("GET /v1/sessions/{id}/transcript", gateway.RequireAuth(authenticator, gateway.NewTranscriptHandler(transcripts, logger)))
+	}
+	if cfg.BatchConcurrency > 0 {
+		processor := batch.NewProcessor(asrClient, translatorClient, ttsClient, quotas, meter, logger)
+		jobs, err := batch.NewManager(processor, cfg.BatchDir, cfg.BatchConcurrency, cfg.BatchQueueSize, cfg.BatchRetention, logger)
+		if err != nil {
+			logger.Error("failed to configure batch jobs", "error", err)
+			os.Exit(1)
+		}
+		go jobs.Run(ctx)
 
-	sessionManager := gateway.NewSessionManager(asrClient, translatorClient, ttsClient, logger)
-	wsHandler := gateway.NewWebSocketHandler(sessionManager, logger)
-	router := gateway.NewRouter(wsHandler, logger)
+		jobHandler := gateway.RequireAuth(authenticator, gateway.NewJobHandler(jobs, int64(cfg.BatchMaxUploadMB)<<20, logger))
+		router.Handle("/v1/jobs", jobHandler)
+		router.Handle("/v1/jobs/", jobHandler)
+	}
+	if cfg.AdminToken != "" {
+		router.Handle("/admin/", gateway.NewAdminHandler(quotas, cfg.AdminToken, logger))
+	}
 
 	addr := fmt.Sprintf(":%d", cfg.GatewayPort)
-	server := transport.NewWSServer(addr, router.Handler(), logger)
+	var serverTLS *tls.Config
+	if cfg.GatewayTLSCertFile != "" {
+		certs, err := transport.NewCertReloader(cfg.GatewayTLSCertFile, cfg.GatewayTLSKeyFile, "", logger)
+		if err != nil {
+			logger.Error("failed to load gateway TLS certificate", "error", err)
+			os.Exit(1)
+		}
+		serverTLS, err = certs.ServerConfig(false)
+		if err != nil {
+			logger.Error("invalid gateway TLS configuration", "error", err)
+			os.Exit(1)
+		}
+		go certs.Watch(ctx, cfg.TLSReloadInterval)
+	}
+
+	server := transport.NewWSServer(addr, router.Handler(), serverTLS, logger)
 
 	go func() {
 		if err := server.Start(); err != nil && err != http.ErrServerClosed {
@@ -72,12 +223,123 @@ func main() {
 	case <-ctx.Done():
 	}
 
+	router.Drain()
+
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer shutdownCancel()
+	defer shutdownTracing(shutdownCtx)
 
 	if err := server.Stop(shutdownCtx); err != nil {
 		logger.Error("shutdown error", "error", err)
 	}
 
+	if err := meter.Close(shutdownCtx); err != nil {
+		logger.Error("failed to flush usage records", "error", err)
+	}
+
 	logger.Info("gateway stopped")
 }
+
+func buildAuthenticator(cfg *config.Config, logger *slog.Logger) (auth.Authenticator, *auth.APIKeyAuthenticator, *auth.TokenAuthenticator, error) {
+	var chain auth.Chain
+	var apiKeys *auth.APIKeyAuthenticator
+	var tokens *auth.TokenAuthenticator
+
+	if cfg.AuthAPIKeys != "" {
+		keys, err := auth.ParseAPIKeys(cfg.AuthAPIKeys)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		apiKeys = keys
+		chain = append(chain, apiKeys)
+	}
+
+	if cfg.AuthTokenSecret != "" {
+		issuer, err := auth.NewTokenAuthenticator([]byte(cfg.AuthTokenSecret), cfg.AuthTokenTTL)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		tokens = issuer
+		chain = append(chain, tokens)
+	}
+
+	if cfg.AuthJWKSFile != "" {
+		jwt, err := auth.NewJWTAuthenticator(auth.JWTConfig{
+			JWKSFile:    cfg.AuthJWKSFile,
+			Issuer:      cfg.AuthJWTIssuer,
+			Audience:    cfg.AuthJWTAudience,
+			TenantClaim: cfg.AuthJWTTenantClaim,
+		})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		chain = append(chain, jwt)
+	}
+
+	if len(chain) == 0 {
+		logger.Warn("no authentication configured, accepting anonymous connections")
+		return auth.Anonymous{}, nil, nil, nil
+	}
+	return chain, apiKeys, tokens, nil
+}
+
+func buildMeter(cfg *config.Config, logger *slog.Logger) (*metering.Meter, error) {
+	var sink metering.Sink
+	switch cfg.MeteringSink {
+	case metering.SinkNone, "":
+		return nil, nil
+	case metering.SinkFile:
+		file, err := metering.NewJSONLinesSink(cfg.MeteringFile)
+		if err != nil {
+			return nil, err
+		}
+		sink = file
+	case metering.SinkWebhook:
+		if cfg.MeteringWebhookURL == "" {
+			return nil, fmt.Errorf("METERING_WEBHOOK_URL is required for the webhook sink")
+		}
+		sink = metering.NewWebhookSink(cfg.MeteringWebhookURL, cfg.MeteringWebhookSecret, 10*time.Second)
+	default:
+		return nil, fmt.Errorf("unknown metering sink %q", cfg.MeteringSink)
+	}
+
+	return metering.NewMeter(sink, cfg.MeteringSpoolDir, logger)
+}
+
+func buildTranscriptStore(cfg *config.Config) (transcript.Store, error) {
+	switch cfg.TranscriptStore {
+	case transcript.StoreNone, "":
+		return nil, nil
+	case transcript.StoreFile:
+		return transcript.NewFileStore(cfg.TranscriptDir)
+	}
+	return nil, fmt.Errorf("unknown transcript store %q", cfg.TranscriptStore)
+}
+
+func buildRecordings(cfg *config.Config, logger *slog.Logger) (*recording.Manager, error) {
+	if _, err := recording.ParseMode(cfg.RecordingMode); err != nil {
+		return nil, err
+	}
+
+	switch cfg.RecordingStore {
+	case recording.StoreNone, "":
+		return nil, nil
+	case recording.StoreFile:
+		storage, err := recording.NewLocalStorage(cfg.RecordingDir)
+		if err != nil {
+			return nil, err
+		}
+		return recording.NewManager(storage, cfg.RecordingRetention, logger), nil
+	}
+	return nil, fmt.Errorf("unknown recording store %q", cfg.RecordingStore)
+}
+
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
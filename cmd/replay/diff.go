@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"ai-translator/internal/recording"
+	"ai-translator/internal/transcript"
+)
+
+const (
+	changeSame    = "same"
+	changeChanged = "changed"
+	changeRemoved = "removed"
+	changeAdded   = "added"
+)
+
+type line struct {
+	Source      string `json:"source"`
+	Language    string `json:"language,omitempty"`
+	Translation string `json:"translation"`
+}
+
+type change struct {
+	Kind     string `json:"kind"`
+	Original *line  `json:"original,omitempty"`
+	Replayed *line  `json:"replayed,omitempty"`
+}
+
+type report struct {
+	SessionID  string   `json:"session_id"`
+	ASR        string   `json:"asr"`
+	Translator string   `json:"translator"`
+	TTS        string   `json:"tts"`
+	Speed      float64  `json:"speed"`
+	Original   int      `json:"original"`
+	Replayed   int      `json:"replayed"`
+	Identical  int      `json:"identical"`
+	Changes    []change `json:"changes"`
+}
+
+func (r *report) Differs() bool {
+	return r.Identical != r.Original || r.Identical != r.Replayed
+}
+
+func originalLines(events []recording.Event) []line {
+	pending := make(map[string][]string)
+	for _, e := range events {
+		if e.Kind == recording.EventTranslation && e.Translation != nil && e.Translation.IsFinal {
+			pending[e.Translation.Text] = append(pending[e.Translation.Text], e.Translation.TranslatedText)
+		}
+	}
+
+	var lines []line
+	for _, e := range events {
+		if e.Kind != recording.EventRecognition || e.Recognition == nil || !e.Recognition.IsFinal || e.Recognition.Transcript == "" {
+			continue
+		}
+		l := line{Source: e.Recognition.Transcript, Language: e.Recognition.DetectedLanguage}
+		if queue := pending[l.Source]; len(queue) > 0 {
+			l.Translation = queue[0]
+			pending[l.Source] = queue[1:]
+		}
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+func replayedLines(t *transcript.Transcript) []line {
+	lines := make([]line, 0, len(t.Utterances))
+	for _, u := range t.Utterances {
+		lines = append(lines, line{Source: u.SourceText, Language: u.SourceLanguage, Translation: u.TranslatedText})
+	}
+	return lines
+}
+
+func diff(original, replayed []line) ([]change, int) {
+	n, m := len(original), len(replayed)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if original[i].Source == replayed[j].Source {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var changes []change
+	var removed, added []line
+	flush := func() {
+		paired := min(len(removed), len(added))
+		for k := 0; k < paired; k++ {
+			changes = append(changes, change{Kind: changeChanged, Original: &removed[k], Replayed: &added[k]})
+		}
+		for k := paired; k < len(removed); k++ {
+			changes = append(changes, change{Kind: changeRemoved, Original: &removed[k]})
+		}
+		for k := paired; k < len(added); k++ {
+			changes = append(changes, change{Kind: changeAdded, Replayed: &added[k]})
+		}
+		removed, added = nil, nil
+	}
+
+	identical := 0
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && original[i].Source == replayed[j].Source:
+			flush()
+			kind := changeSame
+			if original[i] != replayed[j] {
+				kind = changeChanged
+			} else {
+				identical++
+			}
+			changes = append(changes, change{Kind: kind, Original: &original[i], Replayed: &replayed[j]})
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] >= lcs[i+1][j]):
+			added = append(added, replayed[j])
+			j++
+		default:
+			removed = append(removed, original[i])
+			i++
+		}
+	}
+	flush()
+	return changes, identical
+}
+
+func (r *report) WriteText(w io.Writer) {
+	speed := "max"
+	if r.Speed > 0 {
+		speed = fmt.Sprintf("%gx", r.Speed)
+	}
+	fmt.Fprintf(w, "session %s replayed with asr=%s translator=%s tts=%s speed=%s\n\n", r.SessionID, r.ASR, r.Translator, r.TTS, speed)
+	for i, c := range r.Changes {
+		switch c.Kind {
+		case changeSame:
+			fmt.Fprintf(w, "  %3d  %s\n       %s\n", i+1, c.Original.Source, c.Original.Translation)
+		case changeRemoved:
+			fmt.Fprintf(w, "- %3d  %s\n       %s\n", i+1, c.Original.Source, c.Original.Translation)
+		case changeAdded:
+			fmt.Fprintf(w, "+ %3d  %s\n       %s\n", i+1, c.Replayed.Source, c.Replayed.Translation)
+		case changeChanged:
+			fmt.Fprintf(w, "~ %3d\n", i+1)
+			writeField(w, "source", c.Original.Source, c.Replayed.Source)
+			writeField(w, "language", c.Original.Language, c.Replayed.Language)
+			writeField(w, "translation", c.Original.Translation, c.Replayed.Translation)
+		}
+	}
+	fmt.Fprintf(w, "\n%d original, %d replayed, %d identical\n", r.Original, r.Replayed, r.Identical)
+}
+
+func writeField(w io.Writer, name, original, replayed string) {
+	if original == replayed {
+		fmt.Fprintf(w, "    %-12s %s\n", name, original)
+		return
+	}
+	fmt.Fprintf(w, "  - %-12s %s\n  + %-12s %s\n", name, original, name, replayed)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"ai-translator/internal/recording"
+	"ai-translator/internal/transcript"
+)
+
+func recognitionEvent(offsetMs int64, text string, isFinal bool) recording.Event {
+	return recording.Event{
+		OffsetMs:    offsetMs,
+		Kind:        recording.EventRecognition,
+		Recognition: &recording.Recognition{Transcript: text, IsFinal: isFinal, DetectedLanguage: "en-US"},
+	}
+}
+
+func translationEvent(offsetMs int64, text, translated string, isFinal bool) recording.Event {
+	return recording.Event{
+		OffsetMs:    offsetMs,
+		Kind:        recording.EventTranslation,
+		Translation: &recording.Translation{Text: text, IsFinal: isFinal, TranslatedText: translated, Engine: "gemini"},
+	}
+}
+
+func TestOriginalLines(t *testing.T) {
+	events := []recording.Event{
+		{Kind: recording.EventConfig, Config: &recording.SessionConfig{SourceLanguage: "en-US"}},
+		recognitionEvent(100, "hel", false),
+		translationEvent(150, "hel", "ho", false),
+		recognitionEvent(200, "hello", true),
+		translationEvent(300, "hello", "hola", true),
+		recognitionEvent(400, "", true),
+		recognitionEvent(500, "hello", true),
+		translationEvent(600, "hello", "buenas", true),
+		recognitionEvent(700, "bye", true),
+	}
+
+	got := originalLines(events)
+	want := []line{
+		{Source: "hello", Language: "en-US", Translation: "hola"},
+		{Source: "hello", Language: "en-US", Translation: "buenas"},
+		{Source: "bye", Language: "en-US"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("lines = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("line %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestReplayedLines(t *testing.T) {
+	got := replayedLines(&transcript.Transcript{Utterances: []transcript.Utterance{
+		{SourceText: "hello", SourceLanguage: "en-US", TranslatedText: "hola"},
+	}})
+	if len(got) != 1 || got[0] != (line{Source: "hello", Language: "en-US", Translation: "hola"}) {
+		t.Fatalf("lines = %+v", got)
+	}
+	if got := replayedLines(&transcript.Transcript{}); got == nil || len(got) != 0 {
+		t.Fatalf("lines of an empty transcript = %#v", got)
+	}
+}
+
+func kinds(changes []change) string {
+	var out []string
+	for _, c := range changes {
+		out = append(out, c.Kind)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestDiff(t *testing.T) {
+	a := line{Source: "a", Translation: "A"}
+	b := line{Source: "b", Translation: "B"}
+	c := line{Source: "c", Translation: "C"}
+	x := line{Source: "x", Translation: "X"}
+	bRetranslated := line{Source: "b", Translation: "Be"}
+
+	tests := []struct {
+		name      string
+		original  []line
+		replayed  []line
+		kinds     string
+		identical int
+	}{
+		{"identical", []line{a, b}, []line{a, b}, "same,same", 2},
+		{"translation changed", []line{a, b}, []line{a, bRetranslated}, "same,changed", 1},
+		{"line removed", []line{a, b, c}, []line{a, c}, "same,removed,same", 2},
+		{"line added", []line{a, c}, []line{a, b, c}, "same,added,same", 2},
+		{"recognition changed", []line{a, b, c}, []line{a, x, c}, "same,changed,same", 2},
+		{"extra replacements", []line{a}, []line{x, b}, "changed,added", 0},
+		{"empty", nil, nil, "", 0},
+	}
+	for _, tt := range tests {
+		changes, identical := diff(tt.original, tt.replayed)
+		if got := kinds(changes); got != tt.kinds || identical != tt.identical {
+			t.Errorf("%s: diff = %s with %d identical, want %s with %d", tt.name, got, identical, tt.kinds, tt.identical)
+		}
+	}
+
+	changes, _ := diff([]line{a, b}, []line{a, x})
+	if changes[1].Original.Source != "b" || changes[1].Replayed.Source != "x" {
+		t.Fatalf("changed pair = %+v, %+v", changes[1].Original, changes[1].Replayed)
+	}
+}
+
+func TestReportDiffers(t *testing.T) {
+	tests := []struct {
+		r    report
+		want bool
+	}{
+		{report{Original: 2, Replayed: 2, Identical: 2}, false},
+		{report{Original: 2, Replayed: 2, Identical: 1}, true},
+		{report{Original: 2, Replayed: 3, Identical: 2}, true},
+		{report{Original: 0, Replayed: 0, Identical: 0}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.r.Differs(); got != tt.want {
+			t.Errorf("%+v: Differs = %v, want %v", tt.r, got, tt.want)
+		}
+	}
+}
+
+func TestReportWriteText(t *testing.T) {
+	original := []line{{Source: "hello", Translation: "hola"}, {Source: "bye", Translation: "adios"}, {Source: "gone", Translation: "ido"}}
+	replayed := []line{{Source: "hello", Translation: "hola"}, {Source: "bye", Translation: "chau"}, {Source: "new", Translation: "nuevo"}}
+	changes, identical := diff(original, replayed)
+	r := &report{SessionID: "sess_1", ASR: backendRecorded, Translator: backendLive, TTS: backendOff, Original: 3, Replayed: 3, Identical: identical, Changes: changes}
+
+	var buf bytes.Buffer
+	r.WriteText(&buf)
+	out := buf.String()
+	for _, want := range []string{
+		"session sess_1 replayed with asr=recorded translator=live tts=off speed=max\n",
+		"    1  hello\n       hola\n",
+		"~   2\n    source       bye\n",
+		"  - translation  adios\n  + translation  chau\n",
+		"\n3 original, 3 replayed, 1 identical\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report is missing %q:\n%s", want, out)
+		}
+	}
+
+	r.Speed = 2
+	buf.Reset()
+	r.WriteText(&buf)
+	if !strings.Contains(buf.String(), "speed=2x") {
+		t.Fatalf("report header = %q", strings.SplitN(buf.String(), "\n", 2)[0])
+	}
+}
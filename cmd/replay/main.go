@@ -0,0 +1,444 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/audio"
+	"ai-translator/internal/auth"
+	"ai-translator/internal/config"
+	"ai-translator/internal/gateway"
+	"ai-translator/internal/logging"
+	"ai-translator/internal/quota"
+	"ai-translator/internal/recording"
+	"ai-translator/internal/transcript"
+	"ai-translator/internal/transport"
+	"ai-translator/internal/util"
+)
+
+const (
+	backendLive     = "live"
+	backendRecorded = "recorded"
+	backendOff      = "off"
+)
+
+const (
+	chunkDuration = 100 * time.Millisecond
+	flowTimeout   = 30 * time.Second
+)
+
+type options struct {
+	dir        string
+	speed      float64
+	asr        string
+	translator string
+	tts        string
+	settle     time.Duration
+	json       bool
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.dir, "recording", "", "Session recording directory containing manifest.json")
+	flag.Float64Var(&opts.speed, "speed", 1, "Playback speed, 1 is real time and 0 is as fast as possible")
+	flag.StringVar(&opts.asr, "asr", backendRecorded, "ASR backend (live, recorded)")
+	flag.StringVar(&opts.translator, "translator", backendLive, "Translator backend (live, recorded)")
+	flag.StringVar(&opts.tts, "tts", backendOff, "TTS backend (live, off)")
+	flag.DurationVar(&opts.settle, "settle", 5*time.Second, "How long the pipeline must stay idle after the last audio chunk")
+	flag.BoolVar(&opts.json, "json", false, "Print the report as JSON")
+	flag.Parse()
+
+	cfg := config.Load()
+	logger := logging.New(cfg.LogLevel)
+
+	if err := opts.validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	manifest, events, pcm, err := loadRecording(opts.dir)
+	if err != nil {
+		logger.Error("failed to load recording", "error", err, "dir", opts.dir)
+		os.Exit(1)
+	}
+
+	r, err := run(ctx, cfg, opts, manifest, events, pcm, logger)
+	if err != nil {
+		logger.Error("replay failed", "error", err)
+		os.Exit(1)
+	}
+
+	if opts.json {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(r)
+	} else {
+		r.WriteText(os.Stdout)
+	}
+	if r.Differs() {
+		os.Exit(1)
+	}
+}
+
+func (o options) validate() error {
+	switch {
+	case o.dir == "":
+		return fmt.Errorf("-recording is required")
+	case o.speed < 0:
+		return fmt.Errorf("-speed must not be negative")
+	case o.asr != backendLive && o.asr != backendRecorded:
+		return fmt.Errorf("unknown ASR backend %q", o.asr)
+	case o.translator != backendLive && o.translator != backendRecorded:
+		return fmt.Errorf("unknown translator backend %q", o.translator)
+	case o.tts != backendLive && o.tts != backendOff:
+		return fmt.Errorf("unknown TTS backend %q", o.tts)
+	}
+	return nil
+}
+
+func loadRecording(dir string) (*recording.Manifest, []recording.Event, []byte, error) {
+	data, err := os.ReadFile(filepath.Join(dir, recording.ManifestFile))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var manifest recording.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	if manifest.Events == "" {
+		return nil, nil, nil, fmt.Errorf("recording has no captured events")
+	}
+
+	f, err := os.Open(filepath.Join(dir, path.Base(manifest.Events)))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer f.Close()
+	events, err := recording.ReadEvents(f)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("read events: %w", err)
+	}
+
+	for _, file := range manifest.Files {
+		channel := slices.Index(file.Tracks, recording.TrackInbound)
+		if channel < 0 {
+			continue
+		}
+		pcm, err := readChannel(filepath.Join(dir, path.Base(file.Key)), channel)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("read %s: %w", file.Key, err)
+		}
+		return &manifest, events, pcm, nil
+	}
+	return nil, nil, nil, fmt.Errorf("recording has no inbound audio")
+}
+
+func readChannel(name string, channel int) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	wav, err := audio.NewWAVReader(f)
+	if err != nil {
+		return nil, err
+	}
+	if !wav.IsPCM16() || wav.SampleRate != audio.SampleRate {
+		return nil, fmt.Errorf("%w: %d-bit %s at %d Hz", audio.ErrUnsupportedWAV, wav.BitsPerSample, wav.Encoding, wav.SampleRate)
+	}
+
+	data, err := io.ReadAll(wav)
+	if err != nil {
+		return nil, err
+	}
+	if wav.Channels == 1 {
+		return data, nil
+	}
+
+	frame := wav.Channels * audio.BytesPerSample
+	pcm := make([]byte, 0, len(data)/wav.Channels)
+	for i := channel * audio.BytesPerSample; i+audio.BytesPerSample <= len(data); i += frame {
+		pcm = append(pcm, data[i:i+audio.BytesPerSample]...)
+	}
+	return pcm, nil
+}
+
+func run(ctx context.Context, cfg *config.Config, opts options, manifest *recording.Manifest, events []recording.Event, pcm []byte, logger *slog.Logger) (*report, error) {
+	clients, err := newClients(ctx, cfg, opts, events, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer clients.Close()
+
+	store := transcript.NewMemoryStore()
+	sessions := gateway.NewSessionManager(clients.asr, clients.translator, clients.tts, quota.NewManager(quota.Limits{}), nil, store, nil, 0, gateway.DefaultIngestConfig(), logger)
+
+	sessionID := util.NewSessionID()
+	logger = logger.With("session_id", sessionID, "original_session_id", manifest.SessionID)
+	principal := &auth.Principal{Subject: "replay", TenantID: manifest.TenantID, Method: auth.MethodAnonymous}
+
+	conn := &replayConn{lastActivity: time.Now()}
+	session, err := sessions.Create(ctx, sessionID, principal, conn, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("replay started", "audio", audio.DurationOf(len(pcm)), "speed", opts.speed, "asr", opts.asr, "translator", opts.translator, "tts", opts.tts)
+
+	configs := configEvents(events)
+	applyConfigs(session, &configs, 0, true)
+
+	chunk := audio.SamplesForDuration(int(chunkDuration.Milliseconds())) * audio.BytesPerSample
+	start := time.Now()
+	for offset := 0; offset < len(pcm); offset += chunk {
+		position := audio.DurationOf(offset)
+		applyConfigs(session, &configs, position.Milliseconds(), false)
+
+		if opts.speed > 0 {
+			wait := time.Until(start.Add(time.Duration(float64(position) / opts.speed)))
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				sessions.Remove(sessionID)
+				return nil, ctx.Err()
+			}
+		}
+
+		if err := conn.waitForFlow(ctx); err != nil {
+			sessions.Remove(sessionID)
+			return nil, err
+		}
+		if err := session.ProcessAudio(ctx, pcm[offset:min(offset+chunk, len(pcm))]); err != nil {
+			sessions.Remove(sessionID)
+			return nil, err
+		}
+		conn.touch()
+	}
+	if clients.recordedASR != nil {
+		clients.recordedASR.finish()
+	}
+
+	original := originalLines(events)
+	if err := waitForSettle(ctx, conn, store, sessionID, opts, len(original)); err != nil {
+		sessions.Remove(sessionID)
+		return nil, err
+	}
+	sessions.Remove(sessionID)
+
+	result, err := store.Get(context.Background(), sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	replayed := replayedLines(result)
+	changes, identical := diff(original, replayed)
+	return &report{
+		SessionID:  manifest.SessionID,
+		ASR:        opts.asr,
+		Translator: opts.translator,
+		TTS:        opts.tts,
+		Speed:      opts.speed,
+		Original:   len(original),
+		Replayed:   len(replayed),
+		Identical:  identical,
+		Changes:    changes,
+	}, nil
+}
+
+func configEvents(events []recording.Event) []recording.Event {
+	var configs []recording.Event
+	for _, e := range events {
+		if e.Kind == recording.EventConfig && e.Config != nil {
+			configs = append(configs, e)
+		}
+	}
+	return configs
+}
+
+func applyConfigs(session *gateway.Session, configs *[]recording.Event, positionMs int64, first bool) {
+	for len(*configs) > 0 && (first || (*configs)[0].OffsetMs <= positionMs) {
+		c := (*configs)[0].Config
+		session.SetLanguages(c.SourceLanguage, c.TargetLanguage)
+		session.SetStyle(&pb.TranslationStyle{
+			Formality: pb.Formality(pb.Formality_value[c.Formality]),
+			Domain:    pb.Domain(pb.Domain_value[c.Domain]),
+			Audience:  c.Audience,
+		})
+		*configs = (*configs)[1:]
+		first = false
+	}
+}
+
+func waitForSettle(ctx context.Context, conn *replayConn, store transcript.Store, sessionID string, opts options, expected int) error {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		if opts.asr == backendRecorded && opts.tts == backendOff {
+			if t, err := store.Get(ctx, sessionID); err == nil && len(t.Utterances) >= expected {
+				return nil
+			}
+		}
+		if conn.idle() >= opts.settle {
+			return nil
+		}
+	}
+}
+
+type replayConn struct {
+	mu           sync.Mutex
+	lastActivity time.Time
+	resume       chan struct{}
+}
+
+func (c *replayConn) WriteText(text string) error {
+	c.touch()
+
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal([]byte(text), &event); err != nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch {
+	case event.Type == gateway.FlowPause && c.resume == nil:
+		c.resume = make(chan struct{})
+	case event.Type == gateway.FlowResume && c.resume != nil:
+		close(c.resume)
+		c.resume = nil
+	}
+	return nil
+}
+
+func (c *replayConn) waitForFlow(ctx context.Context) error {
+	c.mu.Lock()
+	resume := c.resume
+	c.mu.Unlock()
+
+	if resume == nil {
+		return nil
+	}
+	select {
+	case <-resume:
+		return nil
+	case <-time.After(flowTimeout):
+		return fmt.Errorf("gateway ingest stayed paused for %s", flowTimeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *replayConn) WriteBinary(data []byte) error {
+	c.touch()
+	return nil
+}
+
+func (c *replayConn) touch() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastActivity = time.Now()
+}
+
+func (c *replayConn) idle() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Since(c.lastActivity)
+}
+
+type clients struct {
+	asr         pb.ASRServiceClient
+	translator  pb.TranslatorServiceClient
+	tts         pb.TTSServiceClient
+	recordedASR *recordedASR
+	conns       []*transport.GRPCClient
+}
+
+func newClients(ctx context.Context, cfg *config.Config, opts options, events []recording.Event, logger *slog.Logger) (*clients, error) {
+	c := &clients{}
+
+	var clientTLS *tls.Config
+	if cfg.GRPCTLS && (opts.asr == backendLive || opts.translator == backendLive || opts.tts == backendLive) {
+		certs, err := transport.NewCertReloader(cfg.GRPCTLSCertFile, cfg.GRPCTLSKeyFile, cfg.GRPCTLSCAFile, logger)
+		if err != nil {
+			return nil, err
+		}
+		clientTLS = certs.ClientConfig(cfg.GRPCTLSServerName)
+	}
+
+	dial := func(addr string) (*transport.GRPCClient, error) {
+		conn, err := transport.NewGRPCClient(ctx, addr, clientTLS, logger)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.conns = append(c.conns, conn)
+		return conn, nil
+	}
+
+	if opts.asr == backendLive {
+		conn, err := dial(cfg.ASRAddress)
+		if err != nil {
+			return nil, fmt.Errorf("connect to ASR service: %w", err)
+		}
+		c.asr = pb.NewASRServiceClient(conn.Conn())
+	} else {
+		c.recordedASR = newRecordedASR(events)
+		c.asr = c.recordedASR
+	}
+
+	if opts.translator == backendLive {
+		conn, err := dial(cfg.TranslatorAddr)
+		if err != nil {
+			return nil, fmt.Errorf("connect to Translator service: %w", err)
+		}
+		c.translator = pb.NewTranslatorServiceClient(conn.Conn())
+	} else {
+		c.translator = newRecordedTranslator(events)
+	}
+
+	if opts.tts == backendLive {
+		conn, err := dial(cfg.TTSAddress)
+		if err != nil {
+			return nil, fmt.Errorf("connect to TTS service: %w", err)
+		}
+		c.tts = pb.NewTTSServiceClient(conn.Conn())
+	} else {
+		c.tts = silentTTS{}
+	}
+
+	return c, nil
+}
+
+func (c *clients) Close() {
+	for _, conn := range c.conns {
+		conn.Close()
+	}
+}
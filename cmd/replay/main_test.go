@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/audio"
+	"ai-translator/internal/config"
+	"ai-translator/internal/recording"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestOptionsValidate(t *testing.T) {
+	valid := options{dir: "rec", speed: 1, asr: backendRecorded, translator: backendLive, tts: backendOff}
+	if err := valid.validate(); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+
+	invalid := []func(o *options){
+		func(o *options) { o.dir = "" },
+		func(o *options) { o.speed = -1 },
+		func(o *options) { o.asr = backendOff },
+		func(o *options) { o.translator = "cached" },
+		func(o *options) { o.tts = backendRecorded },
+	}
+	for i, mutate := range invalid {
+		o := valid
+		mutate(&o)
+		if err := o.validate(); err == nil {
+			t.Errorf("case %d: %+v accepted", i, o)
+		}
+	}
+}
+
+func writeWAV(t *testing.T, name string, channels int, frames [][]int16) {
+	t.Helper()
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	defer f.Close()
+	w, err := audio.NewWAVWriter(f, audio.SampleRate, channels)
+	if err != nil {
+		t.Fatalf("NewWAVWriter: %v", err)
+	}
+	var data []byte
+	for _, frame := range frames {
+		for _, sample := range frame {
+			data = binary.LittleEndian.AppendUint16(data, uint16(sample))
+		}
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
+
+func TestReadChannel(t *testing.T) {
+	dir := t.TempDir()
+	stereo := filepath.Join(dir, "mix.wav")
+	writeWAV(t, stereo, 2, [][]int16{{1, -1}, {2, -2}, {3, -3}})
+
+	for channel, want := range [][]int16{{1, 2, 3}, {-1, -2, -3}} {
+		pcm, err := readChannel(stereo, channel)
+		if err != nil {
+			t.Fatalf("readChannel(%d): %v", channel, err)
+		}
+		if got := audio.BytesToPCM(pcm); len(got) != len(want) || got[0] != want[0] || got[2] != want[2] {
+			t.Fatalf("channel %d = %v, want %v", channel, got, want)
+		}
+	}
+
+	mono := filepath.Join(dir, "inbound.wav")
+	writeWAV(t, mono, 1, [][]int16{{7}, {8}})
+	pcm, err := readChannel(mono, 0)
+	if err != nil {
+		t.Fatalf("readChannel mono: %v", err)
+	}
+	if got := audio.BytesToPCM(pcm); len(got) != 2 || got[1] != 8 {
+		t.Fatalf("mono = %v", got)
+	}
+}
+
+func TestReadChannelRejectsOtherSampleRates(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "inbound.wav")
+	f, err := os.Create(name)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	w, _ := audio.NewWAVWriter(f, 8000, 1)
+	w.Write(make([]byte, 16))
+	w.Close()
+	f.Close()
+
+	if _, err := readChannel(name, 0); !errors.Is(err, audio.ErrUnsupportedWAV) {
+		t.Fatalf("readChannel error = %v, want ErrUnsupportedWAV", err)
+	}
+}
+
+func recordSession(t *testing.T, mode string, capture func(r *recording.Recorder)) string {
+	t.Helper()
+	root := t.TempDir()
+	storage, err := recording.NewLocalStorage(root)
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	m := recording.NewManager(storage, 0, discardLogger())
+	r, err := m.Start(context.Background(), "sess_1", "acme", mode, "", time.Now())
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	capture(r)
+	if err := r.Close(context.Background(), time.Now()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return filepath.Join(root, "sess_1")
+}
+
+func TestLoadRecording(t *testing.T) {
+	for _, mode := range []string{recording.ModeTracks, recording.ModeStereo} {
+		dir := recordSession(t, mode, func(r *recording.Recorder) {
+			r.CaptureConfig(recording.SessionConfig{SourceLanguage: "en-US", TargetLanguage: "es-ES"})
+			r.WriteInbound(make([]byte, 3200))
+			r.WriteOutbound(make([]byte, 6400))
+		})
+
+		manifest, events, pcm, err := loadRecording(dir)
+		if err != nil {
+			t.Fatalf("%s: loadRecording: %v", mode, err)
+		}
+		if manifest.SessionID != "sess_1" || manifest.Mode != mode {
+			t.Fatalf("%s: manifest = %+v", mode, manifest)
+		}
+		if len(events) != 1 || events[0].Kind != recording.EventConfig {
+			t.Fatalf("%s: events = %+v", mode, events)
+		}
+		if len(pcm) < 3200 || len(pcm) > 3200+6400 {
+			t.Fatalf("%s: inbound audio = %d bytes", mode, len(pcm))
+		}
+	}
+}
+
+func TestLoadRecordingErrors(t *testing.T) {
+	if _, _, _, err := loadRecording(t.TempDir()); err == nil {
+		t.Fatal("loadRecording accepted a directory without a manifest")
+	}
+
+	dir := recordSession(t, recording.ModeTracks, func(*recording.Recorder) {})
+	manifest := filepath.Join(dir, recording.ManifestFile)
+
+	if err := os.WriteFile(manifest, []byte(`{"session_id":"sess_1","files":[]}`), 0o600); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+	if _, _, _, err := loadRecording(dir); err == nil {
+		t.Fatal("loadRecording accepted a recording without events")
+	}
+
+	if err := os.WriteFile(manifest, []byte(`{"session_id":"sess_1","events":"sess_1/events.jsonl","files":[{"key":"sess_1/outbound.wav","tracks":["outbound"]}]}`), 0o600); err != nil {
+		t.Fatalf("write manifest: %v", err)
+	}
+	if _, _, _, err := loadRecording(dir); err == nil {
+		t.Fatal("loadRecording accepted a recording without inbound audio")
+	}
+}
+
+func TestRecordedTranslator(t *testing.T) {
+	translator := newRecordedTranslator([]recording.Event{
+		translationEvent(0, "hello", "hol", false),
+		translationEvent(10, "hello", "hola", true),
+		translationEvent(20, "hello", "buenas", true),
+	})
+	ctx := context.Background()
+
+	for _, want := range []string{"hola", "buenas", "buenas"} {
+		resp, err := translator.Translate(ctx, &pb.TranslateRequest{SessionId: "s", Text: "hello", IsFinal: true})
+		if err != nil {
+			t.Fatalf("Translate: %v", err)
+		}
+		if resp.TranslatedText != want || resp.SessionId != "s" || resp.Engine != "gemini" || !resp.IsFinal {
+			t.Fatalf("response = %+v, want %q", resp, want)
+		}
+	}
+
+	if resp, err := translator.Translate(ctx, &pb.TranslateRequest{Text: "hello"}); err != nil || resp.TranslatedText != "hol" {
+		t.Fatalf("partial = %v, %v", resp, err)
+	}
+	if _, err := translator.Translate(ctx, &pb.TranslateRequest{Text: "unknown", IsFinal: true}); status.Code(err) != codes.NotFound {
+		t.Fatalf("unknown text error = %v, want NotFound", err)
+	}
+}
+
+func TestRecordedASRFollowsAudio(t *testing.T) {
+	asr := newRecordedASR([]recording.Event{
+		{Kind: recording.EventConfig, Config: &recording.SessionConfig{}},
+		recognitionEvent(100, "hel", false),
+		recognitionEvent(500, "hello", true),
+	})
+	stream, err := asr.StreamingRecognize(context.Background())
+	if err != nil {
+		t.Fatalf("StreamingRecognize: %v", err)
+	}
+	stream.Send(&pb.ASRRequest{Request: &pb.ASRRequest_Config{Config: &pb.StreamingConfig{Session: &pb.SessionInfo{SessionId: "replay_1"}}}})
+	stream.Send(&pb.ASRRequest{Request: &pb.ASRRequest_Audio{Audio: &pb.AudioChunk{Data: make([]byte, 3200)}}})
+
+	resp, err := stream.Recv()
+	if err != nil || resp.Transcript != "hel" || resp.IsFinal || resp.SessionId != "replay_1" {
+		t.Fatalf("first response = %+v, %v", resp, err)
+	}
+
+	received := make(chan *pb.ASRResponse, 1)
+	go func() {
+		resp, _ := stream.Recv()
+		received <- resp
+	}()
+	select {
+	case resp := <-received:
+		t.Fatalf("received %+v before the audio reached its offset", resp)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	stream.Send(&pb.ASRRequest{Request: &pb.ASRRequest_Audio{Audio: &pb.AudioChunk{Data: make([]byte, 16000)}}})
+	select {
+	case resp := <-received:
+		if resp == nil || resp.Transcript != "hello" || !resp.IsFinal || resp.DetectedLanguage != "en-US" {
+			t.Fatalf("second response = %+v", resp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("final result was not released after its audio arrived")
+	}
+
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Fatalf("Recv after the last event = %v, want EOF", err)
+	}
+}
+
+func TestRecordedASRFlushesWhenFinished(t *testing.T) {
+	asr := newRecordedASR([]recording.Event{recognitionEvent(60_000, "late", true)})
+	stream, _ := asr.StreamingRecognize(context.Background())
+
+	received := make(chan *pb.ASRResponse, 1)
+	go func() {
+		resp, _ := stream.Recv()
+		received <- resp
+	}()
+	asr.finish()
+
+	select {
+	case resp := <-received:
+		if resp == nil || resp.Transcript != "late" {
+			t.Fatalf("response = %+v", resp)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("remaining results were not flushed after the audio finished")
+	}
+}
+
+func TestReplayRecordedSession(t *testing.T) {
+	dir := recordSession(t, recording.ModeStereo, func(r *recording.Recorder) {
+		r.CaptureConfig(recording.SessionConfig{SourceLanguage: "en-US", TargetLanguage: "es-ES", Formality: pb.Formality_FORMALITY_FORMAL.String()})
+		r.WriteInbound(make([]byte, 2*audio.SampleRate*audio.BytesPerSample))
+		r.CaptureRecognition(recording.Recognition{Transcript: "hello", IsFinal: true, DetectedLanguage: "en-US"})
+		r.CaptureTranslation(recording.Translation{Text: "hello", IsFinal: true, TranslatedText: "hola"})
+		r.CaptureRecognition(recording.Recognition{Transcript: "goodbye", IsFinal: true, DetectedLanguage: "en-US"})
+		r.CaptureTranslation(recording.Translation{Text: "goodbye", IsFinal: true, TranslatedText: "adios"})
+	})
+	manifest, events, pcm, err := loadRecording(dir)
+	if err != nil {
+		t.Fatalf("loadRecording: %v", err)
+	}
+
+	opts := options{dir: dir, asr: backendRecorded, translator: backendRecorded, tts: backendOff, settle: 5 * time.Second}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	r, err := run(ctx, &config.Config{}, opts, manifest, events, pcm, discardLogger())
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	if r.SessionID != "sess_1" || r.Original != 2 || r.Replayed != 2 || r.Identical != 2 || r.Differs() {
+		t.Fatalf("report = %+v", r)
+	}
+	for _, c := range r.Changes {
+		if c.Kind != changeSame {
+			t.Fatalf("change = %+v, %+v, %+v", c.Kind, c.Original, c.Replayed)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/audio"
+	"ai-translator/internal/recording"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type recordedASR struct {
+	events   []recording.Event
+	finished chan struct{}
+}
+
+func newRecordedASR(events []recording.Event) *recordedASR {
+	a := &recordedASR{finished: make(chan struct{})}
+	for _, e := range events {
+		if e.Kind == recording.EventRecognition && e.Recognition != nil {
+			a.events = append(a.events, e)
+		}
+	}
+	return a
+}
+
+func (a *recordedASR) finish() {
+	close(a.finished)
+}
+
+func (a *recordedASR) StreamingRecognize(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[pb.ASRRequest, pb.ASRResponse], error) {
+	return &recordedASRStream{
+		ctx:      ctx,
+		events:   a.events,
+		finished: a.finished,
+		notify:   make(chan struct{}, 1),
+	}, nil
+}
+
+type recordedASRStream struct {
+	grpc.ClientStream
+	ctx       context.Context
+	events    []recording.Event
+	finished  <-chan struct{}
+	notify    chan struct{}
+	mu        sync.Mutex
+	sessionID string
+	audioMs   int64
+	audioEnd  bool
+}
+
+func (s *recordedASRStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *recordedASRStream) Send(req *pb.ASRRequest) error {
+	s.mu.Lock()
+	switch r := req.Request.(type) {
+	case *pb.ASRRequest_Config:
+		s.sessionID = r.Config.GetSession().GetSessionId()
+	case *pb.ASRRequest_Audio:
+		s.audioMs += audio.DurationOf(len(r.Audio.Data)).Milliseconds()
+	}
+	s.mu.Unlock()
+	s.wake()
+	return nil
+}
+
+func (s *recordedASRStream) CloseSend() error {
+	s.mu.Lock()
+	s.audioEnd = true
+	s.mu.Unlock()
+	s.wake()
+	return nil
+}
+
+func (s *recordedASRStream) wake() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (s *recordedASRStream) Recv() (*pb.ASRResponse, error) {
+	for {
+		s.mu.Lock()
+		if len(s.events) == 0 {
+			s.mu.Unlock()
+			return nil, io.EOF
+		}
+		next := s.events[0]
+		if next.OffsetMs <= s.audioMs || s.audioEnd {
+			s.events = s.events[1:]
+			sessionID := s.sessionID
+			s.mu.Unlock()
+			return &pb.ASRResponse{
+				SessionId:        sessionID,
+				Transcript:       next.Recognition.Transcript,
+				IsFinal:          next.Recognition.IsFinal,
+				Stability:        next.Recognition.Stability,
+				DetectedLanguage: next.Recognition.DetectedLanguage,
+			}, nil
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.notify:
+		case <-s.finished:
+			s.CloseSend()
+		case <-s.ctx.Done():
+			return nil, s.ctx.Err()
+		}
+	}
+}
+
+type translationKey struct {
+	text    string
+	isFinal bool
+}
+
+type recordedTranslator struct {
+	mu      sync.Mutex
+	results map[translationKey][]*recording.Translation
+}
+
+func newRecordedTranslator(events []recording.Event) *recordedTranslator {
+	t := &recordedTranslator{results: make(map[translationKey][]*recording.Translation)}
+	for _, e := range events {
+		if e.Kind == recording.EventTranslation && e.Translation != nil {
+			key := translationKey{text: e.Translation.Text, isFinal: e.Translation.IsFinal}
+			t.results[key] = append(t.results[key], e.Translation)
+		}
+	}
+	return t
+}
+
+func (t *recordedTranslator) Translate(ctx context.Context, req *pb.TranslateRequest, opts ...grpc.CallOption) (*pb.TranslateResponse, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := translationKey{text: req.Text, isFinal: req.IsFinal}
+	queue := t.results[key]
+	if len(queue) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no recorded translation for %q", req.Text)
+	}
+	result := queue[0]
+	if len(queue) > 1 {
+		t.results[key] = queue[1:]
+	}
+
+	return &pb.TranslateResponse{
+		SessionId:      req.SessionId,
+		TranslatedText: result.TranslatedText,
+		SourceLanguage: result.SourceLanguage,
+		TargetLanguage: result.TargetLanguage,
+		IsFinal:        result.IsFinal,
+		Engine:         result.Engine,
+	}, nil
+}
+
+func (t *recordedTranslator) StreamTranslate(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[pb.TranslateRequest, pb.TranslateResponse], error) {
+	return nil, status.Error(codes.Unimplemented, "streaming translation is not recorded")
+}
+
+type silentTTS struct{}
+
+func (silentTTS) Synthesize(ctx context.Context, req *pb.TTSRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.TTSResponse], error) {
+	return silentTTSStream{ctx: ctx}, nil
+}
+
+func (silentTTS) StreamSynthesize(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[pb.TTSRequest, pb.TTSResponse], error) {
+	return nil, status.Error(codes.Unimplemented, "speech synthesis is disabled")
+}
+
+type silentTTSStream struct {
+	grpc.ClientStream
+	ctx context.Context
+}
+
+func (s silentTTSStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s silentTTSStream) Recv() (*pb.TTSResponse, error) {
+	return nil, io.EOF
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/auth"
+	"ai-translator/internal/batch"
+	"ai-translator/internal/config"
+	"ai-translator/internal/logging"
+	"ai-translator/internal/quota"
+	"ai-translator/internal/transcript"
+	"ai-translator/internal/transport"
+)
+
+type options struct {
+	source      string
+	target      string
+	format      string
+	outDir      string
+	subtitles   string
+	lang        string
+	concurrency int
+}
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.source, "source", "", "Source language code, e.g. en-US")
+	flag.StringVar(&opts.target, "target", "", "Target language code, e.g. es-ES")
+	flag.StringVar(&opts.format, "format", "", "Input format (wav, pcm), detected from the file extension by default")
+	flag.StringVar(&opts.outDir, "out", "", "Output directory, the input file's directory by default")
+	flag.StringVar(&opts.subtitles, "transcript", transcript.FormatSRT, "Transcript format (json, srt, vtt)")
+	flag.StringVar(&opts.lang, "lang", transcript.LanguageBilingual, "Transcript language (source, target, bilingual)")
+	flag.IntVar(&opts.concurrency, "concurrency", 1, "Files translated in parallel")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -source LANG -target LANG [flags] FILE...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	mode, err := transcript.ParseLanguageMode(opts.lang)
+	if err == nil {
+		err = opts.validate()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := config.Load()
+	logger := logging.New(cfg.LogLevel)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	processor, closeClients, err := newProcessor(ctx, cfg, logger)
+	if err != nil {
+		logger.Error("failed to connect to backends", "error", err)
+		os.Exit(1)
+	}
+	defer closeClients()
+
+	files := flag.Args()
+	sem := make(chan struct{}, opts.concurrency)
+	errs := make([]error, len(files))
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				errs[i] = ctx.Err()
+				return
+			}
+			errs[i] = translateFile(ctx, processor, file, opts, mode)
+		}()
+	}
+	wg.Wait()
+
+	failed := false
+	for i, err := range errs {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", files[i], err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func (o options) validate() error {
+	switch {
+	case o.source == "" || o.target == "":
+		return fmt.Errorf("-source and -target are required")
+	case flag.NArg() == 0:
+		return fmt.Errorf("no input files")
+	case o.concurrency < 1:
+		return fmt.Errorf("-concurrency must be at least 1")
+	case o.subtitles != transcript.FormatJSON && o.subtitles != transcript.FormatSRT && o.subtitles != transcript.FormatWebVTT:
+		return fmt.Errorf("unknown transcript format %q", o.subtitles)
+	}
+	return nil
+}
+
+func translateFile(ctx context.Context, processor *batch.Processor, file string, opts options, mode string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	format := opts.format
+	if format == "" {
+		format = batch.FormatFromFilename(file)
+	}
+	pcm, err := batch.Decode(data, format)
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Base(file)
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	dir := opts.outDir
+	if dir == "" {
+		dir = filepath.Dir(file)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return err
+	}
+	out := filepath.Join(dir, base+"."+opts.target)
+
+	task := batch.Task{
+		ID:             "file_" + base,
+		TenantID:       auth.DefaultTenantID,
+		SourceLanguage: opts.source,
+		TargetLanguage: opts.target,
+		Audio:          pcm,
+	}
+	result, err := processor.Process(ctx, task, func(p batch.Progress) {
+		fmt.Fprintf(os.Stderr, "%s: %s %3d%% (%d utterances, %d failed)\n", name, p.Stage, p.Percent, p.Utterances, p.Failed)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := batch.WriteWAV(out+".wav", result.Audio); err != nil {
+		return err
+	}
+	if err := writeTranscript(out+"."+opts.subtitles, result.Transcript, opts.subtitles, mode); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%s: wrote %s.wav and %s.%s\n", name, out, out, opts.subtitles)
+	return nil
+}
+
+func writeTranscript(path string, t *transcript.Transcript, format, mode string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case transcript.FormatSRT:
+		err = transcript.WriteSRT(f, t, mode)
+	case transcript.FormatWebVTT:
+		err = transcript.WriteWebVTT(f, t, mode)
+	default:
+		err = transcript.WriteJSON(f, t)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func newProcessor(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*batch.Processor, func(), error) {
+	var clientTLS *tls.Config
+	if cfg.GRPCTLS {
+		certs, err := transport.NewCertReloader(cfg.GRPCTLSCertFile, cfg.GRPCTLSKeyFile, cfg.GRPCTLSCAFile, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+		clientTLS = certs.ClientConfig(cfg.GRPCTLSServerName)
+	}
+
+	var conns []*transport.GRPCClient
+	closeAll := func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}
+	for _, addr := range []string{cfg.ASRAddress, cfg.TranslatorAddr, cfg.TTSAddress} {
+		conn, err := transport.NewGRPCClient(ctx, addr, clientTLS, logger)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("connect to %s: %w", addr, err)
+		}
+		conns = append(conns, conn)
+	}
+
+	processor := batch.NewProcessor(
+		pb.NewASRServiceClient(conns[0].Conn()),
+		pb.NewTranslatorServiceClient(conns[1].Conn()),
+		pb.NewTTSServiceClient(conns[2].Conn()),
+		quota.NewManager(quota.Limits{}),
+		nil,
+		logger,
+	)
+	return processor, closeAll, nil
+}
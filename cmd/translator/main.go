@@ -2,25 +2,31 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	pb "ai-translator/api/proto"
 	"ai-translator/internal/config"
 	"ai-translator/internal/logging"
+	"ai-translator/internal/metrics"
+	"ai-translator/internal/tracing"
 	"ai-translator/internal/translator"
 	"ai-translator/internal/transport"
+	"ai-translator/internal/util"
 )
 
 type translatorServer struct {
 	pb.UnimplementedTranslatorServiceServer
-	client *translator.GeminiClient
-	ctxMgr *translator.ContextManager
-	logger *slog.Logger
+	engines *translator.Chain
+	ctxMgr  *translator.ContextManager
+	logger  *slog.Logger
 }
 
 func (s *translatorServer) Translate(ctx context.Context, req *pb.TranslateRequest) (*pb.TranslateResponse, error) {
@@ -29,27 +35,72 @@ func (s *translatorServer) Translate(ctx context.Context, req *pb.TranslateReque
 	convCtx := s.ctxMgr.Get(req.SessionId)
 	recentContext := convCtx.GetRecentOriginals()
 
-	translated, err := s.client.Translate(ctx, req.Text, req.SourceLanguage, req.TargetLanguage, recentContext)
+	start := time.Now()
+	translated, engine, err := s.engines.Translate(ctx, req.Text, req.SourceLanguage, req.TargetLanguage, styleFromProto(req.Style), recentContext)
+	engineLabel := engine
+	if engineLabel == "" {
+		engineLabel = "none"
+	}
+	metrics.TranslationDuration.WithLabelValues(
+		translator.LanguageLabel(req.SourceLanguage),
+		translator.LanguageLabel(req.TargetLanguage),
+		engineLabel,
+		metrics.Outcome(err),
+	).Observe(time.Since(start).Seconds())
 	if err != nil {
-		logger.Error("translation failed", "error", err)
-		return nil, err
+		logger.Error("translation failed", "error", err, "engine", engine)
+		return nil, translator.ToStatus(err, engine)
 	}
 
 	if req.IsFinal {
 		convCtx.Add(req.Text, translated, req.SourceLanguage, req.TargetLanguage)
 	}
 
-	logger.Debug("translated", "source", req.Text, "target", translated)
+	logger.Debug("translated", "source", req.Text, "target", translated, "engine", engine)
+
+	sourceLanguage := req.SourceLanguage
+	if sourceLanguage == "" {
+		sourceLanguage = translator.DetectLanguage(req.Text)
+	}
 
 	return &pb.TranslateResponse{
 		SessionId:      req.SessionId,
 		TranslatedText: translated,
-		SourceLanguage: req.SourceLanguage,
+		SourceLanguage: sourceLanguage,
 		TargetLanguage: req.TargetLanguage,
 		IsFinal:        req.IsFinal,
+		Engine:         engine,
 	}, nil
 }
 
+func styleFromProto(style *pb.TranslationStyle) translator.Style {
+	result := translator.DefaultStyle()
+	if style == nil {
+		return result
+	}
+
+	switch style.Formality {
+	case pb.Formality_FORMALITY_FORMAL:
+		result.Formality = translator.FormalityFormal
+	case pb.Formality_FORMALITY_INFORMAL:
+		result.Formality = translator.FormalityInformal
+	}
+
+	switch style.Domain {
+	case pb.Domain_DOMAIN_MEDICAL:
+		result.Domain = translator.DomainMedical
+	case pb.Domain_DOMAIN_LEGAL:
+		result.Domain = translator.DomainLegal
+	case pb.Domain_DOMAIN_CUSTOMER_SUPPORT:
+		result.Domain = translator.DomainCustomerSupport
+	case pb.Domain_DOMAIN_TECHNICAL:
+		result.Domain = translator.DomainTechnical
+	}
+
+	result.Audience = style.Audience
+	return result
+}
+
 func (s *translatorServer) StreamTranslate(stream pb.TranslatorService_StreamTranslateServer) error {
 	for {
 		req, err := stream.Recv()
@@ -75,30 +126,77 @@ func main() {
 	cfg := config.Load()
 	logger := logging.New(cfg.LogLevel)
 
-	if cfg.GeminiAPIKey == "" {
-		logger.Error("GEMINI_API_KEY is required")
-		os.Exit(1)
-	}
-
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	geminiClient, err := translator.NewGeminiClient(ctx, cfg.GeminiAPIKey, logger)
+	shutdownTracing, err := tracing.Setup(ctx, "translator", tracing.Config{
+		Exporter:     cfg.TracingExporter,
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		OTLPInsecure: cfg.OTLPInsecure,
+		SampleRatio:  cfg.TracingSampleRatio,
+	}, logger)
+	if err != nil {
+		logger.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	specs, err := translator.ParseEngineSpecs(cfg.TranslatorEngines)
 	if err != nil {
-		logger.Error("failed to create Gemini client", "error", err)
+		logger.Error("invalid TRANSLATOR_ENGINES", "error", err)
 		os.Exit(1)
 	}
-	defer geminiClient.Close()
+
+	breakerCfg := util.BreakerConfig{
+		FailureThreshold: cfg.TranslatorBreakerFailures,
+		Cooldown:         cfg.TranslatorBreakerCooldown,
+	}
+
+	engines := translator.NewChain(logger)
+	defer engines.Close()
+
+	for _, spec := range specs {
+		engine, err := translator.NewEngine(ctx, spec, os.Getenv(spec.Credential), logger)
+		if err != nil {
+			logger.Error("failed to create translation engine", "error", err, "provider", spec.Provider, "model", spec.Model)
+			os.Exit(1)
+		}
+		engines.Add(engine, spec.Timeout, breakerCfg)
+		logger.Info("translation engine registered", "engine", engine.Name(), "timeout", spec.Timeout, "credential", spec.Credential)
+	}
 
 	ctxMgr := translator.NewContextManager()
 
-	grpcServer := transport.NewGRPCServer(logger)
+	var serverTLS *tls.Config
+	if cfg.GRPCTLS {
+		certs, err := transport.NewCertReloader(cfg.GRPCTLSCertFile, cfg.GRPCTLSKeyFile, cfg.GRPCTLSCAFile, logger)
+		if err != nil {
+			logger.Error("failed to load TLS certificates", "error", err)
+			os.Exit(1)
+		}
+		serverTLS, err = certs.ServerConfig(cfg.GRPCTLSClientAuth)
+		if err != nil {
+			logger.Error("invalid TLS configuration", "error", err)
+			os.Exit(1)
+		}
+		go certs.Watch(ctx, cfg.TLSReloadInterval)
+	}
+
+	grpcServer := transport.NewGRPCServer(serverTLS, logger)
 	pb.RegisterTranslatorServiceServer(grpcServer.Server(), &translatorServer{
-		client: geminiClient,
-		ctxMgr: ctxMgr,
-		logger: logger,
+		engines: engines,
+		ctxMgr:  ctxMgr,
+		logger:  logger,
 	})
 
+	grpcServer.WatchHealth(ctx, pb.TranslatorService_ServiceDesc.ServiceName, cfg.HealthCheckInterval, engines.Healthy)
+
+	metricsServer := metrics.NewServer(fmt.Sprintf(":%d", cfg.TranslatorMetricsPort), logger)
+	go func() {
+		if err := metricsServer.Start(); err != nil && err != http.ErrServerClosed {
+			logger.Error("metrics server error", "error", err)
+		}
+	}()
+
 	go func() {
 		addr := fmt.Sprintf(":%d", cfg.TranslatorPort)
 		if err := grpcServer.Start(addr); err != nil {
@@ -120,7 +218,9 @@ func main() {
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer shutdownCancel()
+	defer shutdownTracing(shutdownCtx)
 
 	grpcServer.Stop(shutdownCtx)
+	metricsServer.Stop(shutdownCtx)
 	logger.Info("Translator service stopped")
 }
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/metrics"
+	"ai-translator/internal/translator"
+	"ai-translator/internal/util"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeEngine struct {
+	err error
+}
+
+func (e *fakeEngine) Name() string { return "fake" }
+
+func (e *fakeEngine) Translate(ctx context.Context, text, sourceLang, targetLang string, style translator.Style, conversationContext []string) (string, error) {
+	if e.err != nil {
+		return "", e.err
+	}
+	return "hola", nil
+}
+
+func (e *fakeEngine) Close() error { return nil }
+
+func newTestServer(engine *fakeEngine) *translatorServer {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	chain := translator.NewChain(logger)
+	chain.Add(engine, time.Second, util.BreakerConfig{FailureThreshold: 5, Cooldown: time.Hour})
+	return &translatorServer{engines: chain, ctxMgr: translator.NewContextManager(), logger: logger}
+}
+
+func TestStyleFromProto(t *testing.T) {
+	if got := styleFromProto(nil); got != translator.DefaultStyle() {
+		t.Fatalf("styleFromProto(nil) = %+v", got)
+	}
+
+	got := styleFromProto(&pb.TranslationStyle{
+		Formality: pb.Formality_FORMALITY_INFORMAL,
+		Domain:    pb.Domain_DOMAIN_LEGAL,
+		Audience:  "tenants",
+	})
+	want := translator.Style{Formality: translator.FormalityInformal, Domain: translator.DomainLegal, Audience: "tenants"}
+	if got != want {
+		t.Fatalf("styleFromProto = %+v, want %+v", got, want)
+	}
+
+	domains := map[pb.Domain]translator.Domain{
+		pb.Domain_DOMAIN_GENERAL:          translator.DomainGeneral,
+		pb.Domain_DOMAIN_MEDICAL:          translator.DomainMedical,
+		pb.Domain_DOMAIN_LEGAL:            translator.DomainLegal,
+		pb.Domain_DOMAIN_CUSTOMER_SUPPORT: translator.DomainCustomerSupport,
+		pb.Domain_DOMAIN_TECHNICAL:        translator.DomainTechnical,
+	}
+	for domain, want := range domains {
+		if got := styleFromProto(&pb.TranslationStyle{Domain: domain}).Domain; got != want {
+			t.Errorf("domain %v = %q, want %q", domain, got, want)
+		}
+	}
+	if got := styleFromProto(&pb.TranslationStyle{Formality: pb.Formality_FORMALITY_FORMAL}).Formality; got != translator.FormalityFormal {
+		t.Errorf("formal = %q", got)
+	}
+	if got := styleFromProto(&pb.TranslationStyle{}).Formality; got != translator.FormalityAuto {
+		t.Errorf("auto = %q", got)
+	}
+}
+
+func sampleCount(t *testing.T, labels ...string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := metrics.TranslationDuration.WithLabelValues(labels...).(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatalf("read histogram: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestTranslateRecordsDuration(t *testing.T) {
+	okBefore := sampleCount(t, "en", "es", "fake", "ok")
+	failedBefore := sampleCount(t, "other", "es", "fake", "error")
+
+	resp, err := newTestServer(&fakeEngine{}).Translate(context.Background(), &pb.TranslateRequest{
+		SessionId: "s1", Text: "hello", SourceLanguage: "en-US", TargetLanguage: "es-ES", IsFinal: true,
+	})
+	if err != nil || resp.TranslatedText != "hola" || resp.Engine != "fake" {
+		t.Fatalf("Translate = %v, %v", resp, err)
+	}
+	if got := sampleCount(t, "en", "es", "fake", "ok") - okBefore; got != 1 {
+		t.Fatalf("ok observations grew by %d, want 1", got)
+	}
+
+	_, err = newTestServer(&fakeEngine{err: translator.ErrSafetyBlocked}).Translate(context.Background(), &pb.TranslateRequest{
+		SessionId: "s2", Text: "hello", SourceLanguage: "xx", TargetLanguage: "es-ES",
+	})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Translate error = %v, want FailedPrecondition", err)
+	}
+	if got := sampleCount(t, "other", "es", "fake", "error") - failedBefore; got != 1 {
+		t.Fatalf("error observations grew by %d, want 1", got)
+	}
+}
+
+func TestTranslateDetectsMissingSourceLanguage(t *testing.T) {
+	s := newTestServer(&fakeEngine{})
+	resp, err := s.Translate(context.Background(), &pb.TranslateRequest{SessionId: "s1", Text: "Привет, как ты?", TargetLanguage: "es-ES", IsFinal: true})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	if resp.SourceLanguage != "ru" {
+		t.Fatalf("source language = %q, want the detected ru", resp.SourceLanguage)
+	}
+
+	resp, err = s.Translate(context.Background(), &pb.TranslateRequest{SessionId: "s2", Text: "Привет, как ты?", SourceLanguage: "bg-BG", TargetLanguage: "es-ES", IsFinal: true})
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	if resp.SourceLanguage != "bg-BG" {
+		t.Fatalf("source language = %q, want the requested bg-BG", resp.SourceLanguage)
+	}
+}
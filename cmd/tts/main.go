@@ -2,24 +2,43 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"io"
 	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"unicode/utf8"
 
 	pb "ai-translator/api/proto"
 	"ai-translator/internal/audio"
 	"ai-translator/internal/config"
 	"ai-translator/internal/logging"
+	"ai-translator/internal/metrics"
+	"ai-translator/internal/tracing"
 	"ai-translator/internal/transport"
 	"ai-translator/internal/tts"
+
+	tspb "cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+var encodings = map[pb.AudioEncoding]tspb.AudioEncoding{
+	pb.AudioEncoding_AUDIO_ENCODING_LINEAR16: tspb.AudioEncoding_LINEAR16,
+	pb.AudioEncoding_AUDIO_ENCODING_OGG_OPUS: tspb.AudioEncoding_OGG_OPUS,
+	pb.AudioEncoding_AUDIO_ENCODING_MP3:      tspb.AudioEncoding_MP3,
+}
+
+type synthesizer interface {
+	Synthesize(ctx context.Context, text string, cfg tts.SynthesizeConfig) ([]byte, error)
+}
+
 type ttsServer struct {
 	pb.UnimplementedTTSServiceServer
-	client *tts.Client
+	client synthesizer
 	logger *slog.Logger
 }
 
@@ -41,42 +60,75 @@ func (s *ttsServer) Synthesize(req *pb.TTSRequest, stream pb.TTSService_Synthesi
 		cfg.Pitch = float64(req.VoiceConfig.Pitch)
 	}
 
-	audioData, err := s.client.Synthesize(ctx, req.Text, cfg)
-	if err != nil {
-		logger.Error("synthesis failed", "error", err)
-		return err
+	encoding, ok := encodings[req.Encoding]
+	if !ok {
+		return status.Errorf(codes.InvalidArgument, "unsupported audio encoding %v", req.Encoding)
 	}
+	cfg.Encoding = encoding
 
-	chunkSize := audio.SamplesForDuration(100) * audio.BytesPerSample
+	sentences := []string{req.Text}
+	if split := tts.SplitSentences(req.Text); len(split) > 0 && cfg.Encoding == tspb.AudioEncoding_LINEAR16 {
+		sentences = split
+	}
 
-	for offset := 0; offset < len(audioData); offset += chunkSize {
-		end := offset + chunkSize
-		if end > len(audioData) {
-			end = len(audioData)
+	sent := 0
+	for i, sentence := range sentences {
+		audioData, err := s.client.Synthesize(ctx, sentence, cfg)
+		if err != nil {
+			logger.Error("synthesis failed", "error", err, "sentence", i)
+			return err
 		}
+		recordCharacters(cfg, sentence)
 
-		chunk := audioData[offset:end]
-		isFinal := end >= len(audioData)
+		if err := sendAudio(stream, req.SessionId, cfg, audioData, i == len(sentences)-1); err != nil {
+			return err
+		}
+		sent += len(audioData)
+	}
+
+	logger.Debug("synthesis complete", "text_len", len(req.Text), "sentences", len(sentences), "audio_len", sent)
+	return nil
+}
 
+type responseSender interface {
+	Send(*pb.TTSResponse) error
+}
+
+func sendAudio(stream responseSender, sessionID string, cfg tts.SynthesizeConfig, audioData []byte, final bool) error {
+	chunkSize := audio.SamplesForDuration(100) * audio.BytesPerSample
+
+	for offset := 0; offset < len(audioData) || (final && offset == 0); offset += chunkSize {
+		end := min(offset+chunkSize, len(audioData))
 		resp := &pb.TTSResponse{
-			SessionId: req.SessionId,
+			SessionId: sessionID,
 			Audio: &pb.AudioChunk{
-				Data:       chunk,
+				Data:       audioData[offset:end],
 				SampleRate: audio.SampleRate,
 				Channels:   audio.Channels,
 			},
-			IsFinal: isFinal,
+			IsFinal:   final && end >= len(audioData),
+			VoiceName: cfg.VoiceName,
+			VoiceTier: tts.VoiceTier(cfg.VoiceName),
 		}
 
 		if err := stream.Send(resp); err != nil {
 			return err
 		}
 	}
-
-	logger.Debug("synthesis complete", "text_len", len(req.Text), "audio_len", len(audioData))
 	return nil
 }
 
+func recordCharacters(cfg tts.SynthesizeConfig, text string) {
+	language, voice := cfg.LanguageCode, cfg.VoiceName
+	if tts.GetVoiceForLanguage(language) == "" {
+		language = "other"
+	}
+	if tts.GetLanguageCodeFromVoice(voice) == "" {
+		voice = "custom"
+	}
+	metrics.TTSCharacters.WithLabelValues(language, voice).Add(float64(utf8.RuneCountInString(text)))
+}
+
 func (s *ttsServer) StreamSynthesize(stream pb.TTSService_StreamSynthesizeServer) error {
 	ctx := stream.Context()
 
@@ -92,34 +144,19 @@ func (s *ttsServer) StreamSynthesize(stream pb.TTSService_StreamSynthesizeServer
 		langCode := tts.NormalizeLanguageForTTS(req.LanguageCode)
 		cfg := tts.DefaultSynthesizeConfig(langCode)
 		cfg.VoiceName = tts.GetVoiceForLanguage(langCode)
+		if encoding, ok := encodings[req.Encoding]; ok {
+			cfg.Encoding = encoding
+		}
 
 		audioData, err := s.client.Synthesize(ctx, req.Text, cfg)
 		if err != nil {
 			s.logger.Error("synthesis failed", "error", err, "session_id", req.SessionId)
 			continue
 		}
+		recordCharacters(cfg, req.Text)
 
-		chunkSize := audio.SamplesForDuration(100) * audio.BytesPerSample
-
-		for offset := 0; offset < len(audioData); offset += chunkSize {
-			end := offset + chunkSize
-			if end > len(audioData) {
-				end = len(audioData)
-			}
-
-			resp := &pb.TTSResponse{
-				SessionId: req.SessionId,
-				Audio: &pb.AudioChunk{
-					Data:       audioData[offset:end],
-					SampleRate: audio.SampleRate,
-					Channels:   audio.Channels,
-				},
-				IsFinal: end >= len(audioData),
-			}
-
-			if err := stream.Send(resp); err != nil {
-				return err
-			}
+		if err := sendAudio(stream, req.SessionId, cfg, audioData, true); err != nil {
+			return err
 		}
 	}
 }
@@ -131,6 +168,17 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	shutdownTracing, err := tracing.Setup(ctx, "tts", tracing.Config{
+		Exporter:     cfg.TracingExporter,
+		OTLPEndpoint: cfg.OTLPEndpoint,
+		OTLPInsecure: cfg.OTLPInsecure,
+		SampleRatio:  cfg.TracingSampleRatio,
+	}, logger)
+	if err != nil {
+		logger.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
 	ttsClient, err := tts.NewClient(ctx, logger)
 	if err != nil {
 		logger.Error("failed to create TTS client", "error", err)
@@ -138,12 +186,36 @@ func main() {
 	}
 	defer ttsClient.Close()
 
-	grpcServer := transport.NewGRPCServer(logger)
+	var serverTLS *tls.Config
+	if cfg.GRPCTLS {
+		certs, err := transport.NewCertReloader(cfg.GRPCTLSCertFile, cfg.GRPCTLSKeyFile, cfg.GRPCTLSCAFile, logger)
+		if err != nil {
+			logger.Error("failed to load TLS certificates", "error", err)
+			os.Exit(1)
+		}
+		serverTLS, err = certs.ServerConfig(cfg.GRPCTLSClientAuth)
+		if err != nil {
+			logger.Error("invalid TLS configuration", "error", err)
+			os.Exit(1)
+		}
+		go certs.Watch(ctx, cfg.TLSReloadInterval)
+	}
+
+	grpcServer := transport.NewGRPCServer(serverTLS, logger)
 	pb.RegisterTTSServiceServer(grpcServer.Server(), &ttsServer{
 		client: ttsClient,
 		logger: logger,
 	})
 
+	grpcServer.WatchHealth(ctx, pb.TTSService_ServiceDesc.ServiceName, cfg.HealthCheckInterval, ttsClient.Healthy)
+
+	metricsServer := metrics.NewServer(fmt.Sprintf(":%d", cfg.TTSMetricsPort), logger)
+	go func() {
+		if err := metricsServer.Start(); err != nil && err != http.ErrServerClosed {
+			logger.Error("metrics server error", "error", err)
+		}
+	}()
+
 	go func() {
 		addr := fmt.Sprintf(":%d", cfg.TTSPort)
 		if err := grpcServer.Start(addr); err != nil {
@@ -165,7 +237,9 @@ func main() {
 
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer shutdownCancel()
+	defer shutdownTracing(shutdownCtx)
 
 	grpcServer.Stop(shutdownCtx)
+	metricsServer.Stop(shutdownCtx)
 	logger.Info("TTS service stopped")
 }
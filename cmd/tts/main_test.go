@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/tts"
+
+	"google.golang.org/grpc"
+)
+
+type fakeSynthesizer struct {
+	texts []string
+	fail  int
+}
+
+func (f *fakeSynthesizer) Synthesize(ctx context.Context, text string, cfg tts.SynthesizeConfig) ([]byte, error) {
+	f.texts = append(f.texts, text)
+	if f.fail > 0 && len(f.texts) == f.fail {
+		return nil, errors.New("provider failed")
+	}
+	return make([]byte, 5000), nil
+}
+
+type fakeSynthesizeStream struct {
+	grpc.ServerStream
+	synth     *fakeSynthesizer
+	responses []*pb.TTSResponse
+	requested []int
+}
+
+func (s *fakeSynthesizeStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *fakeSynthesizeStream) Send(resp *pb.TTSResponse) error {
+	s.responses = append(s.responses, resp)
+	s.requested = append(s.requested, len(s.synth.texts))
+	return nil
+}
+
+func newTestServer(synth *fakeSynthesizer) *ttsServer {
+	return &ttsServer{client: synth, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+}
+
+const twoSentences = "The meeting starts at nine today. Please bring the quarterly report with you."
+
+func TestSynthesizeSendsEachSentenceBeforeTheNext(t *testing.T) {
+	synth := &fakeSynthesizer{}
+	stream := &fakeSynthesizeStream{synth: synth}
+	req := &pb.TTSRequest{SessionId: "s1", Text: twoSentences, LanguageCode: "en-US"}
+	if err := newTestServer(synth).Synthesize(req, stream); err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+
+	if len(synth.texts) != 2 {
+		t.Fatalf("provider calls = %q, want one per sentence", synth.texts)
+	}
+	if len(stream.responses) != 4 {
+		t.Fatalf("responses = %d, want 4 chunks", len(stream.responses))
+	}
+	if stream.requested[0] != 1 {
+		t.Fatalf("first chunk sent after %d provider calls, want 1", stream.requested[0])
+	}
+	for i, resp := range stream.responses {
+		if final := i == len(stream.responses)-1; resp.IsFinal != final {
+			t.Errorf("response %d IsFinal = %v, want %v", i, resp.IsFinal, final)
+		}
+	}
+}
+
+func TestSynthesizeEncodedFormatsInOnePiece(t *testing.T) {
+	synth := &fakeSynthesizer{}
+	stream := &fakeSynthesizeStream{synth: synth}
+	req := &pb.TTSRequest{SessionId: "s1", Text: twoSentences, LanguageCode: "en-US", Encoding: pb.AudioEncoding_AUDIO_ENCODING_OGG_OPUS}
+	if err := newTestServer(synth).Synthesize(req, stream); err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	if len(synth.texts) != 1 || synth.texts[0] != twoSentences {
+		t.Fatalf("provider calls = %q, want the whole text once", synth.texts)
+	}
+	if last := stream.responses[len(stream.responses)-1]; !last.IsFinal {
+		t.Fatal("last response is not final")
+	}
+}
+
+func TestSynthesizeFailureAfterFirstSentence(t *testing.T) {
+	synth := &fakeSynthesizer{fail: 2}
+	stream := &fakeSynthesizeStream{synth: synth}
+	req := &pb.TTSRequest{SessionId: "s1", Text: twoSentences, LanguageCode: "en-US"}
+	if err := newTestServer(synth).Synthesize(req, stream); err == nil {
+		t.Fatal("Synthesize succeeded, want the provider error")
+	}
+	for _, resp := range stream.responses {
+		if resp.IsFinal {
+			t.Fatal("a failed synthesis sent a final response")
+		}
+	}
+}
+
+func TestSendAudioEmptyFinal(t *testing.T) {
+	stream := &fakeSynthesizeStream{synth: &fakeSynthesizer{}}
+	if err := sendAudio(stream, "s1", tts.DefaultSynthesizeConfig("en-US"), nil, true); err != nil {
+		t.Fatalf("sendAudio: %v", err)
+	}
+	if len(stream.responses) != 1 || !stream.responses[0].IsFinal {
+		t.Fatalf("responses = %v, want one empty final", stream.responses)
+	}
+}
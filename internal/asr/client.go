@@ -2,15 +2,22 @@ package asr
 
 import (
 	"context"
+	"errors"
 	"io"
 	"log/slog"
+	"time"
+
+	"ai-translator/internal/util"
 
 	speech "cloud.google.com/go/speech/apiv1"
 	speechpb "cloud.google.com/go/speech/apiv1/speechpb"
 )
 
+var ErrProviderUnhealthy = errors.New("speech provider unhealthy")
+
 type Client struct {
 	client *speech.Client
+	health *util.CircuitBreaker
 	logger *slog.Logger
 }
 
@@ -22,6 +29,7 @@ func NewClient(ctx context.Context, logger *slog.Logger) (*Client, error) {
 
 	return &Client{
 		client: client,
+		health: util.NewCircuitBreaker(util.DefaultBreakerConfig()),
 		logger: logger,
 	}, nil
 }
@@ -30,14 +38,32 @@ func (c *Client) Close() error {
 	return c.client.Close()
 }
 
+func (c *Client) Healthy(ctx context.Context) error {
+	if c.health.State() == util.BreakerOpen {
+		return ErrProviderUnhealthy
+	}
+	return nil
+}
+
+func (c *Client) report(ctx context.Context, err error) {
+	switch {
+	case err == nil:
+		c.health.Success()
+	case ctx.Err() == nil && util.IsProviderFailure(err):
+		c.health.Failure()
+	}
+}
+
 func (c *Client) StreamingRecognize(ctx context.Context) (*Stream, error) {
 	stream, err := c.client.StreamingRecognize(ctx)
+	c.report(ctx, err)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Stream{
 		stream: stream,
+		client: c,
 		logger: c.logger,
 	}, nil
 }
@@ -62,6 +88,7 @@ func DefaultStreamConfig() StreamConfig {
 
 type Stream struct {
 	stream speechpb.Speech_StreamingRecognizeClient
+	client *Client
 	logger *slog.Logger
 }
 
@@ -105,6 +132,7 @@ type RecognitionResult struct {
 	IsFinal          bool
 	Stability        float32
 	DetectedLanguage string
+	ResultEndOffset  time.Duration
 }
 
 func (s *Stream) ProcessResponses(ctx context.Context, results chan<- RecognitionResult) error {
@@ -122,6 +150,7 @@ func (s *Stream) ProcessResponses(ctx context.Context, results chan<- Recognitio
 			return nil
 		}
 		if err != nil {
+			s.client.report(ctx, err)
 			return err
 		}
 
@@ -142,6 +171,7 @@ func (s *Stream) ProcessResponses(ctx context.Context, results chan<- Recognitio
 				IsFinal:          result.IsFinal,
 				Stability:        result.Stability,
 				DetectedLanguage: lang,
+				ResultEndOffset:  result.GetResultEndTime().AsDuration(),
 			}:
 			case <-ctx.Done():
 				return ctx.Err()
@@ -0,0 +1,43 @@
+package asr
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ai-translator/internal/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClientHealthFollowsProviderFailures(t *testing.T) {
+	c := &Client{health: util.NewCircuitBreaker(util.BreakerConfig{FailureThreshold: 2, Cooldown: time.Hour})}
+	ctx := context.Background()
+
+	c.report(ctx, status.Error(codes.InvalidArgument, "bad audio"))
+	c.report(ctx, status.Error(codes.InvalidArgument, "bad audio"))
+	if err := c.Healthy(ctx); err != nil {
+		t.Fatalf("Healthy after caller errors = %v, want nil", err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	c.report(canceled, status.Error(codes.Unavailable, "canceled"))
+	c.report(canceled, status.Error(codes.Unavailable, "canceled"))
+	if err := c.Healthy(ctx); err != nil {
+		t.Fatalf("Healthy after canceled calls = %v, want nil", err)
+	}
+
+	c.report(ctx, status.Error(codes.Unavailable, "down"))
+	c.report(ctx, status.Error(codes.Unavailable, "down"))
+	if err := c.Healthy(ctx); !errors.Is(err, ErrProviderUnhealthy) {
+		t.Fatalf("Healthy after provider failures = %v, want ErrProviderUnhealthy", err)
+	}
+
+	c.report(ctx, nil)
+	if err := c.Healthy(ctx); err != nil {
+		t.Fatalf("Healthy after a success = %v, want nil", err)
+	}
+}
@@ -74,6 +74,12 @@ func (b *Buffer) Len() int {
 	return len(b.data)
 }
 
+func (b *Buffer) Available() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.maxSize - len(b.data)
+}
+
 func (b *Buffer) Wait() <-chan struct{} {
 	return b.notify
 }
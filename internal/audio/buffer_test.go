@@ -0,0 +1,104 @@
+package audio
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBufferWriteTruncatesAtCapacity(t *testing.T) {
+	b := NewBuffer(10)
+	if n := b.Write([]byte{1, 2, 3, 4, 5, 6}); n != 6 {
+		t.Fatalf("Write = %d, want 6", n)
+	}
+	if n := b.Write([]byte{7, 8, 9, 10, 11, 12}); n != 4 {
+		t.Fatalf("Write past capacity = %d, want 4", n)
+	}
+	if n := b.Write([]byte{13}); n != 0 {
+		t.Fatalf("Write into a full buffer = %d, want 0", n)
+	}
+	if b.Len() != 10 || b.Available() != 0 {
+		t.Fatalf("Len = %d, Available = %d, want 10 and 0", b.Len(), b.Available())
+	}
+	if got := b.Read(100); !bytes.Equal(got, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}) {
+		t.Fatalf("Read = %v", got)
+	}
+}
+
+func TestBufferReadInOrder(t *testing.T) {
+	b := NewBuffer(100)
+	if got := b.Read(4); got != nil {
+		t.Fatalf("Read from an empty buffer = %v, want nil", got)
+	}
+
+	b.Write([]byte{1, 2, 3, 4, 5})
+	first := b.Read(3)
+	b.Write([]byte{6})
+	if !bytes.Equal(first, []byte{1, 2, 3}) {
+		t.Fatalf("first Read = %v", first)
+	}
+	if got := b.Read(10); !bytes.Equal(got, []byte{4, 5, 6}) {
+		t.Fatalf("second Read = %v, want [4 5 6]", got)
+	}
+	if b.Len() != 0 || b.Available() != 100 {
+		t.Fatalf("Len = %d, Available = %d after draining", b.Len(), b.Available())
+	}
+
+	first[0] = 99
+	b.Write([]byte{7})
+	if got := b.Read(1); got[0] != 7 {
+		t.Fatal("Read returned a slice that aliases the buffer")
+	}
+}
+
+func TestBufferRefillsAfterDraining(t *testing.T) {
+	b := NewBuffer(8)
+	for round := range 5 {
+		if n := b.Write(make([]byte, 8)); n != 8 {
+			t.Fatalf("round %d: Write = %d, want 8", round, n)
+		}
+		if got := len(b.Read(5)) + len(b.Read(5)); got != 8 {
+			t.Fatalf("round %d: read %d bytes, want 8", round, got)
+		}
+	}
+}
+
+func TestBufferWaitCoalescesWrites(t *testing.T) {
+	b := NewBuffer(100)
+	b.Write([]byte{1})
+	b.Write([]byte{2})
+
+	select {
+	case <-b.Wait():
+	default:
+		t.Fatal("no notification after a write")
+	}
+	select {
+	case <-b.Wait():
+		t.Fatal("two writes produced two notifications")
+	default:
+	}
+}
+
+func TestBufferClose(t *testing.T) {
+	b := NewBuffer(100)
+	b.Write([]byte{1, 2})
+	b.Close()
+
+	if !b.IsClosed() {
+		t.Fatal("IsClosed = false after Close")
+	}
+	if n := b.Write([]byte{3}); n != 0 {
+		t.Fatalf("Write after Close = %d, want 0", n)
+	}
+	if got := b.Read(10); !bytes.Equal(got, []byte{1, 2}) {
+		t.Fatalf("Read after Close = %v, want the buffered audio", got)
+	}
+
+	for range 2 {
+		if _, ok := <-b.Wait(); ok {
+			continue
+		}
+		return
+	}
+	t.Fatal("Wait channel not closed")
+}
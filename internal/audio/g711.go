@@ -0,0 +1,97 @@
+package audio
+
+var (
+	muLawToLinear [256]int16
+	aLawToLinear  [256]int16
+)
+
+var (
+	muLawSegmentEnds = [8]int{0x3F, 0x7F, 0xFF, 0x1FF, 0x3FF, 0x7FF, 0xFFF, 0x1FFF}
+	aLawSegmentEnds  = [8]int{0x1F, 0x3F, 0x7F, 0xFF, 0x1FF, 0x3FF, 0x7FF, 0xFFF}
+)
+
+const (
+	muLawBias = 0x84
+	muLawClip = 8159
+)
+
+func init() {
+	for i := range 256 {
+		muLawToLinear[i] = decodeMuLaw(byte(i))
+		aLawToLinear[i] = decodeALaw(byte(i))
+	}
+}
+
+func decodeMuLaw(u byte) int16 {
+	u = ^u
+	t := (int(u&0x0F) << 3) + muLawBias
+	t <<= (u & 0x70) >> 4
+	if u&0x80 != 0 {
+		return int16(muLawBias - t)
+	}
+	return int16(t - muLawBias)
+}
+
+func decodeALaw(a byte) int16 {
+	a ^= 0x55
+	t := int(a&0x0F) << 4
+	switch seg := (a & 0x70) >> 4; seg {
+	case 0:
+		t += 8
+	case 1:
+		t += 0x108
+	default:
+		t += 0x108
+		t <<= seg - 1
+	}
+	if a&0x80 != 0 {
+		return int16(t)
+	}
+	return int16(-t)
+}
+
+func segment(v int, ends [8]int) int {
+	for i, end := range ends {
+		if v <= end {
+			return i
+		}
+	}
+	return len(ends)
+}
+
+func linearToMuLaw(sample int16) byte {
+	v := int(sample) >> 2
+	mask := byte(0xFF)
+	if v < 0 {
+		v = -v
+		mask = 0x7F
+	}
+	v = min(v, muLawClip) + muLawBias>>2
+
+	seg := segment(v, muLawSegmentEnds)
+	if seg >= 8 {
+		return 0x7F ^ mask
+	}
+	return byte(seg<<4|(v>>(seg+1))&0x0F) ^ mask
+}
+
+func linearToALaw(sample int16) byte {
+	v := int(sample) >> 3
+	mask := byte(0xD5)
+	if v < 0 {
+		v = -v - 1
+		mask = 0x55
+	}
+
+	seg := segment(v, aLawSegmentEnds)
+	if seg >= 8 {
+		return 0x7F ^ mask
+	}
+	a := seg << 4
+	if seg < 2 {
+		a |= (v >> 1) & 0x0F
+	} else {
+		a |= (v >> seg) & 0x0F
+	}
+	return byte(a) ^ mask
+}
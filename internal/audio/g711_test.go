@@ -0,0 +1,90 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func TestMuLawRoundTripError(t *testing.T) {
+	for x := math.MinInt16; x <= math.MaxInt16; x++ {
+		got := int(muLawToLinear[linearToMuLaw(int16(x))])
+		if absInt(x) > 32124 {
+			if want := 32124 * (x / absInt(x)); got != want {
+				t.Fatalf("μ-law %d decoded to %d, want clipping to %d", x, got, want)
+			}
+			continue
+		}
+		if bound := 8 + absInt(x)/16; absInt(got-x) > bound {
+			t.Fatalf("μ-law %d decoded to %d, error above %d", x, got, bound)
+		}
+	}
+}
+
+func TestALawRoundTripError(t *testing.T) {
+	for x := math.MinInt16; x <= math.MaxInt16; x++ {
+		got := int(aLawToLinear[linearToALaw(int16(x))])
+		if bound := max(8, absInt(x)/32); absInt(got-x) > bound {
+			t.Fatalf("A-law %d decoded to %d, error above %d", x, got, bound)
+		}
+	}
+}
+
+func TestG711CodesAreStable(t *testing.T) {
+	for code := range 256 {
+		mu := muLawToLinear[code]
+		if again := muLawToLinear[linearToMuLaw(mu)]; again != mu {
+			t.Errorf("μ-law code %#02x decodes to %d, which re-encodes to %d", code, mu, again)
+		}
+		a := aLawToLinear[code]
+		if again := aLawToLinear[linearToALaw(a)]; again != a {
+			t.Errorf("A-law code %#02x decodes to %d, which re-encodes to %d", code, a, again)
+		}
+	}
+}
+
+func TestG711IsMonotonic(t *testing.T) {
+	prevMu, prevA := int16(math.MinInt16), int16(math.MinInt16)
+	for x := math.MinInt16; x <= math.MaxInt16; x++ {
+		mu := muLawToLinear[linearToMuLaw(int16(x))]
+		a := aLawToLinear[linearToALaw(int16(x))]
+		if mu < prevMu || a < prevA {
+			t.Fatalf("decoding is not monotonic at %d: μ-law %d after %d, A-law %d after %d", x, mu, prevMu, a, prevA)
+		}
+		prevMu, prevA = mu, a
+	}
+}
+
+func TestG711KnownCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		got  int16
+		want int16
+	}{
+		{"μ-law 0xFF", muLawToLinear[0xFF], 0},
+		{"μ-law 0x7F", muLawToLinear[0x7F], 0},
+		{"μ-law 0x00", muLawToLinear[0x00], -32124},
+		{"μ-law 0x80", muLawToLinear[0x80], 32124},
+		{"A-law 0xD5", aLawToLinear[0xD5], 8},
+		{"A-law 0x55", aLawToLinear[0x55], -8},
+		{"A-law 0xAA", aLawToLinear[0xAA], 32256},
+		{"A-law 0x2A", aLawToLinear[0x2A], -32256},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %d, want %d", tt.name, tt.got, tt.want)
+		}
+	}
+	if code := linearToMuLaw(0); code != 0xFF {
+		t.Errorf("μ-law encoding of silence = %#02x, want 0xff", code)
+	}
+	if code := linearToALaw(0); code != 0xD5 {
+		t.Errorf("A-law encoding of silence = %#02x, want 0xd5", code)
+	}
+}
@@ -1,5 +1,7 @@
 package audio
 
+import "time"
+
 const (
 	SampleRate     = 16000
 	Channels       = 1
@@ -31,3 +33,7 @@ func DurationMs(samples int) int {
 func SamplesForDuration(ms int) int {
 	return (ms * SampleRate) / 1000
 }
+
+func DurationOf(bytes int) time.Duration {
+	return time.Duration(bytes) * time.Second / (SampleRate * Channels * BytesPerSample)
+}
@@ -0,0 +1,111 @@
+package audio
+
+import "math"
+
+const (
+	resampleZeroCrossings = 8
+	resampleTableDensity  = 256
+	resampleCutoff        = 0.95
+)
+
+type Resampler struct {
+	from, to int
+	step     float64
+	scale    float64
+	taps     int
+	table    []float64
+	buf      []float32
+	pos      float64
+	inCount  int64
+	outCount int64
+}
+
+func NewResampler(from, to int) *Resampler {
+	r := &Resampler{from: from, to: to}
+	if from == to {
+		return r
+	}
+
+	r.step = float64(from) / float64(to)
+	r.scale = resampleCutoff * min(1, float64(to)/float64(from))
+	r.taps = int(math.Ceil(resampleZeroCrossings / r.scale))
+
+	r.table = make([]float64, r.taps*resampleTableDensity+2)
+	for i := range r.table {
+		x := float64(i) / resampleTableDensity
+		if x > float64(r.taps) {
+			break
+		}
+		window := 0.5 + 0.5*math.Cos(math.Pi*x/float64(r.taps))
+		r.table[i] = r.scale * sinc(r.scale*x) * window
+	}
+
+	r.buf = make([]float32, r.taps)
+	r.pos = float64(r.taps)
+	return r
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+func (r *Resampler) Process(in []float32) []float32 {
+	if r.from == r.to {
+		return in
+	}
+
+	r.inCount += int64(len(in))
+	r.buf = append(r.buf, in...)
+
+	var out []float32
+	for int(r.pos)+r.taps < len(r.buf) {
+		out = append(out, r.sample())
+		r.pos += r.step
+	}
+	r.outCount += int64(len(out))
+
+	if drop := int(r.pos) - r.taps; drop > 0 {
+		r.buf = append(r.buf[:0], r.buf[drop:]...)
+		r.pos -= float64(drop)
+	}
+	return out
+}
+
+func (r *Resampler) Flush() []float32 {
+	if r.from == r.to {
+		return nil
+	}
+
+	expected := r.inCount * int64(r.to) / int64(r.from)
+	inCount := r.inCount
+	out := r.Process(make([]float32, r.taps+1))
+	r.inCount = inCount
+	if extra := r.outCount - expected; extra > 0 {
+		out = out[:max(0, int64(len(out))-extra)]
+		r.outCount = expected
+	}
+	return out
+}
+
+func (r *Resampler) sample() float32 {
+	center := int(r.pos)
+
+	var sum float64
+	for k := center - r.taps + 1; k <= center+r.taps; k++ {
+		sum += float64(r.buf[k]) * r.kernel(math.Abs(r.pos-float64(k)))
+	}
+	return float32(sum)
+}
+
+func (r *Resampler) kernel(x float64) float64 {
+	if x >= float64(r.taps) {
+		return 0
+	}
+	p := x * resampleTableDensity
+	i := int(p)
+	f := p - float64(i)
+	return r.table[i] + (r.table[i+1]-r.table[i])*f
+}
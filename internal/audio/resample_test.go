@@ -0,0 +1,122 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func sine(n, rate int, freq, amplitude float64) []float32 {
+	out := make([]float32, n)
+	for i := range out {
+		out[i] = float32(amplitude * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+	}
+	return out
+}
+
+func resampleAll(r *Resampler, in []float32, chunk int) []float32 {
+	var out []float32
+	for len(in) > 0 {
+		n := min(chunk, len(in))
+		out = append(out, r.Process(in[:n])...)
+		in = in[n:]
+	}
+	return append(out, r.Flush()...)
+}
+
+func rms(samples []float32) float64 {
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s) * float64(s)
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
+
+func TestResamplerSameRate(t *testing.T) {
+	r := NewResampler(16000, 16000)
+	in := []float32{0.1, -0.2, 0.3}
+	if out := r.Process(in); &out[0] != &in[0] {
+		t.Fatal("same-rate Process copied its input")
+	}
+	if out := r.Flush(); out != nil {
+		t.Fatalf("same-rate Flush = %v, want nil", out)
+	}
+}
+
+func TestResamplerOutputLength(t *testing.T) {
+	rates := []struct{ from, to int }{
+		{8000, 16000},
+		{11025, 16000},
+		{22050, 16000},
+		{44100, 16000},
+		{48000, 16000},
+		{16000, 8000},
+		{96000, 16000},
+	}
+	for _, rate := range rates {
+		for _, n := range []int{0, 1, 7, 160, 4410, 48001} {
+			for _, chunk := range []int{1, 97, 4096} {
+				out := resampleAll(NewResampler(rate.from, rate.to), make([]float32, n), chunk)
+				if want := n * rate.to / rate.from; len(out) != want {
+					t.Errorf("%d -> %d Hz, %d samples in chunks of %d: got %d samples, want %d", rate.from, rate.to, n, chunk, len(out), want)
+				}
+			}
+		}
+	}
+}
+
+func TestResamplerChunkingDoesNotChangeOutput(t *testing.T) {
+	in := sine(44100, 44100, 440, 0.5)
+	whole := resampleAll(NewResampler(44100, 16000), in, len(in))
+	chunked := resampleAll(NewResampler(44100, 16000), in, 333)
+	if len(whole) != len(chunked) {
+		t.Fatalf("lengths differ: %d and %d", len(whole), len(chunked))
+	}
+	for i := range whole {
+		if math.Abs(float64(whole[i]-chunked[i])) > 1e-6 {
+			t.Fatalf("sample %d differs: %f and %f", i, whole[i], chunked[i])
+		}
+	}
+}
+
+func TestResamplerPreservesPassband(t *testing.T) {
+	for _, from := range []int{8000, 22050, 48000} {
+		out := resampleAll(NewResampler(from, 16000), sine(from, from, 1000, 0.5), 512)
+		steady := out[len(out)/4 : len(out)*3/4]
+		if got, want := rms(steady), 0.5/math.Sqrt2; math.Abs(got-want) > 0.01 {
+			t.Errorf("%d Hz: 1 kHz tone RMS = %.4f, want %.4f", from, got, want)
+		}
+	}
+}
+
+func TestResamplerRejectsAboveNyquist(t *testing.T) {
+	out := resampleAll(NewResampler(48000, 16000), sine(48000, 48000, 12000, 0.5), 512)
+	steady := out[len(out)/4 : len(out)*3/4]
+	if got := rms(steady); got > 0.01 {
+		t.Fatalf("12 kHz tone RMS after resampling to 16 kHz = %.4f, want it filtered out", got)
+	}
+}
+
+func TestResamplerEdges(t *testing.T) {
+	r := NewResampler(8000, 16000)
+	if out := r.Process(nil); len(out) != 0 {
+		t.Fatalf("Process(nil) = %d samples, want 0", len(out))
+	}
+	if out := r.Flush(); len(out) != 0 {
+		t.Fatalf("Flush without input = %d samples, want 0", len(out))
+	}
+
+	r = NewResampler(8000, 16000)
+	in := make([]float32, 800)
+	for i := range in {
+		in[i] = 0.5
+	}
+	out := resampleAll(r, in, 800)
+	for i, s := range out {
+		if s > 0.6 || s < -0.1 {
+			t.Fatalf("sample %d = %f overshoots a step to 0.5", i, s)
+		}
+	}
+	if mid := out[len(out)/2]; math.Abs(float64(mid)-0.5) > 0.01 {
+		t.Fatalf("DC level = %f, want 0.5", mid)
+	}
+}
@@ -0,0 +1,476 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+type WAVEncoding uint16
+
+const (
+	EncodingPCM   WAVEncoding = 1
+	EncodingFloat WAVEncoding = 3
+	EncodingALaw  WAVEncoding = 6
+	EncodingMuLaw WAVEncoding = 7
+)
+
+const (
+	wavFormatExtensible = 0xFFFE
+	wavMaxSize          = 0xFFFFFFFF
+	UnknownLength       = -1
+)
+
+var wavSubformatSuffix = []byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}
+
+var (
+	ErrWAVClosed      = errors.New("wav writer closed")
+	ErrInvalidWAV     = errors.New("invalid wav file")
+	ErrUnsupportedWAV = errors.New("unsupported wav encoding")
+)
+
+func (e WAVEncoding) String() string {
+	switch e {
+	case EncodingPCM:
+		return "pcm"
+	case EncodingFloat:
+		return "float"
+	case EncodingALaw:
+		return "alaw"
+	case EncodingMuLaw:
+		return "mulaw"
+	}
+	return fmt.Sprintf("0x%04x", uint16(e))
+}
+
+type WAVFormat struct {
+	Encoding      WAVEncoding
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+}
+
+func PCM16Format(sampleRate, channels int) WAVFormat {
+	return WAVFormat{Encoding: EncodingPCM, SampleRate: sampleRate, Channels: channels, BitsPerSample: BitsPerSample}
+}
+
+func (f WAVFormat) Validate() error {
+	if f.SampleRate <= 0 || f.Channels <= 0 || f.Channels > 0xFFFF {
+		return fmt.Errorf("%w: %d channels at %d Hz", ErrInvalidWAV, f.Channels, f.SampleRate)
+	}
+
+	var ok bool
+	switch f.Encoding {
+	case EncodingPCM:
+		ok = f.BitsPerSample == 8 || f.BitsPerSample == 16 || f.BitsPerSample == 24 || f.BitsPerSample == 32
+	case EncodingFloat:
+		ok = f.BitsPerSample == 32 || f.BitsPerSample == 64
+	case EncodingALaw, EncodingMuLaw:
+		ok = f.BitsPerSample == 8
+	}
+	if !ok {
+		return fmt.Errorf("%w: %d-bit %s", ErrUnsupportedWAV, f.BitsPerSample, f.Encoding)
+	}
+	return nil
+}
+
+func (f WAVFormat) BytesPerFrame() int {
+	return f.Channels * f.BitsPerSample / 8
+}
+
+func (f WAVFormat) IsPCM16() bool {
+	return f.Encoding == EncodingPCM && f.BitsPerSample == 16
+}
+
+func (f WAVFormat) extensible() bool {
+	return f.Encoding == EncodingPCM && (f.Channels > 2 || f.BitsPerSample > 16)
+}
+
+func (f WAVFormat) header(dataBytes int64) []byte {
+	var fmtChunk []byte
+	if f.extensible() {
+		fmtChunk = make([]byte, 40)
+		binary.LittleEndian.PutUint16(fmtChunk[0:2], wavFormatExtensible)
+		binary.LittleEndian.PutUint16(fmtChunk[16:18], 22)
+		binary.LittleEndian.PutUint16(fmtChunk[18:20], uint16(f.BitsPerSample))
+		binary.LittleEndian.PutUint32(fmtChunk[20:24], channelMask(f.Channels))
+		binary.LittleEndian.PutUint16(fmtChunk[24:26], uint16(f.Encoding))
+		copy(fmtChunk[26:40], wavSubformatSuffix)
+	} else if f.Encoding == EncodingPCM {
+		fmtChunk = make([]byte, 16)
+		binary.LittleEndian.PutUint16(fmtChunk[0:2], uint16(f.Encoding))
+	} else {
+		fmtChunk = make([]byte, 18)
+		binary.LittleEndian.PutUint16(fmtChunk[0:2], uint16(f.Encoding))
+	}
+	blockAlign := f.BytesPerFrame()
+	binary.LittleEndian.PutUint16(fmtChunk[2:4], uint16(f.Channels))
+	binary.LittleEndian.PutUint32(fmtChunk[4:8], uint32(f.SampleRate))
+	binary.LittleEndian.PutUint32(fmtChunk[8:12], uint32(f.SampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(fmtChunk[12:14], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(fmtChunk[14:16], uint16(f.BitsPerSample))
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(0))
+	buf.WriteString("WAVE")
+	buf.WriteString("fmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(fmtChunk)))
+	buf.Write(fmtChunk)
+	if f.Encoding != EncodingPCM {
+		frames := uint32(wavMaxSize)
+		if dataBytes != UnknownLength {
+			frames = clampSize(dataBytes / int64(blockAlign))
+		}
+		buf.WriteString("fact")
+		binary.Write(&buf, binary.LittleEndian, uint32(4))
+		binary.Write(&buf, binary.LittleEndian, frames)
+	}
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(0))
+
+	header := buf.Bytes()
+	riffSize, dataSize := uint32(wavMaxSize), uint32(wavMaxSize)
+	if dataBytes != UnknownLength {
+		riffSize = clampSize(int64(len(header)) - 8 + dataBytes + dataBytes%2)
+		dataSize = clampSize(dataBytes)
+	}
+	binary.LittleEndian.PutUint32(header[4:8], riffSize)
+	binary.LittleEndian.PutUint32(header[len(header)-4:], dataSize)
+	return header
+}
+
+func channelMask(channels int) uint32 {
+	if channels >= 32 {
+		return 0
+	}
+	return 1<<channels - 1
+}
+
+func clampSize(n int64) uint32 {
+	return uint32(min(n, wavMaxSize))
+}
+
+type WAVWriter struct {
+	w         io.Writer
+	format    WAVFormat
+	dataBytes int64
+	closed    bool
+}
+
+func NewWAVWriter(w io.Writer, sampleRate, channels int) (*WAVWriter, error) {
+	return NewWAVFormatWriter(w, PCM16Format(sampleRate, channels))
+}
+
+func NewWAVFormatWriter(w io.Writer, format WAVFormat) (*WAVWriter, error) {
+	if err := format.Validate(); err != nil {
+		return nil, err
+	}
+
+	ww := &WAVWriter{w: w, format: format}
+	length := int64(UnknownLength)
+	if _, ok := w.(io.Seeker); ok {
+		length = 0
+	}
+	if _, err := w.Write(format.header(length)); err != nil {
+		return nil, err
+	}
+	return ww, nil
+}
+
+func (w *WAVWriter) Format() WAVFormat {
+	return w.format
+}
+
+func (w *WAVWriter) Write(data []byte) (int, error) {
+	if w.closed {
+		return 0, ErrWAVClosed
+	}
+	n, err := w.w.Write(data)
+	w.dataBytes += int64(n)
+	return n, err
+}
+
+func (w *WAVWriter) WriteSamples(samples []float32) error {
+	if len(samples)%w.format.Channels != 0 {
+		return fmt.Errorf("%d samples is not a whole number of %d-channel frames", len(samples), w.format.Channels)
+	}
+	_, err := w.Write(encodeSamples(samples, w.format))
+	return err
+}
+
+func (w *WAVWriter) DataBytes() int64 {
+	return w.dataBytes
+}
+
+func (w *WAVWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	seeker, ok := w.w.(io.WriteSeeker)
+	if !ok {
+		return nil
+	}
+
+	if w.dataBytes%2 == 1 {
+		if _, err := w.w.Write([]byte{0}); err != nil {
+			return err
+		}
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := seeker.Write(w.format.header(w.dataBytes)); err != nil {
+		return err
+	}
+	_, err := seeker.Seek(0, io.SeekEnd)
+	return err
+}
+
+type WAVReader struct {
+	WAVFormat
+	DataBytes int64
+	r         io.Reader
+	pending   []byte
+}
+
+func NewWAVReader(r io.Reader) (*WAVReader, error) {
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return nil, ErrInvalidWAV
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return nil, ErrInvalidWAV
+	}
+
+	wr := &WAVReader{}
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, ErrInvalidWAV
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+
+		switch id {
+		case "fmt ":
+			if size < 16 || size > 1024 {
+				return nil, ErrInvalidWAV
+			}
+			fmtChunk := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, fmtChunk); err != nil {
+				return nil, ErrInvalidWAV
+			}
+			format, err := parseFormat(fmtChunk[:size])
+			if err != nil {
+				return nil, err
+			}
+			wr.WAVFormat = format
+		case "data":
+			if wr.Channels == 0 {
+				return nil, ErrInvalidWAV
+			}
+			if size == wavMaxSize || size == 0 {
+				wr.DataBytes = UnknownLength
+				wr.r = r
+			} else {
+				wr.DataBytes = size
+				wr.r = io.LimitReader(r, size)
+			}
+			return wr, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return nil, ErrInvalidWAV
+			}
+		}
+	}
+}
+
+func parseFormat(chunk []byte) (WAVFormat, error) {
+	tag := binary.LittleEndian.Uint16(chunk[0:2])
+	format := WAVFormat{
+		Encoding:      WAVEncoding(tag),
+		Channels:      int(binary.LittleEndian.Uint16(chunk[2:4])),
+		SampleRate:    int(binary.LittleEndian.Uint32(chunk[4:8])),
+		BitsPerSample: int(binary.LittleEndian.Uint16(chunk[14:16])),
+	}
+	blockAlign := int(binary.LittleEndian.Uint16(chunk[12:14]))
+
+	if tag == wavFormatExtensible {
+		if len(chunk) < 40 {
+			return WAVFormat{}, ErrInvalidWAV
+		}
+		if !bytes.Equal(chunk[26:40], wavSubformatSuffix) {
+			return WAVFormat{}, ErrUnsupportedWAV
+		}
+		format.Encoding = WAVEncoding(binary.LittleEndian.Uint16(chunk[24:26]))
+	}
+
+	if err := format.Validate(); err != nil {
+		return WAVFormat{}, err
+	}
+	if blockAlign != format.BytesPerFrame() {
+		return WAVFormat{}, ErrInvalidWAV
+	}
+	return format, nil
+}
+
+func (r *WAVReader) Read(p []byte) (int, error) {
+	return r.r.Read(p)
+}
+
+func (r *WAVReader) ReadSamples(dst []float32) (int, error) {
+	frameBytes := r.BytesPerFrame()
+	frames := len(dst) / r.Channels
+	if frames == 0 {
+		return 0, io.ErrShortBuffer
+	}
+
+	need := frames * frameBytes
+	buf := make([]byte, need)
+	n := copy(buf, r.pending)
+	m, err := io.ReadAtLeast(r.r, buf[n:], max(frameBytes-n, 1))
+	n += m
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+
+	whole := n / frameBytes * frameBytes
+	r.pending = append(r.pending[:0], buf[whole:n]...)
+	if whole == 0 {
+		if err == nil {
+			err = io.EOF
+		}
+		return 0, err
+	}
+	return decodeSamples(dst, buf[:whole], r.WAVFormat), nil
+}
+
+func (r *WAVReader) Normalized() io.Reader {
+	return &normalizer{wav: r, resampler: NewResampler(r.SampleRate, SampleRate)}
+}
+
+type normalizer struct {
+	wav       *WAVReader
+	resampler *Resampler
+	samples   []float32
+	out       []byte
+	flushed   bool
+}
+
+func (n *normalizer) Read(p []byte) (int, error) {
+	for len(n.out) == 0 {
+		if n.flushed {
+			return 0, io.EOF
+		}
+		if n.samples == nil {
+			n.samples = make([]float32, 4096*n.wav.Channels)
+		}
+
+		count, err := n.wav.ReadSamples(n.samples)
+		var mono []float32
+		if count > 0 {
+			mono = n.resampler.Process(downmix(n.samples[:count], n.wav.Channels))
+		}
+		if err == io.EOF {
+			mono = append(mono, n.resampler.Flush()...)
+			n.flushed = true
+		} else if err != nil {
+			return 0, err
+		}
+		n.out = floatToPCM16(mono)
+	}
+
+	c := copy(p, n.out)
+	n.out = n.out[c:]
+	return c, nil
+}
+
+func downmix(samples []float32, channels int) []float32 {
+	if channels == 1 {
+		return samples
+	}
+	mono := make([]float32, len(samples)/channels)
+	for i := range mono {
+		var sum float32
+		for _, s := range samples[i*channels : (i+1)*channels] {
+			sum += s
+		}
+		mono[i] = sum / float32(channels)
+	}
+	return mono
+}
+
+func floatToPCM16(samples []float32) []byte {
+	out := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		v := int16(clampInt(int64(math.Round(float64(s)*32768)), math.MinInt16, math.MaxInt16))
+		binary.LittleEndian.PutUint16(out[i*2:], uint16(v))
+	}
+	return out
+}
+
+func clampInt(v, lo, hi int64) int64 {
+	return max(lo, min(hi, v))
+}
+
+func decodeSamples(dst []float32, data []byte, format WAVFormat) int {
+	size := format.BitsPerSample / 8
+	count := len(data) / size
+	for i := 0; i < count; i++ {
+		b := data[i*size : (i+1)*size]
+		switch {
+		case format.Encoding == EncodingFloat && size == 4:
+			dst[i] = math.Float32frombits(binary.LittleEndian.Uint32(b))
+		case format.Encoding == EncodingFloat:
+			dst[i] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		case format.Encoding == EncodingMuLaw:
+			dst[i] = float32(muLawToLinear[b[0]]) / 32768
+		case format.Encoding == EncodingALaw:
+			dst[i] = float32(aLawToLinear[b[0]]) / 32768
+		case size == 1:
+			dst[i] = float32(int(b[0])-128) / 128
+		case size == 2:
+			dst[i] = float32(int16(binary.LittleEndian.Uint16(b))) / (1 << 15)
+		case size == 3:
+			dst[i] = float32(int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24)>>8) / (1 << 23)
+		default:
+			dst[i] = float32(float64(int32(binary.LittleEndian.Uint32(b))) / (1 << 31))
+		}
+	}
+	return count
+}
+
+func encodeSamples(samples []float32, format WAVFormat) []byte {
+	size := format.BitsPerSample / 8
+	out := make([]byte, len(samples)*size)
+	for i, s := range samples {
+		b := out[i*size : (i+1)*size]
+		v := float64(s)
+		switch {
+		case format.Encoding == EncodingFloat && size == 4:
+			binary.LittleEndian.PutUint32(b, math.Float32bits(s))
+		case format.Encoding == EncodingFloat:
+			binary.LittleEndian.PutUint64(b, math.Float64bits(v))
+		case format.Encoding == EncodingMuLaw:
+			b[0] = linearToMuLaw(int16(clampInt(int64(math.Round(v*32768)), math.MinInt16, math.MaxInt16)))
+		case format.Encoding == EncodingALaw:
+			b[0] = linearToALaw(int16(clampInt(int64(math.Round(v*32768)), math.MinInt16, math.MaxInt16)))
+		case size == 1:
+			b[0] = byte(clampInt(int64(math.Round(v*128)), -128, 127) + 128)
+		case size == 2:
+			binary.LittleEndian.PutUint16(b, uint16(clampInt(int64(math.Round(v*(1<<15))), math.MinInt16, math.MaxInt16)))
+		case size == 3:
+			x := uint32(clampInt(int64(math.Round(v*(1<<23))), -(1 << 23), 1<<23-1))
+			b[0], b[1], b[2] = byte(x), byte(x>>8), byte(x>>16)
+		default:
+			binary.LittleEndian.PutUint32(b, uint32(clampInt(int64(math.Round(v*(1<<31))), math.MinInt32, math.MaxInt32)))
+		}
+	}
+	return out
+}
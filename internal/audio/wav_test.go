@@ -0,0 +1,310 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func riffChunk(id string, data []byte) []byte {
+	out := append([]byte(id), binary.LittleEndian.AppendUint32(nil, uint32(len(data)))...)
+	out = append(out, data...)
+	if len(data)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+func riffFile(chunks ...[]byte) []byte {
+	body := []byte("WAVE")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+}
+
+func fmtData(tag uint16, channels, rate, bits int) []byte {
+	b := make([]byte, 16)
+	binary.LittleEndian.PutUint16(b[0:2], tag)
+	binary.LittleEndian.PutUint16(b[2:4], uint16(channels))
+	binary.LittleEndian.PutUint32(b[4:8], uint32(rate))
+	binary.LittleEndian.PutUint32(b[8:12], uint32(rate*channels*bits/8))
+	binary.LittleEndian.PutUint16(b[12:14], uint16(channels*bits/8))
+	binary.LittleEndian.PutUint16(b[14:16], uint16(bits))
+	return b
+}
+
+func extensibleFmt(subformat WAVEncoding, channels, rate, bits int) []byte {
+	b := fmtData(wavFormatExtensible, channels, rate, bits)
+	ext := make([]byte, 24)
+	binary.LittleEndian.PutUint16(ext[0:2], 22)
+	binary.LittleEndian.PutUint16(ext[2:4], uint16(bits))
+	binary.LittleEndian.PutUint32(ext[4:8], channelMask(channels))
+	binary.LittleEndian.PutUint16(ext[8:10], uint16(subformat))
+	copy(ext[10:24], wavSubformatSuffix)
+	return append(b, ext...)
+}
+
+func readAllSamples(t *testing.T, r *WAVReader) []float32 {
+	t.Helper()
+	var out []float32
+	buf := make([]float32, 64*r.Channels)
+	for {
+		n, err := r.ReadSamples(buf)
+		out = append(out, buf[:n]...)
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("ReadSamples: %v", err)
+		}
+	}
+}
+
+func TestWAVRoundTripFormats(t *testing.T) {
+	samples := []float32{0, 0.25, -0.25, 0.5, -0.5, 0.9, -0.9, 0.001, -1}
+	tests := []struct {
+		format    WAVFormat
+		tolerance float64
+	}{
+		{WAVFormat{Encoding: EncodingPCM, SampleRate: 8000, Channels: 1, BitsPerSample: 8}, 1.0 / 128},
+		{WAVFormat{Encoding: EncodingPCM, SampleRate: 16000, Channels: 1, BitsPerSample: 16}, 1.0 / 32768},
+		{WAVFormat{Encoding: EncodingPCM, SampleRate: 48000, Channels: 1, BitsPerSample: 24}, 1.0 / (1 << 23)},
+		{WAVFormat{Encoding: EncodingPCM, SampleRate: 48000, Channels: 1, BitsPerSample: 32}, 1.0 / (1 << 31)},
+		{WAVFormat{Encoding: EncodingFloat, SampleRate: 44100, Channels: 1, BitsPerSample: 32}, 0},
+		{WAVFormat{Encoding: EncodingFloat, SampleRate: 44100, Channels: 1, BitsPerSample: 64}, 0},
+		{WAVFormat{Encoding: EncodingMuLaw, SampleRate: 8000, Channels: 1, BitsPerSample: 8}, 0.05},
+		{WAVFormat{Encoding: EncodingALaw, SampleRate: 8000, Channels: 1, BitsPerSample: 8}, 0.05},
+		{WAVFormat{Encoding: EncodingPCM, SampleRate: 16000, Channels: 3, BitsPerSample: 16}, 1.0 / 32768},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s%dx%d", tt.format.Encoding, tt.format.BitsPerSample, tt.format.Channels), func(t *testing.T) {
+			in := samples[:len(samples)/tt.format.Channels*tt.format.Channels]
+			var buf bytes.Buffer
+			w, err := NewWAVFormatWriter(&buf, tt.format)
+			if err != nil {
+				t.Fatalf("NewWAVFormatWriter: %v", err)
+			}
+			if err := w.WriteSamples(in); err != nil {
+				t.Fatalf("WriteSamples: %v", err)
+			}
+			w.Close()
+
+			r, err := NewWAVReader(&buf)
+			if err != nil {
+				t.Fatalf("NewWAVReader: %v", err)
+			}
+			if r.WAVFormat != tt.format {
+				t.Fatalf("format = %+v, want %+v", r.WAVFormat, tt.format)
+			}
+			if r.DataBytes != UnknownLength {
+				t.Fatalf("DataBytes = %d for a streamed file, want UnknownLength", r.DataBytes)
+			}
+			out := readAllSamples(t, r)
+			if len(out) != len(in) {
+				t.Fatalf("read %d samples, want %d", len(out), len(in))
+			}
+			for i := range in {
+				if diff := math.Abs(float64(out[i] - in[i])); diff > tt.tolerance {
+					t.Errorf("sample %d = %f, want %f within %g", i, out[i], in[i], tt.tolerance)
+				}
+			}
+		})
+	}
+}
+
+func TestWAVExtensibleHeader(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := NewWAVFormatWriter(&buf, WAVFormat{Encoding: EncodingPCM, SampleRate: 48000, Channels: 2, BitsPerSample: 24}); err != nil {
+		t.Fatalf("NewWAVFormatWriter: %v", err)
+	}
+	data := buf.Bytes()
+	if size := binary.LittleEndian.Uint32(data[16:20]); size != 40 {
+		t.Fatalf("fmt chunk size = %d, want 40", size)
+	}
+	if tag := binary.LittleEndian.Uint16(data[20:22]); tag != wavFormatExtensible {
+		t.Fatalf("format tag = %#x, want WAVE_FORMAT_EXTENSIBLE", tag)
+	}
+
+	var plain bytes.Buffer
+	NewWAVWriter(&plain, 16000, 1)
+	if tag := binary.LittleEndian.Uint16(plain.Bytes()[20:22]); tag != uint16(EncodingPCM) {
+		t.Fatalf("16-bit mono format tag = %#x, want PCM", tag)
+	}
+}
+
+func TestWAVReaderExtensible(t *testing.T) {
+	pcm := binary.LittleEndian.AppendUint32(nil, math.Float32bits(0.5))
+	r, err := NewWAVReader(bytes.NewReader(riffFile(
+		riffChunk("fmt ", extensibleFmt(EncodingFloat, 1, 44100, 32)),
+		riffChunk("data", pcm),
+	)))
+	if err != nil {
+		t.Fatalf("NewWAVReader: %v", err)
+	}
+	if r.Encoding != EncodingFloat || r.BitsPerSample != 32 || r.DataBytes != 4 {
+		t.Fatalf("format = %+v, %d bytes", r.WAVFormat, r.DataBytes)
+	}
+	if out := readAllSamples(t, r); len(out) != 1 || out[0] != 0.5 {
+		t.Fatalf("samples = %v, want [0.5]", out)
+	}
+
+	bad := extensibleFmt(EncodingPCM, 1, 16000, 16)
+	bad[len(bad)-1] ^= 0xFF
+	_, err = NewWAVReader(bytes.NewReader(riffFile(riffChunk("fmt ", bad), riffChunk("data", []byte{0, 0}))))
+	if !errors.Is(err, ErrUnsupportedWAV) {
+		t.Fatalf("unknown subformat error = %v, want ErrUnsupportedWAV", err)
+	}
+
+	_, err = NewWAVReader(bytes.NewReader(riffFile(riffChunk("fmt ", extensibleFmt(EncodingPCM, 1, 16000, 16)[:30]), riffChunk("data", []byte{0, 0}))))
+	if !errors.Is(err, ErrInvalidWAV) {
+		t.Fatalf("short extensible chunk error = %v, want ErrInvalidWAV", err)
+	}
+}
+
+func TestWAVReaderSkipsOddSizedChunks(t *testing.T) {
+	file := riffFile(
+		riffChunk("LIST", []byte("odd")),
+		riffChunk("fmt ", fmtData(uint16(EncodingPCM), 1, 8000, 8)),
+		riffChunk("junk", []byte{1, 2, 3, 4, 5}),
+		riffChunk("data", []byte{128, 255, 0}),
+	)
+	r, err := NewWAVReader(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("NewWAVReader: %v", err)
+	}
+	out := readAllSamples(t, r)
+	if len(out) != 3 || out[0] != 0 || out[2] != -1 {
+		t.Fatalf("samples = %v, want [0 ~1 -1]", out)
+	}
+}
+
+func TestWAVReaderTruncated(t *testing.T) {
+	full := riffFile(
+		riffChunk("fmt ", fmtData(uint16(EncodingPCM), 2, 16000, 16)),
+		riffChunk("data", make([]byte, 400)),
+	)
+	headerLen := len(full) - 400
+
+	for _, n := range []int{0, 4, 11, 12, 20, 30, headerLen - 1} {
+		if _, err := NewWAVReader(bytes.NewReader(full[:n])); !errors.Is(err, ErrInvalidWAV) {
+			t.Errorf("file cut at %d bytes: error = %v, want ErrInvalidWAV", n, err)
+		}
+	}
+
+	r, err := NewWAVReader(bytes.NewReader(full[:headerLen+102]))
+	if err != nil {
+		t.Fatalf("NewWAVReader: %v", err)
+	}
+	if r.DataBytes != 400 {
+		t.Fatalf("DataBytes = %d, want the declared 400", r.DataBytes)
+	}
+	if out := readAllSamples(t, r); len(out) != 50 {
+		t.Fatalf("read %d samples from 102 bytes of stereo 16-bit, want 50 whole frames", len(out))
+	}
+}
+
+func TestWAVReaderRejectsMalformedHeaders(t *testing.T) {
+	pcm16 := fmtData(uint16(EncodingPCM), 1, 16000, 16)
+	badAlign := fmtData(uint16(EncodingPCM), 1, 16000, 16)
+	binary.LittleEndian.PutUint16(badAlign[12:14], 4)
+
+	tests := []struct {
+		name string
+		file []byte
+		want error
+	}{
+		{"not riff", append([]byte("RIFX"), riffFile(riffChunk("data", nil))[4:]...), ErrInvalidWAV},
+		{"data before fmt", riffFile(riffChunk("data", []byte{0, 0}), riffChunk("fmt ", pcm16)), ErrInvalidWAV},
+		{"short fmt", riffFile(riffChunk("fmt ", pcm16[:14]), riffChunk("data", []byte{0, 0})), ErrInvalidWAV},
+		{"block align", riffFile(riffChunk("fmt ", badAlign), riffChunk("data", []byte{0, 0})), ErrInvalidWAV},
+		{"12-bit pcm", riffFile(riffChunk("fmt ", fmtData(uint16(EncodingPCM), 1, 16000, 12)), riffChunk("data", nil)), ErrUnsupportedWAV},
+		{"16-bit float", riffFile(riffChunk("fmt ", fmtData(uint16(EncodingFloat), 1, 16000, 16)), riffChunk("data", nil)), ErrUnsupportedWAV},
+		{"adpcm", riffFile(riffChunk("fmt ", fmtData(2, 1, 16000, 8)), riffChunk("data", nil)), ErrUnsupportedWAV},
+		{"zero rate", riffFile(riffChunk("fmt ", fmtData(uint16(EncodingPCM), 1, 0, 16)), riffChunk("data", nil)), ErrInvalidWAV},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewWAVReader(bytes.NewReader(tt.file)); !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWAVWriterSeekableSizes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.wav")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewWAVFormatWriter(f, WAVFormat{Encoding: EncodingPCM, SampleRate: 8000, Channels: 1, BitsPerSample: 8})
+	if err != nil {
+		t.Fatalf("NewWAVFormatWriter: %v", err)
+	}
+	w.Write([]byte{1, 2, 3})
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := w.Write([]byte{4}); !errors.Is(err, ErrWAVClosed) {
+		t.Fatalf("Write after Close = %v, want ErrWAVClosed", err)
+	}
+	f.Close()
+
+	data, _ := os.ReadFile(path)
+	if len(data) != 44+4 {
+		t.Fatalf("file = %d bytes, want a padded 3-byte data chunk", len(data))
+	}
+	if riff, size := binary.LittleEndian.Uint32(data[4:8]), binary.LittleEndian.Uint32(data[40:44]); riff != 40 || size != 3 {
+		t.Fatalf("RIFF size = %d, data size = %d, want 40 and 3", riff, size)
+	}
+
+	r, err := NewWAVReader(bytes.NewReader(data))
+	if err != nil || r.DataBytes != 3 {
+		t.Fatalf("NewWAVReader = %d bytes, %v", r.DataBytes, err)
+	}
+}
+
+func TestWAVStreamedSizesAreUnknown(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWAVWriter(&buf, 16000, 1)
+	w.Write(make([]byte, 10))
+	w.Close()
+	data := buf.Bytes()
+	if binary.LittleEndian.Uint32(data[4:8]) != wavMaxSize || binary.LittleEndian.Uint32(data[40:44]) != wavMaxSize {
+		t.Fatal("streamed header sizes are not 0xFFFFFFFF")
+	}
+}
+
+func TestWAVNormalized(t *testing.T) {
+	stereo := make([]float32, 2*8000)
+	for i := 0; i < len(stereo); i += 2 {
+		stereo[i], stereo[i+1] = 0.5, -0.5
+	}
+	var buf bytes.Buffer
+	w, _ := NewWAVFormatWriter(&buf, WAVFormat{Encoding: EncodingFloat, SampleRate: 8000, Channels: 2, BitsPerSample: 32})
+	w.WriteSamples(stereo)
+
+	r, err := NewWAVReader(&buf)
+	if err != nil {
+		t.Fatalf("NewWAVReader: %v", err)
+	}
+	pcm, err := io.ReadAll(r.Normalized())
+	if err != nil {
+		t.Fatalf("read normalized: %v", err)
+	}
+	if len(pcm) != SampleRate*BytesPerSample {
+		t.Fatalf("normalized = %d bytes, want one second of 16 kHz mono", len(pcm))
+	}
+	for i, s := range BytesToPCM(pcm) {
+		if s != 0 {
+			t.Fatalf("sample %d = %d, want opposite channels to cancel out", i, s)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type APIKeyAuthenticator struct {
+	keys map[[sha256.Size]byte]Principal
+}
+
+func ParseAPIKeys(spec string) (*APIKeyAuthenticator, error) {
+	a := &APIKeyAuthenticator{keys: make(map[[sha256.Size]byte]Principal)}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid API key entry, expected key:tenant[:subject]")
+		}
+
+		subject := parts[1]
+		if len(parts) == 3 && parts[2] != "" {
+			subject = parts[2]
+		}
+
+		a.keys[sha256.Sum256([]byte(parts[0]))] = Principal{
+			Subject:  subject,
+			TenantID: parts[1],
+			Method:   MethodAPIKey,
+		}
+	}
+
+	return a, nil
+}
+
+func (a *APIKeyAuthenticator) Len() int {
+	return len(a.keys)
+}
+
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := apiKeyFromRequest(r)
+	if key == "" {
+		return nil, ErrNoCredentials
+	}
+
+	principal, ok := a.keys[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return &principal, nil
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+const (
+	MethodAPIKey       = "api_key"
+	MethodSessionToken = "session_token"
+	MethodJWT          = "jwt"
+	MethodAnonymous    = "anonymous"
+)
+
+const DefaultTenantID = "default"
+
+var (
+	ErrNoCredentials      = errors.New("no credentials provided")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrExpiredCredentials = errors.New("credentials expired")
+)
+
+type Principal struct {
+	Subject  string `json:"subject"`
+	TenantID string `json:"tenant_id"`
+	Method   string `json:"method"`
+}
+
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+type Chain []Authenticator
+
+func (c Chain) Authenticate(r *http.Request) (*Principal, error) {
+	for _, a := range c {
+		principal, err := a.Authenticate(r)
+		if err == nil {
+			return principal, nil
+		}
+		if !errors.Is(err, ErrNoCredentials) {
+			return nil, err
+		}
+	}
+	return nil, ErrNoCredentials
+}
+
+type Anonymous struct{}
+
+func (Anonymous) Authenticate(r *http.Request) (*Principal, error) {
+	return &Principal{
+		Subject:  MethodAnonymous,
+		TenantID: DefaultTenantID,
+		Method:   MethodAnonymous,
+	}, nil
+}
+
+type principalKey struct{}
+
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+func apiKeyFromRequest(r *http.Request) string {
+	return r.Header.Get("X-API-Key")
+}
+
+func bearerFromRequest(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if scheme, token, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys("k1:acme, k2:globex:bot ,")
+	if err != nil {
+		t.Fatalf("ParseAPIKeys: %v", err)
+	}
+	if keys.Len() != 2 {
+		t.Fatalf("Len = %d, want 2", keys.Len())
+	}
+
+	for _, bad := range []string{"k1", ":acme", "k1:", "k1:acme:bot:extra"} {
+		if _, err := ParseAPIKeys(bad); err == nil {
+			t.Errorf("ParseAPIKeys(%q) accepted", bad)
+		}
+	}
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	keys, err := ParseAPIKeys("k1:acme,k2:globex:bot")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest("GET", "/v1/translate", nil)
+	r.Header.Set("X-API-Key", "k2")
+	principal, err := keys.Authenticate(r)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if principal.Subject != "bot" || principal.TenantID != "globex" || principal.Method != MethodAPIKey {
+		t.Errorf("principal = %+v", principal)
+	}
+
+	r = httptest.NewRequest("GET", "/v1/translate", nil)
+	r.Header.Set("X-API-Key", "nope")
+	if _, err := keys.Authenticate(r); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unknown key: error = %v, want ErrInvalidCredentials", err)
+	}
+
+	for _, target := range []string{"/v1/translate?api_key=k1", "/ws?api_key=k1"} {
+		if _, err := keys.Authenticate(httptest.NewRequest("GET", target, nil)); !errors.Is(err, ErrNoCredentials) {
+			t.Errorf("%s: error = %v, want ErrNoCredentials", target, err)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	keys, err := ParseAPIKeys("k1:acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := newTestTokens(t, time.Minute)
+	chain := Chain{keys, tokens, NewQueryTokenAuthenticator(tokens)}
+
+	r := httptest.NewRequest("GET", "/ws", nil)
+	r.Header.Set("X-API-Key", "k1")
+	if p, err := chain.Authenticate(r); err != nil || p.Method != MethodAPIKey {
+		t.Errorf("API key: %+v, %v", p, err)
+	}
+
+	if p, err := chain.Authenticate(bearerRequest(issue(t, tokens))); err != nil || p.Method != MethodSessionToken {
+		t.Errorf("bearer token: %+v, %v", p, err)
+	}
+
+	if p, err := chain.Authenticate(httptest.NewRequest("GET", "/ws?access_token="+issue(t, tokens), nil)); err != nil || p.Method != MethodSessionToken {
+		t.Errorf("query token: %+v, %v", p, err)
+	}
+
+	r = httptest.NewRequest("GET", "/ws?access_token="+issue(t, tokens), nil)
+	r.Header.Set("X-API-Key", "wrong")
+	if _, err := chain.Authenticate(r); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("invalid header is not overridden by a valid query token: error = %v", err)
+	}
+
+	if _, err := chain.Authenticate(httptest.NewRequest("GET", "/ws", nil)); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("no credentials: error = %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	MetadataSubject  = "x-principal"
+	MetadataTenantID = "x-tenant-id"
+	MetadataMethod   = "x-auth-method"
+)
+
+func outgoing(ctx context.Context) context.Context {
+	principal, ok := FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx,
+		MetadataSubject, principal.Subject,
+		MetadataTenantID, principal.TenantID,
+		MetadataMethod, principal.Method,
+	)
+}
+
+func incoming(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	tenant := md.Get(MetadataTenantID)
+	if len(tenant) == 0 || tenant[0] == "" {
+		return ctx
+	}
+
+	principal := &Principal{TenantID: tenant[0]}
+	if subject := md.Get(MetadataSubject); len(subject) > 0 {
+		principal.Subject = subject[0]
+	}
+	if method := md.Get(MetadataMethod); len(method) > 0 {
+		principal.Method = method[0]
+	}
+	return NewContext(ctx, principal)
+}
+
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoing(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoing(ctx), desc, cc, method, opts...)
+	}
+}
+
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(incoming(ctx), req)
+	}
+}
+
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: incoming(ss.Context())})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,327 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+const (
+	DefaultTenantClaim = "tenant_id"
+	DefaultJWTLeeway   = 30 * time.Second
+	jwksReloadInterval = 30 * time.Second
+)
+
+type JWTConfig struct {
+	JWKSFile    string
+	Issuer      string
+	Audience    string
+	TenantClaim string
+	Leeway      time.Duration
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type verificationKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+type JWTAuthenticator struct {
+	cfg      JWTConfig
+	mu       sync.RWMutex
+	keys     map[string]verificationKey
+	loadedAt time.Time
+}
+
+func NewJWTAuthenticator(cfg JWTConfig) (*JWTAuthenticator, error) {
+	if cfg.TenantClaim == "" {
+		cfg.TenantClaim = DefaultTenantClaim
+	}
+	if cfg.Leeway <= 0 {
+		cfg.Leeway = DefaultJWTLeeway
+	}
+
+	a := &JWTAuthenticator{cfg: cfg}
+	if err := a.reload(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *JWTAuthenticator) reload() error {
+	data, err := os.ReadFile(a.cfg.JWKSFile)
+	if err != nil {
+		return fmt.Errorf("read JWKS: %w", err)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("parse JWKS: %w", err)
+	}
+
+	keys := make(map[string]verificationKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			return fmt.Errorf("JWKS key %q: %w", k.Kid, err)
+		}
+		keys[k.Kid] = verificationKey{alg: k.Alg, key: pub}
+	}
+	if len(keys) == 0 {
+		return errors.New("JWKS contains no signing keys")
+	}
+
+	a.mu.Lock()
+	a.keys = keys
+	a.loadedAt = time.Now()
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *JWTAuthenticator) lookup(kid string) (verificationKey, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if key, ok := a.keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(a.keys) == 1 {
+		for _, key := range a.keys {
+			return key, true
+		}
+	}
+	return verificationKey{}, false
+}
+
+func (a *JWTAuthenticator) key(kid string) (verificationKey, bool) {
+	if key, ok := a.lookup(kid); ok {
+		return key, true
+	}
+
+	a.mu.RLock()
+	stale := time.Since(a.loadedAt) >= jwksReloadInterval
+	a.mu.RUnlock()
+
+	if stale && a.reload() == nil {
+		return a.lookup(kid)
+	}
+	return verificationKey{}, false
+}
+
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token := bearerFromRequest(r)
+	if strings.Count(token, ".") != 2 {
+		return nil, ErrNoCredentials
+	}
+
+	parts := strings.Split(token, ".")
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	key, ok := a.key(header.Kid)
+	if !ok || (key.alg != "" && key.alg != header.Alg) {
+		return nil, ErrInvalidCredentials
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	if err := verifySignature(header.Alg, key.key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	var claims map[string]any
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	return a.validateClaims(claims)
+}
+
+func (a *JWTAuthenticator) validateClaims(claims map[string]any) (*Principal, error) {
+	now := time.Now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	if now.After(time.Unix(int64(exp), 0).Add(a.cfg.Leeway)) {
+		return nil, ErrExpiredCredentials
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(a.cfg.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return nil, ErrInvalidCredentials
+	}
+
+	if a.cfg.Issuer != "" && claims["iss"] != a.cfg.Issuer {
+		return nil, ErrInvalidCredentials
+	}
+	if a.cfg.Audience != "" && !hasAudience(claims["aud"], a.cfg.Audience) {
+		return nil, ErrInvalidCredentials
+	}
+
+	subject, _ := claims["sub"].(string)
+	tenantID, _ := claims[a.cfg.TenantClaim].(string)
+	if subject == "" || tenantID == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	return &Principal{
+		Subject:  subject,
+		TenantID: tenantID,
+		Method:   MethodJWT,
+	}, nil
+}
+
+func hasAudience(aud any, want string) bool {
+	switch v := aud.(type) {
+	case string:
+		return v == want
+	case []any:
+		for _, a := range v {
+			if a == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+var jwtHashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"PS256": crypto.SHA256, "PS384": crypto.SHA384, "PS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+func verifySignature(alg string, key crypto.PublicKey, signed, signature []byte) error {
+	if alg == "EdDSA" {
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(pub, signed, signature) {
+			return ErrInvalidCredentials
+		}
+		return nil
+	}
+
+	hash, ok := jwtHashes[alg]
+	if !ok {
+		return fmt.Errorf("unsupported JWT algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if strings.HasPrefix(alg, "RS") {
+			return rsa.VerifyPKCS1v15(pub, hash, digest, signature)
+		}
+		if strings.HasPrefix(alg, "PS") {
+			return rsa.VerifyPSS(pub, hash, digest, signature, nil)
+		}
+	case *ecdsa.PublicKey:
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if !strings.HasPrefix(alg, "ES") || len(signature) != 2*size {
+			return ErrInvalidCredentials
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if ecdsa.Verify(pub, digest, r, s) {
+			return nil
+		}
+	}
+	return ErrInvalidCredentials
+}
+
+var jwkCurves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		curve, ok := jwkCurves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return pub, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testKey struct {
+	kid    string
+	alg    string
+	signer crypto.Signer
+}
+
+func (k testKey) jwk() map[string]string {
+	enc := base64.RawURLEncoding.EncodeToString
+	entry := map[string]string{"kid": k.kid, "alg": k.alg, "use": "sig"}
+	switch pub := k.signer.Public().(type) {
+	case *rsa.PublicKey:
+		entry["kty"] = "RSA"
+		entry["n"] = enc(pub.N.Bytes())
+		entry["e"] = enc(big.NewInt(int64(pub.E)).Bytes())
+	case *ecdsa.PublicKey:
+		entry["kty"] = "EC"
+		entry["crv"] = "P-256"
+		entry["x"] = enc(pub.X.FillBytes(make([]byte, 32)))
+		entry["y"] = enc(pub.Y.FillBytes(make([]byte, 32)))
+	case ed25519.PublicKey:
+		entry["kty"] = "OKP"
+		entry["crv"] = "Ed25519"
+		entry["x"] = enc(pub)
+	}
+	return entry
+}
+
+func (k testKey) sign(t *testing.T, alg string, claims map[string]any) string {
+	t.Helper()
+	enc := base64.RawURLEncoding.EncodeToString
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": k.kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := enc(header) + "." + enc(payload)
+
+	var signature []byte
+	var err error
+	switch key := k.signer.(type) {
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(key, []byte(signed))
+	case *ecdsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key, digest[:])
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(signed))
+		if alg == "PS256" {
+			signature, err = rsa.SignPSS(rand.Reader, key, crypto.SHA256, digest[:], nil)
+		} else {
+			signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		}
+	}
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return signed + "." + enc(signature)
+}
+
+func writeJWKS(t *testing.T, path string, keys ...testKey) {
+	t.Helper()
+	var set struct {
+		Keys []map[string]string `json:"keys"`
+	}
+	for _, k := range keys {
+		set.Keys = append(set.Keys, k.jwk())
+	}
+	data, _ := json.Marshal(set)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func generateKeys(t *testing.T) (testKey, testKey, testKey) {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKey{kid: "rsa", alg: "RS256", signer: rsaKey},
+		testKey{kid: "ec", alg: "ES256", signer: ecKey},
+		testKey{kid: "ed", alg: "EdDSA", signer: edKey}
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"sub":       "alice",
+		"tenant_id": "acme",
+		"iss":       "https://issuer.example.com",
+		"aud":       "ai-translator",
+		"exp":       time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func withClaim(key string, value any) map[string]any {
+	claims := validClaims()
+	if value == nil {
+		delete(claims, key)
+	} else {
+		claims[key] = value
+	}
+	return claims
+}
+
+func newTestJWT(t *testing.T, keys ...testKey) (*JWTAuthenticator, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeJWKS(t, path, keys...)
+	a, err := NewJWTAuthenticator(JWTConfig{
+		JWKSFile: path,
+		Issuer:   "https://issuer.example.com",
+		Audience: "ai-translator",
+		Leeway:   30 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewJWTAuthenticator: %v", err)
+	}
+	return a, path
+}
+
+func TestJWTAuthenticate(t *testing.T) {
+	rsaKey, ecKey, edKey := generateKeys(t)
+	a, _ := newTestJWT(t, rsaKey, ecKey, edKey)
+	unknown := testKey{kid: "unknown", alg: "RS256", signer: rsaKey.signer}
+	hour := time.Hour
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{name: "RS256", token: rsaKey.sign(t, "RS256", validClaims())},
+		{name: "ES256", token: ecKey.sign(t, "ES256", validClaims())},
+		{name: "EdDSA", token: edKey.sign(t, "EdDSA", validClaims())},
+		{name: "audience list", token: rsaKey.sign(t, "RS256", withClaim("aud", []string{"other", "ai-translator"}))},
+		{name: "within leeway", token: rsaKey.sign(t, "RS256", withClaim("exp", time.Now().Add(-10*time.Second).Unix()))},
+		{name: "expired", token: rsaKey.sign(t, "RS256", withClaim("exp", time.Now().Add(-hour).Unix())), want: ErrExpiredCredentials},
+		{name: "missing exp", token: rsaKey.sign(t, "RS256", withClaim("exp", nil)), want: ErrInvalidCredentials},
+		{name: "not yet valid", token: rsaKey.sign(t, "RS256", withClaim("nbf", time.Now().Add(hour).Unix())), want: ErrInvalidCredentials},
+		{name: "wrong audience", token: rsaKey.sign(t, "RS256", withClaim("aud", "someone-else")), want: ErrInvalidCredentials},
+		{name: "wrong audience list", token: rsaKey.sign(t, "RS256", withClaim("aud", []string{"a", "b"})), want: ErrInvalidCredentials},
+		{name: "wrong issuer", token: rsaKey.sign(t, "RS256", withClaim("iss", "https://evil.example.com")), want: ErrInvalidCredentials},
+		{name: "missing tenant", token: rsaKey.sign(t, "RS256", withClaim("tenant_id", nil)), want: ErrInvalidCredentials},
+		{name: "missing subject", token: rsaKey.sign(t, "RS256", withClaim("sub", nil)), want: ErrInvalidCredentials},
+		{name: "alg mismatch with key", token: rsaKey.sign(t, "PS256", validClaims()), want: ErrInvalidCredentials},
+		{name: "alg none", token: unsigned(validClaims()), want: ErrInvalidCredentials},
+		{name: "key signed by another kid", token: testKey{kid: "rsa", signer: ecKey.signer}.sign(t, "ES256", validClaims()), want: ErrInvalidCredentials},
+		{name: "unknown kid", token: unknown.sign(t, "RS256", validClaims()), want: ErrInvalidCredentials},
+		{name: "not a jwt", token: "abc", want: ErrNoCredentials},
+		{name: "garbage header", token: "!!.e30.e30", want: ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := a.Authenticate(bearerRequest(tt.token))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Authenticate error = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && (principal.Subject != "alice" || principal.TenantID != "acme" || principal.Method != MethodJWT) {
+				t.Errorf("principal = %+v", principal)
+			}
+		})
+	}
+}
+
+func TestJWTTamperedPayload(t *testing.T) {
+	rsaKey, _, _ := generateKeys(t)
+	a, _ := newTestJWT(t, rsaKey)
+
+	token := rsaKey.sign(t, "RS256", validClaims())
+	forged := rsaKey.sign(t, "RS256", withClaim("tenant_id", "other"))
+	parts := strings.Split(token, ".")
+	forgedParts := strings.Split(forged, ".")
+
+	if _, err := a.Authenticate(bearerRequest(parts[0] + "." + forgedParts[1] + "." + parts[2])); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("Authenticate error = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func unsigned(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "none", "kid": "rsa"})
+	payload, _ := json.Marshal(claims)
+	return base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+}
+
+func TestJWTRotatedKeyIsLoaded(t *testing.T) {
+	rsaKey, ecKey, _ := generateKeys(t)
+	a, path := newTestJWT(t, rsaKey)
+
+	writeJWKS(t, path, rsaKey, ecKey)
+	token := ecKey.sign(t, "ES256", validClaims())
+
+	if _, err := a.Authenticate(bearerRequest(token)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("error = %v, want the new kid rejected until the reload interval passes", err)
+	}
+
+	a.mu.Lock()
+	a.loadedAt = time.Now().Add(-jwksReloadInterval)
+	a.mu.Unlock()
+
+	if _, err := a.Authenticate(bearerRequest(token)); err != nil {
+		t.Fatalf("Authenticate after rotation: %v", err)
+	}
+}
+
+func TestJWTNotAcceptedInQuery(t *testing.T) {
+	rsaKey, _, _ := generateKeys(t)
+	a, _ := newTestJWT(t, rsaKey)
+
+	r := httptest.NewRequest("GET", "/ws?access_token="+rsaKey.sign(t, "RS256", validClaims()), nil)
+	if _, err := a.Authenticate(r); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("Authenticate error = %v, want ErrNoCredentials", err)
+	}
+}
+
+func TestNewJWTAuthenticatorErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewJWTAuthenticator(JWTConfig{JWKSFile: filepath.Join(dir, "missing.json")}); err == nil {
+		t.Error("expected an error for a missing JWKS file")
+	}
+
+	empty := filepath.Join(dir, "empty.json")
+	os.WriteFile(empty, []byte(`{"keys":[{"kty":"RSA","use":"enc","kid":"x","n":"AQAB","e":"AQAB"}]}`), 0o600)
+	if _, err := NewJWTAuthenticator(JWTConfig{JWKSFile: empty}); err == nil {
+		t.Error("expected an error for a JWKS without signing keys")
+	}
+
+	badCurve := filepath.Join(dir, "curve.json")
+	os.WriteFile(badCurve, []byte(`{"keys":[{"kty":"EC","crv":"P-256","kid":"x","x":"AQ","y":"AQ"}]}`), 0o600)
+	if _, err := NewJWTAuthenticator(JWTConfig{JWKSFile: badCurve}); err == nil {
+		t.Error("expected an error for a point that is not on the curve")
+	}
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTokenTTL = 5 * time.Minute
+	TokenQueryParam = "access_token"
+)
+
+type tokenClaims struct {
+	Subject   string `json:"sub"`
+	TenantID  string `json:"tid"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type TokenAuthenticator struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewTokenAuthenticator(secret []byte, ttl time.Duration) (*TokenAuthenticator, error) {
+	if len(secret) < 32 {
+		return nil, errors.New("session token secret must be at least 32 bytes")
+	}
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+	return &TokenAuthenticator{secret: secret, ttl: ttl}, nil
+}
+
+func (a *TokenAuthenticator) Issue(principal *Principal) (string, time.Time, error) {
+	expiresAt := time.Now().Add(a.ttl).Truncate(time.Second)
+
+	payload, err := json.Marshal(tokenClaims{
+		Subject:   principal.Subject,
+		TenantID:  principal.TenantID,
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(a.sign(encoded)), expiresAt, nil
+}
+
+func (a *TokenAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	return a.verify(bearerFromRequest(r))
+}
+
+func (a *TokenAuthenticator) verify(token string) (*Principal, error) {
+	if strings.Count(token, ".") != 1 {
+		return nil, ErrNoCredentials
+	}
+
+	encoded, signature, _ := strings.Cut(token, ".")
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(sig, a.sign(encoded)) {
+		return nil, ErrInvalidCredentials
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	var claims tokenClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Subject == "" || claims.TenantID == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	if time.Now().Unix() >= claims.ExpiresAt {
+		return nil, ErrExpiredCredentials
+	}
+
+	return &Principal{
+		Subject:  claims.Subject,
+		TenantID: claims.TenantID,
+		Method:   MethodSessionToken,
+	}, nil
+}
+
+func (a *TokenAuthenticator) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, a.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
+
+type QueryTokenAuthenticator struct {
+	tokens *TokenAuthenticator
+}
+
+func NewQueryTokenAuthenticator(tokens *TokenAuthenticator) *QueryTokenAuthenticator {
+	return &QueryTokenAuthenticator{tokens: tokens}
+}
+
+func (a *QueryTokenAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token := r.URL.Query().Get(TokenQueryParam)
+	if token == "" {
+		return nil, ErrNoCredentials
+	}
+	return a.tokens.verify(token)
+}
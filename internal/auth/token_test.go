@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestTokens(t *testing.T, ttl time.Duration) *TokenAuthenticator {
+	t.Helper()
+	tokens, err := NewTokenAuthenticator(testSecret, ttl)
+	if err != nil {
+		t.Fatalf("NewTokenAuthenticator: %v", err)
+	}
+	return tokens
+}
+
+func issue(t *testing.T, tokens *TokenAuthenticator) string {
+	t.Helper()
+	token, _, err := tokens.Issue(&Principal{Subject: "alice", TenantID: "acme"})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	return token
+}
+
+func bearerRequest(token string) *http.Request {
+	r := httptest.NewRequest("GET", "/ws", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func TestNewTokenAuthenticatorRejectsShortSecret(t *testing.T) {
+	if _, err := NewTokenAuthenticator([]byte("short"), time.Minute); err == nil {
+		t.Fatal("expected an error for a short secret")
+	}
+}
+
+func TestSessionTokenRoundTrip(t *testing.T) {
+	tokens := newTestTokens(t, time.Minute)
+	token, expiresAt, err := tokens.Issue(&Principal{Subject: "alice", TenantID: "acme"})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if d := time.Until(expiresAt); d <= 0 || d > time.Minute {
+		t.Errorf("expires in %s, want within the TTL", d)
+	}
+
+	principal, err := tokens.Authenticate(bearerRequest(token))
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if principal.Subject != "alice" || principal.TenantID != "acme" || principal.Method != MethodSessionToken {
+		t.Errorf("principal = %+v", principal)
+	}
+}
+
+func TestSessionTokenRejections(t *testing.T) {
+	tokens := newTestTokens(t, time.Minute)
+	token := issue(t, tokens)
+	payload, signature, _ := strings.Cut(token, ".")
+
+	other, err := NewTokenAuthenticator([]byte("fedcba9876543210fedcba9876543210"), time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{name: "missing", token: "", want: ErrNoCredentials},
+		{name: "not a session token", token: "a.b.c", want: ErrNoCredentials},
+		{name: "tampered payload", token: payload + "x." + signature, want: ErrInvalidCredentials},
+		{name: "tampered signature", token: payload + "." + signature[:len(signature)-2] + "AA", want: ErrInvalidCredentials},
+		{name: "bad signature encoding", token: payload + ".!!", want: ErrInvalidCredentials},
+		{name: "other secret", token: issue(t, other), want: ErrInvalidCredentials},
+		{name: "expired", token: issue(t, newTestTokens(t, time.Nanosecond)), want: ErrExpiredCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tokens.Authenticate(bearerRequest(tt.token)); !errors.Is(err, tt.want) {
+				t.Errorf("Authenticate error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionTokenOnlyInHeader(t *testing.T) {
+	tokens := newTestTokens(t, time.Minute)
+	r := httptest.NewRequest("GET", "/v1/translate?access_token="+issue(t, tokens), nil)
+	if _, err := tokens.Authenticate(r); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("Authenticate error = %v, want ErrNoCredentials for a query token", err)
+	}
+}
+
+func TestQueryTokenAuthenticator(t *testing.T) {
+	tokens := newTestTokens(t, time.Minute)
+	query := NewQueryTokenAuthenticator(tokens)
+
+	principal, err := query.Authenticate(httptest.NewRequest("GET", "/ws?access_token="+issue(t, tokens), nil))
+	if err != nil || principal.TenantID != "acme" {
+		t.Fatalf("Authenticate = %+v, %v", principal, err)
+	}
+
+	if _, err := query.Authenticate(httptest.NewRequest("GET", "/ws", nil)); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("no token: error = %v, want ErrNoCredentials", err)
+	}
+	expired := issue(t, newTestTokens(t, time.Nanosecond))
+	if _, err := query.Authenticate(httptest.NewRequest("GET", "/ws?access_token="+expired, nil)); !errors.Is(err, ErrExpiredCredentials) {
+		t.Errorf("expired token: error = %v, want ErrExpiredCredentials", err)
+	}
+	if _, err := query.Authenticate(httptest.NewRequest("GET", "/ws?access_token=secret-api-key", nil)); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("API key in query: error = %v, want ErrNoCredentials", err)
+	}
+}
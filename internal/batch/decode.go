@@ -0,0 +1,100 @@
+package batch
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+
+	"ai-translator/internal/audio"
+)
+
+const (
+	FormatWAV = "wav"
+	FormatPCM = "pcm"
+)
+
+var (
+	ErrEmptyAudio   = errors.New("audio is empty")
+	ErrInvalidAudio = errors.New("invalid audio")
+)
+
+func FormatFromContentType(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch strings.ToLower(mediaType) {
+	case "audio/wav", "audio/wave", "audio/x-wav", "audio/vnd.wave":
+		return FormatWAV
+	case "audio/l16", "audio/pcm", "application/octet-stream":
+		return FormatPCM
+	}
+	return ""
+}
+
+func FormatFromFilename(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".wav", ".wave":
+		return FormatWAV
+	case ".pcm", ".raw", ".l16":
+		return FormatPCM
+	}
+	return ""
+}
+
+func Decode(data []byte, format string) ([]byte, error) {
+	var pcm bytes.Buffer
+	if _, err := DecodeTo(&pcm, bytes.NewReader(data), format); err != nil {
+		return nil, err
+	}
+	return pcm.Bytes(), nil
+}
+
+func DecodeTo(w io.Writer, r io.Reader, format string) (int64, error) {
+	var src io.Reader
+	switch format {
+	case FormatWAV:
+		wav, err := audio.NewWAVReader(r)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %w", ErrInvalidAudio, err)
+		}
+		src = wav.Normalized()
+	case FormatPCM:
+		src = r
+	default:
+		return 0, fmt.Errorf("%w: unsupported format %q, expected wav or pcm", ErrInvalidAudio, format)
+	}
+
+	n, err := copySamples(w, src)
+	if err != nil {
+		return n, err
+	}
+	if n == 0 {
+		return 0, ErrEmptyAudio
+	}
+	return n, nil
+}
+
+func copySamples(w io.Writer, r io.Reader) (int64, error) {
+	buf := make([]byte, 32*1024)
+	var written int64
+	pending := 0
+	for {
+		n, err := r.Read(buf[pending:])
+		pending += n
+		if whole := pending &^ 1; whole > 0 {
+			if _, werr := w.Write(buf[:whole]); werr != nil {
+				return written, werr
+			}
+			written += int64(whole)
+			pending = copy(buf, buf[whole:pending])
+		}
+		if err == io.EOF {
+			return written, nil
+		}
+		if err != nil {
+			return written, fmt.Errorf("%w: %w", ErrInvalidAudio, err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package batch
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"ai-translator/internal/audio"
+)
+
+func wavBytes(t *testing.T, sampleRate, channels int, pcm []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := audio.NewWAVWriter(&buf, sampleRate, channels)
+	if err != nil {
+		t.Fatalf("NewWAVWriter: %v", err)
+	}
+	if _, err := w.Write(pcm); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeToPCMDropsTrailingByte(t *testing.T) {
+	var out bytes.Buffer
+	n, err := DecodeTo(&out, iotest.OneByteReader(bytes.NewReader([]byte{1, 2, 3, 4, 5})), FormatPCM)
+	if err != nil {
+		t.Fatalf("DecodeTo: %v", err)
+	}
+	if n != 4 || !bytes.Equal(out.Bytes(), []byte{1, 2, 3, 4}) {
+		t.Fatalf("DecodeTo = %d %v, want 4 [1 2 3 4]", n, out.Bytes())
+	}
+}
+
+func TestDecodeToWAVNormalizes(t *testing.T) {
+	pcm := tone(1)
+	stereo := make([]byte, 0, len(pcm)*2)
+	for i := 0; i < len(pcm); i += 2 {
+		stereo = append(stereo, pcm[i], pcm[i+1], pcm[i], pcm[i+1])
+	}
+
+	var out bytes.Buffer
+	n, err := DecodeTo(&out, bytes.NewReader(wavBytes(t, audio.SampleRate, 2, stereo)), FormatWAV)
+	if err != nil {
+		t.Fatalf("DecodeTo: %v", err)
+	}
+	if n != int64(len(pcm)) || int64(out.Len()) != n {
+		t.Fatalf("DecodeTo wrote %d bytes, reported %d, want %d", out.Len(), n, len(pcm))
+	}
+}
+
+func TestDecodeToErrors(t *testing.T) {
+	readErr := errors.New("connection reset")
+	tests := []struct {
+		name   string
+		r      io.Reader
+		format string
+		want   error
+	}{
+		{"unknown format", bytes.NewReader([]byte{1, 2}), "mp3", ErrInvalidAudio},
+		{"not a wav", bytes.NewReader([]byte("hello world, this is not audio")), FormatWAV, ErrInvalidAudio},
+		{"empty pcm", bytes.NewReader([]byte{1}), FormatPCM, ErrEmptyAudio},
+		{"empty wav", bytes.NewReader(wavBytes(t, audio.SampleRate, 1, nil)), FormatWAV, ErrEmptyAudio},
+		{"read error", iotest.ErrReader(readErr), FormatPCM, readErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeTo(io.Discard, tt.r, tt.format)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("DecodeTo error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestDecodeToWriteErrorIsNotInvalidAudio(t *testing.T) {
+	_, err := DecodeTo(failingWriter{}, bytes.NewReader(tone(1)), FormatPCM)
+	if err == nil || errors.Is(err, ErrInvalidAudio) {
+		t.Fatalf("DecodeTo error = %v, want a write error", err)
+	}
+}
+
+func TestFormatDetection(t *testing.T) {
+	if got := FormatFromContentType("audio/wav; codecs=1"); got != FormatWAV {
+		t.Errorf("FormatFromContentType(audio/wav) = %q", got)
+	}
+	if got := FormatFromContentType("audio/L16; rate=16000"); got != FormatPCM {
+		t.Errorf("FormatFromContentType(audio/L16) = %q", got)
+	}
+	if got := FormatFromFilename("talk.WAV"); got != FormatWAV {
+		t.Errorf("FormatFromFilename(talk.WAV) = %q", got)
+	}
+	if got := FormatFromFilename("talk.mp3"); got != "" {
+		t.Errorf("FormatFromFilename(talk.mp3) = %q", got)
+	}
+}
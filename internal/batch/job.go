@@ -0,0 +1,374 @@
+package batch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"ai-translator/internal/audio"
+	"ai-translator/internal/metrics"
+	"ai-translator/internal/transcript"
+	"ai-translator/internal/util"
+)
+
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+const (
+	inputFile      = "input.pcm"
+	AudioFile      = "translated.wav"
+	TranscriptFile = "transcript.json"
+)
+
+var (
+	ErrNotFound  = errors.New("job not found")
+	ErrQueueFull = errors.New("job queue is full")
+	ErrFinished  = errors.New("job already finished")
+	ErrNotReady  = errors.New("job has not succeeded")
+)
+
+type Job struct {
+	ID             string     `json:"id"`
+	TenantID       string     `json:"tenant_id"`
+	Status         string     `json:"status"`
+	SourceLanguage string     `json:"source_language"`
+	TargetLanguage string     `json:"target_language"`
+	Progress       Progress   `json:"progress"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	StartedAt      *time.Time `json:"started_at,omitempty"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+func (j *Job) Finished() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed || j.Status == StatusCancelled
+}
+
+type entry struct {
+	job    Job
+	cancel context.CancelFunc
+}
+
+type Manager struct {
+	processor   *Processor
+	dir         string
+	concurrency int
+	retention   time.Duration
+	queue       chan *entry
+	mu          sync.Mutex
+	jobs        map[string]*entry
+	logger      *slog.Logger
+}
+
+func NewManager(processor *Processor, dir string, concurrency, queueSize int, retention time.Duration, logger *slog.Logger) (*Manager, error) {
+	if concurrency < 1 {
+		return nil, fmt.Errorf("batch concurrency must be at least 1")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create job directory: %w", err)
+	}
+	return &Manager{
+		processor:   processor,
+		dir:         dir,
+		concurrency: concurrency,
+		retention:   retention,
+		queue:       make(chan *entry, queueSize),
+		jobs:        make(map[string]*entry),
+		logger:      logger,
+	}, nil
+}
+
+func (m *Manager) Submit(tenantID, sourceLanguage, targetLanguage, format string, body io.Reader) (Job, error) {
+	if len(m.queue) == cap(m.queue) {
+		return Job{}, ErrQueueFull
+	}
+
+	id := "job_" + util.NewRequestID()
+	dir := filepath.Join(m.dir, id)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return Job{}, err
+	}
+	size, err := writeInput(filepath.Join(dir, inputFile), body, format)
+	if err != nil {
+		os.RemoveAll(dir)
+		return Job{}, err
+	}
+
+	e := &entry{job: Job{
+		ID:             id,
+		TenantID:       tenantID,
+		Status:         StatusQueued,
+		SourceLanguage: sourceLanguage,
+		TargetLanguage: targetLanguage,
+		Progress:       Progress{Stage: StageQueued, TotalAudioMs: audio.DurationOf(int(size)).Milliseconds()},
+		CreatedAt:      time.Now().UTC(),
+	}}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	select {
+	case m.queue <- e:
+	default:
+		os.RemoveAll(dir)
+		return Job{}, ErrQueueFull
+	}
+	m.jobs[id] = e
+	metrics.BatchQueueDepth.Inc()
+
+	m.logger.Info("batch job queued", "job_id", id, "tenant_id", tenantID, "audio_ms", e.job.Progress.TotalAudioMs)
+	return e.job, nil
+}
+
+func (m *Manager) Get(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+	return e.job, nil
+}
+
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+
+	switch e.job.Status {
+	case StatusQueued:
+		m.finish(e, StatusCancelled, nil)
+	case StatusRunning:
+		e.cancel()
+	default:
+		return e.job, ErrFinished
+	}
+	return e.job, nil
+}
+
+func (m *Manager) AudioPath(id string) (string, error) {
+	return m.output(id, AudioFile)
+}
+
+func (m *Manager) Transcript(id string) (*transcript.Transcript, error) {
+	path, err := m.output(id, TranscriptFile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var t transcript.Transcript
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (m *Manager) output(id, name string) (string, error) {
+	job, err := m.Get(id)
+	if err != nil {
+		return "", err
+	}
+	if job.Status != StatusSucceeded {
+		return "", ErrNotReady
+	}
+	return filepath.Join(m.dir, id, name), nil
+}
+
+func (m *Manager) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < m.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.work(ctx)
+		}()
+	}
+
+	if m.retention > 0 {
+		ticker := time.NewTicker(min(m.retention, time.Hour))
+		defer ticker.Stop()
+
+		m.sweep(time.Now())
+	loop:
+		for {
+			select {
+			case <-ctx.Done():
+				break loop
+			case now := <-ticker.C:
+				m.sweep(now)
+			}
+		}
+	}
+	wg.Wait()
+}
+
+func (m *Manager) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-m.queue:
+			metrics.BatchQueueDepth.Dec()
+			m.process(ctx, e)
+		}
+	}
+}
+
+func (m *Manager) process(ctx context.Context, e *entry) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	m.mu.Lock()
+	if e.job.Status != StatusQueued {
+		m.mu.Unlock()
+		return
+	}
+	now := time.Now().UTC()
+	e.job.Status = StatusRunning
+	e.job.StartedAt = &now
+	e.cancel = cancel
+	task := Task{ID: e.job.ID, TenantID: e.job.TenantID, SourceLanguage: e.job.SourceLanguage, TargetLanguage: e.job.TargetLanguage}
+	m.mu.Unlock()
+
+	logger := m.logger.With("job_id", task.ID, "tenant_id", task.TenantID)
+	logger.Info("batch job started")
+
+	dir := filepath.Join(m.dir, task.ID)
+	pcm, err := os.ReadFile(filepath.Join(dir, inputFile))
+	if err == nil {
+		task.Audio = pcm
+		var result *Result
+		result, err = m.processor.Process(ctx, task, func(p Progress) {
+			m.mu.Lock()
+			e.job.Progress = p
+			m.mu.Unlock()
+		})
+		if err == nil {
+			err = writeOutputs(dir, result)
+		}
+	}
+	os.Remove(filepath.Join(dir, inputFile))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case err == nil:
+		e.job.Progress.Stage = StageDone
+		e.job.Progress.Percent = 100
+		m.finish(e, StatusSucceeded, nil)
+	case ctx.Err() != nil:
+		m.finish(e, StatusCancelled, nil)
+	default:
+		m.finish(e, StatusFailed, err)
+	}
+	logger.Info("batch job finished", "status", e.job.Status, "error", err)
+}
+
+func (m *Manager) finish(e *entry, status string, err error) {
+	now := time.Now().UTC()
+	e.job.Status = status
+	e.job.FinishedAt = &now
+	if err != nil {
+		e.job.Error = err.Error()
+	}
+	if status != StatusSucceeded {
+		os.RemoveAll(filepath.Join(m.dir, e.job.ID))
+	}
+	metrics.BatchJobs.WithLabelValues(status).Inc()
+}
+
+func writeInput(path string, body io.Reader, format string) (int64, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640)
+	if err != nil {
+		return 0, err
+	}
+	w := bufio.NewWriter(f)
+	size, err := DecodeTo(w, body, format)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return size, err
+}
+
+func writeOutputs(dir string, result *Result) error {
+	if err := WriteWAV(filepath.Join(dir, AudioFile), result.Audio); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(result.Transcript)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, TranscriptFile), data, 0o640)
+}
+
+func WriteWAV(path string, pcm []byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	wav, err := audio.NewWAVWriter(f, audio.SampleRate, audio.Channels)
+	if err == nil {
+		_, err = wav.Write(pcm)
+	}
+	if err == nil {
+		err = wav.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (m *Manager) sweep(now time.Time) {
+	cutoff := now.Add(-m.retention)
+
+	m.mu.Lock()
+	for id, e := range m.jobs {
+		if e.job.Finished() && e.job.FinishedAt.Before(cutoff) {
+			delete(m.jobs, id)
+			os.RemoveAll(filepath.Join(m.dir, id))
+		}
+	}
+	m.mu.Unlock()
+
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		m.logger.Error("failed to list batch jobs", "error", err)
+		return
+	}
+	for _, d := range entries {
+		info, err := d.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if _, err := m.Get(d.Name()); errors.Is(err, ErrNotFound) {
+			os.RemoveAll(filepath.Join(m.dir, d.Name()))
+		}
+	}
+}
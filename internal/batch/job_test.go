@@ -0,0 +1,87 @@
+package batch
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestJobManager(t *testing.T, queueSize int) *Manager {
+	t.Helper()
+	m, err := NewManager(nil, t.TempDir(), 1, queueSize, time.Hour, discardLogger())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return m
+}
+
+func TestSubmitWritesDecodedInput(t *testing.T) {
+	m := newTestJobManager(t, 1)
+	pcm := tone(2)
+
+	job, err := m.Submit("acme", "en-US", "es-ES", FormatWAV, bytes.NewReader(wavBytes(t, 8000, 1, tone(1))))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if job.Status != StatusQueued || job.Progress.TotalAudioMs != 2000 {
+		t.Fatalf("job = %+v", job)
+	}
+	data, err := os.ReadFile(filepath.Join(m.dir, job.ID, inputFile))
+	if err != nil {
+		t.Fatalf("read input: %v", err)
+	}
+	if len(data) != len(pcm) {
+		t.Fatalf("input = %d bytes, want %d", len(data), len(pcm))
+	}
+}
+
+func TestSubmitRejectsInvalidAudio(t *testing.T) {
+	m := newTestJobManager(t, 1)
+
+	_, err := m.Submit("acme", "en-US", "es-ES", FormatWAV, bytes.NewReader([]byte("not audio")))
+	if !errors.Is(err, ErrInvalidAudio) {
+		t.Fatalf("Submit error = %v, want ErrInvalidAudio", err)
+	}
+	entries, _ := os.ReadDir(m.dir)
+	if len(entries) != 0 {
+		t.Fatalf("job directory left behind: %v", entries)
+	}
+}
+
+func TestSubmitQueueFull(t *testing.T) {
+	m := newTestJobManager(t, 1)
+
+	if _, err := m.Submit("acme", "en-US", "es-ES", FormatPCM, bytes.NewReader(tone(1))); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	_, err := m.Submit("acme", "en-US", "es-ES", FormatPCM, bytes.NewReader(tone(1)))
+	if !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Submit error = %v, want ErrQueueFull", err)
+	}
+	entries, _ := os.ReadDir(m.dir)
+	if len(entries) != 1 {
+		t.Fatalf("job directories = %d, want 1", len(entries))
+	}
+}
+
+func TestCancelQueuedJob(t *testing.T) {
+	m := newTestJobManager(t, 1)
+	job, err := m.Submit("acme", "en-US", "es-ES", FormatPCM, bytes.NewReader(tone(1)))
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	job, err = m.Cancel(job.ID)
+	if err != nil || job.Status != StatusCancelled {
+		t.Fatalf("Cancel = %+v, %v", job, err)
+	}
+	if _, err := m.Cancel(job.ID); !errors.Is(err, ErrFinished) {
+		t.Fatalf("second Cancel error = %v, want ErrFinished", err)
+	}
+	if _, err := m.AudioPath(job.ID); !errors.Is(err, ErrNotReady) {
+		t.Fatalf("AudioPath error = %v, want ErrNotReady", err)
+	}
+}
@@ -0,0 +1,334 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"time"
+	"unicode/utf8"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/audio"
+	"ai-translator/internal/metering"
+	"ai-translator/internal/quota"
+	"ai-translator/internal/transcript"
+)
+
+const (
+	chunkBytes = audio.SampleRate * audio.BytesPerSample / 10
+	gapBytes   = audio.SampleRate * audio.BytesPerSample / 4
+
+	segmentBytes       = 240 * audio.SampleRate * audio.BytesPerSample
+	segmentSearchBytes = 10 * audio.SampleRate * audio.BytesPerSample
+)
+
+var ErrRecognitionClosed = errors.New("recognizer closed the stream before all audio was sent")
+
+type Progress struct {
+	Stage        string `json:"stage"`
+	AudioMs      int64  `json:"audio_ms"`
+	TotalAudioMs int64  `json:"total_audio_ms"`
+	Utterances   int    `json:"utterances"`
+	Failed       int    `json:"failed"`
+	Percent      int    `json:"percent"`
+}
+
+const (
+	StageQueued      = "queued"
+	StageRecognizing = "recognizing"
+	StageFinalizing  = "finalizing"
+	StageDone        = "done"
+)
+
+type Task struct {
+	ID             string
+	TenantID       string
+	SourceLanguage string
+	TargetLanguage string
+	Audio          []byte
+}
+
+type Result struct {
+	Audio      []byte
+	Transcript *transcript.Transcript
+}
+
+type Processor struct {
+	asrClient        pb.ASRServiceClient
+	translatorClient pb.TranslatorServiceClient
+	ttsClient        pb.TTSServiceClient
+	quotas           *quota.Manager
+	meter            *metering.Meter
+	logger           *slog.Logger
+}
+
+func NewProcessor(asrClient pb.ASRServiceClient, translatorClient pb.TranslatorServiceClient, ttsClient pb.TTSServiceClient, quotas *quota.Manager, meter *metering.Meter, logger *slog.Logger) *Processor {
+	return &Processor{
+		asrClient:        asrClient,
+		translatorClient: translatorClient,
+		ttsClient:        ttsClient,
+		quotas:           quotas,
+		meter:            meter,
+		logger:           logger,
+	}
+}
+
+func (p *Processor) Process(ctx context.Context, task Task, progress func(Progress)) (*Result, error) {
+	defer p.meter.EndSession(task.ID)
+
+	total := audio.DurationOf(len(task.Audio))
+	if err := p.quotas.ConsumeAudio(task.TenantID, total); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	run := &run{
+		task: task,
+		result: &Result{
+			Transcript: &transcript.Transcript{
+				SessionID:  task.ID,
+				TenantID:   task.TenantID,
+				StartedAt:  now,
+				Utterances: []transcript.Utterance{},
+			},
+		},
+		report:   Progress{Stage: StageRecognizing, TotalAudioMs: total.Milliseconds()},
+		progress: progress,
+		logger:   p.logger.With("job_id", task.ID, "tenant_id", task.TenantID),
+	}
+	progress(run.report)
+
+	for start := 0; start < len(task.Audio); {
+		end := segmentEnd(task.Audio, start)
+		if err := p.recognize(ctx, run, start, end); err != nil {
+			return nil, err
+		}
+		start = end
+	}
+
+	run.report.Stage = StageFinalizing
+	progress(run.report)
+
+	result := run.result
+	endedAt := time.Now().UTC()
+	result.Transcript.EndedAt = &endedAt
+	if pad := int(total.Milliseconds())*audio.SampleRate/1000*audio.BytesPerSample - len(result.Audio); pad > 0 {
+		result.Audio = append(result.Audio, make([]byte, pad)...)
+	}
+	return result, nil
+}
+
+type run struct {
+	task     Task
+	result   *Result
+	report   Progress
+	progress func(Progress)
+	logger   *slog.Logger
+}
+
+func segmentEnd(pcm []byte, start int) int {
+	if len(pcm)-start <= segmentBytes {
+		return len(pcm)
+	}
+
+	end := start + segmentBytes
+	quietest, lowest := end, math.Inf(1)
+	for offset := end - segmentSearchBytes; offset+chunkBytes <= end; offset += chunkBytes {
+		var energy float64
+		for _, sample := range audio.BytesToPCM(pcm[offset : offset+chunkBytes]) {
+			energy += float64(sample) * float64(sample)
+		}
+		if energy < lowest {
+			quietest, lowest = offset+chunkBytes/2, energy
+		}
+	}
+	return quietest
+}
+
+func (p *Processor) recognize(ctx context.Context, run *run, start, end int) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	task := run.task
+	stream, err := p.asrClient.StreamingRecognize(ctx)
+	if err != nil {
+		return fmt.Errorf("start recognition: %w", err)
+	}
+	err = stream.Send(&pb.ASRRequest{
+		Request: &pb.ASRRequest_Config{
+			Config: &pb.StreamingConfig{
+				Session:                    &pb.SessionInfo{SessionId: task.ID},
+				EnableAutomaticPunctuation: true,
+				EnableLanguageDetection:    true,
+				LanguageCodes:              []string{task.SourceLanguage},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("configure recognition: %w", err)
+	}
+
+	sendErr := make(chan error, 1)
+	go func() {
+		sendErr <- p.sendAudio(stream, task, task.Audio[start:end])
+	}()
+
+	offsetMs := audio.DurationOf(start).Milliseconds()
+	segmentEndMs := min(audio.DurationOf(end).Milliseconds(), run.report.TotalAudioMs)
+	startMs := offsetMs
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("recognition: %w", err)
+		}
+		if !resp.IsFinal || resp.Transcript == "" {
+			continue
+		}
+
+		endMs := min(max(offsetMs+resp.ResultEndOffsetMs, startMs), segmentEndMs)
+		u, err := p.translate(ctx, task, resp, run.result, startMs, endMs)
+		if err != nil {
+			if quota.IsQuotaExhausted(err) || quota.IsRateLimit(err) || ctx.Err() != nil {
+				return err
+			}
+			run.logger.Warn("utterance failed", "error", err, "index", u.Index)
+			run.report.Failed++
+		}
+		run.result.Transcript.Utterances = append(run.result.Transcript.Utterances, u)
+
+		startMs = endMs
+		run.update(endMs)
+	}
+	if err := <-sendErr; err != nil {
+		return fmt.Errorf("send audio: %w", err)
+	}
+	run.update(segmentEndMs)
+	return nil
+}
+
+func (r *run) update(audioMs int64) {
+	r.report.AudioMs = audioMs
+	r.report.Utterances = len(r.result.Transcript.Utterances)
+	if r.report.TotalAudioMs > 0 {
+		r.report.Percent = int(r.report.AudioMs * 100 / r.report.TotalAudioMs)
+	}
+	r.progress(r.report)
+}
+
+func (p *Processor) sendAudio(stream pb.ASRService_StreamingRecognizeClient, task Task, pcm []byte) error {
+	defer stream.CloseSend()
+
+	for offset := 0; offset < len(pcm); offset += chunkBytes {
+		chunk := pcm[offset:min(offset+chunkBytes, len(pcm))]
+		err := stream.Send(&pb.ASRRequest{
+			Request: &pb.ASRRequest_Audio{
+				Audio: &pb.AudioChunk{
+					Data:       chunk,
+					SampleRate: audio.SampleRate,
+					Channels:   audio.Channels,
+				},
+			},
+		})
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return fmt.Errorf("%w after %s of %s", ErrRecognitionClosed, audio.DurationOf(offset), audio.DurationOf(len(pcm)))
+			}
+			return err
+		}
+		p.meter.AddAudio(task.TenantID, task.ID, audio.DurationOf(len(chunk)))
+	}
+	return nil
+}
+
+func (p *Processor) translate(ctx context.Context, task Task, resp *pb.ASRResponse, result *Result, startMs, endMs int64) (transcript.Utterance, error) {
+	u := transcript.Utterance{
+		Index:          len(result.Transcript.Utterances),
+		StartMs:        startMs,
+		EndMs:          endMs,
+		SourceText:     resp.Transcript,
+		SourceLanguage: resp.DetectedLanguage,
+		TargetLanguage: task.TargetLanguage,
+		RecordedAt:     time.Now().UTC(),
+	}
+
+	if err := p.quotas.ConsumeTranslation(task.TenantID, utf8.RuneCountInString(resp.Transcript)); err != nil {
+		return u, err
+	}
+	transResp, err := p.translatorClient.Translate(ctx, &pb.TranslateRequest{
+		SessionId:      task.ID,
+		Text:           resp.Transcript,
+		SourceLanguage: resp.DetectedLanguage,
+		TargetLanguage: task.TargetLanguage,
+		IsFinal:        true,
+	})
+	if err != nil {
+		return u, fmt.Errorf("translate utterance %d: %w", u.Index, err)
+	}
+	p.meter.AddTranslation(task.TenantID, task.ID, transResp.Engine, utf8.RuneCountInString(resp.Transcript))
+
+	u.TranslatedText = transResp.TranslatedText
+	if transResp.TargetLanguage != "" {
+		u.TargetLanguage = transResp.TargetLanguage
+	}
+	if u.TranslatedText == "" {
+		return u, nil
+	}
+
+	speech, err := p.synthesize(ctx, task, u.TranslatedText)
+	if err != nil {
+		return u, fmt.Errorf("synthesize utterance %d: %w", u.Index, err)
+	}
+
+	position := int(startMs) * audio.SampleRate / 1000 * audio.BytesPerSample
+	if len(result.Audio) > 0 {
+		position = max(position, len(result.Audio)+gapBytes)
+	}
+	if pad := position - len(result.Audio); pad > 0 {
+		result.Audio = append(result.Audio, make([]byte, pad)...)
+	}
+	result.Audio = append(result.Audio, speech[:len(speech)&^1]...)
+	return u, nil
+}
+
+func (p *Processor) synthesize(ctx context.Context, task Task, text string) ([]byte, error) {
+	if err := p.quotas.ConsumeTTS(task.TenantID, utf8.RuneCountInString(text)); err != nil {
+		return nil, err
+	}
+
+	stream, err := p.ttsClient.Synthesize(ctx, &pb.TTSRequest{
+		SessionId:    task.ID,
+		Text:         text,
+		LanguageCode: task.TargetLanguage,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var speech []byte
+	metered := false
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return speech, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !metered {
+			p.meter.AddSynthesis(task.TenantID, task.ID, resp.VoiceTier, utf8.RuneCountInString(text))
+			metered = true
+		}
+		if resp.Audio != nil {
+			speech = append(speech, resp.Audio.Data...)
+		}
+		if resp.IsFinal {
+			return speech, nil
+		}
+	}
+}
@@ -0,0 +1,283 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/audio"
+	"ai-translator/internal/quota"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeASRStream struct {
+	grpc.ClientStream
+	ctx        context.Context
+	closeAfter int
+	respond    func(pcm []byte) []*pb.ASRResponse
+	config     *pb.StreamingConfig
+	audio      []byte
+	chunks     int
+	once       sync.Once
+	done       chan struct{}
+	responses  []*pb.ASRResponse
+	started    bool
+}
+
+func (s *fakeASRStream) Send(req *pb.ASRRequest) error {
+	if config := req.GetConfig(); config != nil {
+		s.config = config
+		return nil
+	}
+	if s.closeAfter > 0 && s.chunks == s.closeAfter {
+		s.once.Do(func() { close(s.done) })
+		return io.EOF
+	}
+	s.chunks++
+	s.audio = append(s.audio, req.GetAudio().Data...)
+	return nil
+}
+
+func (s *fakeASRStream) CloseSend() error {
+	s.once.Do(func() { close(s.done) })
+	return nil
+}
+
+func (s *fakeASRStream) Recv() (*pb.ASRResponse, error) {
+	select {
+	case <-s.done:
+	case <-s.ctx.Done():
+		return nil, status.FromContextError(s.ctx.Err()).Err()
+	}
+	if !s.started {
+		s.started = true
+		if s.respond != nil {
+			s.responses = s.respond(s.audio)
+		}
+	}
+	if len(s.responses) == 0 {
+		return nil, io.EOF
+	}
+	resp := s.responses[0]
+	s.responses = s.responses[1:]
+	return resp, nil
+}
+
+type fakeASRClient struct {
+	pb.ASRServiceClient
+	closeAfter int
+	respond    func(pcm []byte) []*pb.ASRResponse
+	streams    []*fakeASRStream
+}
+
+func (c *fakeASRClient) StreamingRecognize(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[pb.ASRRequest, pb.ASRResponse], error) {
+	stream := &fakeASRStream{ctx: ctx, closeAfter: c.closeAfter, respond: c.respond, done: make(chan struct{})}
+	c.streams = append(c.streams, stream)
+	return stream, nil
+}
+
+func wholeSegment(pcm []byte) []*pb.ASRResponse {
+	return []*pb.ASRResponse{
+		{Transcript: "hel", IsFinal: false},
+		{Transcript: "hello", IsFinal: true, DetectedLanguage: "en-US", ResultEndOffsetMs: audio.DurationOf(len(pcm)).Milliseconds()},
+	}
+}
+
+type fakeTranslatorClient struct {
+	pb.TranslatorServiceClient
+	err error
+}
+
+func (c *fakeTranslatorClient) Translate(ctx context.Context, in *pb.TranslateRequest, opts ...grpc.CallOption) (*pb.TranslateResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &pb.TranslateResponse{TranslatedText: "hola", Engine: "fake", TargetLanguage: in.TargetLanguage, IsFinal: true}, nil
+}
+
+type fakeTTSStream struct {
+	grpc.ClientStream
+	sent bool
+}
+
+func (s *fakeTTSStream) Recv() (*pb.TTSResponse, error) {
+	if s.sent {
+		return nil, io.EOF
+	}
+	s.sent = true
+	return &pb.TTSResponse{Audio: &pb.AudioChunk{Data: make([]byte, 320)}, IsFinal: true}, nil
+}
+
+type fakeTTSClient struct {
+	pb.TTSServiceClient
+}
+
+func (c *fakeTTSClient) Synthesize(ctx context.Context, in *pb.TTSRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.TTSResponse], error) {
+	return &fakeTTSStream{}, nil
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func newTestProcessor(asr *fakeASRClient, translator *fakeTranslatorClient) *Processor {
+	return NewProcessor(asr, translator, &fakeTTSClient{}, quota.NewManager(quota.Limits{}), nil, discardLogger())
+}
+
+func bytesFor(seconds float64) int {
+	return int(seconds*audio.SampleRate) * audio.BytesPerSample
+}
+
+func tone(seconds float64) []byte {
+	samples := make([]int16, bytesFor(seconds)/audio.BytesPerSample)
+	for i := range samples {
+		samples[i] = 8000
+		if i%2 == 1 {
+			samples[i] = -8000
+		}
+	}
+	return audio.PCMToBytes(samples)
+}
+
+func TestSegmentEndShortAudio(t *testing.T) {
+	pcm := make([]byte, segmentBytes)
+	if got := segmentEnd(pcm, 0); got != len(pcm) {
+		t.Fatalf("segmentEnd = %d, want %d", got, len(pcm))
+	}
+	if got := segmentEnd(pcm, 3200); got != len(pcm) {
+		t.Fatalf("segmentEnd from offset = %d, want %d", got, len(pcm))
+	}
+}
+
+func TestSegmentEndCutsAtQuietestChunk(t *testing.T) {
+	pcm := tone(300)
+	gap := segmentBytes - bytesFor(4)
+	clear(pcm[gap : gap+chunkBytes])
+
+	if got, want := segmentEnd(pcm, 0), gap+chunkBytes/2; got != want {
+		t.Fatalf("segmentEnd = %d, want %d", got, want)
+	}
+
+	start := bytesFor(30)
+	got := segmentEnd(pcm, start)
+	if got <= start || got-start > segmentBytes || got-start < segmentBytes-segmentSearchBytes {
+		t.Fatalf("segmentEnd from %d = %d, outside the search window", start, got)
+	}
+	if got%audio.BytesPerSample != 0 {
+		t.Fatalf("segmentEnd = %d splits a sample", got)
+	}
+}
+
+func TestProcessSplitsLongAudioIntoSegments(t *testing.T) {
+	pcm := tone(540)
+	gap := segmentBytes - bytesFor(2)
+	clear(pcm[gap : gap+chunkBytes])
+
+	asr := &fakeASRClient{respond: wholeSegment}
+	var reports []Progress
+	result, err := newTestProcessor(asr, &fakeTranslatorClient{}).Process(context.Background(), Task{
+		ID:             "job_1",
+		TenantID:       "acme",
+		SourceLanguage: "en-US",
+		TargetLanguage: "es-ES",
+		Audio:          pcm,
+	}, func(p Progress) { reports = append(reports, p) })
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+
+	if len(asr.streams) != 3 {
+		t.Fatalf("streams = %d, want 3", len(asr.streams))
+	}
+	sent := 0
+	for i, stream := range asr.streams {
+		if len(stream.audio) > segmentBytes {
+			t.Errorf("stream %d carried %s of audio", i, audio.DurationOf(len(stream.audio)))
+		}
+		if stream.config == nil || stream.config.Session.GetSessionId() != "job_1" {
+			t.Errorf("stream %d was not configured", i)
+		}
+		sent += len(stream.audio)
+	}
+	if sent != len(pcm) {
+		t.Fatalf("sent %d bytes, want %d", sent, len(pcm))
+	}
+	if got, want := len(asr.streams[0].audio), gap+chunkBytes/2; got != want {
+		t.Fatalf("first segment = %d bytes, want a cut at the quiet chunk %d", got, want)
+	}
+
+	utterances := result.Transcript.Utterances
+	if len(utterances) != 3 {
+		t.Fatalf("utterances = %d, want 3", len(utterances))
+	}
+	var offset int
+	for i, u := range utterances {
+		start := audio.DurationOf(offset).Milliseconds()
+		offset += len(asr.streams[i].audio)
+		end := audio.DurationOf(offset).Milliseconds()
+		if u.StartMs != start || u.EndMs != end {
+			t.Errorf("utterance %d = %d-%d ms, want %d-%d ms", i, u.StartMs, u.EndMs, start, end)
+		}
+		if u.TranslatedText != "hola" {
+			t.Errorf("utterance %d translated to %q", i, u.TranslatedText)
+		}
+	}
+
+	if len(result.Audio) != len(pcm) {
+		t.Fatalf("output audio = %d bytes, want %d", len(result.Audio), len(pcm))
+	}
+	last := reports[len(reports)-1]
+	if last.Stage != StageFinalizing || last.Percent != 100 || last.Utterances != 3 {
+		t.Fatalf("final progress = %+v", last)
+	}
+	for i := 1; i < len(reports); i++ {
+		if reports[i].AudioMs < reports[i-1].AudioMs {
+			t.Fatalf("progress went backwards: %+v after %+v", reports[i], reports[i-1])
+		}
+	}
+}
+
+func TestProcessFailsWhenRecognizerClosesEarly(t *testing.T) {
+	asr := &fakeASRClient{closeAfter: 5, respond: wholeSegment}
+	_, err := newTestProcessor(asr, &fakeTranslatorClient{}).Process(context.Background(), Task{
+		ID:    "job_1",
+		Audio: tone(10),
+	}, func(Progress) {})
+	if !errors.Is(err, ErrRecognitionClosed) {
+		t.Fatalf("Process error = %v, want ErrRecognitionClosed", err)
+	}
+}
+
+func TestProcessCountsFailedUtterances(t *testing.T) {
+	asr := &fakeASRClient{respond: wholeSegment}
+	translator := &fakeTranslatorClient{err: status.Error(codes.Unavailable, "down")}
+	result, err := newTestProcessor(asr, translator).Process(context.Background(), Task{
+		ID:    "job_1",
+		Audio: tone(2),
+	}, func(Progress) {})
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if len(result.Transcript.Utterances) != 1 || result.Transcript.Utterances[0].TranslatedText != "" {
+		t.Fatalf("utterances = %+v, want one untranslated", result.Transcript.Utterances)
+	}
+	if len(result.Audio) != bytesFor(2) {
+		t.Fatalf("output audio = %d bytes, want silence of the input length", len(result.Audio))
+	}
+}
+
+func TestProcessStopsOnQuota(t *testing.T) {
+	asr := &fakeASRClient{respond: wholeSegment}
+	p := NewProcessor(asr, &fakeTranslatorClient{}, &fakeTTSClient{}, quota.NewManager(quota.Limits{TranslationCharsPerDay: 3}), nil, discardLogger())
+	_, err := p.Process(context.Background(), Task{ID: "job_1", TenantID: "acme", Audio: tone(2)}, func(Progress) {})
+	if !quota.IsQuotaExhausted(err) {
+		t.Fatalf("Process error = %v, want quota exhausted", err)
+	}
+}
@@ -7,34 +7,164 @@ import (
 )
 
 type Config struct {
-	GatewayPort     int
-	ASRPort         int
-	TranslatorPort  int
-	TTSPort         int
-	ASRAddress      string
-	TranslatorAddr  string
-	TTSAddress      string
-	GeminiAPIKey    string
-	GCPProjectID    string
-	GCPCredentials  string
-	LogLevel        string
-	ShutdownTimeout time.Duration
+	GatewayPort               int
+	ASRPort                   int
+	TranslatorPort            int
+	TTSPort                   int
+	ASRAddress                string
+	TranslatorAddr            string
+	TTSAddress                string
+	TranslatorEngines         string
+	TranslatorBreakerFailures int
+	TranslatorBreakerCooldown time.Duration
+	GCPProjectID              string
+	GCPCredentials            string
+	LogLevel                  string
+	ShutdownTimeout           time.Duration
+	TranslateTimeout          time.Duration
+	SynthesizeTimeout         time.Duration
+	BackendRetryAttempts      int
+	BackendBreakerFailures    int
+	BackendBreakerCooldown    time.Duration
+	HealthCheckInterval       time.Duration
+	ASRMetricsPort            int
+	TranslatorMetricsPort     int
+	TTSMetricsPort            int
+	TracingExporter           string
+	OTLPEndpoint              string
+	OTLPInsecure              bool
+	TracingSampleRatio        float64
+	AllowedOrigins            string
+	AuthAPIKeys               string
+	AuthTokenSecret           string
+	AuthTokenTTL              time.Duration
+	AuthJWKSFile              string
+	AuthJWTIssuer             string
+	AuthJWTAudience           string
+	AuthJWTTenantClaim        string
+	GRPCTLS                   bool
+	GRPCTLSCertFile           string
+	GRPCTLSKeyFile            string
+	GRPCTLSCAFile             string
+	GRPCTLSClientAuth         bool
+	GRPCTLSServerName         string
+	GatewayTLSCertFile        string
+	GatewayTLSKeyFile         string
+	TLSReloadInterval         time.Duration
+	QuotaMaxSessions          int
+	QuotaSessionsPerMinute    float64
+	QuotaAudioMinutesPerDay   float64
+	QuotaTranslationChars     int
+	QuotaTTSChars             int
+	QuotaCharsPerSecond       float64
+	AdminToken                string
+	MeteringSink              string
+	MeteringFile              string
+	MeteringWebhookURL        string
+	MeteringWebhookSecret     string
+	MeteringSpoolDir          string
+	MeteringFlushInterval     time.Duration
+	TranscriptStore           string
+	TranscriptDir             string
+	RecordingStore            string
+	RecordingDir              string
+	RecordingMode             string
+	RecordingRetention        time.Duration
+	BatchDir                  string
+	BatchConcurrency          int
+	BatchQueueSize            int
+	BatchMaxUploadMB          int
+	BatchRetention            time.Duration
+	SessionResumeGrace        time.Duration
+	WSPingInterval            time.Duration
+	WSIdleTimeout             time.Duration
+	WSWriteTimeout            time.Duration
+	WSSendQueueKB             int
+	WSSlowClientPolicy        string
+	IngestBuffer              time.Duration
+	IngestHighWatermarkPct    int
+	IngestLowWatermarkPct     int
 }
 
 func Load() *Config {
 	return &Config{
-		GatewayPort:     getEnvInt("GATEWAY_PORT", 8080),
-		ASRPort:         getEnvInt("ASR_PORT", 50051),
-		TranslatorPort:  getEnvInt("TRANSLATOR_PORT", 50052),
-		TTSPort:         getEnvInt("TTS_PORT", 50053),
-		ASRAddress:      getEnv("ASR_ADDRESS", "localhost:50051"),
-		TranslatorAddr:  getEnv("TRANSLATOR_ADDRESS", "localhost:50052"),
-		TTSAddress:      getEnv("TTS_ADDRESS", "localhost:50053"),
-		GeminiAPIKey:    getEnv("GEMINI_API_KEY", ""),
-		GCPProjectID:    getEnv("GCP_PROJECT_ID", ""),
-		GCPCredentials:  getEnv("GOOGLE_APPLICATION_CREDENTIALS", ""),
-		LogLevel:        getEnv("LOG_LEVEL", "info"),
-		ShutdownTimeout: time.Duration(getEnvInt("SHUTDOWN_TIMEOUT_SEC", 30)) * time.Second,
+		GatewayPort:               getEnvInt("GATEWAY_PORT", 8080),
+		ASRPort:                   getEnvInt("ASR_PORT", 50051),
+		TranslatorPort:            getEnvInt("TRANSLATOR_PORT", 50052),
+		TTSPort:                   getEnvInt("TTS_PORT", 50053),
+		ASRAddress:                getEnv("ASR_ADDRESS", "localhost:50051"),
+		TranslatorAddr:            getEnv("TRANSLATOR_ADDRESS", "localhost:50052"),
+		TTSAddress:                getEnv("TTS_ADDRESS", "localhost:50053"),
+		TranslatorEngines:         getEnv("TRANSLATOR_ENGINES", "gemini:gemini-1.5-flash=5s"),
+		TranslatorBreakerFailures: getEnvInt("TRANSLATOR_BREAKER_FAILURES", 3),
+		TranslatorBreakerCooldown: time.Duration(getEnvInt("TRANSLATOR_BREAKER_COOLDOWN_SEC", 30)) * time.Second,
+		GCPProjectID:              getEnv("GCP_PROJECT_ID", ""),
+		GCPCredentials:            getEnv("GOOGLE_APPLICATION_CREDENTIALS", ""),
+		LogLevel:                  getEnv("LOG_LEVEL", "info"),
+		ShutdownTimeout:           time.Duration(getEnvInt("SHUTDOWN_TIMEOUT_SEC", 30)) * time.Second,
+		TranslateTimeout:          time.Duration(getEnvInt("TRANSLATE_TIMEOUT_SEC", 15)) * time.Second,
+		SynthesizeTimeout:         time.Duration(getEnvInt("SYNTHESIZE_TIMEOUT_SEC", 15)) * time.Second,
+		BackendRetryAttempts:      getEnvInt("BACKEND_RETRY_ATTEMPTS", 3),
+		BackendBreakerFailures:    getEnvInt("BACKEND_BREAKER_FAILURES", 5),
+		BackendBreakerCooldown:    time.Duration(getEnvInt("BACKEND_BREAKER_COOLDOWN_SEC", 15)) * time.Second,
+		HealthCheckInterval:       time.Duration(getEnvInt("HEALTH_CHECK_INTERVAL_SEC", 5)) * time.Second,
+		ASRMetricsPort:            getEnvInt("ASR_METRICS_PORT", 9091),
+		TranslatorMetricsPort:     getEnvInt("TRANSLATOR_METRICS_PORT", 9092),
+		TTSMetricsPort:            getEnvInt("TTS_METRICS_PORT", 9093),
+		TracingExporter:           getEnv("TRACING_EXPORTER", "none"),
+		OTLPEndpoint:              getEnv("OTLP_ENDPOINT", "localhost:4317"),
+		OTLPInsecure:              getEnvBool("OTLP_INSECURE", true),
+		TracingSampleRatio:        getEnvFloat("TRACING_SAMPLE_RATIO", 1.0),
+		AllowedOrigins:            getEnv("ALLOWED_ORIGINS", ""),
+		AuthAPIKeys:               getEnv("AUTH_API_KEYS", ""),
+		AuthTokenSecret:           getEnv("AUTH_TOKEN_SECRET", ""),
+		AuthTokenTTL:              time.Duration(getEnvInt("AUTH_TOKEN_TTL_SEC", 300)) * time.Second,
+		AuthJWKSFile:              getEnv("AUTH_JWKS_FILE", ""),
+		AuthJWTIssuer:             getEnv("AUTH_JWT_ISSUER", ""),
+		AuthJWTAudience:           getEnv("AUTH_JWT_AUDIENCE", ""),
+		AuthJWTTenantClaim:        getEnv("AUTH_JWT_TENANT_CLAIM", "tenant_id"),
+		GRPCTLS:                   getEnvBool("GRPC_TLS", false),
+		GRPCTLSCertFile:           getEnv("GRPC_TLS_CERT_FILE", ""),
+		GRPCTLSKeyFile:            getEnv("GRPC_TLS_KEY_FILE", ""),
+		GRPCTLSCAFile:             getEnv("GRPC_TLS_CA_FILE", ""),
+		GRPCTLSClientAuth:         getEnvBool("GRPC_TLS_CLIENT_AUTH", false),
+		GRPCTLSServerName:         getEnv("GRPC_TLS_SERVER_NAME", ""),
+		GatewayTLSCertFile:        getEnv("GATEWAY_TLS_CERT_FILE", ""),
+		GatewayTLSKeyFile:         getEnv("GATEWAY_TLS_KEY_FILE", ""),
+		TLSReloadInterval:         time.Duration(getEnvInt("TLS_RELOAD_INTERVAL_SEC", 10)) * time.Second,
+		QuotaMaxSessions:          getEnvInt("QUOTA_MAX_SESSIONS", 0),
+		QuotaSessionsPerMinute:    getEnvFloat("QUOTA_SESSIONS_PER_MINUTE", 0),
+		QuotaAudioMinutesPerDay:   getEnvFloat("QUOTA_AUDIO_MINUTES_PER_DAY", 0),
+		QuotaTranslationChars:     getEnvInt("QUOTA_TRANSLATION_CHARS_PER_DAY", 0),
+		QuotaTTSChars:             getEnvInt("QUOTA_TTS_CHARS_PER_DAY", 0),
+		QuotaCharsPerSecond:       getEnvFloat("QUOTA_CHARS_PER_SECOND", 0),
+		AdminToken:                getEnv("ADMIN_TOKEN", ""),
+		MeteringSink:              getEnv("METERING_SINK", "none"),
+		MeteringFile:              getEnv("METERING_FILE", "usage.jsonl"),
+		MeteringWebhookURL:        getEnv("METERING_WEBHOOK_URL", ""),
+		MeteringWebhookSecret:     getEnv("METERING_WEBHOOK_SECRET", ""),
+		MeteringSpoolDir:          getEnv("METERING_SPOOL_DIR", "metering-spool"),
+		MeteringFlushInterval:     time.Duration(getEnvInt("METERING_FLUSH_INTERVAL_SEC", 60)) * time.Second,
+		TranscriptStore:           getEnv("TRANSCRIPT_STORE", "none"),
+		TranscriptDir:             getEnv("TRANSCRIPT_DIR", "transcripts"),
+		RecordingStore:            getEnv("RECORDING_STORE", "none"),
+		RecordingDir:              getEnv("RECORDING_DIR", "recordings"),
+		RecordingMode:             getEnv("RECORDING_MODE", "tracks"),
+		RecordingRetention:        time.Duration(getEnvInt("RECORDING_RETENTION_HOURS", 720)) * time.Hour,
+		BatchDir:                  getEnv("BATCH_DIR", "jobs"),
+		BatchConcurrency:          getEnvInt("BATCH_CONCURRENCY", 2),
+		BatchQueueSize:            getEnvInt("BATCH_QUEUE_SIZE", 16),
+		BatchMaxUploadMB:          getEnvInt("BATCH_MAX_UPLOAD_MB", 100),
+		BatchRetention:            time.Duration(getEnvInt("BATCH_RETENTION_HOURS", 24)) * time.Hour,
+		SessionResumeGrace:        time.Duration(getEnvInt("SESSION_RESUME_GRACE_SEC", 30)) * time.Second,
+		WSPingInterval:            time.Duration(getEnvInt("WS_PING_INTERVAL_SEC", 20)) * time.Second,
+		WSIdleTimeout:             time.Duration(getEnvInt("WS_IDLE_TIMEOUT_SEC", 60)) * time.Second,
+		WSWriteTimeout:            time.Duration(getEnvInt("WS_WRITE_TIMEOUT_SEC", 10)) * time.Second,
+		WSSendQueueKB:             getEnvInt("WS_SEND_QUEUE_KB", 1024),
+		WSSlowClientPolicy:        getEnv("WS_SLOW_CLIENT_POLICY", "drop_audio"),
+		IngestBuffer:              time.Duration(getEnvInt("INGEST_BUFFER_SEC", 10)) * time.Second,
+		IngestHighWatermarkPct:    getEnvInt("INGEST_HIGH_WATERMARK_PCT", 75),
+		IngestLowWatermarkPct:     getEnvInt("INGEST_LOW_WATERMARK_PCT", 25),
 	}
 }
 
@@ -53,3 +183,21 @@ func getEnvInt(key string, defaultVal int) int {
 	}
 	return defaultVal
 }
+
+func getEnvBool(key string, defaultVal bool) bool {
+	if val := os.Getenv(key); val != "" {
+		if b, err := strconv.ParseBool(val); err == nil {
+			return b
+		}
+	}
+	return defaultVal
+}
+
+func getEnvFloat(key string, defaultVal float64) float64 {
+	if val := os.Getenv(key); val != "" {
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			return f
+		}
+	}
+	return defaultVal
+}
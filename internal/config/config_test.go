@@ -0,0 +1,37 @@
+package config
+
+import "testing"
+
+func TestTracingDefaults(t *testing.T) {
+	for _, key := range []string{"TRACING_EXPORTER", "OTLP_ENDPOINT", "OTLP_INSECURE", "TRACING_SAMPLE_RATIO"} {
+		t.Setenv(key, "")
+	}
+	cfg := Load()
+	if cfg.TracingExporter != "none" || cfg.OTLPEndpoint != "localhost:4317" || !cfg.OTLPInsecure || cfg.TracingSampleRatio != 1.0 {
+		t.Fatalf("tracing defaults = %q %q %v %v", cfg.TracingExporter, cfg.OTLPEndpoint, cfg.OTLPInsecure, cfg.TracingSampleRatio)
+	}
+}
+
+func TestTracingFromEnv(t *testing.T) {
+	t.Setenv("TRACING_EXPORTER", "otlp")
+	t.Setenv("OTLP_ENDPOINT", "collector:4317")
+	t.Setenv("OTLP_INSECURE", "false")
+	t.Setenv("TRACING_SAMPLE_RATIO", "0.25")
+
+	cfg := Load()
+	if cfg.TracingExporter != "otlp" || cfg.OTLPEndpoint != "collector:4317" || cfg.OTLPInsecure || cfg.TracingSampleRatio != 0.25 {
+		t.Fatalf("tracing config = %q %q %v %v", cfg.TracingExporter, cfg.OTLPEndpoint, cfg.OTLPInsecure, cfg.TracingSampleRatio)
+	}
+}
+
+func TestInvalidValuesFallBackToDefaults(t *testing.T) {
+	t.Setenv("OTLP_INSECURE", "maybe")
+	t.Setenv("TRACING_SAMPLE_RATIO", "half")
+
+	if got := getEnvBool("OTLP_INSECURE", true); !got {
+		t.Errorf("getEnvBool with an invalid value = %v, want the default", got)
+	}
+	if got := getEnvFloat("TRACING_SAMPLE_RATIO", 0.5); got != 0.5 {
+		t.Errorf("getEnvFloat with an invalid value = %v, want the default", got)
+	}
+}
@@ -0,0 +1,106 @@
+package gateway
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"ai-translator/internal/quota"
+)
+
+type AdminHandler struct {
+	mux    *http.ServeMux
+	quotas *quota.Manager
+	token  string
+	logger *slog.Logger
+}
+
+func NewAdminHandler(quotas *quota.Manager, token string, logger *slog.Logger) *AdminHandler {
+	h := &AdminHandler{
+		mux:    http.NewServeMux(),
+		quotas: quotas,
+		token:  token,
+		logger: logger,
+	}
+	h.mux.HandleFunc("GET /admin/quotas", h.listQuotas)
+	h.mux.HandleFunc("PUT /admin/quotas", h.updateDefaults)
+	h.mux.HandleFunc("GET /admin/quotas/{tenant}", h.getQuota)
+	h.mux.HandleFunc("PUT /admin/quotas/{tenant}", h.updateQuota)
+	h.mux.HandleFunc("DELETE /admin/quotas/{tenant}", h.resetQuota)
+	return h
+}
+
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="ai-translator-admin"`)
+		writeJSONError(w, http.StatusUnauthorized, "admin token required")
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *AdminHandler) authorized(r *http.Request) bool {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || scheme != "Bearer" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) == 1
+}
+
+type quotasResponse struct {
+	Defaults quota.Limits         `json:"defaults"`
+	Tenants  []quota.TenantStatus `json:"tenants"`
+}
+
+func (h *AdminHandler) listQuotas(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, quotasResponse{
+		Defaults: h.quotas.Defaults(),
+		Tenants:  h.quotas.Statuses(),
+	})
+}
+
+func (h *AdminHandler) updateDefaults(w http.ResponseWriter, r *http.Request) {
+	limits := h.quotas.Defaults()
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid limits: "+err.Error())
+		return
+	}
+	if err := h.quotas.SetDefaults(limits); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.logger.Info("default tenant limits updated", "limits", limits)
+	h.listQuotas(w, r)
+}
+
+func (h *AdminHandler) getQuota(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.quotas.Status(r.PathValue("tenant")))
+}
+
+func (h *AdminHandler) updateQuota(w http.ResponseWriter, r *http.Request) {
+	tenant := r.PathValue("tenant")
+
+	limits := h.quotas.Status(tenant).Limits
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid limits: "+err.Error())
+		return
+	}
+	if err := h.quotas.SetLimits(tenant, limits); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	h.logger.Info("tenant limits updated", "tenant_id", tenant, "limits", limits)
+	writeJSON(w, http.StatusOK, h.quotas.Status(tenant))
+}
+
+func (h *AdminHandler) resetQuota(w http.ResponseWriter, r *http.Request) {
+	tenant := r.PathValue("tenant")
+	h.quotas.ResetLimits(tenant)
+
+	h.logger.Info("tenant limits reset to defaults", "tenant_id", tenant)
+	writeJSON(w, http.StatusOK, h.quotas.Status(tenant))
+}
@@ -0,0 +1,84 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"ai-translator/internal/auth"
+)
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeJSONError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"error": message})
+}
+
+func authError(w http.ResponseWriter, err error) {
+	message := "invalid credentials"
+	switch {
+	case errors.Is(err, auth.ErrNoCredentials):
+		message = "authentication required"
+	case errors.Is(err, auth.ErrExpiredCredentials):
+		message = "credentials expired"
+	}
+	w.Header().Set("WWW-Authenticate", `Bearer realm="ai-translator"`)
+	writeJSONError(w, http.StatusUnauthorized, message)
+}
+
+func RequireAuth(authenticator auth.Authenticator, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, err := authenticator.Authenticate(r)
+		if err != nil {
+			authError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), principal)))
+	})
+}
+
+type TokenHandler struct {
+	tokens *auth.TokenAuthenticator
+	logger *slog.Logger
+}
+
+func NewTokenHandler(tokens *auth.TokenAuthenticator, logger *slog.Logger) *TokenHandler {
+	return &TokenHandler{tokens: tokens, logger: logger}
+}
+
+type tokenResponse struct {
+	Token     string    `json:"token"`
+	TenantID  string    `json:"tenant_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (h *TokenHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		authError(w, auth.ErrNoCredentials)
+		return
+	}
+
+	token, expiresAt, err := h.tokens.Issue(principal)
+	if err != nil {
+		h.logger.Error("failed to issue session token", "error", err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to issue token")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(tokenResponse{Token: token, TenantID: principal.TenantID, ExpiresAt: expiresAt})
+}
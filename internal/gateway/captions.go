@@ -0,0 +1,260 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-translator/internal/auth"
+	"ai-translator/internal/metrics"
+)
+
+const (
+	captionHistorySize    = 200
+	captionSubscriberSize = 64
+	captionKeepalive      = 15 * time.Second
+	captionWriteTimeout   = 10 * time.Second
+	captionRetry          = 3 * time.Second
+)
+
+type Caption struct {
+	ID             uint64 `json:"id,omitempty"`
+	Transcript     string `json:"transcript"`
+	Translation    string `json:"translation"`
+	SourceLanguage string `json:"source_language,omitempty"`
+	TargetLanguage string `json:"target_language,omitempty"`
+	IsFinal        bool   `json:"is_final"`
+	StartMs        int64  `json:"start_ms,omitempty"`
+	EndMs          int64  `json:"end_ms,omitempty"`
+}
+
+type captionHub struct {
+	mu      sync.Mutex
+	nextID  uint64
+	history []Caption
+	subs    map[chan Caption]struct{}
+	closed  bool
+}
+
+func newCaptionHub() *captionHub {
+	return &captionHub{subs: make(map[chan Caption]struct{})}
+}
+
+func (h *captionHub) publish(c Caption) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	if c.IsFinal {
+		h.nextID++
+		c.ID = h.nextID
+		if len(h.history) == captionHistorySize {
+			h.history = append(h.history[:0], h.history[1:]...)
+		}
+		h.history = append(h.history, c)
+	}
+
+	for ch := range h.subs {
+		select {
+		case ch <- c:
+		default:
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (h *captionHub) subscribe(lastID uint64) ([]Caption, <-chan Caption, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var replay []Caption
+	for _, c := range h.history {
+		if c.ID > lastID {
+			replay = append(replay, c)
+		}
+	}
+
+	ch := make(chan Caption, captionSubscriberSize)
+	if h.closed {
+		close(ch)
+		return replay, ch, func() {}
+	}
+	h.subs[ch] = struct{}{}
+
+	cancel := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[ch]; ok {
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+	return replay, ch, cancel
+}
+
+func (h *captionHub) isClosed() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.closed
+}
+
+func (h *captionHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+	for ch := range h.subs {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+type CaptionHandler struct {
+	sessions *SessionManager
+	logger   *slog.Logger
+}
+
+func NewCaptionHandler(sessions *SessionManager, logger *slog.Logger) *CaptionHandler {
+	return &CaptionHandler{sessions: sessions, logger: logger}
+}
+
+func (h *CaptionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		authError(w, auth.ErrNoCredentials)
+		return
+	}
+
+	session := h.sessions.Get(r.PathValue("id"))
+	if session == nil || session.Principal().TenantID != principal.TenantID {
+		writeJSONError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	var lastID uint64
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
+		lastID = id
+	}
+	languages := parseCaptionLanguages(r.URL.Query().Get("lang"))
+
+	replay, captions, cancel := session.captions.subscribe(lastID)
+	defer cancel()
+
+	metrics.CaptionViewers.Inc()
+	defer metrics.CaptionViewers.Dec()
+
+	logger := h.logger.With("session_id", session.ID, "tenant_id", principal.TenantID, "principal", principal.Subject)
+	logger.Debug("caption viewer connected", "last_event_id", lastID, "replayed", len(replay))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	stream := &captionStream{w: w, rc: http.NewResponseController(w), languages: languages}
+	if err := stream.write(fmt.Sprintf("retry: %d\n\n", captionRetry.Milliseconds())); err != nil {
+		return
+	}
+	for _, c := range replay {
+		if err := stream.send(c); err != nil {
+			return
+		}
+	}
+
+	keepalive := time.NewTicker(captionKeepalive)
+	defer keepalive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			logger.Debug("caption viewer disconnected")
+			return
+		case <-keepalive.C:
+			if err := stream.write(": keepalive\n\n"); err != nil {
+				return
+			}
+		case c, ok := <-captions:
+			if !ok {
+				if session.captions.isClosed() {
+					stream.write("event: end\ndata: {}\n\n")
+				} else {
+					logger.Warn("caption viewer too slow, closing stream")
+				}
+				return
+			}
+			if err := stream.send(c); err != nil {
+				return
+			}
+		}
+	}
+}
+
+type captionStream struct {
+	w         http.ResponseWriter
+	rc        *http.ResponseController
+	languages []string
+}
+
+func (s *captionStream) send(c Caption) error {
+	if !matchCaptionLanguage(s.languages, c.TargetLanguage) {
+		return nil
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if !c.IsFinal {
+		return s.write("event: partial\ndata: " + string(data) + "\n\n")
+	}
+	return s.write(fmt.Sprintf("id: %d\nevent: caption\ndata: %s\n\n", c.ID, data))
+}
+
+func (s *captionStream) write(event string) error {
+	s.rc.SetWriteDeadline(time.Now().Add(captionWriteTimeout))
+	if _, err := s.w.Write([]byte(event)); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+func parseCaptionLanguages(value string) []string {
+	var languages []string
+	for _, lang := range strings.Split(value, ",") {
+		if lang = strings.TrimSpace(lang); lang != "" {
+			languages = append(languages, lang)
+		}
+	}
+	return languages
+}
+
+func matchCaptionLanguage(languages []string, target string) bool {
+	if len(languages) == 0 {
+		return true
+	}
+	for _, lang := range languages {
+		if strings.EqualFold(lang, target) {
+			return true
+		}
+		if !strings.Contains(lang, "-") && len(target) > len(lang) && target[len(lang)] == '-' && strings.EqualFold(lang, target[:len(lang)]) {
+			return true
+		}
+	}
+	return false
+}
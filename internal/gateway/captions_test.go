@@ -0,0 +1,291 @@
+package gateway
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-translator/internal/auth"
+	"ai-translator/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCaptionHubNumbersFinalCaptions(t *testing.T) {
+	h := newCaptionHub()
+	_, ch, cancel := h.subscribe(0)
+	defer cancel()
+
+	h.publish(Caption{Transcript: "hel"})
+	h.publish(Caption{Transcript: "hello", IsFinal: true})
+	h.publish(Caption{Transcript: "bye", IsFinal: true})
+
+	for _, want := range []Caption{{Transcript: "hel"}, {ID: 1, Transcript: "hello", IsFinal: true}, {ID: 2, Transcript: "bye", IsFinal: true}} {
+		if got := <-ch; got != want {
+			t.Fatalf("caption = %+v, want %+v", got, want)
+		}
+	}
+
+	replay, _, cancelReplay := h.subscribe(1)
+	defer cancelReplay()
+	if len(replay) != 1 || replay[0].ID != 2 {
+		t.Fatalf("replay after 1 = %+v, want only caption 2", replay)
+	}
+}
+
+func TestCaptionHubHistoryIsBounded(t *testing.T) {
+	h := newCaptionHub()
+	for range captionHistorySize + 10 {
+		h.publish(Caption{IsFinal: true})
+	}
+	replay, _, cancel := h.subscribe(0)
+	defer cancel()
+	if len(replay) != captionHistorySize || replay[0].ID != 11 || replay[len(replay)-1].ID != captionHistorySize+10 {
+		t.Fatalf("history = %d captions from %d to %d", len(replay), replay[0].ID, replay[len(replay)-1].ID)
+	}
+}
+
+func TestCaptionHubDropsSlowSubscriber(t *testing.T) {
+	h := newCaptionHub()
+	_, slow, cancel := h.subscribe(0)
+	defer cancel()
+
+	for range captionSubscriberSize + 1 {
+		h.publish(Caption{})
+	}
+	received := 0
+	for range slow {
+		received++
+	}
+	if received != captionSubscriberSize {
+		t.Fatalf("slow subscriber received %d captions before being dropped, want %d", received, captionSubscriberSize)
+	}
+	if h.isClosed() {
+		t.Fatal("dropping a subscriber closed the hub")
+	}
+}
+
+func TestCaptionHubClose(t *testing.T) {
+	h := newCaptionHub()
+	h.publish(Caption{Transcript: "hello", IsFinal: true})
+	_, ch, cancel := h.subscribe(0)
+
+	h.close()
+	if _, ok := <-ch; ok {
+		t.Fatal("subscriber channel still open after close")
+	}
+	cancel()
+	h.close()
+	h.publish(Caption{Transcript: "late", IsFinal: true})
+
+	replay, late, _ := h.subscribe(0)
+	if len(replay) != 1 || replay[0].Transcript != "hello" {
+		t.Fatalf("replay after close = %+v", replay)
+	}
+	if _, ok := <-late; ok {
+		t.Fatal("subscribing to a closed hub returned an open channel")
+	}
+}
+
+func TestCaptionLanguages(t *testing.T) {
+	if got := parseCaptionLanguages(" es , ,fr-CA"); len(got) != 2 || got[0] != "es" || got[1] != "fr-CA" {
+		t.Fatalf("parseCaptionLanguages = %q", got)
+	}
+	if got := parseCaptionLanguages(""); got != nil {
+		t.Fatalf("parseCaptionLanguages(\"\") = %q", got)
+	}
+
+	tests := []struct {
+		languages []string
+		target    string
+		want      bool
+	}{
+		{nil, "es-ES", true},
+		{[]string{"es-ES"}, "es-es", true},
+		{[]string{"es"}, "es-MX", true},
+		{[]string{"es"}, "es", true},
+		{[]string{"es"}, "est", false},
+		{[]string{"es-MX"}, "es-ES", false},
+		{[]string{"es-MX"}, "es", false},
+		{[]string{"fr", "de"}, "de-DE", true},
+		{[]string{"fr"}, "", false},
+	}
+	for _, tt := range tests {
+		if got := matchCaptionLanguage(tt.languages, tt.target); got != tt.want {
+			t.Errorf("matchCaptionLanguage(%q, %q) = %v, want %v", tt.languages, tt.target, got, tt.want)
+		}
+	}
+}
+
+func captionServer(t *testing.T, m *SessionManager, principal *auth.Principal) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	h := NewCaptionHandler(m, discardLogger())
+	mux.HandleFunc("GET /v1/sessions/{id}/captions", func(w http.ResponseWriter, r *http.Request) {
+		if principal != nil {
+			r = r.WithContext(auth.NewContext(r.Context(), principal))
+		}
+		h.ServeHTTP(w, r)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func getCaptions(t *testing.T, srv *httptest.Server, path, lastEventID string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func sseEvents(resp *http.Response) <-chan string {
+	events := make(chan string, 16)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(resp.Body)
+		var block []string
+		for scanner.Scan() {
+			if scanner.Text() != "" {
+				block = append(block, scanner.Text())
+				continue
+			}
+			events <- strings.Join(block, "\n")
+			block = nil
+		}
+	}()
+	return events
+}
+
+func nextEvent(t *testing.T, events <-chan string) string {
+	t.Helper()
+	select {
+	case e, ok := <-events:
+		if !ok {
+			t.Fatal("caption stream ended")
+		}
+		return e
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for a caption event")
+	}
+	return ""
+}
+
+func TestCaptionStreamAccess(t *testing.T) {
+	m := newTestManager(nil, nil, nil, 0)
+	s := newTestSession(t, m, &fakeConn{})
+	path := "/v1/sessions/" + s.ID + "/captions"
+
+	tests := []struct {
+		name        string
+		principal   *auth.Principal
+		path        string
+		lastEventID string
+		code        int
+	}{
+		{"anonymous", nil, path, "", http.StatusUnauthorized},
+		{"other tenant", &auth.Principal{Subject: "bob", TenantID: "globex"}, path, "", http.StatusNotFound},
+		{"unknown session", testPrincipal, "/v1/sessions/missing/captions", "", http.StatusNotFound},
+		{"bad last event id", testPrincipal, path, "abc", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		resp := getCaptions(t, captionServer(t, m, tt.principal), tt.path, tt.lastEventID)
+		if resp.StatusCode != tt.code {
+			t.Errorf("%s: status = %d, want %d", tt.name, resp.StatusCode, tt.code)
+		}
+	}
+}
+
+func TestCaptionStream(t *testing.T) {
+	m := newTestManager(nil, nil, nil, 0)
+	s := newTestSession(t, m, &fakeConn{})
+	s.captions.publish(Caption{Transcript: "one", Translation: "uno", TargetLanguage: "es-ES", IsFinal: true})
+	s.captions.publish(Caption{Transcript: "two", Translation: "dos", TargetLanguage: "es-ES", IsFinal: true})
+
+	viewers := testutil.ToFloat64(metrics.CaptionViewers)
+	resp := getCaptions(t, captionServer(t, m, testPrincipal), "/v1/sessions/"+s.ID+"/captions?lang=es", "1")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", got)
+	}
+	events := sseEvents(resp)
+
+	if got := nextEvent(t, events); got != "retry: 3000" {
+		t.Fatalf("first event = %q, want the retry hint", got)
+	}
+	if got := nextEvent(t, events); !strings.HasPrefix(got, "id: 2\nevent: caption\ndata: ") || !strings.Contains(got, `"translation":"dos"`) {
+		t.Fatalf("replayed event = %q, want only caption 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.CaptionViewers) - viewers; got != 1 {
+		t.Fatalf("caption viewers grew by %v, want 1", got)
+	}
+
+	s.captions.publish(Caption{Transcript: "ignored", TargetLanguage: "fr-FR", IsFinal: true})
+	s.captions.publish(Caption{Transcript: "thr", Translation: "tr", TargetLanguage: "es-ES"})
+	s.captions.publish(Caption{Transcript: "three", Translation: "tres", TargetLanguage: "es-ES", IsFinal: true})
+
+	partial := nextEvent(t, events)
+	if !strings.HasPrefix(partial, "event: partial\ndata: ") || strings.Contains(partial, "id:") {
+		t.Fatalf("partial event = %q", partial)
+	}
+	final := nextEvent(t, events)
+	if !strings.HasPrefix(final, "id: 4\nevent: caption\n") {
+		t.Fatalf("final event = %q, want caption 4 after the filtered fr-FR caption", final)
+	}
+	var c Caption
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(final, "id: 4\nevent: caption\ndata: ")), &c); err != nil {
+		t.Fatalf("decode caption: %v", err)
+	}
+	if c.Transcript != "three" || c.Translation != "tres" || !c.IsFinal {
+		t.Fatalf("caption = %+v", c)
+	}
+
+	m.Remove(s.ID)
+	if got := nextEvent(t, events); got != "event: end\ndata: {}" {
+		t.Fatalf("event after session end = %q", got)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for testutil.ToFloat64(metrics.CaptionViewers) != viewers && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := testutil.ToFloat64(metrics.CaptionViewers); got != viewers {
+		t.Fatalf("caption viewers = %v after the stream ended, want %v", got, viewers)
+	}
+}
+
+func TestSessionPublishesCaptions(t *testing.T) {
+	s := newTestSession(t, newTestManager(&fakeTranslatorClient{}, nil, nil, 0), &fakeConn{})
+	s.SetLanguages("en-US", "es-ES")
+	_, ch, cancel := s.captions.subscribe(0)
+	defer cancel()
+
+	runTranslate(t, s, partial("hel"), final("hello"))
+
+	first := <-ch
+	if first.IsFinal || first.ID != 0 || first.Transcript != "hel" || first.Translation != "hola" || first.StartMs != 0 {
+		t.Fatalf("partial caption = %+v", first)
+	}
+	second := <-ch
+	if !second.IsFinal || second.ID != 1 || second.Transcript != "hello" || second.Translation != "hola" || second.TargetLanguage != "es-ES" {
+		t.Fatalf("final caption = %+v", second)
+	}
+	if second.EndMs < second.StartMs {
+		t.Fatalf("final caption spans %d-%d ms", second.StartMs, second.EndMs)
+	}
+}
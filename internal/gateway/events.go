@@ -0,0 +1,229 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"ai-translator/internal/quota"
+	"ai-translator/internal/transport"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	StageSession     = "session"
+	StageRecognition = "recognition"
+	StageTranslation = "translation"
+	StageSynthesis   = "synthesis"
+)
+
+const (
+	ErrorCodeSafetyBlocked      = "SAFETY_BLOCKED"
+	ErrorCodeQuotaExhausted     = "QUOTA_EXHAUSTED"
+	ErrorCodeDeadlineExceeded   = "DEADLINE_EXCEEDED"
+	ErrorCodeInvalidArgument    = "INVALID_ARGUMENT"
+	ErrorCodeInvalidOutput      = "INVALID_OUTPUT"
+	ErrorCodeBackendUnavailable = "BACKEND_UNAVAILABLE"
+	ErrorCodeInternal           = "INTERNAL"
+	ErrorCodeTenantQuota        = "TENANT_QUOTA_EXCEEDED"
+	ErrorCodeRateLimited        = "RATE_LIMITED"
+	ErrorCodeTextTooLarge       = "TEXT_TOO_LARGE"
+	ErrorCodeSessionNotFound    = "SESSION_NOT_FOUND"
+	ErrorCodeSlowClient         = "SLOW_CLIENT"
+)
+
+const (
+	CloseQuotaExhausted  = 4402
+	CloseSessionNotFound = 4404
+	CloseSlowClient      = 4408
+	CloseSessionResumed  = 4409
+	CloseRateLimited     = 4429
+)
+
+var errorMessages = map[string]string{
+	ErrorCodeSafetyBlocked:      "The utterance was not translated because it was blocked by content safety filters.",
+	ErrorCodeQuotaExhausted:     "The utterance was not translated because the service is over capacity. Please try again shortly.",
+	ErrorCodeDeadlineExceeded:   "The utterance was not translated because the service took too long to respond.",
+	ErrorCodeInvalidArgument:    "The utterance could not be processed with the current session settings.",
+	ErrorCodeInvalidOutput:      "The utterance was not translated because no valid translation was produced.",
+	ErrorCodeBackendUnavailable: "The utterance was not translated because the service is temporarily unavailable.",
+	ErrorCodeInternal:           "The utterance could not be processed.",
+	ErrorCodeTenantQuota:        "Your organization's daily usage quota has been reached.",
+	ErrorCodeRateLimited:        "Your organization is sending requests too quickly. Please slow down and try again.",
+	ErrorCodeTextTooLarge:       "The text is longer than your organization's character rate allows at once. Please split it into smaller parts.",
+	ErrorCodeSessionNotFound:    "The session could not be resumed because it has ended or expired.",
+	ErrorCodeSlowClient:         "The connection was closed because the client did not keep up with the audio being sent.",
+}
+
+type ErrorEvent struct {
+	Type    string   `json:"type"`
+	Code    string   `json:"code"`
+	Stage   string   `json:"stage"`
+	Message string   `json:"message"`
+	Text    string   `json:"text,omitempty"`
+	IsFinal bool     `json:"is_final"`
+	Latency *Latency `json:"latency,omitempty"`
+}
+
+type ReadyEvent struct {
+	Status      string `json:"status"`
+	SessionID   string `json:"session_id"`
+	ResumeToken string `json:"resume_token,omitempty"`
+	Recording   bool   `json:"recording"`
+	Missed      uint64 `json:"missed,omitempty"`
+}
+
+type FlowEvent struct {
+	Type       string `json:"type"`
+	BufferedMs int64  `json:"buffered_ms"`
+}
+
+type AudioDroppedEvent struct {
+	Type   string `json:"type"`
+	Frames int    `json:"frames"`
+	Bytes  int    `json:"bytes"`
+}
+
+type UtteranceEvent struct {
+	Type           string   `json:"type"`
+	Transcript     string   `json:"transcript"`
+	Translation    string   `json:"translation"`
+	SourceLanguage string   `json:"source_language,omitempty"`
+	TargetLanguage string   `json:"target_language,omitempty"`
+	IsFinal        bool     `json:"is_final"`
+	Latency        *Latency `json:"latency,omitempty"`
+}
+
+func NewErrorEvent(stage string, err error, text string, isFinal bool) ErrorEvent {
+	code := ErrorCodeFromError(err)
+	return ErrorEvent{
+		Type:    "error",
+		Code:    code,
+		Stage:   stage,
+		Message: errorMessages[code],
+		Text:    text,
+		IsFinal: isFinal,
+	}
+}
+
+func ErrorCodeFromError(err error) string {
+	switch {
+	case quota.IsQuotaExhausted(err):
+		return ErrorCodeTenantQuota
+	case quota.IsRateLimit(err):
+		return ErrorCodeRateLimited
+	case quota.IsTooLarge(err):
+		return ErrorCodeTextTooLarge
+	case errors.Is(err, ErrSessionNotFound):
+		return ErrorCodeSessionNotFound
+	case errors.Is(err, ErrSlowClient):
+		return ErrorCodeSlowClient
+	}
+
+	st := status.Convert(err)
+
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			if _, known := errorMessages[info.Reason]; known {
+				return info.Reason
+			}
+		}
+	}
+
+	switch st.Code() {
+	case codes.FailedPrecondition:
+		return ErrorCodeSafetyBlocked
+	case codes.ResourceExhausted:
+		return ErrorCodeQuotaExhausted
+	case codes.DeadlineExceeded:
+		return ErrorCodeDeadlineExceeded
+	case codes.InvalidArgument:
+		return ErrorCodeInvalidArgument
+	case codes.Unavailable:
+		return ErrorCodeBackendUnavailable
+	}
+	return ErrorCodeInternal
+}
+
+func statusFromErrorCode(code string) int {
+	switch code {
+	case ErrorCodeTenantQuota, ErrorCodeRateLimited:
+		return http.StatusTooManyRequests
+	case ErrorCodeInvalidArgument:
+		return http.StatusBadRequest
+	case ErrorCodeTextTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case ErrorCodeSafetyBlocked:
+		return http.StatusUnprocessableEntity
+	case ErrorCodeDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case ErrorCodeQuotaExhausted, ErrorCodeBackendUnavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadGateway
+}
+
+func writeErrorCode(w http.ResponseWriter, err error) {
+	code := ErrorCodeFromError(err)
+	writeJSON(w, statusFromErrorCode(code), map[string]string{"error": errorMessages[code], "code": code})
+}
+
+func writeEvent(conn Conn, event any) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return conn.WriteText(string(data))
+}
+
+func (s *Session) sendEvent(event any) error {
+	return writeEvent(s.conn, event)
+}
+
+func (s *Session) sendUtteranceError(stage string, err error, text string, timings *utteranceTimings) {
+	timings.observe()
+
+	event := NewErrorEvent(stage, err, text, true)
+	event.Latency = timings.Latency()
+	if sendErr := s.sendEvent(event); sendErr != nil {
+		s.logger.Error("failed to send error event", "error", sendErr)
+	}
+}
+
+func (s *Session) sendUtterance(u *utterance) {
+	u.timings.observe()
+
+	event := UtteranceEvent{
+		Type:           "utterance",
+		Transcript:     u.transcript,
+		Translation:    u.translation.TranslatedText,
+		SourceLanguage: u.translation.SourceLanguage,
+		TargetLanguage: u.translation.TargetLanguage,
+		IsFinal:        true,
+		Latency:        u.timings.Latency(),
+	}
+	if err := s.sendEvent(event); err != nil {
+		s.logger.Error("failed to send utterance event", "error", err)
+	}
+}
+
+func closeCodeFromError(err error) int {
+	switch {
+	case quota.IsQuotaExhausted(err):
+		return CloseQuotaExhausted
+	case errors.Is(err, ErrSessionNotFound):
+		return CloseSessionNotFound
+	}
+	return CloseRateLimited
+}
+
+func rejectConnection(conn *transport.WSConn, stage string, err error, logger *slog.Logger) {
+	if sendErr := writeEvent(conn, NewErrorEvent(stage, err, "", true)); sendErr != nil {
+		logger.Error("failed to send error event", "error", sendErr)
+	}
+	conn.CloseWithCode(closeCodeFromError(err), err.Error())
+}
@@ -0,0 +1,106 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"ai-translator/internal/quota"
+	"ai-translator/internal/translator"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrorCodeFromError(t *testing.T) {
+	quotas := quota.NewManager(quota.Limits{TranslationCharsPerDay: 1})
+	exhausted := quotas.ConsumeTranslation("acme", 2)
+	limited := quota.NewManager(quota.Limits{CharsPerSecond: 1})
+	tooLarge := limited.ConsumeTranslation("acme", 5001)
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"safety detail", translator.ToStatus(translator.ErrSafetyBlocked, "gemini"), ErrorCodeSafetyBlocked},
+		{"invalid output detail", translator.ToStatus(translator.ErrEmptyTranslation, "gemini"), ErrorCodeInvalidOutput},
+		{"deadline detail", translator.ToStatus(context.DeadlineExceeded, "gemini"), ErrorCodeDeadlineExceeded},
+		{"internal detail", translator.ToStatus(errors.New("boom"), "gemini"), ErrorCodeInternal},
+		{"failed precondition", status.Error(codes.FailedPrecondition, "blocked"), ErrorCodeSafetyBlocked},
+		{"resource exhausted", status.Error(codes.ResourceExhausted, "quota"), ErrorCodeQuotaExhausted},
+		{"deadline", status.Error(codes.DeadlineExceeded, "slow"), ErrorCodeDeadlineExceeded},
+		{"invalid argument", status.Error(codes.InvalidArgument, "bad"), ErrorCodeInvalidArgument},
+		{"unavailable", status.Error(codes.Unavailable, "down"), ErrorCodeBackendUnavailable},
+		{"plain error", errors.New("boom"), ErrorCodeInternal},
+		{"tenant quota", exhausted, ErrorCodeTenantQuota},
+		{"text too large", tooLarge, ErrorCodeTextTooLarge},
+		{"session not found", ErrSessionNotFound, ErrorCodeSessionNotFound},
+		{"slow client", ErrSlowClient, ErrorCodeSlowClient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCodeFromError(tt.err); got != tt.want {
+				t.Fatalf("ErrorCodeFromError(%v) = %s, want %s", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEveryErrorCodeHasAMessage(t *testing.T) {
+	for _, code := range []string{
+		ErrorCodeSafetyBlocked, ErrorCodeQuotaExhausted, ErrorCodeDeadlineExceeded, ErrorCodeInvalidArgument,
+		ErrorCodeInvalidOutput, ErrorCodeBackendUnavailable, ErrorCodeInternal, ErrorCodeTenantQuota,
+		ErrorCodeRateLimited, ErrorCodeTextTooLarge, ErrorCodeSessionNotFound, ErrorCodeSlowClient,
+	} {
+		if errorMessages[code] == "" {
+			t.Errorf("%s has no message", code)
+		}
+	}
+}
+
+func TestStatusFromErrorCode(t *testing.T) {
+	tests := map[string]int{
+		ErrorCodeTenantQuota:        http.StatusTooManyRequests,
+		ErrorCodeRateLimited:        http.StatusTooManyRequests,
+		ErrorCodeInvalidArgument:    http.StatusBadRequest,
+		ErrorCodeSafetyBlocked:      http.StatusUnprocessableEntity,
+		ErrorCodeDeadlineExceeded:   http.StatusGatewayTimeout,
+		ErrorCodeQuotaExhausted:     http.StatusServiceUnavailable,
+		ErrorCodeBackendUnavailable: http.StatusServiceUnavailable,
+		ErrorCodeInvalidOutput:      http.StatusBadGateway,
+		ErrorCodeInternal:           http.StatusBadGateway,
+	}
+	for code, want := range tests {
+		if got := statusFromErrorCode(code); got != want {
+			t.Errorf("statusFromErrorCode(%s) = %d, want %d", code, got, want)
+		}
+	}
+}
+
+func TestTranslationErrorReachesClient(t *testing.T) {
+	conn := &fakeConn{}
+	blocked := translator.ToStatus(translator.ErrSafetyBlocked, "gemini")
+	s := newTestSession(t, newTestManager(&fakeTranslatorClient{errs: []error{blocked, blocked}}, nil, nil, 0), conn)
+
+	if got := runTranslate(t, s, partial("kill"), final("kill the lights")); len(got) != 0 {
+		t.Fatalf("%d utterances translated, want none", len(got))
+	}
+
+	errs := conn.events("error")
+	if len(errs) != 1 {
+		t.Fatalf("%d error events, want one for the final transcript", len(errs))
+	}
+	var event ErrorEvent
+	if err := json.Unmarshal([]byte(errs[0]), &event); err != nil {
+		t.Fatalf("decode error event: %v", err)
+	}
+	if event.Code != ErrorCodeSafetyBlocked || event.Stage != StageTranslation || event.Text != "kill the lights" || !event.IsFinal {
+		t.Fatalf("error event = %+v", event)
+	}
+	if event.Message != errorMessages[ErrorCodeSafetyBlocked] {
+		t.Fatalf("message = %q", event.Message)
+	}
+}
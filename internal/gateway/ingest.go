@@ -0,0 +1,110 @@
+package gateway
+
+import (
+	"fmt"
+	"time"
+
+	"ai-translator/internal/audio"
+	"ai-translator/internal/metrics"
+)
+
+const (
+	FlowPause  = "flow.pause"
+	FlowResume = "flow.resume"
+)
+
+var ingestReadBytes = audio.SamplesForDuration(100) * audio.BytesPerSample
+
+type IngestConfig struct {
+	BufferBytes   int
+	HighWatermark int
+	LowWatermark  int
+}
+
+func NewIngestConfig(buffer time.Duration, highPercent, lowPercent int) (IngestConfig, error) {
+	switch {
+	case buffer <= 0:
+		return IngestConfig{}, fmt.Errorf("ingest buffer must be positive")
+	case highPercent <= 0 || highPercent > 100:
+		return IngestConfig{}, fmt.Errorf("high watermark must be between 1 and 100 percent, got %d", highPercent)
+	case lowPercent < 0 || lowPercent >= highPercent:
+		return IngestConfig{}, fmt.Errorf("low watermark must be below the high watermark, got %d", lowPercent)
+	}
+
+	size := audio.SamplesForDuration(int(buffer.Milliseconds())) * audio.BytesPerSample
+	return IngestConfig{
+		BufferBytes:   size,
+		HighWatermark: size * highPercent / 100,
+		LowWatermark:  size * lowPercent / 100,
+	}, nil
+}
+
+func DefaultIngestConfig() IngestConfig {
+	c, _ := NewIngestConfig(10*time.Second, 75, 25)
+	return c
+}
+
+type ingestStats struct {
+	receivedBytes int
+	droppedChunks int
+	droppedBytes  int
+	pauses        int
+}
+
+func (s *Session) bufferAudio(data []byte) {
+	s.audioBuffer.Write(data)
+	s.flowMu.Lock()
+	s.ingestStats.receivedBytes += len(data)
+	s.flowMu.Unlock()
+	s.updateFlow()
+}
+
+func (s *Session) dropAudio(size int) {
+	s.flowMu.Lock()
+	s.ingestStats.receivedBytes += size
+	s.ingestStats.droppedChunks++
+	s.ingestStats.droppedBytes += size
+	s.flowMu.Unlock()
+	metrics.AudioChunksDropped.Inc()
+	s.logger.Warn("ingest buffer full, dropping chunk", "buffered", audio.DurationOf(s.audioBuffer.Len()))
+}
+
+func (s *Session) updateFlow() {
+	s.flowMu.Lock()
+	defer s.flowMu.Unlock()
+
+	buffered := s.audioBuffer.Len()
+	var action string
+	switch {
+	case !s.flowPaused && buffered >= s.ingest.HighWatermark:
+		s.flowPaused = true
+		s.ingestStats.pauses++
+		action = FlowPause
+	case s.flowPaused && buffered <= s.ingest.LowWatermark:
+		s.flowPaused = false
+		action = FlowResume
+	default:
+		return
+	}
+
+	metrics.FlowControlEvents.WithLabelValues(action).Inc()
+	s.logger.Debug("ingest flow control", "action", action, "buffered", audio.DurationOf(buffered))
+	event := FlowEvent{Type: action, BufferedMs: audio.DurationOf(buffered).Milliseconds()}
+	if err := s.sendEvent(event); err != nil {
+		s.logger.Error("failed to send flow control event", "error", err)
+	}
+}
+
+func (s *Session) logSummary() {
+	s.flowMu.Lock()
+	stats := s.ingestStats
+	s.flowMu.Unlock()
+
+	s.logger.Info("session summary",
+		"duration", time.Since(s.startedAt).Round(time.Millisecond),
+		"audio_received", audio.DurationOf(stats.receivedBytes),
+		"audio_dropped", audio.DurationOf(stats.droppedBytes),
+		"chunks_dropped", stats.droppedChunks,
+		"flow_pauses", stats.pauses,
+	)
+}
@@ -0,0 +1,161 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"ai-translator/internal/audio"
+	"ai-translator/internal/metrics"
+	"ai-translator/internal/quota"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNewIngestConfig(t *testing.T) {
+	c, err := NewIngestConfig(10*time.Second, 75, 25)
+	if err != nil {
+		t.Fatalf("NewIngestConfig: %v", err)
+	}
+	if want := 10 * audio.SampleRate * audio.BytesPerSample; c.BufferBytes != want || c.HighWatermark != want*3/4 || c.LowWatermark != want/4 {
+		t.Fatalf("config = %+v", c)
+	}
+
+	invalid := []struct {
+		buffer    time.Duration
+		high, low int
+	}{
+		{0, 75, 25},
+		{time.Second, 0, 0},
+		{time.Second, 101, 25},
+		{time.Second, 50, 50},
+		{time.Second, 50, -1},
+	}
+	for _, tt := range invalid {
+		if _, err := NewIngestConfig(tt.buffer, tt.high, tt.low); err == nil {
+			t.Errorf("NewIngestConfig(%s, %d, %d) accepted", tt.buffer, tt.high, tt.low)
+		}
+	}
+}
+
+func newIngestSession(t *testing.T, ingest IngestConfig, quotas *quota.Manager) (*Session, *fakeConn) {
+	t.Helper()
+	if quotas == nil {
+		quotas = quota.NewManager(quota.Limits{})
+	}
+	m := NewSessionManager(nil, nil, nil, quotas, nil, nil, nil, 0, ingest, discardLogger())
+	conn := &fakeConn{}
+	s := newTestSession(t, m, conn)
+	s.pipelineOnce.Do(func() {})
+	return s, conn
+}
+
+func flowEvents(t *testing.T, conn *fakeConn) []FlowEvent {
+	t.Helper()
+	var out []FlowEvent
+	for _, text := range conn.texts {
+		var event FlowEvent
+		if err := json.Unmarshal([]byte(text), &event); err == nil && (event.Type == FlowPause || event.Type == FlowResume) {
+			out = append(out, event)
+		}
+	}
+	return out
+}
+
+func drain(s *Session, bytes int) {
+	for bytes > 0 {
+		chunk := s.audioBuffer.Read(min(bytes, ingestReadBytes))
+		if chunk == nil {
+			return
+		}
+		bytes -= len(chunk)
+		s.updateFlow()
+	}
+}
+
+func TestIngestPausesAndResumesAtWatermarks(t *testing.T) {
+	ingest, _ := NewIngestConfig(time.Second, 75, 25)
+	s, conn := newIngestSession(t, ingest, nil)
+	chunk := make([]byte, ingest.BufferBytes/20)
+
+	for s.audioBuffer.Len()+len(chunk) < ingest.HighWatermark {
+		s.ProcessAudio(context.Background(), chunk)
+	}
+	if events := flowEvents(t, conn); len(events) != 0 {
+		t.Fatalf("flow events below the high watermark: %+v", events)
+	}
+
+	s.ProcessAudio(context.Background(), chunk)
+	events := flowEvents(t, conn)
+	if len(events) != 1 || events[0].Type != FlowPause {
+		t.Fatalf("flow events at the high watermark = %+v, want one pause", events)
+	}
+	if want := audio.DurationOf(s.audioBuffer.Len()).Milliseconds(); events[0].BufferedMs != want {
+		t.Fatalf("pause buffered_ms = %d, want %d", events[0].BufferedMs, want)
+	}
+
+	s.ProcessAudio(context.Background(), chunk)
+	drain(s, s.audioBuffer.Len()-ingest.LowWatermark-1)
+	if events := flowEvents(t, conn); len(events) != 1 {
+		t.Fatalf("flow events between the watermarks = %+v, want only the pause", events)
+	}
+
+	drain(s, 1)
+	events = flowEvents(t, conn)
+	if len(events) != 2 || events[1].Type != FlowResume || s.audioBuffer.Len() != ingest.LowWatermark {
+		t.Fatalf("flow events at the low watermark = %+v with %d bytes buffered, want a resume", events, s.audioBuffer.Len())
+	}
+	if events[1].BufferedMs != audio.DurationOf(ingest.LowWatermark).Milliseconds() {
+		t.Fatalf("resume buffered_ms = %d", events[1].BufferedMs)
+	}
+
+	for s.audioBuffer.Len() < ingest.HighWatermark {
+		s.ProcessAudio(context.Background(), chunk)
+	}
+	if events := flowEvents(t, conn); len(events) != 3 || events[2].Type != FlowPause || s.ingestStats.pauses != 2 {
+		t.Fatalf("flow events after refilling = %+v, %d pauses counted, want a second pause", events, s.ingestStats.pauses)
+	}
+}
+
+func TestIngestDropCountersMatchRejectedChunks(t *testing.T) {
+	ingest, _ := NewIngestConfig(time.Second, 75, 25)
+	quotas := quota.NewManager(quota.Limits{})
+	s, conn := newIngestSession(t, ingest, quotas)
+	chunk := make([]byte, ingest.BufferBytes/8)
+	before := testutil.ToFloat64(metrics.AudioChunksDropped)
+
+	sent, dropped := 0, 0
+	for range 20 {
+		length := s.audioBuffer.Len()
+		if err := s.ProcessAudio(context.Background(), chunk); err != nil {
+			t.Fatalf("ProcessAudio: %v", err)
+		}
+		sent++
+		if s.audioBuffer.Len() == length {
+			dropped++
+			if len(flowEvents(t, conn)) == 0 {
+				t.Fatal("chunk dropped before the client was told to pause")
+			}
+		}
+	}
+
+	stats := s.ingestStats
+	if dropped != 12 || stats.droppedChunks != dropped || stats.droppedBytes != dropped*len(chunk) {
+		t.Fatalf("dropped %d chunks, counted %d chunks and %d bytes", dropped, stats.droppedChunks, stats.droppedBytes)
+	}
+	if stats.receivedBytes != sent*len(chunk) {
+		t.Fatalf("received %d bytes, want %d", stats.receivedBytes, sent*len(chunk))
+	}
+	if got := testutil.ToFloat64(metrics.AudioChunksDropped) - before; got != float64(dropped) {
+		t.Fatalf("dropped chunk metric grew by %v, want %d", got, dropped)
+	}
+	if pauses := len(flowEvents(t, conn)); pauses != stats.pauses || pauses != 1 {
+		t.Fatalf("%d flow events sent, %d pauses counted, want 1", pauses, stats.pauses)
+	}
+
+	charged := quotas.Status("acme").Usage.AudioMinutes
+	if want := audio.DurationOf(ingest.BufferBytes).Minutes(); charged < want-1e-9 || charged > want+1e-9 {
+		t.Fatalf("charged %v audio minutes, want only the buffered %v", charged, want)
+	}
+}
@@ -0,0 +1,201 @@
+package gateway
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"ai-translator/internal/auth"
+	"ai-translator/internal/batch"
+)
+
+const jobTransferTimeout = 30 * time.Second
+
+type JobHandler struct {
+	mux       *http.ServeMux
+	jobs      *batch.Manager
+	maxUpload int64
+	logger    *slog.Logger
+}
+
+func NewJobHandler(jobs *batch.Manager, maxUpload int64, logger *slog.Logger) *JobHandler {
+	h := &JobHandler{
+		mux:       http.NewServeMux(),
+		jobs:      jobs,
+		maxUpload: maxUpload,
+		logger:    logger,
+	}
+	h.mux.HandleFunc("POST /v1/jobs", h.submit)
+	h.mux.HandleFunc("GET /v1/jobs/{id}", h.get)
+	h.mux.HandleFunc("POST /v1/jobs/{id}/cancel", h.cancel)
+	h.mux.HandleFunc("GET /v1/jobs/{id}/audio", h.audio)
+	h.mux.HandleFunc("GET /v1/jobs/{id}/transcript", h.transcript)
+	return h
+}
+
+func (h *JobHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *JobHandler) submit(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		authError(w, auth.ErrNoCredentials)
+		return
+	}
+
+	query := r.URL.Query()
+	source, target := query.Get("source_language"), query.Get("target_language")
+	if source == "" || target == "" {
+		writeJSONError(w, http.StatusBadRequest, "source_language and target_language are required")
+		return
+	}
+
+	format := query.Get("format")
+	if format == "" {
+		format = batch.FormatFromContentType(r.Header.Get("Content-Type"))
+	}
+
+	rc := http.NewResponseController(w)
+	body := &uploadReader{r: http.MaxBytesReader(w, r.Body, h.maxUpload), rc: rc}
+	job, err := h.jobs.Submit(principal.TenantID, source, target, format, body)
+	rc.SetWriteDeadline(time.Now().Add(jobTransferTimeout))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		switch {
+		case errors.As(body.err, &tooLarge):
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "audio exceeds the upload limit")
+		case body.err != nil:
+			writeJSONError(w, http.StatusBadRequest, "failed to read audio")
+		case errors.Is(err, batch.ErrInvalidAudio), errors.Is(err, batch.ErrEmptyAudio):
+			writeJSONError(w, http.StatusUnsupportedMediaType, err.Error())
+		case errors.Is(err, batch.ErrQueueFull):
+			w.Header().Set("Retry-After", "30")
+			writeJSONError(w, http.StatusServiceUnavailable, err.Error())
+		default:
+			h.logger.Error("failed to submit batch job", "error", err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to submit job")
+		}
+		return
+	}
+
+	w.Header().Set("Location", "/v1/jobs/"+job.ID)
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+type uploadReader struct {
+	r   io.Reader
+	rc  *http.ResponseController
+	err error
+}
+
+func (u *uploadReader) Read(p []byte) (int, error) {
+	u.rc.SetReadDeadline(time.Now().Add(jobTransferTimeout))
+	n, err := u.r.Read(p)
+	if err != nil && err != io.EOF {
+		u.err = err
+	}
+	return n, err
+}
+
+func (h *JobHandler) lookup(w http.ResponseWriter, r *http.Request) (batch.Job, bool) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		authError(w, auth.ErrNoCredentials)
+		return batch.Job{}, false
+	}
+
+	job, err := h.jobs.Get(r.PathValue("id"))
+	if err != nil || job.TenantID != principal.TenantID {
+		writeJSONError(w, http.StatusNotFound, "job not found")
+		return batch.Job{}, false
+	}
+	return job, true
+}
+
+func (h *JobHandler) get(w http.ResponseWriter, r *http.Request) {
+	if job, ok := h.lookup(w, r); ok {
+		writeJSON(w, http.StatusOK, job)
+	}
+}
+
+func (h *JobHandler) cancel(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := h.jobs.Cancel(job.ID)
+	if errors.Is(err, batch.ErrFinished) {
+		writeJSONError(w, http.StatusConflict, "job already "+job.Status)
+		return
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (h *JobHandler) audio(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	path, err := h.jobs.AudioPath(job.ID)
+	if err != nil {
+		h.outputError(w, job, err)
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		h.outputError(w, job, err)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		h.outputError(w, job, err)
+		return
+	}
+	w.Header().Set("Content-Type", "audio/wav")
+	http.ServeContent(downloadWriter{ResponseWriter: w, rc: http.NewResponseController(w)}, r, job.ID+".wav", info.ModTime(), f)
+}
+
+type downloadWriter struct {
+	http.ResponseWriter
+	rc *http.ResponseController
+}
+
+func (d downloadWriter) Write(p []byte) (int, error) {
+	d.rc.SetWriteDeadline(time.Now().Add(jobTransferTimeout))
+	return d.ResponseWriter.Write(p)
+}
+
+func (h *JobHandler) transcript(w http.ResponseWriter, r *http.Request) {
+	job, ok := h.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	t, err := h.jobs.Transcript(job.ID)
+	if err != nil {
+		h.outputError(w, job, err)
+		return
+	}
+	writeTranscript(w, r, t, h.logger)
+}
+
+func (h *JobHandler) outputError(w http.ResponseWriter, job batch.Job, err error) {
+	if errors.Is(err, batch.ErrNotReady) {
+		writeJSONError(w, http.StatusConflict, "job is "+job.Status)
+		return
+	}
+	h.logger.Error("failed to read batch job output", "error", err, "job_id", job.ID)
+	writeJSONError(w, http.StatusInternalServerError, "failed to read job output")
+}
@@ -0,0 +1,122 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ai-translator/internal/auth"
+	"ai-translator/internal/batch"
+)
+
+func newTestJobHandler(t *testing.T, maxUpload int64) *JobHandler {
+	t.Helper()
+	jobs, err := batch.NewManager(nil, t.TempDir(), 1, 4, time.Hour, discardLogger())
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return NewJobHandler(jobs, maxUpload, discardLogger())
+}
+
+func jobRequest(method, target string, body []byte, principal *auth.Principal) *http.Request {
+	r := httptest.NewRequest(method, target, bytes.NewReader(body))
+	return r.WithContext(auth.NewContext(context.Background(), principal))
+}
+
+func TestSubmitJobStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		body   []byte
+		want   int
+	}{
+		{"accepted", "/v1/jobs?source_language=en-US&target_language=es-ES&format=pcm", make([]byte, 3200), http.StatusAccepted},
+		{"missing languages", "/v1/jobs?format=pcm", make([]byte, 3200), http.StatusBadRequest},
+		{"too large", "/v1/jobs?source_language=en-US&target_language=es-ES&format=pcm", make([]byte, 64*1024), http.StatusRequestEntityTooLarge},
+		{"not a wav", "/v1/jobs?source_language=en-US&target_language=es-ES&format=wav", []byte("definitely not a wav file"), http.StatusUnsupportedMediaType},
+		{"unknown format", "/v1/jobs?source_language=en-US&target_language=es-ES", make([]byte, 3200), http.StatusUnsupportedMediaType},
+		{"empty", "/v1/jobs?source_language=en-US&target_language=es-ES&format=pcm", nil, http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestJobHandler(t, 32*1024)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, jobRequest(http.MethodPost, tt.target, tt.body, testPrincipal))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestJobsAreScopedToTenant(t *testing.T) {
+	h := newTestJobHandler(t, 32*1024)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, jobRequest(http.MethodPost, "/v1/jobs?source_language=en-US&target_language=es-ES&format=pcm", make([]byte, 3200), testPrincipal))
+	var job batch.Job
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatalf("decode job: %v", err)
+	}
+	if w.Header().Get("Location") != "/v1/jobs/"+job.ID || job.Progress.TotalAudioMs != 100 {
+		t.Fatalf("job = %+v, Location %q", job, w.Header().Get("Location"))
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, jobRequest(http.MethodGet, "/v1/jobs/"+job.ID, nil, testPrincipal))
+	if w.Code != http.StatusOK {
+		t.Fatalf("owner status = %d, want 200", w.Code)
+	}
+
+	other := &auth.Principal{Subject: "bob", TenantID: "globex", Method: auth.MethodAPIKey}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, jobRequest(http.MethodGet, "/v1/jobs/"+job.ID, nil, other))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("other tenant status = %d, want 404", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, jobRequest(http.MethodGet, "/v1/jobs/"+job.ID+"/audio", nil, testPrincipal))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("audio before completion status = %d, want 409", w.Code)
+	}
+}
+
+func TestSubmitJobOutlivesServerReadTimeout(t *testing.T) {
+	h := newTestJobHandler(t, 1<<20)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), testPrincipal)))
+	}))
+	srv.Config.ReadTimeout = 100 * time.Millisecond
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	body, upload := io.Pipe()
+	go func() {
+		for range 10 {
+			time.Sleep(30 * time.Millisecond)
+			if _, err := upload.Write(make([]byte, 32*1024)); err != nil {
+				return
+			}
+		}
+		upload.Close()
+	}()
+	resp, err := http.Post(srv.URL+"/v1/jobs?source_language=en-US&target_language=es-ES&format=pcm", "application/octet-stream", body)
+	if err != nil {
+		t.Fatalf("POST /v1/jobs: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var job batch.Job
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		t.Fatalf("decode job: %v", err)
+	}
+	if resp.StatusCode != http.StatusAccepted || job.Progress.TotalAudioMs != 10*1024 {
+		t.Fatalf("status = %d, job = %+v", resp.StatusCode, job)
+	}
+}
@@ -0,0 +1,92 @@
+package gateway
+
+import (
+	"time"
+
+	"ai-translator/internal/metrics"
+)
+
+const (
+	MilestoneFirstPartial    = "first_partial"
+	MilestoneFinalTranscript = "final_transcript"
+	MilestoneTranslationDone = "translation_done"
+	MilestoneFirstAudio      = "first_audio"
+	MilestoneLastAudio       = "last_audio"
+)
+
+type Latency struct {
+	FirstPartialMs    int64 `json:"first_partial_ms,omitempty"`
+	FinalTranscriptMs int64 `json:"final_transcript_ms,omitempty"`
+	TranslationDoneMs int64 `json:"translation_done_ms,omitempty"`
+	FirstAudioMs      int64 `json:"first_audio_ms,omitempty"`
+	LastAudioMs       int64 `json:"last_audio_ms,omitempty"`
+}
+
+type utteranceTimings struct {
+	AudioReceived   time.Time
+	FirstPartial    time.Time
+	FinalTranscript time.Time
+	TranslationDone time.Time
+	FirstAudio      time.Time
+	LastAudio       time.Time
+}
+
+func (t *utteranceTimings) markAudio(now time.Time) {
+	if t == nil {
+		return
+	}
+	if t.FirstAudio.IsZero() {
+		t.FirstAudio = now
+	}
+	t.LastAudio = now
+}
+
+func (t *utteranceTimings) milestones() map[string]time.Time {
+	return map[string]time.Time{
+		MilestoneFirstPartial:    t.FirstPartial,
+		MilestoneFinalTranscript: t.FinalTranscript,
+		MilestoneTranslationDone: t.TranslationDone,
+		MilestoneFirstAudio:      t.FirstAudio,
+		MilestoneLastAudio:       t.LastAudio,
+	}
+}
+
+func (t *utteranceTimings) Latency() *Latency {
+	if t == nil || t.AudioReceived.IsZero() {
+		return nil
+	}
+	return &Latency{
+		FirstPartialMs:    t.since(t.FirstPartial).Milliseconds(),
+		FinalTranscriptMs: t.since(t.FinalTranscript).Milliseconds(),
+		TranslationDoneMs: t.since(t.TranslationDone).Milliseconds(),
+		FirstAudioMs:      t.since(t.FirstAudio).Milliseconds(),
+		LastAudioMs:       t.since(t.LastAudio).Milliseconds(),
+	}
+}
+
+func (t *utteranceTimings) observe() {
+	if t == nil || t.AudioReceived.IsZero() {
+		return
+	}
+	for milestone, at := range t.milestones() {
+		if !at.IsZero() {
+			metrics.UtteranceLatency.WithLabelValues(milestone).Observe(t.since(at).Seconds())
+		}
+	}
+}
+
+func (t *utteranceTimings) since(at time.Time) time.Duration {
+	if at.IsZero() || at.Before(t.AudioReceived) {
+		return 0
+	}
+	return at.Sub(t.AudioReceived)
+}
+
+func firstNonZero(times ...time.Time) time.Time {
+	for _, t := range times {
+		if !t.IsZero() {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,154 @@
+package gateway
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"ai-translator/internal/metrics"
+	"ai-translator/internal/quota"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func latencySamples(t *testing.T, milestone string) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := metrics.UtteranceLatency.WithLabelValues(milestone).(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatalf("read histogram: %v", err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestLatencyBreakdown(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	timings := &utteranceTimings{
+		AudioReceived:   start,
+		FirstPartial:    start.Add(300 * time.Millisecond),
+		FinalTranscript: start.Add(1200 * time.Millisecond),
+		TranslationDone: start.Add(1500 * time.Millisecond),
+	}
+	timings.markAudio(start.Add(1800 * time.Millisecond))
+	timings.markAudio(start.Add(2600 * time.Millisecond))
+
+	want := Latency{FirstPartialMs: 300, FinalTranscriptMs: 1200, TranslationDoneMs: 1500, FirstAudioMs: 1800, LastAudioMs: 2600}
+	if got := timings.Latency(); got == nil || *got != want {
+		t.Fatalf("Latency = %+v, want %+v", got, want)
+	}
+}
+
+func TestLatencyOmitsMissingMilestones(t *testing.T) {
+	start := time.Now()
+	timings := &utteranceTimings{
+		AudioReceived:   start,
+		FirstPartial:    start.Add(-time.Second),
+		FinalTranscript: start.Add(time.Second),
+	}
+
+	data, err := json.Marshal(timings.Latency())
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if got := string(data); got != `{"final_transcript_ms":1000}` {
+		t.Fatalf("latency = %s, want only the final transcript", got)
+	}
+}
+
+func TestLatencyWithoutAudio(t *testing.T) {
+	var timings *utteranceTimings
+	if timings.Latency() != nil {
+		t.Fatal("nil timings produced a latency")
+	}
+	timings.markAudio(time.Now())
+	timings.observe()
+
+	if (&utteranceTimings{FinalTranscript: time.Now()}).Latency() != nil {
+		t.Fatal("timings without received audio produced a latency")
+	}
+}
+
+func TestObserveRecordsReachedMilestones(t *testing.T) {
+	before := map[string]uint64{}
+	for _, milestone := range []string{MilestoneFirstPartial, MilestoneFinalTranscript, MilestoneTranslationDone, MilestoneFirstAudio, MilestoneLastAudio} {
+		before[milestone] = latencySamples(t, milestone)
+	}
+
+	start := time.Now()
+	(&utteranceTimings{
+		AudioReceived:   start,
+		FinalTranscript: start.Add(time.Second),
+		TranslationDone: start.Add(2 * time.Second),
+	}).observe()
+
+	for milestone, n := range before {
+		want := uint64(0)
+		if milestone == MilestoneFinalTranscript || milestone == MilestoneTranslationDone {
+			want = 1
+		}
+		if got := latencySamples(t, milestone) - n; got != want {
+			t.Errorf("%s grew by %d, want %d", milestone, got, want)
+		}
+	}
+}
+
+func TestFirstNonZero(t *testing.T) {
+	now := time.Now()
+	if got := firstNonZero(time.Time{}, now, now.Add(time.Second)); !got.Equal(now) {
+		t.Fatalf("firstNonZero = %v, want %v", got, now)
+	}
+	if got := firstNonZero(time.Time{}); !got.IsZero() {
+		t.Fatalf("firstNonZero of zeros = %v", got)
+	}
+}
+
+func TestAudioReceivedStartsEachUtterance(t *testing.T) {
+	s := newTestSession(t, newTestManager(nil, nil, nil, 0), &fakeConn{})
+	first := time.Now()
+	s.markAudioReceived(first)
+	s.markAudioReceived(first.Add(time.Second))
+
+	if got := s.takeAudioReceived(); !got.Equal(first) {
+		t.Fatalf("audio received at %v, want the first chunk %v", got, first)
+	}
+	if got := s.takeAudioReceived(); !got.IsZero() {
+		t.Fatalf("audio received at %v after it was taken, want zero", got)
+	}
+}
+
+func TestUtteranceEventCarriesLatency(t *testing.T) {
+	conn := &fakeConn{}
+	m := NewSessionManager(nil, &fakeTranslatorClient{}, &fakeTTSClient{delays: []time.Duration{0, 5 * time.Millisecond}}, quota.NewManager(quota.Limits{}), nil, nil, nil, 0, DefaultIngestConfig(), discardLogger())
+	s := newTestSession(t, m, conn)
+	s.markAudioReceived(time.Now())
+
+	utterances := runTranslate(t, s, partial("hello"), final("hello world"))
+	in := make(chan *utterance, len(utterances))
+	for _, u := range utterances {
+		in <- u
+	}
+	close(in)
+	s.synthesizeAndStream(s.ctx, in, make(chan []byte, 10), "es-ES")
+
+	events := conn.events("utterance")
+	if len(events) != 1 {
+		t.Fatalf("%d utterance events, want one for the final", len(events))
+	}
+	var event UtteranceEvent
+	if err := json.Unmarshal([]byte(events[0]), &event); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	l := event.Latency
+	if l == nil {
+		t.Fatal("utterance event has no latency")
+	}
+	steps := []int64{l.FirstPartialMs, l.FinalTranscriptMs, l.TranslationDoneMs, l.FirstAudioMs, l.LastAudioMs}
+	for i := 1; i < len(steps); i++ {
+		if steps[i] < steps[i-1] {
+			t.Fatalf("latency milestones out of order: %+v", l)
+		}
+	}
+	if l.LastAudioMs-l.FirstAudioMs < 5 {
+		t.Fatalf("latency = %+v, want the last audio at least 5ms after the first", l)
+	}
+}
@@ -0,0 +1,52 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"ai-translator/internal/metrics"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestActiveSessionsGauge(t *testing.T) {
+	m := newTestManager(nil, nil, nil, 0)
+	before := testutil.ToFloat64(metrics.ActiveSessions)
+
+	s := newTestSession(t, m, &fakeConn{})
+	if got := testutil.ToFloat64(metrics.ActiveSessions) - before; got != 1 {
+		t.Fatalf("active sessions grew by %v, want 1", got)
+	}
+
+	m.Remove(s.ID)
+	m.Remove(s.ID)
+	if got := testutil.ToFloat64(metrics.ActiveSessions) - before; got != 0 {
+		t.Fatalf("active sessions after removal differ by %v, want 0", got)
+	}
+}
+
+func TestAudioBytesCounted(t *testing.T) {
+	in, out := metrics.AudioBytes.WithLabelValues("in"), metrics.AudioBytes.WithLabelValues("out")
+	inBefore, outBefore := testutil.ToFloat64(in), testutil.ToFloat64(out)
+
+	s, conn := newIngestSession(t, DefaultIngestConfig(), nil)
+	if err := s.ProcessAudio(context.Background(), make([]byte, 640)); err != nil {
+		t.Fatalf("ProcessAudio: %v", err)
+	}
+	if got := testutil.ToFloat64(in) - inBefore; got != 640 {
+		t.Fatalf("inbound bytes grew by %v, want 640", got)
+	}
+
+	audio := make(chan []byte, 2)
+	audio <- make([]byte, 100)
+	audio <- make([]byte, 60)
+	close(audio)
+	s.streamAudioToClient(context.Background(), audio)
+
+	if len(conn.binary) != 2 {
+		t.Fatalf("client received %d audio messages, want 2", len(conn.binary))
+	}
+	if got := testutil.ToFloat64(out) - outBefore; got != 160 {
+		t.Fatalf("outbound bytes grew by %v, want 160", got)
+	}
+}
@@ -0,0 +1,121 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/quota"
+	"ai-translator/internal/recording"
+	"ai-translator/internal/transcript"
+)
+
+func newRecordingSession(t *testing.T, transcripts transcript.Store) (*SessionManager, *Session, string) {
+	t.Helper()
+	dir := t.TempDir()
+	storage, err := recording.NewLocalStorage(dir)
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	recordings := recording.NewManager(storage, time.Hour, discardLogger())
+	m := NewSessionManager(nil, nil, nil, quota.NewManager(quota.Limits{}), nil, transcripts, recordings, 0, DefaultIngestConfig(), discardLogger())
+	s := newTestSession(t, m, &fakeConn{})
+	s.pipelineOnce.Do(func() {})
+	s.SetLanguages("en-US", "es-ES")
+	return m, s, dir
+}
+
+func TestSessionRecordingIsOptIn(t *testing.T) {
+	_, s, dir := newRecordingSession(t, nil)
+
+	if s.SetRecording(false, recording.ModeTracks) || s.readyEvent("ready").Recording {
+		t.Fatal("recording started without the record option")
+	}
+	if err := s.ProcessAudio(context.Background(), make([]byte, 320)); err != nil {
+		t.Fatalf("ProcessAudio: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("recording directory has %d entries, want none", len(entries))
+	}
+}
+
+func TestSessionRecordingRequiresStorage(t *testing.T) {
+	s := newTestSession(t, newTestManager(nil, nil, nil, 0), &fakeConn{})
+	if s.SetRecording(true, recording.ModeTracks) || s.readyEvent("ready").Recording {
+		t.Fatal("recording started without recording storage")
+	}
+}
+
+func TestSessionRecordsAudioAndManifest(t *testing.T) {
+	m, s, dir := newRecordingSession(t, transcript.NewMemoryStore())
+	s.SetStyle(&pb.TranslationStyle{Formality: pb.Formality_FORMALITY_FORMAL})
+
+	if !s.SetRecording(true, recording.ModeTracks) {
+		t.Fatal("SetRecording returned false")
+	}
+	if !s.readyEvent("ready").Recording {
+		t.Fatal("ready event does not report the recording")
+	}
+	if err := s.ProcessAudio(context.Background(), make([]byte, 3200)); err != nil {
+		t.Fatalf("ProcessAudio: %v", err)
+	}
+	m.Remove(s.ID)
+
+	data, err := os.ReadFile(filepath.Join(dir, s.ID, recording.ManifestFile))
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	var manifest recording.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+	if manifest.SessionID != s.ID || manifest.TenantID != testPrincipal.TenantID || manifest.Mode != recording.ModeTracks {
+		t.Fatalf("manifest = %+v", manifest)
+	}
+	if manifest.Transcript != "/v1/sessions/"+s.ID+"/transcript" {
+		t.Fatalf("manifest transcript = %q", manifest.Transcript)
+	}
+	if manifest.ExpiresAt == nil {
+		t.Fatal("manifest has no expiry with retention configured")
+	}
+	if len(manifest.Files) != 2 || manifest.Files[0].Bytes < 3200 {
+		t.Fatalf("manifest files = %+v, want the inbound audio recorded", manifest.Files)
+	}
+
+	f, err := os.Open(filepath.Join(dir, s.ID, recording.EventsFile))
+	if err != nil {
+		t.Fatalf("open events: %v", err)
+	}
+	defer f.Close()
+	events, err := recording.ReadEvents(f)
+	if err != nil {
+		t.Fatalf("ReadEvents: %v", err)
+	}
+	if len(events) == 0 || events[0].Kind != recording.EventConfig {
+		t.Fatalf("events = %+v, want the session config first", events)
+	}
+	if c := events[0].Config; c.SourceLanguage != "en-US" || c.TargetLanguage != "es-ES" || c.Formality != pb.Formality_FORMALITY_FORMAL.String() {
+		t.Fatalf("config event = %+v", c)
+	}
+}
+
+func TestSessionRecordingStopsForGood(t *testing.T) {
+	_, s, dir := newRecordingSession(t, nil)
+
+	if !s.SetRecording(true, recording.ModeStereo) {
+		t.Fatal("SetRecording returned false")
+	}
+	if s.SetRecording(false, recording.ModeStereo) || s.readyEvent("ready").Recording {
+		t.Fatal("recording still active after the client turned it off")
+	}
+	if _, err := os.Stat(filepath.Join(dir, s.ID, recording.ManifestFile)); err != nil {
+		t.Fatalf("stopping did not finish the recording: %v", err)
+	}
+	if s.SetRecording(true, recording.ModeStereo) {
+		t.Fatal("recording restarted after the client stopped it")
+	}
+}
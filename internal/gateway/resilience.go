@@ -0,0 +1,235 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"time"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/util"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type BackendConfig struct {
+	Timeout time.Duration
+	Retry   util.RetryConfig
+	Breaker util.BreakerConfig
+}
+
+type Backend struct {
+	name    string
+	service string
+	health  healthpb.HealthClient
+	cfg     BackendConfig
+	breaker *util.CircuitBreaker
+}
+
+func NewBackend(name, service string, conn grpc.ClientConnInterface, cfg BackendConfig) *Backend {
+	return &Backend{
+		name:    name,
+		service: service,
+		health:  healthpb.NewHealthClient(conn),
+		cfg:     cfg,
+		breaker: util.NewCircuitBreaker(cfg.Breaker),
+	}
+}
+
+type DependencyStatus struct {
+	Status  string `json:"status"`
+	Breaker string `json:"breaker"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (d DependencyStatus) Ready() bool {
+	return d.Status == healthpb.HealthCheckResponse_SERVING.String() && d.Breaker != util.BreakerOpen.String()
+}
+
+func (b *Backend) Check(ctx context.Context) DependencyStatus {
+	result := DependencyStatus{
+		Status:  healthpb.HealthCheckResponse_UNKNOWN.String(),
+		Breaker: b.breaker.State().String(),
+	}
+
+	resp, err := b.health.Check(ctx, &healthpb.HealthCheckRequest{Service: b.service})
+	if err != nil {
+		result.Error = status.Convert(err).Message()
+		return result
+	}
+
+	result.Status = resp.Status.String()
+	return result
+}
+
+func (b *Backend) Name() string {
+	return b.name
+}
+
+func (b *Backend) State() util.BreakerState {
+	return b.breaker.State()
+}
+
+func (b *Backend) invoke(ctx context.Context, fn func() error) error {
+	retry := b.cfg.Retry
+	retry.ShouldRetry = func(err error) bool {
+		return ctx.Err() == nil && b.breaker.State() != util.BreakerOpen && isRetryableCode(status.Code(err))
+	}
+
+	return util.Retry(ctx, retry, func() error {
+		if !b.breaker.Allow() {
+			return status.Errorf(codes.Unavailable, "%s backend circuit open", b.name)
+		}
+		err := fn()
+		b.record(ctx, err)
+		return err
+	})
+}
+
+func (b *Backend) record(ctx context.Context, err error) {
+	switch {
+	case err == nil:
+		b.breaker.Success()
+	case ctx.Err() != nil:
+		b.breaker.Release()
+	case isBackendFailure(status.Code(err)):
+		b.breaker.Failure()
+	default:
+		b.breaker.Success()
+	}
+}
+
+func (b *Backend) withDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if b.cfg.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, b.cfg.Timeout)
+}
+
+func (b *Backend) withFirstResponseDeadline(ctx context.Context) (context.Context, context.CancelFunc, func() bool) {
+	callCtx, cancel := context.WithCancel(ctx)
+	if b.cfg.Timeout <= 0 {
+		return callCtx, cancel, func() bool { return true }
+	}
+	timer := time.AfterFunc(b.cfg.Timeout, cancel)
+	return callCtx, cancel, timer.Stop
+}
+
+func isRetryableCode(code codes.Code) bool {
+	switch code {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+func isBackendFailure(code codes.Code) bool {
+	switch code {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	}
+	return false
+}
+
+type resilientASRClient struct {
+	pb.ASRServiceClient
+	backend *Backend
+}
+
+func NewResilientASRClient(client pb.ASRServiceClient, backend *Backend) pb.ASRServiceClient {
+	return &resilientASRClient{ASRServiceClient: client, backend: backend}
+}
+
+func (c *resilientASRClient) StreamingRecognize(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[pb.ASRRequest, pb.ASRResponse], error) {
+	var stream grpc.BidiStreamingClient[pb.ASRRequest, pb.ASRResponse]
+	err := c.backend.invoke(ctx, func() error {
+		var err error
+		stream, err = c.ASRServiceClient.StreamingRecognize(ctx, opts...)
+		return err
+	})
+	return stream, err
+}
+
+type resilientTranslatorClient struct {
+	pb.TranslatorServiceClient
+	backend *Backend
+}
+
+func NewResilientTranslatorClient(client pb.TranslatorServiceClient, backend *Backend) pb.TranslatorServiceClient {
+	return &resilientTranslatorClient{TranslatorServiceClient: client, backend: backend}
+}
+
+func (c *resilientTranslatorClient) Translate(ctx context.Context, in *pb.TranslateRequest, opts ...grpc.CallOption) (*pb.TranslateResponse, error) {
+	var resp *pb.TranslateResponse
+	err := c.backend.invoke(ctx, func() error {
+		callCtx, cancel := c.backend.withDeadline(ctx)
+		defer cancel()
+
+		var err error
+		resp, err = c.TranslatorServiceClient.Translate(callCtx, in, opts...)
+		return err
+	})
+	return resp, err
+}
+
+type resilientTTSClient struct {
+	pb.TTSServiceClient
+	backend *Backend
+}
+
+func NewResilientTTSClient(client pb.TTSServiceClient, backend *Backend) pb.TTSServiceClient {
+	return &resilientTTSClient{TTSServiceClient: client, backend: backend}
+}
+
+func (c *resilientTTSClient) Synthesize(ctx context.Context, in *pb.TTSRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.TTSResponse], error) {
+	var result *prefetchedStream
+	err := c.backend.invoke(ctx, func() error {
+		callCtx, cancel, stop := c.backend.withFirstResponseDeadline(ctx)
+
+		stream, err := c.TTSServiceClient.Synthesize(callCtx, in, opts...)
+		var first *pb.TTSResponse
+		if err == nil {
+			first, err = stream.Recv()
+		}
+		if !stop() {
+			cancel()
+			return status.Errorf(codes.DeadlineExceeded, "%s backend sent no audio within %s", c.backend.name, c.backend.cfg.Timeout)
+		}
+		if err != nil && err != io.EOF {
+			cancel()
+			return err
+		}
+
+		result = &prefetchedStream{ServerStreamingClient: stream, first: first, cancel: cancel}
+		if first == nil || first.IsFinal {
+			cancel()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type prefetchedStream struct {
+	grpc.ServerStreamingClient[pb.TTSResponse]
+	first  *pb.TTSResponse
+	cancel context.CancelFunc
+}
+
+func (s *prefetchedStream) Recv() (*pb.TTSResponse, error) {
+	if s.first != nil {
+		msg := s.first
+		s.first = nil
+		return msg, nil
+	}
+
+	msg, err := s.ServerStreamingClient.Recv()
+	if err != nil || msg.IsFinal {
+		s.cancel()
+	}
+	return msg, err
+}
@@ -0,0 +1,201 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/util"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeTTSStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	delays []time.Duration
+	sent   int
+}
+
+func (s *fakeTTSStream) Recv() (*pb.TTSResponse, error) {
+	if s.sent == len(s.delays) {
+		return nil, io.EOF
+	}
+	select {
+	case <-time.After(s.delays[s.sent]):
+	case <-s.ctx.Done():
+		return nil, status.FromContextError(s.ctx.Err()).Err()
+	}
+	s.sent++
+	return &pb.TTSResponse{Audio: &pb.AudioChunk{Data: []byte{1, 2}}, IsFinal: s.sent == len(s.delays)}, nil
+}
+
+type fakeTTSClient struct {
+	pb.TTSServiceClient
+	delays []time.Duration
+	calls  atomic.Int32
+}
+
+func (c *fakeTTSClient) Synthesize(ctx context.Context, in *pb.TTSRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.TTSResponse], error) {
+	c.calls.Add(1)
+	return &fakeTTSStream{ctx: ctx, delays: c.delays}, nil
+}
+
+type fakeTranslatorClient struct {
+	pb.TranslatorServiceClient
+	errs  []error
+	delay time.Duration
+	calls atomic.Int32
+}
+
+func (c *fakeTranslatorClient) Translate(ctx context.Context, in *pb.TranslateRequest, opts ...grpc.CallOption) (*pb.TranslateResponse, error) {
+	n := int(c.calls.Add(1)) - 1
+	select {
+	case <-time.After(c.delay):
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	if n < len(c.errs) && c.errs[n] != nil {
+		return nil, c.errs[n]
+	}
+	return &pb.TranslateResponse{TranslatedText: "hola", Engine: "fake", TargetLanguage: in.TargetLanguage, IsFinal: in.IsFinal}, nil
+}
+
+func testBackend(timeout time.Duration, attempts, failures int) *Backend {
+	return NewBackend("test", "test.Service", nil, BackendConfig{
+		Timeout: timeout,
+		Retry:   util.RetryConfig{MaxAttempts: attempts, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond},
+		Breaker: util.BreakerConfig{FailureThreshold: failures, Cooldown: time.Hour},
+	})
+}
+
+func drainTTS(t *testing.T, stream grpc.ServerStreamingClient[pb.TTSResponse]) (int, error) {
+	t.Helper()
+	chunks := 0
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return chunks, nil
+		}
+		if err != nil {
+			return chunks, err
+		}
+		chunks++
+		if msg.IsFinal {
+			return chunks, nil
+		}
+	}
+}
+
+func TestSynthesizeDeadlineOnlyBoundsFirstChunk(t *testing.T) {
+	backend := testBackend(50*time.Millisecond, 1, 1)
+	client := NewResilientTTSClient(&fakeTTSClient{delays: []time.Duration{0, 40 * time.Millisecond, 40 * time.Millisecond, 40 * time.Millisecond}}, backend)
+
+	stream, err := client.Synthesize(context.Background(), &pb.TTSRequest{Text: "hello"})
+	if err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	chunks, err := drainTTS(t, stream)
+	if err != nil {
+		t.Fatalf("stream cut off after %d chunks: %v", chunks, err)
+	}
+	if chunks != 4 {
+		t.Errorf("got %d chunks, want 4", chunks)
+	}
+	if backend.State() != util.BreakerClosed {
+		t.Errorf("breaker %s, want closed", backend.State())
+	}
+}
+
+func TestSynthesizeSlowFirstChunkTimesOut(t *testing.T) {
+	backend := testBackend(20*time.Millisecond, 2, 2)
+	tts := &fakeTTSClient{delays: []time.Duration{time.Second}}
+	client := NewResilientTTSClient(tts, backend)
+
+	start := time.Now()
+	_, err := client.Synthesize(context.Background(), &pb.TTSRequest{Text: "hello"})
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("error %v, want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("took %s, deadline not applied", elapsed)
+	}
+	if tts.calls.Load() != 2 {
+		t.Errorf("%d attempts, want 2", tts.calls.Load())
+	}
+	if backend.State() != util.BreakerOpen {
+		t.Errorf("breaker %s, want open", backend.State())
+	}
+}
+
+func TestSynthesizeCallerCancelStopsStream(t *testing.T) {
+	backend := testBackend(time.Second, 1, 1)
+	client := NewResilientTTSClient(&fakeTTSClient{delays: []time.Duration{0, time.Second}}, backend)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Synthesize(ctx, &pb.TTSRequest{Text: "hello"})
+	if err != nil {
+		t.Fatalf("Synthesize: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("first Recv: %v", err)
+	}
+	cancel()
+	if _, err := stream.Recv(); status.Code(err) != codes.Canceled {
+		t.Fatalf("error %v, want Canceled", err)
+	}
+	if backend.State() != util.BreakerClosed {
+		t.Errorf("breaker %s, want closed", backend.State())
+	}
+}
+
+func TestTranslateRetriesUnavailable(t *testing.T) {
+	backend := testBackend(time.Second, 3, 5)
+	translator := &fakeTranslatorClient{errs: []error{status.Error(codes.Unavailable, "down"), status.Error(codes.Unavailable, "down")}}
+	client := NewResilientTranslatorClient(translator, backend)
+
+	resp, err := client.Translate(context.Background(), &pb.TranslateRequest{Text: "hello"})
+	if err != nil || resp.TranslatedText != "hola" {
+		t.Fatalf("Translate = %v, %v", resp, err)
+	}
+	if translator.calls.Load() != 3 {
+		t.Errorf("%d attempts, want 3", translator.calls.Load())
+	}
+}
+
+func TestTranslateDoesNotRetryInvalidArgument(t *testing.T) {
+	backend := testBackend(time.Second, 3, 1)
+	translator := &fakeTranslatorClient{errs: []error{status.Error(codes.InvalidArgument, "bad")}}
+	client := NewResilientTranslatorClient(translator, backend)
+
+	if _, err := client.Translate(context.Background(), &pb.TranslateRequest{Text: "hello"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("error %v, want InvalidArgument", err)
+	}
+	if translator.calls.Load() != 1 {
+		t.Errorf("%d attempts, want 1", translator.calls.Load())
+	}
+	if backend.State() != util.BreakerClosed {
+		t.Errorf("breaker %s, want closed", backend.State())
+	}
+}
+
+func TestTranslateOpenBreakerFailsFast(t *testing.T) {
+	backend := testBackend(10*time.Millisecond, 1, 1)
+	translator := &fakeTranslatorClient{delay: time.Second}
+	client := NewResilientTranslatorClient(translator, backend)
+
+	if _, err := client.Translate(context.Background(), &pb.TranslateRequest{Text: "hello"}); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("error %v, want DeadlineExceeded", err)
+	}
+	if _, err := client.Translate(context.Background(), &pb.TranslateRequest{Text: "hello"}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("error %v, want Unavailable from the open breaker", err)
+	}
+	if translator.calls.Load() != 1 {
+		t.Errorf("%d calls, want 1", translator.calls.Load())
+	}
+}
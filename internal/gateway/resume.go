@@ -0,0 +1,193 @@
+package gateway
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-translator/internal/auth"
+	"ai-translator/internal/metrics"
+)
+
+const resumeBufferBytes = 1 << 20
+
+var ErrSessionNotFound = errors.New("session not found or expired")
+
+type outboundMessage struct {
+	seq  uint64
+	text bool
+	data []byte
+}
+
+type outbox struct {
+	mu       sync.Mutex
+	conn     Conn
+	broken   bool
+	seq      uint64
+	messages []outboundMessage
+	size     int
+}
+
+func newOutbox(conn Conn) *outbox {
+	return &outbox{conn: conn}
+}
+
+func (o *outbox) WriteText(text string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.seq++
+	return o.write(outboundMessage{seq: o.seq, text: true, data: []byte(withSeq(text, o.seq))})
+}
+
+func (o *outbox) WriteBinary(data []byte) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.seq++
+	return o.write(outboundMessage{seq: o.seq, data: data})
+}
+
+func (o *outbox) write(m outboundMessage) error {
+	o.messages = append(o.messages, m)
+	o.size += len(m.data)
+	for len(o.messages) > 1 && o.size > resumeBufferBytes {
+		o.size -= len(o.messages[0].data)
+		o.messages[0] = outboundMessage{}
+		o.messages = o.messages[1:]
+	}
+
+	if o.conn == nil || o.broken {
+		return nil
+	}
+	if err := o.send(o.conn, m); err != nil {
+		o.broken = true
+	}
+	return nil
+}
+
+func (o *outbox) send(conn Conn, m outboundMessage) error {
+	if m.text {
+		return conn.WriteText(string(m.data))
+	}
+	return conn.WriteBinary(m.data)
+}
+
+func (o *outbox) attach(conn Conn, lastSeq uint64, resumed ReadyEvent) (Conn, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	var replay []outboundMessage
+	if lastSeq <= o.seq {
+		first := o.seq + 1 - uint64(len(o.messages))
+		if lastSeq+1 < first {
+			resumed.Missed = first - lastSeq - 1
+		}
+		for _, m := range o.messages {
+			if m.seq > lastSeq {
+				replay = append(replay, m)
+			}
+		}
+	}
+
+	previous := o.conn
+	o.conn, o.broken = conn, false
+	if err := writeEvent(conn, resumed); err != nil {
+		o.broken = true
+		return previous, err
+	}
+	for _, m := range replay {
+		if err := o.send(conn, m); err != nil {
+			o.broken = true
+			return previous, err
+		}
+	}
+	return previous, nil
+}
+
+func (o *outbox) detach(conn Conn) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.conn != conn {
+		return false
+	}
+	o.conn = nil
+	return true
+}
+
+func (o *outbox) attached() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.conn != nil
+}
+
+func withSeq(text string, seq uint64) string {
+	rest, ok := strings.CutPrefix(text, "{")
+	if !ok {
+		return text
+	}
+	if !strings.HasPrefix(rest, "}") {
+		rest = "," + rest
+	}
+	return `{"seq":` + strconv.FormatUint(seq, 10) + rest
+}
+
+func (m *SessionManager) Resume(token string, principal *auth.Principal, conn Conn, lastSeq uint64) (*Session, Conn, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session := m.resumableLocked(token, principal)
+	if session == nil {
+		metrics.SessionResumes.WithLabelValues(metrics.Outcome(ErrSessionNotFound)).Inc()
+		return nil, nil, ErrSessionNotFound
+	}
+	metrics.SessionResumes.WithLabelValues(metrics.Outcome(nil)).Inc()
+
+	if session.detachTimer != nil {
+		session.detachTimer.Stop()
+		session.detachTimer = nil
+	}
+	previous, err := session.outbox.attach(conn, lastSeq, session.readyEvent("resumed"))
+	return session, previous, err
+}
+
+func (m *SessionManager) resumable(token string, principal *auth.Principal) *Session {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.resumableLocked(token, principal)
+}
+
+func (m *SessionManager) resumableLocked(token string, principal *auth.Principal) *Session {
+	session := m.sessions[m.resumeTokens[token]]
+	if session == nil || session.outbox == nil || session.principal.TenantID != principal.TenantID || session.principal.Subject != principal.Subject {
+		return nil
+	}
+	return session
+}
+
+func (m *SessionManager) Detach(id string, conn Conn) {
+	if m.resumeGrace <= 0 {
+		m.Remove(id)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	session, ok := m.sessions[id]
+	if !ok || !session.outbox.detach(conn) {
+		return
+	}
+	session.logger.Info("connection lost, session kept for resume", "grace", m.resumeGrace)
+	session.detachTimer = time.AfterFunc(m.resumeGrace, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if m.sessions[id] == session && !session.outbox.attached() {
+			session.logger.Info("session expired without resume")
+			m.remove(id)
+		}
+	})
+}
@@ -0,0 +1,314 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"ai-translator/internal/auth"
+)
+
+func seqs(t *testing.T, texts []string) []uint64 {
+	t.Helper()
+	var out []uint64
+	for _, text := range texts {
+		var msg struct {
+			Seq *uint64 `json:"seq"`
+		}
+		if err := json.Unmarshal([]byte(text), &msg); err != nil {
+			t.Fatalf("decode %q: %v", text, err)
+		}
+		if msg.Seq != nil {
+			out = append(out, *msg.Seq)
+		}
+	}
+	return out
+}
+
+func readyEvent(t *testing.T, text string) ReadyEvent {
+	t.Helper()
+	var event ReadyEvent
+	if err := json.Unmarshal([]byte(text), &event); err != nil {
+		t.Fatalf("decode %q: %v", text, err)
+	}
+	return event
+}
+
+func writeEvents(t *testing.T, s *Session, from, to int) {
+	t.Helper()
+	for i := from; i <= to; i++ {
+		if err := s.conn.WriteText(fmt.Sprintf(`{"type":"test","n":%d}`, i)); err != nil {
+			t.Fatalf("WriteText: %v", err)
+		}
+	}
+}
+
+func TestWithSeq(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{`{"type":"ready"}`, `{"seq":7,"type":"ready"}`},
+		{`{}`, `{"seq":7}`},
+		{`not json`, `not json`},
+	}
+	for _, tt := range tests {
+		if got := withSeq(tt.in, 7); got != tt.want {
+			t.Errorf("withSeq(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestResumeReplaysFromLastAcknowledgedSeq(t *testing.T) {
+	m := newTestManager(nil, nil, nil, time.Minute)
+	first := &fakeConn{}
+	s := newTestSession(t, m, first)
+
+	writeEvents(t, s, 1, 5)
+	s.conn.WriteBinary([]byte{1, 2})
+	m.Detach(s.ID, first)
+	writeEvents(t, s, 7, 8)
+
+	second := &fakeConn{}
+	resumed, previous, err := m.Resume(s.resumeToken, testPrincipal, second, 3)
+	if err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	if resumed != s || previous != nil {
+		t.Fatalf("Resume = %p, previous %v, want the session and no previous connection", resumed, previous)
+	}
+
+	if got := seqs(t, first.texts); len(got) != 5 || len(first.binary) != 1 {
+		t.Fatalf("first connection got seqs %v and %d binary frames, want 1-5 and one frame", got, len(first.binary))
+	}
+	ready := readyEvent(t, second.texts[0])
+	if ready.Status != "resumed" || ready.SessionID != s.ID || ready.ResumeToken != s.resumeToken || ready.Missed != 0 {
+		t.Fatalf("ready event = %+v", ready)
+	}
+	if got := fmt.Sprint(seqs(t, second.texts[1:])); got != "[4 5 7 8]" {
+		t.Fatalf("replayed text seqs = %s, want [4 5 7 8]", got)
+	}
+	if len(second.binary) != 1 {
+		t.Fatalf("replayed %d binary frames, want 1", len(second.binary))
+	}
+
+	writeEvents(t, s, 9, 9)
+	if got := seqs(t, second.texts); got[len(got)-1] != 9 || len(seqs(t, first.texts)) != 5 {
+		t.Fatal("live events did not move to the resumed connection")
+	}
+}
+
+func TestResumeWithEverythingAcknowledged(t *testing.T) {
+	m := newTestManager(nil, nil, nil, time.Minute)
+	first := &fakeConn{}
+	s := newTestSession(t, m, first)
+	writeEvents(t, s, 1, 3)
+	m.Detach(s.ID, first)
+
+	for _, lastSeq := range []uint64{3, 10} {
+		conn := &fakeConn{}
+		if _, _, err := m.Resume(s.resumeToken, testPrincipal, conn, lastSeq); err != nil {
+			t.Fatalf("Resume: %v", err)
+		}
+		if len(conn.texts) != 1 || readyEvent(t, conn.texts[0]).Missed != 0 {
+			t.Fatalf("last_seq %d: got %q, want only the ready event", lastSeq, conn.texts)
+		}
+	}
+}
+
+func TestResumeReportsMissedMessages(t *testing.T) {
+	m := newTestManager(nil, nil, nil, time.Minute)
+	first := &fakeConn{}
+	s := newTestSession(t, m, first)
+
+	chunk := make([]byte, resumeBufferBytes/4)
+	for range 6 {
+		s.conn.WriteBinary(chunk)
+	}
+	m.Detach(s.ID, first)
+
+	second := &fakeConn{}
+	if _, _, err := m.Resume(s.resumeToken, testPrincipal, second, 0); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	ready := readyEvent(t, second.texts[0])
+	if ready.Missed != uint64(6-len(second.binary)) || len(second.binary) != 4 {
+		t.Fatalf("missed = %d with %d frames replayed, want 2 and 4", ready.Missed, len(second.binary))
+	}
+}
+
+func TestResumeRejectsUnknownTokens(t *testing.T) {
+	m := newTestManager(nil, nil, nil, time.Minute)
+	first := &fakeConn{}
+	s := newTestSession(t, m, first)
+	m.Detach(s.ID, first)
+
+	tests := []struct {
+		name      string
+		token     string
+		principal *auth.Principal
+	}{
+		{"unknown token", "not-a-token", testPrincipal},
+		{"empty token", "", testPrincipal},
+		{"other subject", s.resumeToken, &auth.Principal{Subject: "mallory", TenantID: "acme"}},
+		{"other tenant", s.resumeToken, &auth.Principal{Subject: "alice", TenantID: "globex"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &fakeConn{}
+			if _, _, err := m.Resume(tt.token, tt.principal, conn, 0); !errors.Is(err, ErrSessionNotFound) {
+				t.Fatalf("Resume error = %v, want ErrSessionNotFound", err)
+			}
+			if len(conn.texts) != 0 {
+				t.Fatalf("rejected connection received %q", conn.texts)
+			}
+		})
+	}
+
+	token := s.resumeToken
+	m.Remove(s.ID)
+	if _, _, err := m.Resume(token, testPrincipal, &fakeConn{}, 0); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("Resume after the session ended = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestResumeGraceExpiry(t *testing.T) {
+	m := newTestManager(nil, nil, nil, 20*time.Millisecond)
+	first := &fakeConn{}
+	s := newTestSession(t, m, first)
+	m.Detach(s.ID, first)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for m.Get(s.ID) != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("detached session outlived its grace window")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, _, err := m.Resume(s.resumeToken, testPrincipal, &fakeConn{}, 0); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("Resume after expiry = %v, want ErrSessionNotFound", err)
+	}
+}
+
+func TestResumeWithinGraceKeepsSession(t *testing.T) {
+	m := newTestManager(nil, nil, nil, 30*time.Millisecond)
+	first := &fakeConn{}
+	s := newTestSession(t, m, first)
+	m.Detach(s.ID, first)
+
+	second := &fakeConn{}
+	if _, _, err := m.Resume(s.resumeToken, testPrincipal, second, 0); err != nil {
+		t.Fatalf("Resume: %v", err)
+	}
+	m.Detach(s.ID, first)
+	time.Sleep(100 * time.Millisecond)
+	if m.Get(s.ID) == nil {
+		t.Fatal("resumed session expired")
+	}
+
+	m.Detach(s.ID, second)
+	time.Sleep(100 * time.Millisecond)
+	if m.Get(s.ID) != nil {
+		t.Fatal("session outlived the grace window after its second disconnect")
+	}
+}
+
+func TestDetachWithoutGraceRemovesSession(t *testing.T) {
+	m := newTestManager(nil, nil, nil, 0)
+	conn := &fakeConn{}
+	s := newTestSession(t, m, conn)
+	if s.resumeToken != "" {
+		t.Fatal("session without a grace window has a resume token")
+	}
+	m.Detach(s.ID, conn)
+	if m.Get(s.ID) != nil {
+		t.Fatal("session kept without a grace window")
+	}
+}
+
+func TestResumeAfterWriteFailure(t *testing.T) {
+	m := newTestManager(nil, nil, nil, time.Minute)
+	first := &fakeConn{}
+	s := newTestSession(t, m, first)
+
+	writeEvents(t, s, 1, 2)
+	first.err = errors.New("broken pipe")
+	writeEvents(t, s, 3, 4)
+	first.err = nil
+	writeEvents(t, s, 5, 5)
+	if got := len(first.texts); got != 2 {
+		t.Fatalf("broken connection received %d events, want 2", got)
+	}
+
+	second := &fakeConn{}
+	previous, err := s.outbox.attach(second, 2, s.readyEvent("resumed"))
+	if err != nil || previous != first {
+		t.Fatalf("attach = %v, %v, want the broken connection", previous, err)
+	}
+	if got := fmt.Sprint(seqs(t, second.texts[1:])); got != "[3 4 5]" {
+		t.Fatalf("replayed seqs = %s, want [3 4 5]", got)
+	}
+}
+
+func TestConcurrentResumes(t *testing.T) {
+	m := newTestManager(nil, nil, nil, time.Minute)
+	first := &fakeConn{}
+	s := newTestSession(t, m, first)
+	writeEvents(t, s, 1, 3)
+	m.Detach(s.ID, first)
+
+	conns := []*fakeConn{{}, {}}
+	previous := make([]Conn, len(conns))
+	var wg sync.WaitGroup
+	for i, conn := range conns {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var err error
+			if _, previous[i], err = m.Resume(s.resumeToken, testPrincipal, conn, 1); err != nil {
+				t.Errorf("Resume %d: %v", i, err)
+			}
+		}()
+	}
+
+	stop := make(chan struct{})
+	writes := make(chan struct{})
+	go func() {
+		defer close(writes)
+		for i := 4; i < 1000; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				s.conn.WriteText(fmt.Sprintf(`{"type":"test","n":%d}`, i))
+			}
+		}
+	}()
+	wg.Wait()
+	close(stop)
+	<-writes
+
+	winner, loser := 1, 0
+	if previous[0] != nil {
+		winner, loser = 0, 1
+	}
+	if previous[loser] != nil || previous[winner] != conns[loser] {
+		t.Fatalf("previous connections = %v, want the later resume to take over from the earlier one", previous)
+	}
+	if !s.outbox.attached() {
+		t.Fatal("no connection attached after the resumes")
+	}
+
+	got := seqs(t, conns[winner].texts)
+	if len(got) < 2 || got[0] != 2 {
+		t.Fatalf("winner seqs start %v, want a replay from 2", got[:min(len(got), 3)])
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] != got[i-1]+1 {
+			t.Fatalf("winner seqs jump from %d to %d", got[i-1], got[i])
+		}
+	}
+	if last := s.outbox.seq; got[len(got)-1] != last {
+		t.Fatalf("winner's last seq = %d, want %d", got[len(got)-1], last)
+	}
+}
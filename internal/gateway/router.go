@@ -1,20 +1,32 @@
 package gateway
 
 import (
+	"context"
+	"encoding/json"
 	"log/slog"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ai-translator/internal/metrics"
 )
 
+const readyCheckTimeout = 2 * time.Second
+
 type Router struct {
 	mux       *http.ServeMux
 	wsHandler *WebSocketHandler
+	backends  []*Backend
+	draining  atomic.Bool
 	logger    *slog.Logger
 }
 
-func NewRouter(wsHandler *WebSocketHandler, logger *slog.Logger) *Router {
+func NewRouter(wsHandler *WebSocketHandler, backends []*Backend, logger *slog.Logger) *Router {
 	r := &Router{
 		mux:       http.NewServeMux(),
 		wsHandler: wsHandler,
+		backends:  backends,
 		logger:    logger,
 	}
 	r.setupRoutes()
@@ -25,6 +37,11 @@ func (r *Router) setupRoutes() {
 	r.mux.HandleFunc("/ws", r.wsHandler.HandleConnection)
 	r.mux.HandleFunc("/health", r.handleHealth)
 	r.mux.HandleFunc("/ready", r.handleReady)
+	r.mux.Handle("/metrics", metrics.Handler())
+}
+
+func (r *Router) Handle(pattern string, handler http.Handler) {
+	r.mux.Handle(pattern, handler)
 }
 
 func (r *Router) handleHealth(w http.ResponseWriter, req *http.Request) {
@@ -33,10 +50,52 @@ func (r *Router) handleHealth(w http.ResponseWriter, req *http.Request) {
 	w.Write([]byte(`{"status":"healthy"}`))
 }
 
+type readyResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+func (r *Router) Drain() {
+	r.draining.Store(true)
+}
+
 func (r *Router) handleReady(w http.ResponseWriter, req *http.Request) {
+	ctx, cancel := context.WithTimeout(req.Context(), readyCheckTimeout)
+	defer cancel()
+
+	results := make([]DependencyStatus, len(r.backends))
+	var wg sync.WaitGroup
+	for i, b := range r.backends {
+		wg.Add(1)
+		go func(i int, b *Backend) {
+			defer wg.Done()
+			results[i] = b.Check(ctx)
+		}(i, b)
+	}
+	wg.Wait()
+
+	resp := readyResponse{
+		Status:       "ready",
+		Dependencies: make(map[string]DependencyStatus, len(r.backends)),
+	}
+
+	code := http.StatusOK
+	if r.draining.Load() {
+		resp.Status = "draining"
+		code = http.StatusServiceUnavailable
+	}
+
+	for i, b := range r.backends {
+		resp.Dependencies[b.Name()] = results[i]
+		if !results[i].Ready() && code == http.StatusOK {
+			resp.Status = "not_ready"
+			code = http.StatusServiceUnavailable
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status":"ready"}`))
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(resp)
 }
 
 func (r *Router) Handler() http.Handler {
@@ -0,0 +1,114 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+type fakeHealthClient struct {
+	healthpb.HealthClient
+	status healthpb.HealthCheckResponse_ServingStatus
+	err    error
+	asked  string
+}
+
+func (c *fakeHealthClient) Check(ctx context.Context, in *healthpb.HealthCheckRequest, opts ...grpc.CallOption) (*healthpb.HealthCheckResponse, error) {
+	c.asked = in.Service
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &healthpb.HealthCheckResponse{Status: c.status}, nil
+}
+
+func healthBackend(name string, health *fakeHealthClient) *Backend {
+	b := testBackend(time.Second, 1, 1)
+	b.name, b.service, b.health = name, name+".Service", health
+	return b
+}
+
+func serving() *fakeHealthClient {
+	return &fakeHealthClient{status: healthpb.HealthCheckResponse_SERVING}
+}
+
+func getReady(t *testing.T, r *Router) (int, readyResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ready", nil))
+	var resp readyResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode /ready: %v", err)
+	}
+	return rec.Code, resp
+}
+
+func TestReadyWhenAllBackendsServe(t *testing.T) {
+	asr := serving()
+	r := NewRouter(nil, []*Backend{healthBackend("asr", asr), healthBackend("translator", serving()), healthBackend("tts", serving())}, discardLogger())
+
+	code, resp := getReady(t, r)
+	if code != http.StatusOK || resp.Status != "ready" {
+		t.Fatalf("/ready = %d %q, want 200 ready", code, resp.Status)
+	}
+	if len(resp.Dependencies) != 3 {
+		t.Fatalf("dependencies = %v, want all three", resp.Dependencies)
+	}
+	if dep := resp.Dependencies["asr"]; dep.Status != "SERVING" || dep.Breaker != "closed" || dep.Error != "" {
+		t.Fatalf("asr = %+v", dep)
+	}
+	if asr.asked != "asr.Service" {
+		t.Fatalf("health checked service %q, want the backend's service", asr.asked)
+	}
+}
+
+func TestReadyReportsUnhealthyDependencies(t *testing.T) {
+	notServing := &fakeHealthClient{status: healthpb.HealthCheckResponse_NOT_SERVING}
+	unreachable := &fakeHealthClient{err: status.Error(codes.Unavailable, "connection refused")}
+	r := NewRouter(nil, []*Backend{healthBackend("asr", serving()), healthBackend("translator", notServing), healthBackend("tts", unreachable)}, discardLogger())
+
+	code, resp := getReady(t, r)
+	if code != http.StatusServiceUnavailable || resp.Status != "not_ready" {
+		t.Fatalf("/ready = %d %q, want 503 not_ready", code, resp.Status)
+	}
+	if dep := resp.Dependencies["translator"]; dep.Status != "NOT_SERVING" {
+		t.Fatalf("translator = %+v", dep)
+	}
+	if dep := resp.Dependencies["tts"]; dep.Status != "UNKNOWN" || dep.Error != "connection refused" {
+		t.Fatalf("tts = %+v", dep)
+	}
+	if !resp.Dependencies["asr"].Ready() {
+		t.Fatalf("asr = %+v, want ready", resp.Dependencies["asr"])
+	}
+}
+
+func TestReadyFailsWhileBreakerIsOpen(t *testing.T) {
+	b := healthBackend("translator", serving())
+	b.breaker.Failure()
+	r := NewRouter(nil, []*Backend{b}, discardLogger())
+
+	code, resp := getReady(t, r)
+	if code != http.StatusServiceUnavailable || resp.Dependencies["translator"].Breaker != "open" {
+		t.Fatalf("/ready = %d %+v, want 503 with an open breaker", code, resp)
+	}
+}
+
+func TestReadyReportsDraining(t *testing.T) {
+	r := NewRouter(nil, []*Backend{healthBackend("asr", &fakeHealthClient{status: healthpb.HealthCheckResponse_NOT_SERVING})}, discardLogger())
+	r.Drain()
+
+	code, resp := getReady(t, r)
+	if code != http.StatusServiceUnavailable || resp.Status != "draining" {
+		t.Fatalf("/ready = %d %q, want 503 draining", code, resp.Status)
+	}
+	if resp.Dependencies["asr"].Status != "NOT_SERVING" {
+		t.Fatalf("dependencies = %+v, want them reported while draining", resp.Dependencies)
+	}
+}
@@ -0,0 +1,212 @@
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"ai-translator/internal/metrics"
+	"ai-translator/internal/transport"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	SlowClientDropAudio  = "drop_audio"
+	SlowClientDisconnect = "disconnect"
+)
+
+var ErrSlowClient = errors.New("client is not reading fast enough")
+
+func ParseSlowClientPolicy(policy string) (string, error) {
+	switch policy {
+	case SlowClientDropAudio, SlowClientDisconnect:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown slow client policy %q, expected drop_audio or disconnect", policy)
+}
+
+type SendQueueConfig struct {
+	MaxBytes int
+	Policy   string
+}
+
+type queuedMessage struct {
+	messageType int
+	data        []byte
+}
+
+type sendQueue struct {
+	conn         *transport.WSConn
+	config       SendQueueConfig
+	logger       *slog.Logger
+	mu           sync.Mutex
+	messages     []queuedMessage
+	size         int
+	dropped      int
+	droppedBytes int
+	overflow     bool
+	closed       bool
+	wake         chan struct{}
+	done         chan struct{}
+}
+
+func newSendQueue(conn *transport.WSConn, config SendQueueConfig, logger *slog.Logger) *sendQueue {
+	q := &sendQueue{
+		conn:   conn,
+		config: config,
+		logger: logger,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+func (q *sendQueue) WriteText(text string) error {
+	return q.push(queuedMessage{messageType: websocket.TextMessage, data: []byte(text)})
+}
+
+func (q *sendQueue) WriteBinary(data []byte) error {
+	return q.push(queuedMessage{messageType: websocket.BinaryMessage, data: data})
+}
+
+func (q *sendQueue) push(m queuedMessage) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.overflow {
+		return ErrSlowClient
+	}
+	if q.closed {
+		return websocket.ErrCloseSent
+	}
+
+	for q.size+len(m.data) > q.config.MaxBytes && len(q.messages) > 0 {
+		if q.config.Policy != SlowClientDropAudio || !q.dropOldestAudio() {
+			q.overflow = true
+			q.signal()
+			return ErrSlowClient
+		}
+	}
+
+	q.messages = append(q.messages, m)
+	q.size += len(m.data)
+	q.signal()
+	return nil
+}
+
+func (q *sendQueue) dropOldestAudio() bool {
+	for i, m := range q.messages {
+		if m.messageType != websocket.BinaryMessage {
+			continue
+		}
+		q.messages = append(q.messages[:i], q.messages[i+1:]...)
+		q.size -= len(m.data)
+		q.dropped++
+		q.droppedBytes += len(m.data)
+		metrics.OutboundAudioDropped.Add(float64(len(m.data)))
+		return true
+	}
+	return false
+}
+
+func (q *sendQueue) signal() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (q *sendQueue) next() (queuedMessage, AudioDroppedEvent, bool, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.overflow || len(q.messages) == 0 {
+		return queuedMessage{}, AudioDroppedEvent{}, q.overflow, false
+	}
+
+	m := q.messages[0]
+	q.messages[0] = queuedMessage{}
+	q.messages = q.messages[1:]
+	q.size -= len(m.data)
+
+	var notice AudioDroppedEvent
+	if q.dropped > 0 {
+		notice = AudioDroppedEvent{Type: "audio_dropped", Frames: q.dropped, Bytes: q.droppedBytes}
+		q.dropped, q.droppedBytes = 0, 0
+	}
+	return m, notice, false, true
+}
+
+func (q *sendQueue) run() {
+	for {
+		select {
+		case <-q.done:
+			return
+		case <-q.wake:
+		}
+
+		for {
+			m, notice, overflow, ok := q.next()
+			if overflow {
+				q.disconnect()
+				return
+			}
+			if !ok {
+				break
+			}
+			if notice.Frames > 0 {
+				q.logger.Warn("client too slow, dropped queued audio", "frames", notice.Frames, "bytes", notice.Bytes)
+				if err := writeEvent(q.conn, notice); err != nil {
+					q.fail(err)
+					return
+				}
+			}
+			if err := q.conn.WriteMessage(m.messageType, m.data); err != nil {
+				q.fail(err)
+				return
+			}
+		}
+	}
+}
+
+func (q *sendQueue) disconnect() {
+	q.mu.Lock()
+	q.messages, q.size = nil, 0
+	q.mu.Unlock()
+
+	q.logger.Warn("client too slow, disconnecting", "max_bytes", q.config.MaxBytes)
+	metrics.SlowClientDisconnects.Inc()
+	if err := writeEvent(q.conn, NewErrorEvent(StageSession, ErrSlowClient, "", true)); err != nil {
+		q.logger.Debug("failed to send error event", "error", err)
+	}
+	q.conn.CloseWithCode(CloseSlowClient, ErrSlowClient.Error())
+}
+
+func (q *sendQueue) fail(err error) {
+	q.mu.Lock()
+	q.closed = true
+	q.messages, q.size = nil, 0
+	q.mu.Unlock()
+
+	q.logger.Warn("failed to write to client", "error", err)
+	q.conn.Close()
+}
+
+func (q *sendQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return
+	}
+	q.closed = true
+	close(q.done)
+}
+
+func (q *sendQueue) CloseWithCode(code int, reason string) error {
+	q.close()
+	return q.conn.CloseWithCode(code, reason)
+}
@@ -0,0 +1,250 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-translator/internal/transport"
+
+	"github.com/gorilla/websocket"
+)
+
+func wsPair(t *testing.T) (*transport.WSConn, *websocket.Conn) {
+	t.Helper()
+	upgrader := transport.NewWSUpgrader(nil, transport.WSTimeouts{WriteTimeout: time.Second})
+	conns := make(chan *transport.WSConn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, discardLogger(), "sess-1")
+		if err != nil {
+			return
+		}
+		conns <- ws
+	}))
+	t.Cleanup(srv.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	server := <-conns
+	t.Cleanup(func() { server.Close() })
+	return server, client
+}
+
+func idleSendQueue(conn *transport.WSConn, maxBytes int, policy string) *sendQueue {
+	return &sendQueue{
+		conn:   conn,
+		config: SendQueueConfig{MaxBytes: maxBytes, Policy: policy},
+		logger: discardLogger(),
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+}
+
+func (q *sendQueue) queued() (texts, frames, size int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, m := range q.messages {
+		size += len(m.data)
+		if m.messageType == websocket.TextMessage {
+			texts++
+		} else {
+			frames++
+		}
+	}
+	if size != q.size {
+		panic("sendQueue size is out of sync with its messages")
+	}
+	return texts, frames, size
+}
+
+func TestParseSlowClientPolicy(t *testing.T) {
+	for _, policy := range []string{SlowClientDropAudio, SlowClientDisconnect} {
+		if got, err := ParseSlowClientPolicy(policy); err != nil || got != policy {
+			t.Errorf("ParseSlowClientPolicy(%q) = %q, %v", policy, got, err)
+		}
+	}
+	if _, err := ParseSlowClientPolicy("block"); err == nil {
+		t.Error("ParseSlowClientPolicy accepted an unknown policy")
+	}
+}
+
+func TestDropAudioHoldsByteLimitAndKeepsEvents(t *testing.T) {
+	q := idleSendQueue(nil, 1000, SlowClientDropAudio)
+	frame := make([]byte, 300)
+
+	for i := range 20 {
+		if err := q.WriteBinary(frame); err != nil {
+			t.Fatalf("WriteBinary %d: %v", i, err)
+		}
+		if i%4 == 0 {
+			if err := q.WriteText(`{"type":"translation"}`); err != nil {
+				t.Fatalf("WriteText %d: %v", i, err)
+			}
+		}
+		if _, _, size := q.queued(); size > 1000 {
+			t.Fatalf("queue holds %d bytes after message %d, limit 1000", size, i)
+		}
+	}
+
+	texts, frames, _ := q.queued()
+	if texts != 5 {
+		t.Fatalf("%d events queued, want all 5", texts)
+	}
+	if q.dropped+frames != 20 || q.droppedBytes != q.dropped*len(frame) {
+		t.Fatalf("dropped %d frames (%d bytes) with %d queued, want 20 in total", q.dropped, q.droppedBytes, frames)
+	}
+}
+
+func TestDropAudioDisconnectsWhenOnlyEventsAreQueued(t *testing.T) {
+	q := idleSendQueue(nil, 100, SlowClientDropAudio)
+	event := `{"type":"translation","text":"` + strings.Repeat("x", 30) + `"}`
+
+	var err error
+	for range 10 {
+		if err = q.WriteText(event); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrSlowClient) {
+		t.Fatalf("WriteText = %v, want ErrSlowClient once events fill the queue", err)
+	}
+	if _, _, size := q.queued(); size > 100 {
+		t.Fatalf("queue holds %d bytes, limit 100", size)
+	}
+	if err := q.WriteBinary([]byte{1}); !errors.Is(err, ErrSlowClient) {
+		t.Fatalf("WriteBinary after overflow = %v, want ErrSlowClient", err)
+	}
+	if q.dropped != 0 {
+		t.Fatalf("dropped %d frames with no audio queued", q.dropped)
+	}
+}
+
+func TestDisconnectPolicyHoldsByteLimit(t *testing.T) {
+	q := idleSendQueue(nil, 1000, SlowClientDisconnect)
+	frame := make([]byte, 300)
+
+	for i := range 3 {
+		if err := q.WriteBinary(frame); err != nil {
+			t.Fatalf("WriteBinary %d: %v", i, err)
+		}
+	}
+	if err := q.WriteBinary(frame); !errors.Is(err, ErrSlowClient) {
+		t.Fatalf("WriteBinary over the limit = %v, want ErrSlowClient", err)
+	}
+	if _, frames, size := q.queued(); frames != 3 || size != 900 || q.dropped != 0 {
+		t.Fatalf("queue = %d frames, %d bytes, %d dropped, want 3, 900 and none", frames, size, q.dropped)
+	}
+	if err := q.WriteText(`{"type":"done"}`); !errors.Is(err, ErrSlowClient) {
+		t.Fatalf("WriteText after overflow = %v, want ErrSlowClient", err)
+	}
+}
+
+func TestSendQueueAcceptsOversizedMessageWhenEmpty(t *testing.T) {
+	for _, policy := range []string{SlowClientDropAudio, SlowClientDisconnect} {
+		q := idleSendQueue(nil, 100, policy)
+		if err := q.WriteBinary(make([]byte, 500)); err != nil {
+			t.Fatalf("%s: oversized frame into an empty queue = %v", policy, err)
+		}
+		if err := q.WriteText(`{"type":"done"}`); policy == SlowClientDisconnect && !errors.Is(err, ErrSlowClient) {
+			t.Fatalf("%s: event behind an oversized frame = %v, want ErrSlowClient", policy, err)
+		} else if policy == SlowClientDropAudio && err != nil {
+			t.Fatalf("%s: event behind an oversized frame = %v, want the frame dropped", policy, err)
+		}
+	}
+}
+
+func TestSendQueueDeliversDropNoticeBeforeNextMessage(t *testing.T) {
+	server, client := wsPair(t)
+	q := idleSendQueue(server, 1000, SlowClientDropAudio)
+
+	q.WriteText(`{"type":"ready"}`)
+	for range 6 {
+		q.WriteBinary(make([]byte, 300))
+	}
+	q.WriteText(`{"type":"translation"}`)
+	dropped, droppedBytes := q.dropped, q.droppedBytes
+	_, frames, _ := q.queued()
+
+	go q.run()
+	q.signal()
+	defer q.close()
+
+	var got []string
+	for range 3 + frames {
+		kind, data, err := client.ReadMessage()
+		if err != nil {
+			t.Fatalf("ReadMessage: %v", err)
+		}
+		if kind == websocket.BinaryMessage {
+			got = append(got, "audio")
+			continue
+		}
+		var event AudioDroppedEvent
+		json.Unmarshal(data, &event)
+		if event.Type == "audio_dropped" && (event.Frames != dropped || event.Bytes != droppedBytes) {
+			t.Fatalf("audio_dropped = %+v, want %d frames and %d bytes", event, dropped, droppedBytes)
+		}
+		got = append(got, event.Type)
+	}
+
+	want := "audio_dropped ready " + strings.TrimSpace(strings.Repeat("audio ", frames)) + " translation"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("client received %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestSendQueueOverflowSendsErrorAndCloses(t *testing.T) {
+	server, client := wsPair(t)
+	q := idleSendQueue(server, 500, SlowClientDisconnect)
+	for range 3 {
+		q.WriteBinary(make([]byte, 300))
+	}
+
+	go q.run()
+	q.signal()
+
+	_, data, err := client.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	var event ErrorEvent
+	if err := json.Unmarshal(data, &event); err != nil || event.Type != "error" || event.Code != ErrorCodeFromError(ErrSlowClient) {
+		t.Fatalf("first message = %s, want the slow client error", data)
+	}
+	if _, _, err := client.ReadMessage(); !websocket.IsCloseError(err, CloseSlowClient) {
+		t.Fatalf("close = %v, want code %d", err, CloseSlowClient)
+	}
+}
+
+func TestSendQueueDeliversInOrder(t *testing.T) {
+	server, client := wsPair(t)
+	q := newSendQueue(server, SendQueueConfig{MaxBytes: 1 << 20, Policy: SlowClientDisconnect}, discardLogger())
+	defer q.close()
+
+	q.WriteText(`{"type":"ready"}`)
+	q.WriteBinary([]byte{1, 2})
+	q.WriteText(`{"type":"done"}`)
+
+	for _, want := range []string{`{"type":"ready"}`, "\x01\x02", `{"type":"done"}`} {
+		_, data, err := client.ReadMessage()
+		if err != nil || string(data) != want {
+			t.Fatalf("ReadMessage = %q, %v, want %q", data, err, want)
+		}
+	}
+
+	if err := q.CloseWithCode(websocket.CloseNormalClosure, ""); err != nil {
+		t.Fatalf("CloseWithCode: %v", err)
+	}
+	if err := q.WriteText(`{"type":"late"}`); !errors.Is(err, websocket.ErrCloseSent) {
+		t.Fatalf("WriteText after close = %v, want ErrCloseSent", err)
+	}
+}
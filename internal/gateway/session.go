@@ -2,68 +2,162 @@ package gateway
 
 import (
 	"context"
+	"io"
 	"log/slog"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	pb "ai-translator/api/proto"
 	"ai-translator/internal/audio"
-	"ai-translator/internal/transport"
+	"ai-translator/internal/auth"
+	"ai-translator/internal/metering"
+	"ai-translator/internal/metrics"
+	"ai-translator/internal/quota"
+	"ai-translator/internal/recording"
+	"ai-translator/internal/transcript"
+	"ai-translator/internal/util"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Session struct {
 	ID               string
-	conn             *transport.WSConn
+	principal        *auth.Principal
+	quotas           *quota.Manager
+	releaseQuota     func()
+	meter            *metering.Meter
+	transcripts      transcript.Store
+	captions         *captionHub
+	recordings       *recording.Manager
+	recorder         *recording.Recorder
+	recordingStopped bool
+	startedAt        time.Time
+	utteranceCount   int
+	conn             Conn
+	outbox           *outbox
+	resumeToken      string
+	detachTimer      *time.Timer
 	logger           *slog.Logger
 	audioBuffer      *audio.Buffer
+	ingest           IngestConfig
+	flowMu           sync.Mutex
+	flowPaused       bool
+	ingestStats      ingestStats
 	sourceLang       string
 	targetLang       string
+	style            *pb.TranslationStyle
 	mu               sync.RWMutex
 	asrClient        pb.ASRServiceClient
 	translatorClient pb.TranslatorServiceClient
 	ttsClient        pb.TTSServiceClient
-	audioChan        chan []byte
+	pipelineOnce     sync.Once
+	audioReceivedAt  time.Time
+	ctx              context.Context
+	cancel           context.CancelFunc
 	closed           bool
 }
 
+type Conn interface {
+	WriteText(text string) error
+	WriteBinary(data []byte) error
+}
+
+type recognition struct {
+	resp       *pb.ASRResponse
+	receivedAt time.Time
+}
+
+type utterance struct {
+	transcript  string
+	translation *pb.TranslateResponse
+	timings     *utteranceTimings
+	span        trace.Span
+}
+
 type SessionManager struct {
 	sessions         map[string]*Session
 	mu               sync.RWMutex
 	asrClient        pb.ASRServiceClient
 	translatorClient pb.TranslatorServiceClient
 	ttsClient        pb.TTSServiceClient
+	quotas           *quota.Manager
+	meter            *metering.Meter
+	transcripts      transcript.Store
+	recordings       *recording.Manager
+	resumeGrace      time.Duration
+	resumeTokens     map[string]string
+	ingest           IngestConfig
 	logger           *slog.Logger
 }
 
-func NewSessionManager(asrClient pb.ASRServiceClient, translatorClient pb.TranslatorServiceClient, ttsClient pb.TTSServiceClient, logger *slog.Logger) *SessionManager {
+func NewSessionManager(asrClient pb.ASRServiceClient, translatorClient pb.TranslatorServiceClient, ttsClient pb.TTSServiceClient, quotas *quota.Manager, meter *metering.Meter, transcripts transcript.Store, recordings *recording.Manager, resumeGrace time.Duration, ingest IngestConfig, logger *slog.Logger) *SessionManager {
 	return &SessionManager{
 		sessions:         make(map[string]*Session),
+		resumeTokens:     make(map[string]string),
 		asrClient:        asrClient,
 		translatorClient: translatorClient,
 		ttsClient:        ttsClient,
+		quotas:           quotas,
+		meter:            meter,
+		transcripts:      transcripts,
+		recordings:       recordings,
+		resumeGrace:      resumeGrace,
+		ingest:           ingest,
 		logger:           logger,
 	}
 }
 
-func (m *SessionManager) Create(id string, conn *transport.WSConn, logger *slog.Logger) *Session {
+func (m *SessionManager) Create(ctx context.Context, id string, principal *auth.Principal, conn Conn, logger *slog.Logger) (*Session, error) {
+	release, err := m.quotas.AcquireSession(principal.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	sessionCtx := auth.NewContext(trace.ContextWithSpan(context.Background(), trace.SpanFromContext(ctx)), principal)
+	sessionCtx, cancel := context.WithCancel(sessionCtx)
+
 	session := &Session{
+		ctx:              sessionCtx,
+		cancel:           cancel,
 		ID:               id,
+		principal:        principal,
+		quotas:           m.quotas,
+		releaseQuota:     release,
+		meter:            m.meter,
+		transcripts:      m.transcripts,
+		captions:         newCaptionHub(),
+		recordings:       m.recordings,
+		startedAt:        time.Now(),
 		conn:             conn,
 		logger:           logger,
-		audioBuffer:      audio.NewBuffer(16000 * 2 * 30),
+		audioBuffer:      audio.NewBuffer(m.ingest.BufferBytes),
+		ingest:           m.ingest,
 		asrClient:        m.asrClient,
 		translatorClient: m.translatorClient,
 		ttsClient:        m.ttsClient,
-		audioChan:        make(chan []byte, 100),
+	}
+	if m.resumeGrace > 0 {
+		session.outbox = newOutbox(conn)
+		session.conn = session.outbox
+		session.resumeToken = util.NewToken()
+		m.resumeTokens[session.resumeToken] = id
 	}
 
-	m.sessions[id] = session
+	if session.transcripts != nil {
+		if err := session.transcripts.Begin(sessionCtx, id, principal.TenantID, session.startedAt.UTC()); err != nil {
+			logger.Error("failed to start transcript", "error", err)
+		}
+	}
 
-	go session.startPipeline()
+	m.sessions[id] = session
+	metrics.ActiveSessions.Inc()
 
-	return session
+	return session, nil
 }
 
 func (m *SessionManager) Get(id string) *Session {
@@ -75,10 +169,33 @@ func (m *SessionManager) Get(id string) *Session {
 func (m *SessionManager) Remove(id string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.remove(id)
+}
 
-	if session, ok := m.sessions[id]; ok {
-		session.Close()
-		delete(m.sessions, id)
+func (m *SessionManager) remove(id string) {
+	session, ok := m.sessions[id]
+	if !ok {
+		return
+	}
+	if session.detachTimer != nil {
+		session.detachTimer.Stop()
+	}
+	session.Close()
+	delete(m.resumeTokens, session.resumeToken)
+	delete(m.sessions, id)
+	metrics.ActiveSessions.Dec()
+}
+
+func (s *Session) Principal() *auth.Principal {
+	return s.principal
+}
+
+func (s *Session) readyEvent(status string) ReadyEvent {
+	return ReadyEvent{
+		Status:      status,
+		SessionID:   s.ID,
+		ResumeToken: s.resumeToken,
+		Recording:   s.currentRecorder() != nil,
 	}
 }
 
@@ -95,21 +212,115 @@ func (s *Session) GetLanguages() (string, string) {
 	return s.sourceLang, s.targetLang
 }
 
+func (s *Session) SetStyle(style *pb.TranslationStyle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.style = style
+}
+
+func (s *Session) GetStyle() *pb.TranslationStyle {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.style
+}
+
+func (s *Session) SetRecording(enabled bool, mode string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return false
+	}
+	if !enabled {
+		if s.recorder != nil {
+			if err := s.recorder.Close(context.Background(), time.Now()); err != nil {
+				s.logger.Error("failed to finish recording", "error", err)
+			}
+			s.recorder = nil
+			s.recordingStopped = true
+			s.logger.Info("recording stopped by client")
+		}
+		return false
+	}
+	if s.recorder != nil {
+		s.recorder.CaptureConfig(s.recordingConfig())
+		return true
+	}
+	if s.recordings == nil || s.recordingStopped {
+		return false
+	}
+
+	var transcriptPath string
+	if s.transcripts != nil {
+		transcriptPath = "/v1/sessions/" + s.ID + "/transcript"
+	}
+	recorder, err := s.recordings.Start(s.ctx, s.ID, s.principal.TenantID, mode, transcriptPath, s.startedAt)
+	if err != nil {
+		s.logger.Error("failed to start recording", "error", err)
+		return false
+	}
+	s.recorder = recorder
+	s.recorder.CaptureConfig(s.recordingConfig())
+	s.logger.Info("recording started", "mode", mode)
+	return true
+}
+
+func (s *Session) recordingConfig() recording.SessionConfig {
+	c := recording.SessionConfig{
+		SourceLanguage: s.sourceLang,
+		TargetLanguage: s.targetLang,
+	}
+	if s.style != nil {
+		c.Formality = s.style.Formality.String()
+		c.Domain = s.style.Domain.String()
+		c.Audience = s.style.Audience
+	}
+	return c
+}
+
+func (s *Session) currentRecorder() *recording.Recorder {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.recorder
+}
+
 func (s *Session) ProcessAudio(ctx context.Context, data []byte) error {
-	select {
-	case s.audioChan <- data:
-		return nil
-	case <-ctx.Done():
-		return ctx.Err()
-	default:
-		s.logger.Warn("audio channel full, dropping chunk")
+	metrics.AudioBytes.WithLabelValues("in").Add(float64(len(data)))
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.audioBuffer.Available() < len(data) {
+		s.dropAudio(len(data))
 		return nil
 	}
+	if err := s.quotas.ConsumeAudio(s.principal.TenantID, audio.DurationOf(len(data))); err != nil {
+		return err
+	}
+	s.markAudioReceived(time.Now())
+	s.currentRecorder().WriteInbound(data)
+	s.pipelineOnce.Do(func() { go s.startPipeline() })
+	s.bufferAudio(data)
+	return nil
+}
+
+func (s *Session) markAudioReceived(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.audioReceivedAt.IsZero() {
+		s.audioReceivedAt = now
+	}
+}
+
+func (s *Session) takeAudioReceived() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	at := s.audioReceivedAt
+	s.audioReceivedAt = time.Time{}
+	return at
 }
 
 func (s *Session) startPipeline() {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	ctx := s.ctx
 
 	asrStream, err := s.asrClient.StreamingRecognize(ctx)
 	if err != nil {
@@ -136,8 +347,8 @@ func (s *Session) startPipeline() {
 		return
 	}
 
-	transcriptChan := make(chan *pb.ASRResponse, 10)
-	translatedChan := make(chan *pb.TranslateResponse, 10)
+	transcriptChan := make(chan *recognition, 10)
+	translatedChan := make(chan *utterance, 10)
 	audioChan := make(chan []byte, 100)
 
 	go s.forwardAudioToASR(ctx, asrStream)
@@ -155,30 +366,33 @@ func (s *Session) forwardAudioToASR(ctx context.Context, stream pb.ASRService_St
 		case <-ctx.Done():
 			stream.CloseSend()
 			return
-		case audioData, ok := <-s.audioChan:
+		case _, ok := <-s.audioBuffer.Wait():
+			for audioData := s.audioBuffer.Read(ingestReadBytes); audioData != nil; audioData = s.audioBuffer.Read(ingestReadBytes) {
+				s.updateFlow()
+				err := stream.Send(&pb.ASRRequest{
+					Request: &pb.ASRRequest_Audio{
+						Audio: &pb.AudioChunk{
+							Data:       audioData,
+							SampleRate: audio.SampleRate,
+							Channels:   audio.Channels,
+						},
+					},
+				})
+				if err != nil {
+					s.logger.Error("failed to send audio to ASR", "error", err)
+					return
+				}
+				s.meter.AddAudio(s.principal.TenantID, s.ID, audio.DurationOf(len(audioData)))
+			}
 			if !ok {
 				stream.CloseSend()
 				return
 			}
-
-			err := stream.Send(&pb.ASRRequest{
-				Request: &pb.ASRRequest_Audio{
-					Audio: &pb.AudioChunk{
-						Data:       audioData,
-						SampleRate: audio.SampleRate,
-						Channels:   audio.Channels,
-					},
-				},
-			})
-			if err != nil {
-				s.logger.Error("failed to send audio to ASR", "error", err)
-				return
-			}
 		}
 	}
 }
 
-func (s *Session) receiveASRResponses(ctx context.Context, stream pb.ASRService_StreamingRecognizeClient, out chan<- *pb.ASRResponse) {
+func (s *Session) receiveASRResponses(ctx context.Context, stream pb.ASRService_StreamingRecognizeClient, out chan<- *recognition) {
 	defer close(out)
 
 	for {
@@ -190,95 +404,267 @@ func (s *Session) receiveASRResponses(ctx context.Context, stream pb.ASRService_
 			return
 		}
 
+		s.currentRecorder().CaptureRecognition(recording.Recognition{
+			Transcript:       resp.Transcript,
+			IsFinal:          resp.IsFinal,
+			Stability:        resp.Stability,
+			DetectedLanguage: resp.DetectedLanguage,
+		})
+
 		select {
-		case out <- resp:
+		case out <- &recognition{resp: resp, receivedAt: time.Now()}:
 		case <-ctx.Done():
 			return
 		}
 	}
 }
 
-func (s *Session) translateTranscripts(ctx context.Context, in <-chan *pb.ASRResponse, out chan<- *pb.TranslateResponse, targetLang string) {
+func (s *Session) translateTranscripts(ctx context.Context, in <-chan *recognition, out chan<- *utterance, targetLang string) {
 	defer close(out)
 
+	pending := &utteranceTimings{}
+	usage := &utteranceUsage{}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case resp, ok := <-in:
+		case t, ok := <-in:
 			if !ok {
 				return
 			}
 
+			resp := t.resp
 			if resp.Transcript == "" {
 				continue
 			}
 
-			transResp, err := s.translatorClient.Translate(ctx, &pb.TranslateRequest{
+			callCtx := ctx
+			var timings *utteranceTimings
+			var span trace.Span
+			if resp.IsFinal {
+				timings, pending = pending, &utteranceTimings{}
+				timings.FinalTranscript = t.receivedAt
+				timings.AudioReceived = s.takeAudioReceived()
+				if timings.AudioReceived.IsZero() || timings.AudioReceived.After(t.receivedAt) {
+					timings.AudioReceived = firstNonZero(timings.FirstPartial, t.receivedAt)
+				}
+				callCtx, span = startUtteranceSpan(ctx, s.ID, resp, timings.AudioReceived)
+			} else if pending.FirstPartial.IsZero() {
+				pending.FirstPartial = t.receivedAt
+			}
+
+			chars := utf8.RuneCountInString(resp.Transcript)
+			current := usage
+			if resp.IsFinal {
+				usage = &utteranceUsage{}
+			}
+
+			charge := max(chars-current.charged, 0)
+			if err := s.quotas.ConsumeTranslation(s.principal.TenantID, charge); err != nil {
+				s.logger.Warn("translation rejected by tenant quota", "error", err, "final", resp.IsFinal)
+				if resp.IsFinal {
+					s.sendUtteranceError(StageTranslation, err, resp.Transcript, timings)
+					s.recordUtterance(resp, nil, timings)
+					endSpan(span, err)
+				}
+				continue
+			}
+
+			current.charged += charge
+
+			transResp, err := s.translatorClient.Translate(callCtx, &pb.TranslateRequest{
 				SessionId:      s.ID,
 				Text:           resp.Transcript,
 				SourceLanguage: resp.DetectedLanguage,
 				TargetLanguage: targetLang,
 				IsFinal:        resp.IsFinal,
+				Style:          s.GetStyle(),
 			})
 			if err != nil {
-				s.logger.Error("translation error", "error", err)
+				s.logger.Error("translation error", "error", err, "code", ErrorCodeFromError(err))
+				if resp.IsFinal {
+					s.sendUtteranceError(StageTranslation, err, resp.Transcript, timings)
+					s.recordUtterance(resp, nil, timings)
+					endSpan(span, err)
+				}
 				continue
 			}
 
+			s.meter.AddTranslation(s.principal.TenantID, s.ID, transResp.Engine, max(chars-current.metered, 0))
+			current.metered = max(current.metered, chars)
+			s.currentRecorder().CaptureTranslation(recording.Translation{
+				Text:           resp.Transcript,
+				IsFinal:        resp.IsFinal,
+				SourceLanguage: transResp.SourceLanguage,
+				TargetLanguage: transResp.TargetLanguage,
+				TranslatedText: transResp.TranslatedText,
+				Engine:         transResp.Engine,
+			})
+
+			if resp.IsFinal {
+				timings.TranslationDone = time.Now()
+				span.SetAttributes(attribute.String("translation.engine", transResp.Engine))
+				s.recordUtterance(resp, transResp, timings)
+			}
+			s.publishCaption(resp, transResp, timings)
+
 			select {
-			case out <- transResp:
+			case out <- &utterance{transcript: resp.Transcript, translation: transResp, timings: timings, span: span}:
 			case <-ctx.Done():
+				endSpan(span, ctx.Err())
 				return
 			}
 		}
 	}
 }
 
-func (s *Session) synthesizeAndStream(ctx context.Context, in <-chan *pb.TranslateResponse, out chan<- []byte, targetLang string) {
+type utteranceUsage struct {
+	charged int
+	metered int
+}
+
+func (s *Session) recordUtterance(resp *pb.ASRResponse, translation *pb.TranslateResponse, timings *utteranceTimings) {
+	if s.transcripts == nil {
+		return
+	}
+
+	_, targetLang := s.GetLanguages()
+	u := transcript.Utterance{
+		Index:          s.utteranceCount,
+		StartMs:        timings.AudioReceived.Sub(s.startedAt).Milliseconds(),
+		EndMs:          timings.FinalTranscript.Sub(s.startedAt).Milliseconds(),
+		SourceText:     resp.Transcript,
+		SourceLanguage: resp.DetectedLanguage,
+		TargetLanguage: targetLang,
+		RecordedAt:     time.Now().UTC(),
+	}
+	if translation != nil {
+		u.TranslatedText = translation.TranslatedText
+		if translation.TargetLanguage != "" {
+			u.TargetLanguage = translation.TargetLanguage
+		}
+	}
+	s.utteranceCount++
+
+	if err := s.transcripts.Append(s.ctx, s.ID, u); err != nil {
+		s.logger.Error("failed to record utterance", "error", err)
+	}
+}
+
+func (s *Session) publishCaption(resp *pb.ASRResponse, translation *pb.TranslateResponse, timings *utteranceTimings) {
+	c := Caption{
+		Transcript:     resp.Transcript,
+		Translation:    translation.TranslatedText,
+		SourceLanguage: translation.SourceLanguage,
+		TargetLanguage: translation.TargetLanguage,
+		IsFinal:        resp.IsFinal,
+	}
+	if resp.IsFinal {
+		c.StartMs = timings.AudioReceived.Sub(s.startedAt).Milliseconds()
+		c.EndMs = timings.FinalTranscript.Sub(s.startedAt).Milliseconds()
+	}
+	s.captions.publish(c)
+}
+
+func (s *Session) synthesizeAndStream(ctx context.Context, in <-chan *utterance, out chan<- []byte, targetLang string) {
 	defer close(out)
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case resp, ok := <-in:
+		case u, ok := <-in:
 			if !ok {
 				return
 			}
 
-			if resp.TranslatedText == "" {
-				continue
+			err := s.synthesize(ctx, u, out, targetLang)
+			endSpan(u.span, err)
+			if ctx.Err() != nil {
+				return
 			}
-
-			ttsStream, err := s.ttsClient.Synthesize(ctx, &pb.TTSRequest{
-				SessionId:    s.ID,
-				Text:         resp.TranslatedText,
-				LanguageCode: targetLang,
-			})
-			if err != nil {
-				s.logger.Error("TTS synthesis error", "error", err)
-				continue
+			if err == nil && u.timings != nil {
+				s.sendUtterance(u)
 			}
+		}
+	}
+}
 
-			for {
-				ttsResp, err := ttsStream.Recv()
-				if err != nil {
-					break
-				}
+func (s *Session) synthesize(ctx context.Context, u *utterance, out chan<- []byte, targetLang string) error {
+	resp := u.translation
+	if resp.TranslatedText == "" {
+		return nil
+	}
 
-				if ttsResp.Audio != nil && len(ttsResp.Audio.Data) > 0 {
-					select {
-					case out <- ttsResp.Audio.Data:
-					case <-ctx.Done():
-						return
-					}
-				}
+	if u.span != nil {
+		ctx = trace.ContextWithSpan(ctx, u.span)
+	}
+	ctx, span := tracer.Start(ctx, "tts.synthesize", trace.WithAttributes(
+		attribute.String("tts.language", targetLang),
+		attribute.Int("tts.characters", len([]rune(resp.TranslatedText))),
+	))
+
+	err := s.quotas.ConsumeTTS(s.principal.TenantID, utf8.RuneCountInString(resp.TranslatedText))
+	if err != nil {
+		s.logger.Warn("synthesis rejected by tenant quota", "error", err, "final", resp.IsFinal)
+		if resp.IsFinal {
+			s.sendUtteranceError(StageSynthesis, err, resp.TranslatedText, u.timings)
+		}
+	} else {
+		err = s.streamSynthesis(ctx, u, out, targetLang)
+	}
+	endSpan(span, err)
+	return err
+}
+
+func (s *Session) streamSynthesis(ctx context.Context, u *utterance, out chan<- []byte, targetLang string) error {
+	resp := u.translation
+	ttsStream, err := s.ttsClient.Synthesize(ctx, &pb.TTSRequest{
+		SessionId:    s.ID,
+		Text:         resp.TranslatedText,
+		LanguageCode: targetLang,
+	})
+	if err != nil {
+		s.logger.Error("TTS synthesis error", "error", err)
+		if resp.IsFinal {
+			s.sendUtteranceError(StageSynthesis, err, resp.TranslatedText, u.timings)
+		}
+		return err
+	}
 
-				if ttsResp.IsFinal {
-					break
+	metered := false
+	for {
+		ttsResp, err := ttsStream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error("TTS receive error", "error", err)
+				if resp.IsFinal {
+					s.sendUtteranceError(StageSynthesis, err, resp.TranslatedText, u.timings)
 				}
 			}
+			return err
+		}
+
+		if !metered {
+			s.meter.AddSynthesis(s.principal.TenantID, s.ID, ttsResp.VoiceTier, utf8.RuneCountInString(resp.TranslatedText))
+			metered = true
+		}
+
+		if ttsResp.Audio != nil && len(ttsResp.Audio.Data) > 0 {
+			u.timings.markAudio(time.Now())
+			select {
+			case out <- ttsResp.Audio.Data:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		if ttsResp.IsFinal {
+			return nil
 		}
 	}
 }
@@ -297,6 +683,8 @@ func (s *Session) streamAudioToClient(ctx context.Context, in <-chan []byte) {
 				s.logger.Error("failed to send audio to client", "error", err)
 				return
 			}
+			metrics.AudioBytes.WithLabelValues("out").Add(float64(len(audioData)))
+			s.currentRecorder().WriteOutbound(audioData)
 		}
 	}
 }
@@ -310,6 +698,18 @@ func (s *Session) Close() {
 	}
 
 	s.closed = true
-	close(s.audioChan)
+	s.cancel()
+	s.releaseQuota()
+	s.meter.EndSession(s.ID)
+	if err := s.recorder.Close(context.Background(), time.Now()); err != nil {
+		s.logger.Error("failed to finish recording", "error", err)
+	}
+	if s.transcripts != nil {
+		if err := s.transcripts.End(context.Background(), s.ID, time.Now().UTC()); err != nil {
+			s.logger.Error("failed to finish transcript", "error", err)
+		}
+	}
+	s.captions.close()
 	s.audioBuffer.Close()
+	s.logSummary()
 }
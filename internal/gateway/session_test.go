@@ -0,0 +1,201 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/auth"
+	"ai-translator/internal/metering"
+	"ai-translator/internal/quota"
+)
+
+type fakeConn struct {
+	mu     sync.Mutex
+	texts  []string
+	binary [][]byte
+	err    error
+}
+
+func (c *fakeConn) WriteText(text string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.texts = append(c.texts, text)
+	return nil
+}
+
+func (c *fakeConn) WriteBinary(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	c.binary = append(c.binary, data)
+	return nil
+}
+
+func (c *fakeConn) events(kind string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []string
+	for _, text := range c.texts {
+		if strings.Contains(text, `"type":"`+kind+`"`) {
+			out = append(out, text)
+		}
+	}
+	return out
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+var testPrincipal = &auth.Principal{Subject: "alice", TenantID: "acme", Method: auth.MethodAPIKey}
+
+func newTestManager(translator pb.TranslatorServiceClient, quotas *quota.Manager, meter *metering.Meter, resumeGrace time.Duration) *SessionManager {
+	if quotas == nil {
+		quotas = quota.NewManager(quota.Limits{})
+	}
+	return NewSessionManager(nil, translator, nil, quotas, meter, nil, nil, resumeGrace, DefaultIngestConfig(), discardLogger())
+}
+
+func newTestSession(t *testing.T, m *SessionManager, conn Conn) *Session {
+	t.Helper()
+	s, err := m.Create(context.Background(), "sess-"+t.Name(), testPrincipal, conn, discardLogger())
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	t.Cleanup(func() { m.Remove(s.ID) })
+	return s
+}
+
+func partial(text string) *pb.ASRResponse {
+	return &pb.ASRResponse{Transcript: text, DetectedLanguage: "en-US"}
+}
+
+func final(text string) *pb.ASRResponse {
+	return &pb.ASRResponse{Transcript: text, DetectedLanguage: "en-US", IsFinal: true}
+}
+
+func runTranslate(t *testing.T, s *Session, responses ...*pb.ASRResponse) []*utterance {
+	t.Helper()
+	in := make(chan *recognition)
+	out := make(chan *utterance)
+	go s.translateTranscripts(s.ctx, in, out, "es-ES")
+
+	var got []*utterance
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for u := range out {
+			got = append(got, u)
+		}
+	}()
+
+	for _, resp := range responses {
+		in <- &recognition{resp: resp, receivedAt: time.Now()}
+	}
+	close(in)
+	<-done
+	return got
+}
+
+func TestTranslationQuotaChargesEachUtteranceOnce(t *testing.T) {
+	quotas := quota.NewManager(quota.Limits{TranslationCharsPerDay: 1000})
+	translator := &fakeTranslatorClient{}
+	s := newTestSession(t, newTestManager(translator, quotas, nil, 0), &fakeConn{})
+
+	runTranslate(t, s,
+		partial("hello"),
+		partial("hello wor"),
+		partial("hello"),
+		partial("hello world"),
+		final("hello world!"),
+		partial("good"),
+		final("goodbye"),
+	)
+
+	if got := quotas.Status("acme").Usage.TranslationChars; got != int64(len("hello world!")+len("goodbye")) {
+		t.Errorf("charged %d characters, want %d", got, len("hello world!")+len("goodbye"))
+	}
+	if translator.calls.Load() != 7 {
+		t.Errorf("%d translations, want 7", translator.calls.Load())
+	}
+}
+
+func TestTranslationQuotaRejectsFinalOverLimit(t *testing.T) {
+	quotas := quota.NewManager(quota.Limits{TranslationCharsPerDay: 20})
+	conn := &fakeConn{}
+	translator := &fakeTranslatorClient{}
+	s := newTestSession(t, newTestManager(translator, quotas, nil, 0), conn)
+
+	got := runTranslate(t, s,
+		partial("hello"),
+		final("hello world"),
+		partial("this is"),
+		final("this is too long"),
+	)
+
+	if len(got) != 3 {
+		t.Fatalf("%d utterances forwarded, want 3", len(got))
+	}
+	if usage := quotas.Status("acme").Usage.TranslationChars; usage != 18 {
+		t.Errorf("charged %d characters, want 18", usage)
+	}
+	if errs := conn.events("error"); len(errs) != 1 || !strings.Contains(errs[0], "TENANT_QUOTA_EXCEEDED") {
+		t.Errorf("error events = %v, want one TENANT_QUOTA_EXCEEDED", errs)
+	}
+}
+
+type memorySink struct {
+	mu      sync.Mutex
+	records []metering.Record
+}
+
+func (s *memorySink) Write(ctx context.Context, batch metering.Batch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records = append(s.records, batch.Records...)
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+func TestTranslationMeteredOncePerUtterance(t *testing.T) {
+	sink := &memorySink{}
+	meter, err := metering.NewMeter(sink, "", discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newTestSession(t, newTestManager(&fakeTranslatorClient{}, nil, meter, 0), &fakeConn{})
+
+	runTranslate(t, s,
+		partial("hello"),
+		partial("hello wor"),
+		partial("hello world"),
+		final("hello world"),
+		partial("bye"),
+		final("bye now"),
+	)
+	if err := meter.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var total float64
+	for _, r := range sink.records {
+		if r.Metric == metering.MetricTranslationChars {
+			total += r.Quantity
+		}
+	}
+	if want := float64(len("hello world") + len("bye now")); total != want {
+		t.Errorf("metered %v translation characters, want %v", total, want)
+	}
+}
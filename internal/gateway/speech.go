@@ -0,0 +1,251 @@
+package gateway
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/audio"
+	"ai-translator/internal/auth"
+	"ai-translator/internal/metering"
+	"ai-translator/internal/metrics"
+	"ai-translator/internal/quota"
+	"ai-translator/internal/util"
+)
+
+const (
+	FormatWAV = "wav"
+	FormatOgg = "ogg"
+	FormatMP3 = "mp3"
+	FormatPCM = "pcm"
+)
+
+const (
+	maxSpeechRequestSize = 64 << 10
+	maxSpeechTextBytes   = 5000
+	speechWriteTimeout   = 10 * time.Second
+)
+
+type speechFormat struct {
+	name        string
+	contentType string
+	mediaTypes  []string
+	encoding    pb.AudioEncoding
+}
+
+var speechFormats = []speechFormat{
+	{FormatWAV, "audio/wav", []string{"audio/wav", "audio/wave", "audio/x-wav", "audio/vnd.wave"}, pb.AudioEncoding_AUDIO_ENCODING_LINEAR16},
+	{FormatOgg, "audio/ogg; codecs=opus", []string{"audio/ogg", "audio/opus"}, pb.AudioEncoding_AUDIO_ENCODING_OGG_OPUS},
+	{FormatMP3, "audio/mpeg", []string{"audio/mpeg", "audio/mp3"}, pb.AudioEncoding_AUDIO_ENCODING_MP3},
+	{FormatPCM, fmt.Sprintf("audio/L16; rate=%d; channels=%d", audio.SampleRate, audio.Channels), []string{"audio/l16", "audio/pcm"}, pb.AudioEncoding_AUDIO_ENCODING_LINEAR16},
+}
+
+type SpeechRequest struct {
+	Text         string  `json:"text"`
+	LanguageCode string  `json:"language_code"`
+	VoiceName    string  `json:"voice_name,omitempty"`
+	SpeakingRate float32 `json:"speaking_rate,omitempty"`
+	Pitch        float32 `json:"pitch,omitempty"`
+}
+
+type SpeechHandler struct {
+	ttsClient pb.TTSServiceClient
+	quotas    *quota.Manager
+	meter     *metering.Meter
+	logger    *slog.Logger
+}
+
+func NewSpeechHandler(ttsClient pb.TTSServiceClient, quotas *quota.Manager, meter *metering.Meter, logger *slog.Logger) *SpeechHandler {
+	return &SpeechHandler{
+		ttsClient: ttsClient,
+		quotas:    quotas,
+		meter:     meter,
+		logger:    logger,
+	}
+}
+
+func (h *SpeechHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		authError(w, auth.ErrNoCredentials)
+		return
+	}
+
+	var format speechFormat
+	if name := r.URL.Query().Get("format"); name != "" {
+		format, ok = speechFormatByName(name)
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, "unknown format "+name+", expected wav, ogg, mp3 or pcm")
+			return
+		}
+	} else if format, ok = negotiateSpeechFormat(r.Header.Get("Accept")); !ok {
+		writeJSONError(w, http.StatusNotAcceptable, "supported types are audio/wav, audio/ogg, audio/mpeg and audio/L16")
+		return
+	}
+
+	var body SpeechRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSpeechRequestSize)).Decode(&body); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		writeJSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := body.validate(); err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	requestID := "tts_" + util.NewRequestID()
+	logger := h.logger.With("request_id", requestID, "tenant_id", principal.TenantID, "format", format.name)
+	defer h.meter.EndSession(requestID)
+
+	rc := http.NewResponseController(w)
+	var out io.Writer
+	start := func() error {
+		out = w
+		w.Header().Set("Content-Type", format.contentType)
+		w.Header().Set("Vary", "Accept")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		if format.name != FormatWAV {
+			return nil
+		}
+		wav, err := audio.NewWAVWriter(w, audio.SampleRate, audio.Channels)
+		if err != nil {
+			return err
+		}
+		out = wav
+		return nil
+	}
+
+	req := &pb.TTSRequest{
+		SessionId:    requestID,
+		Text:         body.Text,
+		LanguageCode: body.LanguageCode,
+		Encoding:     format.encoding,
+	}
+	if body.VoiceName != "" || body.SpeakingRate != 0 || body.Pitch != 0 {
+		req.VoiceConfig = &pb.VoiceConfig{
+			VoiceName:    body.VoiceName,
+			SpeakingRate: body.SpeakingRate,
+			Pitch:        body.Pitch,
+		}
+	}
+
+	var writeErr error
+	err := synthesizeText(r.Context(), h.ttsClient, h.quotas, h.meter, principal.TenantID, req, func(chunk []byte) error {
+		rc.SetWriteDeadline(time.Now().Add(speechWriteTimeout))
+		if out == nil {
+			writeErr = start()
+		}
+		if writeErr == nil {
+			_, writeErr = out.Write(chunk)
+		}
+		if writeErr == nil {
+			writeErr = rc.Flush()
+		}
+		return writeErr
+	})
+	metrics.SpeechRequests.WithLabelValues(format.name, metrics.Outcome(err)).Inc()
+
+	switch {
+	case err == nil:
+		rc.SetWriteDeadline(time.Now().Add(speechWriteTimeout))
+		if out == nil {
+			err = start()
+		}
+		if wav, ok := out.(*audio.WAVWriter); ok && err == nil {
+			err = wav.Close()
+		}
+		if err != nil {
+			logger.Debug("speech client went away", "error", err)
+		}
+	case writeErr != nil || r.Context().Err() != nil:
+		logger.Debug("speech client went away", "error", err)
+	case out != nil:
+		logger.Error("speech synthesis failed mid-stream", "error", err)
+		panic(http.ErrAbortHandler)
+	case quota.IsQuotaExhausted(err) || quota.IsRateLimit(err) || quota.IsTooLarge(err):
+		logger.Warn("speech rejected by tenant quota", "error", err)
+		writeErrorCode(w, err)
+	default:
+		logger.Error("speech synthesis failed", "error", err, "code", ErrorCodeFromError(err))
+		writeErrorCode(w, err)
+	}
+}
+
+func (b SpeechRequest) validate() error {
+	switch {
+	case strings.TrimSpace(b.Text) == "":
+		return fmt.Errorf("text is required")
+	case len(b.Text) > maxSpeechTextBytes:
+		return fmt.Errorf("text must be at most %d bytes", maxSpeechTextBytes)
+	case b.LanguageCode == "":
+		return fmt.Errorf("language_code is required")
+	case b.SpeakingRate < 0:
+		return fmt.Errorf("speaking_rate must not be negative")
+	}
+	return nil
+}
+
+func speechFormatByName(name string) (speechFormat, bool) {
+	for _, f := range speechFormats {
+		if strings.EqualFold(f.name, name) {
+			return f, true
+		}
+	}
+	return speechFormat{}, false
+}
+
+func negotiateSpeechFormat(accept string) (speechFormat, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return speechFormats[0], true
+	}
+
+	var best speechFormat
+	bestQ := 0.0
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q <= bestQ {
+			continue
+		}
+		if f, ok := speechFormatForMediaType(mediaType); ok {
+			best, bestQ = f, q
+		}
+	}
+	return best, bestQ > 0
+}
+
+func speechFormatForMediaType(mediaType string) (speechFormat, bool) {
+	if mediaType == "*/*" || mediaType == "audio/*" {
+		return speechFormats[0], true
+	}
+	for _, f := range speechFormats {
+		for _, t := range f.mediaTypes {
+			if mediaType == t {
+				return f, true
+			}
+		}
+	}
+	return speechFormat{}, false
+}
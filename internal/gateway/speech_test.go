@@ -0,0 +1,230 @@
+package gateway
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/auth"
+	"ai-translator/internal/quota"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type chanTTSStream struct {
+	grpc.ClientStream
+	ctx    context.Context
+	chunks <-chan *pb.TTSResponse
+	end    error
+}
+
+func (s *chanTTSStream) Recv() (*pb.TTSResponse, error) {
+	select {
+	case resp, ok := <-s.chunks:
+		if !ok {
+			if s.end != nil {
+				return nil, s.end
+			}
+			return nil, io.EOF
+		}
+		return resp, nil
+	case <-s.ctx.Done():
+		return nil, status.FromContextError(s.ctx.Err()).Err()
+	}
+}
+
+type chanTTSClient struct {
+	pb.TTSServiceClient
+	chunks chan *pb.TTSResponse
+	err    error
+	end    error
+	req    *pb.TTSRequest
+}
+
+func (c *chanTTSClient) Synthesize(ctx context.Context, in *pb.TTSRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[pb.TTSResponse], error) {
+	c.req = in
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &chanTTSStream{ctx: ctx, chunks: c.chunks, end: c.end}, nil
+}
+
+func speechChunk(n int, final bool) *pb.TTSResponse {
+	return &pb.TTSResponse{Audio: &pb.AudioChunk{Data: make([]byte, n)}, IsFinal: final, VoiceTier: "standard"}
+}
+
+func speechServer(t *testing.T, client *chanTTSClient, quotas *quota.Manager) *httptest.Server {
+	t.Helper()
+	if quotas == nil {
+		quotas = quota.NewManager(quota.Limits{})
+	}
+	h := NewSpeechHandler(client, quotas, nil, discardLogger())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), testPrincipal)))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func postSpeech(t *testing.T, srv *httptest.Server, query, accept, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/v1/speech"+query, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("POST /v1/speech: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+const speechBody = `{"text": "Hola, ¿en qué puedo ayudarte?", "language_code": "es-ES"}`
+
+func TestSpeechStreamsChunksAsTheyArrive(t *testing.T) {
+	client := &chanTTSClient{chunks: make(chan *pb.TTSResponse)}
+	srv := speechServer(t, client, nil)
+
+	go func() { client.chunks <- speechChunk(3200, false) }()
+	resp := postSpeech(t, srv, "?format=pcm", "", speechBody)
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "audio/L16") {
+		t.Fatalf("status = %d, Content-Type = %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	first := make([]byte, 3200)
+	if _, err := io.ReadFull(resp.Body, first); err != nil {
+		t.Fatalf("read first chunk before the rest was synthesized: %v", err)
+	}
+
+	client.chunks <- speechChunk(3200, true)
+	rest, err := io.ReadAll(resp.Body)
+	if err != nil || len(rest) != 3200 {
+		t.Fatalf("rest = %d bytes, %v", len(rest), err)
+	}
+}
+
+func TestSpeechOutlivesServerWriteTimeout(t *testing.T) {
+	client := &chanTTSClient{chunks: make(chan *pb.TTSResponse, 2)}
+	h := NewSpeechHandler(client, quota.NewManager(quota.Limits{}), nil, discardLogger())
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), testPrincipal)))
+	}))
+	srv.Config.WriteTimeout = 100 * time.Millisecond
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	go func() {
+		client.chunks <- speechChunk(3200, false)
+		time.Sleep(200 * time.Millisecond)
+		client.chunks <- speechChunk(3200, true)
+	}()
+	resp := postSpeech(t, srv, "?format=pcm", "", speechBody)
+	data, err := io.ReadAll(resp.Body)
+	if err != nil || len(data) != 6400 {
+		t.Fatalf("read %d bytes, %v, want the whole synthesis", len(data), err)
+	}
+}
+
+func TestSpeechWAVHeader(t *testing.T) {
+	client := &chanTTSClient{chunks: make(chan *pb.TTSResponse, 1)}
+	client.chunks <- speechChunk(3200, true)
+	srv := speechServer(t, client, nil)
+
+	resp := postSpeech(t, srv, "", "audio/x-wav", speechBody)
+	data, _ := io.ReadAll(resp.Body)
+	if resp.Header.Get("Content-Type") != "audio/wav" || len(data) != 44+3200 || string(data[:4]) != "RIFF" {
+		t.Fatalf("Content-Type = %q, %d bytes", resp.Header.Get("Content-Type"), len(data))
+	}
+	if client.req.Encoding != pb.AudioEncoding_AUDIO_ENCODING_LINEAR16 || client.req.LanguageCode != "es-ES" {
+		t.Fatalf("TTS request = %v", client.req)
+	}
+}
+
+func TestSpeechRejectsBeforeAudio(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		accept string
+		body   string
+		err    error
+		quotas *quota.Manager
+		want   int
+	}{
+		{"unknown format", "?format=flac", "", speechBody, nil, nil, http.StatusBadRequest},
+		{"not acceptable", "", "text/html", speechBody, nil, nil, http.StatusNotAcceptable},
+		{"missing text", "", "", `{"language_code": "es-ES"}`, nil, nil, http.StatusBadRequest},
+		{"missing language", "", "", `{"text": "hola"}`, nil, nil, http.StatusBadRequest},
+		{"tts quota", "", "", speechBody, nil, quota.NewManager(quota.Limits{TTSCharsPerDay: 5}), http.StatusTooManyRequests},
+		{"backend down", "", "", speechBody, status.Error(codes.Unavailable, "down"), nil, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &chanTTSClient{chunks: make(chan *pb.TTSResponse), err: tt.err}
+			resp := postSpeech(t, speechServer(t, client, tt.quotas), tt.query, tt.accept, tt.body)
+			if resp.StatusCode != tt.want {
+				body, _ := io.ReadAll(resp.Body)
+				t.Fatalf("status = %d, want %d: %s", resp.StatusCode, tt.want, body)
+			}
+		})
+	}
+}
+
+func TestSpeechAbortsWhenSynthesisFailsMidStream(t *testing.T) {
+	client := &chanTTSClient{chunks: make(chan *pb.TTSResponse, 1), end: status.Error(codes.Unavailable, "down")}
+	client.chunks <- speechChunk(3200, false)
+	srv := speechServer(t, client, nil)
+
+	resp := postSpeech(t, srv, "?format=pcm", "", speechBody)
+	first := make([]byte, 3200)
+	if _, err := io.ReadFull(resp.Body, first); err != nil {
+		t.Fatalf("read first chunk: %v", err)
+	}
+	close(client.chunks)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.ReadAll(resp.Body)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("truncated response ended cleanly")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("response did not end")
+	}
+}
+
+func TestNegotiateSpeechFormat(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"", FormatWAV, true},
+		{"*/*", FormatWAV, true},
+		{"audio/mpeg", FormatMP3, true},
+		{"audio/ogg;q=0.5, audio/mpeg;q=0.9", FormatMP3, true},
+		{"audio/L16; rate=16000", FormatPCM, true},
+		{"text/html", "", false},
+		{"audio/mpeg;q=0", "", false},
+	}
+	for _, tt := range tests {
+		f, ok := negotiateSpeechFormat(tt.accept)
+		if ok != tt.ok || f.name != tt.want {
+			t.Errorf("negotiateSpeechFormat(%q) = %q %v, want %q %v", tt.accept, f.name, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package gateway
+
+import (
+	"context"
+	"time"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/auth"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("ai-translator/internal/gateway")
+
+func startSessionSpan(ctx context.Context, sessionID string, principal *auth.Principal) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "gateway.session",
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("session.id", sessionID),
+			attribute.String("tenant.id", principal.TenantID),
+			attribute.String("auth.method", principal.Method),
+		),
+	)
+}
+
+func startUtteranceSpan(ctx context.Context, sessionID string, resp *pb.ASRResponse, start time.Time) (context.Context, trace.Span) {
+	uctx, span := tracer.Start(ctx, "gateway.utterance",
+		trace.WithNewRoot(),
+		trace.WithLinks(trace.LinkFromContext(ctx, attribute.String("link.type", "session"))),
+		trace.WithTimestamp(start),
+		trace.WithAttributes(
+			attribute.String("session.id", sessionID),
+			attribute.String("asr.detected_language", resp.DetectedLanguage),
+			attribute.Int("asr.characters", len([]rune(resp.Transcript))),
+		),
+	)
+
+	_, asrSpan := tracer.Start(uctx, "asr.final", trace.WithTimestamp(start), trace.WithAttributes(
+		attribute.Float64("asr.stability", float64(resp.Stability)),
+	))
+	asrSpan.End()
+
+	return uctx, span
+}
+
+func endSpan(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
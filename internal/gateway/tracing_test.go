@@ -0,0 +1,187 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/quota"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	grpccodes "google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	recorderOnce sync.Once
+	recorder     *tracetest.SpanRecorder
+)
+
+func recordSpans() *tracetest.SpanRecorder {
+	recorderOnce.Do(func() {
+		recorder = tracetest.NewSpanRecorder()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	})
+	return recorder
+}
+
+func endedSpans(traceID trace.TraceID) map[string]sdktrace.ReadOnlySpan {
+	spans := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range recordSpans().Ended() {
+		if span.SpanContext().TraceID() == traceID {
+			spans[span.Name()] = span
+		}
+	}
+	return spans
+}
+
+func attr(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if string(kv.Key) == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+type tracingTranslatorClient struct {
+	fakeTranslatorClient
+	mu   sync.Mutex
+	seen []trace.SpanContext
+}
+
+func (c *tracingTranslatorClient) Translate(ctx context.Context, in *pb.TranslateRequest, opts ...grpc.CallOption) (*pb.TranslateResponse, error) {
+	c.mu.Lock()
+	c.seen = append(c.seen, trace.SpanContextFromContext(ctx))
+	c.mu.Unlock()
+	return c.fakeTranslatorClient.Translate(ctx, in, opts...)
+}
+
+func newTracedSession(t *testing.T, translator pb.TranslatorServiceClient) (*Session, trace.Span) {
+	t.Helper()
+	recordSpans()
+	m := NewSessionManager(nil, translator, &fakeTTSClient{delays: []time.Duration{0}}, quota.NewManager(quota.Limits{}), nil, nil, nil, 0, DefaultIngestConfig(), discardLogger())
+	ctx, sessionSpan := startSessionSpan(context.Background(), "sess-"+t.Name(), testPrincipal)
+	s, err := m.Create(ctx, "sess-"+t.Name(), testPrincipal, &fakeConn{}, discardLogger())
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	t.Cleanup(func() {
+		m.Remove(s.ID)
+		sessionSpan.End()
+	})
+	return s, sessionSpan
+}
+
+func TestSessionSpanAttributes(t *testing.T) {
+	recordSpans()
+	_, span := startSessionSpan(context.Background(), "sess-1", testPrincipal)
+	span.End()
+
+	got := endedSpans(span.SpanContext().TraceID())["gateway.session"]
+	if got == nil {
+		t.Fatal("session span was not recorded")
+	}
+	if got.SpanKind() != trace.SpanKindServer {
+		t.Errorf("kind = %v, want server", got.SpanKind())
+	}
+	if attr(got, "session.id").AsString() != "sess-1" || attr(got, "tenant.id").AsString() != "acme" || attr(got, "auth.method").AsString() != testPrincipal.Method {
+		t.Errorf("attributes = %v", got.Attributes())
+	}
+}
+
+func TestUtteranceTraceLinksStages(t *testing.T) {
+	translator := &tracingTranslatorClient{}
+	s, sessionSpan := newTracedSession(t, translator)
+
+	utterances := runTranslate(t, s, partial("hello"), final("hello world"))
+	if len(utterances) != 2 {
+		t.Fatalf("%d utterances, want 2", len(utterances))
+	}
+	in := make(chan *utterance, len(utterances))
+	for _, u := range utterances {
+		in <- u
+	}
+	close(in)
+	s.synthesizeAndStream(s.ctx, in, make(chan []byte, 10), "es-ES")
+
+	final := utterances[1]
+	traceID := final.span.SpanContext().TraceID()
+	if traceID == sessionSpan.SpanContext().TraceID() {
+		t.Fatal("utterance shares the session trace, want a trace of its own")
+	}
+	spans := endedSpans(traceID)
+
+	root := spans["gateway.utterance"]
+	if root == nil {
+		t.Fatalf("utterance span was not ended; recorded %v", spans)
+	}
+	if root.Parent().IsValid() {
+		t.Errorf("utterance span has parent %v, want a new root", root.Parent())
+	}
+	if links := root.Links(); len(links) != 1 || links[0].SpanContext.SpanID() != sessionSpan.SpanContext().SpanID() {
+		t.Errorf("links = %v, want one to the session span", links)
+	}
+	if attr(root, "session.id").AsString() != s.ID || attr(root, "translation.engine").AsString() != "fake" {
+		t.Errorf("utterance attributes = %v", root.Attributes())
+	}
+	if root.Status().Code != codes.Unset {
+		t.Errorf("utterance status = %v", root.Status())
+	}
+
+	for _, name := range []string{"asr.final", "tts.synthesize"} {
+		span := spans[name]
+		if span == nil || span.Parent().SpanID() != root.SpanContext().SpanID() {
+			t.Errorf("%s is not a child of the utterance span", name)
+		}
+	}
+
+	if len(translator.seen) != 2 {
+		t.Fatalf("%d translate calls, want 2", len(translator.seen))
+	}
+	if translator.seen[0].TraceID() != sessionSpan.SpanContext().TraceID() {
+		t.Errorf("partial translation ran in trace %v, want the session trace", translator.seen[0].TraceID())
+	}
+	if translator.seen[1].SpanID() != root.SpanContext().SpanID() {
+		t.Errorf("final translation ran in span %v, want the utterance span", translator.seen[1].SpanID())
+	}
+}
+
+func TestUtteranceSpanRecordsTranslationFailure(t *testing.T) {
+	down := status.Error(grpccodes.Unavailable, "translator down")
+	s, _ := newTracedSession(t, &tracingTranslatorClient{fakeTranslatorClient: fakeTranslatorClient{errs: []error{down}}})
+	before := len(recordSpans().Ended())
+
+	if got := runTranslate(t, s, final("hello world")); len(got) != 0 {
+		t.Fatalf("%d utterances, want none", len(got))
+	}
+
+	var root sdktrace.ReadOnlySpan
+	for _, span := range recordSpans().Ended()[before:] {
+		if span.Name() == "gateway.utterance" && attr(span, "session.id").AsString() == s.ID {
+			root = span
+		}
+	}
+	if root == nil {
+		t.Fatal("utterance span was not ended after the failure")
+	}
+	if root.Status().Code != codes.Error || root.Status().Description != down.Error() {
+		t.Fatalf("status = %+v, want the translation error", root.Status())
+	}
+	if len(root.Events()) == 0 || root.Events()[0].Name != "exception" {
+		t.Fatalf("events = %v, want the recorded error", root.Events())
+	}
+}
+
+func TestEndSpanToleratesNil(t *testing.T) {
+	endSpan(nil, errors.New("boom"))
+}
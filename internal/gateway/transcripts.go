@@ -0,0 +1,70 @@
+package gateway
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"ai-translator/internal/auth"
+	"ai-translator/internal/transcript"
+)
+
+type TranscriptHandler struct {
+	store  transcript.Store
+	logger *slog.Logger
+}
+
+func NewTranscriptHandler(store transcript.Store, logger *slog.Logger) *TranscriptHandler {
+	return &TranscriptHandler{store: store, logger: logger}
+}
+
+func (h *TranscriptHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		authError(w, auth.ErrNoCredentials)
+		return
+	}
+
+	t, err := h.store.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		if errors.Is(err, transcript.ErrNotFound) || errors.Is(err, transcript.ErrInvalidSessionID) {
+			writeJSONError(w, http.StatusNotFound, "transcript not found")
+			return
+		}
+		h.logger.Error("failed to load transcript", "error", err, "session_id", r.PathValue("id"))
+		writeJSONError(w, http.StatusInternalServerError, "failed to load transcript")
+		return
+	}
+	if t.TenantID != principal.TenantID {
+		writeJSONError(w, http.StatusNotFound, "transcript not found")
+		return
+	}
+
+	writeTranscript(w, r, t, h.logger)
+}
+
+func writeTranscript(w http.ResponseWriter, r *http.Request, t *transcript.Transcript, logger *slog.Logger) {
+	query := r.URL.Query()
+	mode, err := transcript.ParseLanguageMode(query.Get("lang"))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch format := query.Get("format"); format {
+	case "", transcript.FormatJSON:
+		writeJSON(w, http.StatusOK, t)
+	case transcript.FormatSRT:
+		w.Header().Set("Content-Type", "application/x-subrip; charset=utf-8")
+		if err := transcript.WriteSRT(w, t, mode); err != nil {
+			logger.Error("failed to write transcript", "error", err, "format", format)
+		}
+	case transcript.FormatWebVTT:
+		w.Header().Set("Content-Type", "text/vtt; charset=utf-8")
+		if err := transcript.WriteWebVTT(w, t, mode); err != nil {
+			logger.Error("failed to write transcript", "error", err, "format", format)
+		}
+	default:
+		writeJSONError(w, http.StatusBadRequest, "unknown format "+format+", expected json, srt or vtt")
+	}
+}
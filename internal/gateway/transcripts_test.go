@@ -0,0 +1,119 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ai-translator/internal/auth"
+	"ai-translator/internal/quota"
+	"ai-translator/internal/transcript"
+)
+
+func transcriptRequest(t *testing.T, store transcript.Store, principal *auth.Principal, target string) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle("GET /v1/sessions/{id}/transcript", NewTranscriptHandler(store, discardLogger()))
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if principal != nil {
+		req = req.WithContext(auth.NewContext(req.Context(), principal))
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+func storedTranscript(t *testing.T) transcript.Store {
+	t.Helper()
+	ctx := context.Background()
+	store := transcript.NewMemoryStore()
+	store.Begin(ctx, "sess-1", "acme", time.Now())
+	store.Append(ctx, "sess-1", transcript.Utterance{StartMs: 0, EndMs: 1500, SourceText: "hello", TranslatedText: "hola"})
+	return store
+}
+
+func TestTranscriptFormats(t *testing.T) {
+	store := storedTranscript(t)
+	tests := []struct {
+		query       string
+		contentType string
+		body        string
+	}{
+		{"", "application/json", `"source_text":"hello"`},
+		{"?format=srt", "application/x-subrip; charset=utf-8", "1\n00:00:00,000 --> 00:00:01,500\nhello\nhola\n"},
+		{"?format=vtt&lang=target", "text/vtt; charset=utf-8", "WEBVTT\n\n1\n00:00:00.000 --> 00:00:01.500\nhola\n"},
+	}
+	for _, tt := range tests {
+		rec := transcriptRequest(t, store, testPrincipal, "/v1/sessions/sess-1/transcript"+tt.query)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", tt.query, rec.Code, rec.Body)
+		}
+		if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, tt.contentType) {
+			t.Errorf("%s: Content-Type = %q, want %q", tt.query, got, tt.contentType)
+		}
+		if !strings.Contains(rec.Body.String(), tt.body) {
+			t.Errorf("%s: body = %q, want it to contain %q", tt.query, rec.Body, tt.body)
+		}
+	}
+}
+
+func TestTranscriptRejectsBadQueries(t *testing.T) {
+	store := storedTranscript(t)
+	for _, query := range []string{"?format=docx", "?format=srt&lang=es"} {
+		if rec := transcriptRequest(t, store, testPrincipal, "/v1/sessions/sess-1/transcript"+query); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestTranscriptAccess(t *testing.T) {
+	store := storedTranscript(t)
+	other := &auth.Principal{Subject: "mallory", TenantID: "globex", Method: auth.MethodAPIKey}
+
+	if rec := transcriptRequest(t, store, nil, "/v1/sessions/sess-1/transcript"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous status %d, want 401", rec.Code)
+	}
+	if rec := transcriptRequest(t, store, other, "/v1/sessions/sess-1/transcript"); rec.Code != http.StatusNotFound {
+		t.Errorf("other tenant status %d, want 404", rec.Code)
+	}
+	if rec := transcriptRequest(t, store, testPrincipal, "/v1/sessions/missing/transcript"); rec.Code != http.StatusNotFound {
+		t.Errorf("missing session status %d, want 404", rec.Code)
+	}
+}
+
+func TestSessionRecordsTranscript(t *testing.T) {
+	store := transcript.NewMemoryStore()
+	m := NewSessionManager(nil, &fakeTranslatorClient{}, nil, quota.NewManager(quota.Limits{}), nil, store, nil, 0, DefaultIngestConfig(), discardLogger())
+	s := newTestSession(t, m, &fakeConn{})
+	s.SetLanguages("en-US", "es-ES")
+	s.startedAt = s.startedAt.Add(-2 * time.Second)
+	s.markAudioReceived(time.Now())
+
+	runTranslate(t, s, partial("hello"), final("hello world"), final("bye"))
+	m.Remove(s.ID)
+
+	got, err := store.Get(context.Background(), s.ID)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.TenantID != "acme" || got.EndedAt == nil {
+		t.Fatalf("transcript = %+v, want it owned by acme and ended", got)
+	}
+	if len(got.Utterances) != 2 {
+		t.Fatalf("utterances = %+v, want the two finals", got.Utterances)
+	}
+	first := got.Utterances[0]
+	if first.Index != 0 || first.SourceText != "hello world" || first.TranslatedText != "hola" || first.SourceLanguage != "en-US" || first.TargetLanguage != "es-ES" {
+		t.Fatalf("first utterance = %+v", first)
+	}
+	if first.StartMs < 2000 || first.StartMs > 3000 || first.EndMs < first.StartMs {
+		t.Fatalf("first utterance spans %d-%d ms, want it to start 2000ms into the session", first.StartMs, first.EndMs)
+	}
+	if got.Utterances[1].Index != 1 {
+		t.Fatalf("second utterance index = %d", got.Utterances[1].Index)
+	}
+}
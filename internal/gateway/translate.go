@@ -0,0 +1,395 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/audio"
+	"ai-translator/internal/auth"
+	"ai-translator/internal/metering"
+	"ai-translator/internal/metrics"
+	"ai-translator/internal/quota"
+	"ai-translator/internal/util"
+)
+
+const (
+	AudioNone   = ""
+	AudioBase64 = "base64"
+	AudioStream = "stream"
+)
+
+const (
+	AudioFormatPCM     = "pcm_s16le"
+	maxTextRequestSize = 1 << 20
+	maxTextSegments    = 100
+	textWriteTimeout   = 10 * time.Second
+)
+
+type TextTranslateRequest struct {
+	SessionID      string   `json:"session_id,omitempty"`
+	SourceLanguage string   `json:"source_language,omitempty"`
+	TargetLanguage string   `json:"target_language"`
+	Segments       []string `json:"segments"`
+	Formality      string   `json:"formality,omitempty"`
+	Domain         string   `json:"domain,omitempty"`
+	Audience       string   `json:"audience,omitempty"`
+	Audio          string   `json:"audio,omitempty"`
+}
+
+type SegmentError struct {
+	Stage   string `json:"stage"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type TextTranslation struct {
+	Index            int           `json:"index"`
+	Text             string        `json:"text"`
+	Translation      string        `json:"translation"`
+	DetectedLanguage string        `json:"detected_language,omitempty"`
+	TargetLanguage   string        `json:"target_language,omitempty"`
+	Engine           string        `json:"engine,omitempty"`
+	Audio            []byte        `json:"audio,omitempty"`
+	Error            *SegmentError `json:"error,omitempty"`
+}
+
+type TextTranslateResponse struct {
+	SessionID        string            `json:"session_id"`
+	DetectedLanguage string            `json:"detected_language,omitempty"`
+	TargetLanguage   string            `json:"target_language"`
+	AudioFormat      string            `json:"audio_format,omitempty"`
+	SampleRate       int               `json:"sample_rate,omitempty"`
+	Translations     []TextTranslation `json:"translations"`
+}
+
+type textTranslationEvent struct {
+	Type string `json:"type"`
+	TextTranslation
+}
+
+type textAudioEvent struct {
+	Type  string `json:"type"`
+	Index int    `json:"index"`
+	Data  []byte `json:"data"`
+}
+
+type textDoneEvent struct {
+	Type             string `json:"type"`
+	SessionID        string `json:"session_id"`
+	DetectedLanguage string `json:"detected_language,omitempty"`
+	TargetLanguage   string `json:"target_language"`
+	AudioFormat      string `json:"audio_format"`
+	SampleRate       int    `json:"sample_rate"`
+}
+
+type textErrorEvent struct {
+	Type  string `json:"type"`
+	Index *int   `json:"index,omitempty"`
+	SegmentError
+}
+
+type TextHandler struct {
+	translatorClient pb.TranslatorServiceClient
+	ttsClient        pb.TTSServiceClient
+	quotas           *quota.Manager
+	meter            *metering.Meter
+	logger           *slog.Logger
+}
+
+func NewTextHandler(translatorClient pb.TranslatorServiceClient, ttsClient pb.TTSServiceClient, quotas *quota.Manager, meter *metering.Meter, logger *slog.Logger) *TextHandler {
+	return &TextHandler{
+		translatorClient: translatorClient,
+		ttsClient:        ttsClient,
+		quotas:           quotas,
+		meter:            meter,
+		logger:           logger,
+	}
+}
+
+type textRequest struct {
+	TextTranslateRequest
+	tenantID  string
+	requestID string
+	contextID string
+	style     *pb.TranslationStyle
+	logger    *slog.Logger
+}
+
+func (h *TextHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		authError(w, auth.ErrNoCredentials)
+		return
+	}
+
+	var body TextTranslateRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTextRequestSize)).Decode(&body); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeJSONError(w, http.StatusRequestEntityTooLarge, "request body too large")
+			return
+		}
+		writeJSONError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	style, err := translationStyle(body.Formality, body.Domain, body.Audience)
+	if err == nil {
+		err = body.validate()
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if body.SessionID == "" {
+		body.SessionID = "txt_" + util.NewRequestID()
+	}
+	req := &textRequest{
+		TextTranslateRequest: body,
+		tenantID:             principal.TenantID,
+		requestID:            "txt_" + util.NewRequestID(),
+		contextID:            principal.TenantID + "/" + body.SessionID,
+		style:                style,
+	}
+	req.logger = h.logger.With("session_id", body.SessionID, "request_id", req.requestID, "tenant_id", principal.TenantID)
+	defer h.meter.EndSession(req.requestID)
+
+	if body.Audio == AudioStream {
+		h.stream(w, r, req)
+		return
+	}
+	h.respond(w, r, req)
+}
+
+func (b TextTranslateRequest) validate() error {
+	switch {
+	case b.TargetLanguage == "":
+		return fmt.Errorf("target_language is required")
+	case len(b.Segments) == 0:
+		return fmt.Errorf("segments must not be empty")
+	case len(b.Segments) > maxTextSegments:
+		return fmt.Errorf("at most %d segments are allowed per request", maxTextSegments)
+	case b.Audio != AudioNone && b.Audio != AudioBase64 && b.Audio != AudioStream:
+		return fmt.Errorf("unknown audio mode %q, expected base64 or stream", b.Audio)
+	}
+	return nil
+}
+
+func (h *TextHandler) respond(w http.ResponseWriter, r *http.Request, req *textRequest) {
+	resp := TextTranslateResponse{
+		SessionID:      req.SessionID,
+		TargetLanguage: req.TargetLanguage,
+		Translations:   make([]TextTranslation, 0, len(req.Segments)),
+	}
+	if req.Audio == AudioBase64 {
+		resp.AudioFormat = AudioFormatPCM
+		resp.SampleRate = audio.SampleRate
+	}
+
+	for i, text := range req.Segments {
+		t, err := h.translate(r.Context(), req, i, text)
+		if err == nil && req.Audio == AudioBase64 {
+			err = h.synthesizeSegment(r.Context(), req, &t, func(chunk []byte) error {
+				t.Audio = append(t.Audio, chunk...)
+				return nil
+			})
+		}
+		if err != nil {
+			h.abort(w, req, err)
+			return
+		}
+		if resp.DetectedLanguage == "" {
+			resp.DetectedLanguage = t.DetectedLanguage
+		}
+		resp.Translations = append(resp.Translations, t)
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (h *TextHandler) stream(w http.ResponseWriter, r *http.Request, req *textRequest) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	send := func(event any) error {
+		rc.SetWriteDeadline(time.Now().Add(textWriteTimeout))
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	done := textDoneEvent{
+		Type:           "done",
+		SessionID:      req.SessionID,
+		TargetLanguage: req.TargetLanguage,
+		AudioFormat:    AudioFormatPCM,
+		SampleRate:     audio.SampleRate,
+	}
+	for i, text := range req.Segments {
+		t, err := h.translate(r.Context(), req, i, text)
+		if err != nil {
+			h.abortStream(r.Context(), req, send, i, StageTranslation, err)
+			return
+		}
+		if err := send(textTranslationEvent{Type: "translation", TextTranslation: t}); err != nil {
+			h.abortStream(r.Context(), req, send, i, StageTranslation, err)
+			return
+		}
+
+		err = h.synthesizeSegment(r.Context(), req, &t, func(chunk []byte) error {
+			return send(textAudioEvent{Type: "audio", Index: i, Data: chunk})
+		})
+		if err == nil && t.Error != nil && t.Error.Stage == StageSynthesis {
+			err = send(textErrorEvent{Type: "error", Index: &i, SegmentError: *t.Error})
+		}
+		if err != nil {
+			h.abortStream(r.Context(), req, send, i, StageSynthesis, err)
+			return
+		}
+
+		if done.DetectedLanguage == "" {
+			done.DetectedLanguage = t.DetectedLanguage
+		}
+	}
+
+	if err := send(done); err != nil {
+		req.logger.Error("failed to stream text translation", "error", err)
+	}
+}
+
+func (h *TextHandler) abort(w http.ResponseWriter, req *textRequest, err error) {
+	if !quota.IsQuotaExhausted(err) && !quota.IsRateLimit(err) {
+		return
+	}
+	req.logger.Warn("text translation rejected by tenant quota", "error", err)
+	writeErrorCode(w, err)
+}
+
+func (h *TextHandler) abortStream(ctx context.Context, req *textRequest, send func(any) error, index int, stage string, err error) {
+	if ctx.Err() != nil {
+		return
+	}
+	if !quota.IsQuotaExhausted(err) && !quota.IsRateLimit(err) {
+		req.logger.Error("failed to stream text translation", "error", err, "index", index)
+		return
+	}
+	req.logger.Warn("text translation rejected by tenant quota", "error", err, "stage", stage)
+	send(textErrorEvent{Type: "error", Index: &index, SegmentError: newSegmentError(stage, err)})
+}
+
+func (h *TextHandler) translate(ctx context.Context, req *textRequest, index int, text string) (TextTranslation, error) {
+	t := TextTranslation{Index: index, Text: text, TargetLanguage: req.TargetLanguage}
+	if strings.TrimSpace(text) == "" {
+		return t, nil
+	}
+
+	chars := utf8.RuneCountInString(text)
+	if err := h.quotas.ConsumeTranslation(req.tenantID, chars); err != nil {
+		return t, err
+	}
+
+	resp, err := h.translatorClient.Translate(ctx, &pb.TranslateRequest{
+		SessionId:      req.contextID,
+		Text:           text,
+		SourceLanguage: req.SourceLanguage,
+		TargetLanguage: req.TargetLanguage,
+		IsFinal:        true,
+		Style:          req.style,
+	})
+	metrics.TextSegments.WithLabelValues(metrics.Outcome(err)).Inc()
+	if err != nil {
+		if ctx.Err() != nil {
+			return t, ctx.Err()
+		}
+		req.logger.Error("text translation error", "error", err, "index", index, "code", ErrorCodeFromError(err))
+		segErr := newSegmentError(StageTranslation, err)
+		t.Error = &segErr
+		return t, nil
+	}
+	h.meter.AddTranslation(req.tenantID, req.requestID, resp.Engine, chars)
+
+	t.Translation = resp.TranslatedText
+	t.DetectedLanguage = resp.SourceLanguage
+	t.Engine = resp.Engine
+	if resp.TargetLanguage != "" {
+		t.TargetLanguage = resp.TargetLanguage
+	}
+	return t, nil
+}
+
+func (h *TextHandler) synthesizeSegment(ctx context.Context, req *textRequest, t *TextTranslation, emit func([]byte) error) error {
+	if t.Error != nil || t.Translation == "" {
+		return nil
+	}
+
+	err := synthesizeText(ctx, h.ttsClient, h.quotas, h.meter, req.tenantID, &pb.TTSRequest{
+		SessionId:    req.requestID,
+		Text:         t.Translation,
+		LanguageCode: t.TargetLanguage,
+	}, emit)
+	if err == nil || quota.IsQuotaExhausted(err) || quota.IsRateLimit(err) || ctx.Err() != nil {
+		return err
+	}
+	req.logger.Error("text synthesis error", "error", err, "index", t.Index)
+	segErr := newSegmentError(StageSynthesis, err)
+	t.Error = &segErr
+	t.Audio = nil
+	return nil
+}
+
+func synthesizeText(ctx context.Context, ttsClient pb.TTSServiceClient, quotas *quota.Manager, meter *metering.Meter, tenantID string, req *pb.TTSRequest, emit func([]byte) error) error {
+	chars := utf8.RuneCountInString(req.Text)
+	if err := quotas.ConsumeTTS(tenantID, chars); err != nil {
+		return err
+	}
+
+	stream, err := ttsClient.Synthesize(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	metered := false
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if !metered {
+			meter.AddSynthesis(tenantID, req.SessionId, resp.VoiceTier, chars)
+			metered = true
+		}
+
+		if resp.Audio != nil && len(resp.Audio.Data) > 0 {
+			if err := emit(resp.Audio.Data); err != nil {
+				return err
+			}
+		}
+		if resp.IsFinal {
+			return nil
+		}
+	}
+}
+
+func newSegmentError(stage string, err error) SegmentError {
+	event := NewErrorEvent(stage, err, "", true)
+	return SegmentError{Stage: event.Stage, Code: event.Code, Message: event.Message}
+}
@@ -0,0 +1,307 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/audio"
+	"ai-translator/internal/auth"
+	"ai-translator/internal/metrics"
+	"ai-translator/internal/quota"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type textTranslatorClient struct {
+	pb.TranslatorServiceClient
+	mu       sync.Mutex
+	requests []*pb.TranslateRequest
+	fail     map[string]error
+}
+
+func (c *textTranslatorClient) Translate(ctx context.Context, in *pb.TranslateRequest, opts ...grpc.CallOption) (*pb.TranslateResponse, error) {
+	c.mu.Lock()
+	c.requests = append(c.requests, in)
+	c.mu.Unlock()
+	if err := c.fail[in.Text]; err != nil {
+		return nil, err
+	}
+	return &pb.TranslateResponse{TranslatedText: "es:" + in.Text, SourceLanguage: "en", TargetLanguage: in.TargetLanguage, Engine: "fake", IsFinal: true}, nil
+}
+
+func postText(t *testing.T, h http.Handler, principal *auth.Principal, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/v1/translate", strings.NewReader(body))
+	if principal != nil {
+		req = req.WithContext(auth.NewContext(req.Context(), principal))
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func newTextHandler(translator pb.TranslatorServiceClient, tts pb.TTSServiceClient, quotas *quota.Manager) *TextHandler {
+	if quotas == nil {
+		quotas = quota.NewManager(quota.Limits{})
+	}
+	return NewTextHandler(translator, tts, quotas, nil, discardLogger())
+}
+
+func decodeText(t *testing.T, rec *httptest.ResponseRecorder) TextTranslateResponse {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	var resp TextTranslateResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return resp
+}
+
+func TestTextTranslateRequiresAuth(t *testing.T) {
+	rec := postText(t, newTextHandler(&textTranslatorClient{}, nil, nil), nil, `{"target_language":"es","segments":["hi"]}`)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", rec.Code)
+	}
+}
+
+func TestTextTranslateRejectsBadRequests(t *testing.T) {
+	h := newTextHandler(&textTranslatorClient{}, nil, nil)
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`not json`, http.StatusBadRequest},
+		{`{"segments":["hi"]}`, http.StatusBadRequest},
+		{`{"target_language":"es","segments":[]}`, http.StatusBadRequest},
+		{`{"target_language":"es","segments":["` + strings.Repeat(`a","`, maxTextSegments) + `a"]}`, http.StatusBadRequest},
+		{`{"target_language":"es","segments":["hi"],"audio":"wav"}`, http.StatusBadRequest},
+		{`{"target_language":"es","segments":["hi"],"formality":"polite"}`, http.StatusBadRequest},
+		{`{"target_language":"es","segments":["hi"],"domain":"finance"}`, http.StatusBadRequest},
+		{`{"target_language":"es","segments":["` + strings.Repeat("a", maxTextRequestSize) + `"]}`, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		rec := postText(t, h, testPrincipal, tt.body)
+		if rec.Code != tt.code {
+			t.Errorf("%.60s: status = %d, want %d: %s", tt.body, rec.Code, tt.code, rec.Body)
+		}
+	}
+}
+
+func TestTextTranslateSegments(t *testing.T) {
+	translator := &textTranslatorClient{}
+	okBefore := testutil.ToFloat64(metrics.TextSegments.WithLabelValues("ok"))
+
+	rec := postText(t, newTextHandler(translator, nil, nil), testPrincipal,
+		`{"session_id":"conv-1","target_language":"es-ES","segments":["hello","  ","bye"],"formality":"formal","domain":"medical","audience":"patients"}`)
+	resp := decodeText(t, rec)
+
+	if resp.SessionID != "conv-1" || resp.TargetLanguage != "es-ES" || resp.DetectedLanguage != "en" || resp.AudioFormat != "" {
+		t.Fatalf("response = %+v", resp)
+	}
+	if len(resp.Translations) != 3 {
+		t.Fatalf("translations = %+v", resp.Translations)
+	}
+	for i, want := range []string{"es:hello", "", "es:bye"} {
+		tr := resp.Translations[i]
+		if tr.Index != i || tr.Translation != want || tr.Error != nil || tr.Audio != nil {
+			t.Errorf("translation %d = %+v, want %q", i, tr, want)
+		}
+	}
+
+	if len(translator.requests) != 2 {
+		t.Fatalf("translator calls = %d, want blank segments skipped", len(translator.requests))
+	}
+	req := translator.requests[0]
+	if req.SessionId != "acme/conv-1" || !req.IsFinal || req.SourceLanguage != "" {
+		t.Fatalf("translate request = %+v", req)
+	}
+	if req.Style.Formality != pb.Formality_FORMALITY_FORMAL || req.Style.Domain != pb.Domain_DOMAIN_MEDICAL || req.Style.Audience != "patients" {
+		t.Fatalf("style = %+v", req.Style)
+	}
+	if got := testutil.ToFloat64(metrics.TextSegments.WithLabelValues("ok")) - okBefore; got != 2 {
+		t.Fatalf("ok segments grew by %v, want 2", got)
+	}
+}
+
+func TestTextTranslateGeneratesSessionID(t *testing.T) {
+	translator := &textTranslatorClient{}
+	resp := decodeText(t, postText(t, newTextHandler(translator, nil, nil), testPrincipal, `{"target_language":"es","segments":["hello"]}`))
+	if !strings.HasPrefix(resp.SessionID, "txt_") {
+		t.Fatalf("session id = %q", resp.SessionID)
+	}
+	if translator.requests[0].SessionId != "acme/"+resp.SessionID {
+		t.Fatalf("context id = %q", translator.requests[0].SessionId)
+	}
+}
+
+func TestTextTranslateReportsSegmentErrors(t *testing.T) {
+	translator := &textTranslatorClient{fail: map[string]error{"bad": status.Error(codes.FailedPrecondition, "blocked")}}
+	resp := decodeText(t, postText(t, newTextHandler(translator, nil, nil), testPrincipal, `{"target_language":"es","segments":["bad","good"]}`))
+
+	failed := resp.Translations[0]
+	if failed.Translation != "" || failed.Error == nil || failed.Error.Stage != StageTranslation || failed.Error.Code != ErrorCodeSafetyBlocked {
+		t.Fatalf("failed segment = %+v, %+v", failed, failed.Error)
+	}
+	if resp.Translations[1].Translation != "es:good" || resp.Translations[1].Error != nil {
+		t.Fatalf("later segment = %+v", resp.Translations[1])
+	}
+}
+
+func TestTextTranslateQuota(t *testing.T) {
+	quotas := quota.NewManager(quota.Limits{TranslationCharsPerDay: 6})
+	translator := &textTranslatorClient{}
+	rec := postText(t, newTextHandler(translator, nil, quotas), testPrincipal, `{"target_language":"es","segments":["hello","again"]}`)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429: %s", rec.Code, rec.Body)
+	}
+	if !strings.Contains(rec.Body.String(), ErrorCodeTenantQuota) {
+		t.Fatalf("body = %s", rec.Body)
+	}
+	if len(translator.requests) != 1 {
+		t.Fatalf("translator calls = %d, want the request stopped at the quota", len(translator.requests))
+	}
+}
+
+func TestTextTranslateBase64Audio(t *testing.T) {
+	tts := &fakeTTSClient{delays: []time.Duration{0, 0}}
+	resp := decodeText(t, postText(t, newTextHandler(&textTranslatorClient{}, tts, nil), testPrincipal, `{"target_language":"es","segments":["hello","","bye"],"audio":"base64"}`))
+
+	if resp.AudioFormat != AudioFormatPCM || resp.SampleRate != audio.SampleRate {
+		t.Fatalf("audio format = %q at %d Hz", resp.AudioFormat, resp.SampleRate)
+	}
+	for i, want := range []int{4, 0, 4} {
+		if got := len(resp.Translations[i].Audio); got != want {
+			t.Errorf("segment %d audio = %d bytes, want %d", i, got, want)
+		}
+	}
+	if got := tts.calls.Load(); got != 2 {
+		t.Fatalf("tts calls = %d, want blank segments skipped", got)
+	}
+}
+
+func TestTextTranslateSynthesisFailureKeepsTranslation(t *testing.T) {
+	tts := &chanTTSClient{err: status.Error(codes.Unavailable, "down")}
+	resp := decodeText(t, postText(t, newTextHandler(&textTranslatorClient{}, tts, nil), testPrincipal, `{"target_language":"es-ES","segments":["hello"],"audio":"base64"}`))
+
+	tr := resp.Translations[0]
+	if tr.Translation != "es:hello" || tr.Audio != nil || tr.Error == nil || tr.Error.Stage != StageSynthesis {
+		t.Fatalf("segment = %+v, %+v", tr, tr.Error)
+	}
+	if tts.req.Text != "es:hello" || tts.req.LanguageCode != "es-ES" {
+		t.Fatalf("tts request = %+v", tts.req)
+	}
+}
+
+func streamEvents(t *testing.T, rec *httptest.ResponseRecorder) []map[string]any {
+	t.Helper()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/x-ndjson" {
+		t.Fatalf("Content-Type = %q", got)
+	}
+	var events []map[string]any
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var event map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("decode event %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func eventTypes(events []map[string]any) string {
+	var types []string
+	for _, e := range events {
+		types = append(types, e["type"].(string))
+	}
+	return strings.Join(types, ",")
+}
+
+func TestTextTranslateStream(t *testing.T) {
+	tts := &fakeTTSClient{delays: []time.Duration{0, 0}}
+	events := streamEvents(t, postText(t, newTextHandler(&textTranslatorClient{}, tts, nil), testPrincipal, `{"session_id":"conv-1","target_language":"es","segments":["hello","bye"],"audio":"stream"}`))
+
+	if got, want := eventTypes(events), "translation,audio,audio,translation,audio,audio,done"; got != want {
+		t.Fatalf("events = %s, want %s", got, want)
+	}
+	if events[0]["translation"] != "es:hello" || events[3]["index"] != float64(1) || events[4]["index"] != float64(1) {
+		t.Fatalf("events = %v", events)
+	}
+	done := events[len(events)-1]
+	if done["session_id"] != "conv-1" || done["detected_language"] != "en" || done["audio_format"] != AudioFormatPCM || done["sample_rate"] != float64(audio.SampleRate) {
+		t.Fatalf("done = %v", done)
+	}
+}
+
+func TestTextTranslateStreamReportsSynthesisFailure(t *testing.T) {
+	tts := &chanTTSClient{err: status.Error(codes.Unavailable, "down")}
+	events := streamEvents(t, postText(t, newTextHandler(&textTranslatorClient{}, tts, nil), testPrincipal, `{"target_language":"es","segments":["hello"],"audio":"stream"}`))
+
+	if got, want := eventTypes(events), "translation,error,done"; got != want {
+		t.Fatalf("events = %s, want %s", got, want)
+	}
+	if events[1]["stage"] != StageSynthesis || events[1]["index"] != float64(0) {
+		t.Fatalf("error event = %v", events[1])
+	}
+}
+
+func TestTextTranslateStreamStopsAtQuota(t *testing.T) {
+	quotas := quota.NewManager(quota.Limits{TranslationCharsPerDay: 6})
+	tts := &fakeTTSClient{delays: []time.Duration{0}}
+	events := streamEvents(t, postText(t, newTextHandler(&textTranslatorClient{}, tts, quotas), testPrincipal, `{"target_language":"es","segments":["hello","again"],"audio":"stream"}`))
+
+	if got, want := eventTypes(events), "translation,audio,error"; got != want {
+		t.Fatalf("events = %s, want %s", got, want)
+	}
+	if events[2]["code"] != ErrorCodeTenantQuota || events[2]["index"] != float64(1) || events[2]["stage"] != StageTranslation {
+		t.Fatalf("error event = %v", events[2])
+	}
+}
+
+func TestTextTranslateStreamOutlivesServerWriteTimeout(t *testing.T) {
+	tts := &fakeTTSClient{delays: []time.Duration{100 * time.Millisecond, 100 * time.Millisecond}}
+	h := newTextHandler(&textTranslatorClient{}, tts, nil)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), testPrincipal)))
+	}))
+	srv.Config.WriteTimeout = 150 * time.Millisecond
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/v1/translate", "application/json", strings.NewReader(`{"target_language":"es","segments":["hello","bye"],"audio":"stream"}`))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	defer resp.Body.Close()
+	var types []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var event map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("decode event %q: %v", scanner.Text(), err)
+		}
+		types = append(types, event["type"].(string))
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("read stream: %v", err)
+	}
+	if got, want := strings.Join(types, ","), "translation,audio,audio,translation,audio,audio,done"; got != want {
+		t.Fatalf("events = %s, want %s", got, want)
+	}
+}
@@ -3,23 +3,41 @@ package gateway
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
 
+	pb "ai-translator/api/proto"
+	"ai-translator/internal/auth"
+	"ai-translator/internal/quota"
+	"ai-translator/internal/recording"
 	"ai-translator/internal/transport"
 	"ai-translator/internal/util"
 
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type WebSocketHandler struct {
 	sessionManager *SessionManager
+	upgrader       *transport.WSUpgrader
+	authenticator  auth.Authenticator
+	recordMode     string
+	sendQueue      SendQueueConfig
 	logger         *slog.Logger
 }
 
-func NewWebSocketHandler(sm *SessionManager, logger *slog.Logger) *WebSocketHandler {
+func NewWebSocketHandler(sm *SessionManager, upgrader *transport.WSUpgrader, authenticator auth.Authenticator, recordMode string, sendQueue SendQueueConfig, logger *slog.Logger) *WebSocketHandler {
 	return &WebSocketHandler{
 		sessionManager: sm,
+		upgrader:       upgrader,
+		authenticator:  authenticator,
+		recordMode:     recordMode,
+		sendQueue:      sendQueue,
 		logger:         logger,
 	}
 }
@@ -27,39 +45,173 @@ func NewWebSocketHandler(sm *SessionManager, logger *slog.Logger) *WebSocketHand
 type ClientConfig struct {
 	SourceLanguage string `json:"source_language"`
 	TargetLanguage string `json:"target_language"`
+	Formality      string `json:"formality,omitempty"`
+	Domain         string `json:"domain,omitempty"`
+	Audience       string `json:"audience,omitempty"`
+	Record         bool   `json:"record,omitempty"`
+	RecordMode     string `json:"record_mode,omitempty"`
+}
+
+var formalities = map[string]pb.Formality{
+	"":         pb.Formality_FORMALITY_AUTO,
+	"auto":     pb.Formality_FORMALITY_AUTO,
+	"formal":   pb.Formality_FORMALITY_FORMAL,
+	"informal": pb.Formality_FORMALITY_INFORMAL,
+}
+
+var domains = map[string]pb.Domain{
+	"":                 pb.Domain_DOMAIN_GENERAL,
+	"general":          pb.Domain_DOMAIN_GENERAL,
+	"medical":          pb.Domain_DOMAIN_MEDICAL,
+	"legal":            pb.Domain_DOMAIN_LEGAL,
+	"customer-support": pb.Domain_DOMAIN_CUSTOMER_SUPPORT,
+	"technical":        pb.Domain_DOMAIN_TECHNICAL,
+}
+
+func (c ClientConfig) TranslationStyle() (*pb.TranslationStyle, error) {
+	return translationStyle(c.Formality, c.Domain, c.Audience)
+}
+
+func translationStyle(formality, domain, audience string) (*pb.TranslationStyle, error) {
+	f, ok := formalities[strings.ToLower(formality)]
+	if !ok {
+		return nil, fmt.Errorf("unknown formality %q", formality)
+	}
+
+	d, ok := domains[strings.ToLower(domain)]
+	if !ok {
+		return nil, fmt.Errorf("unknown domain %q", domain)
+	}
+
+	return &pb.TranslationStyle{
+		Formality: f,
+		Domain:    d,
+		Audience:  audience,
+	}, nil
 }
 
 func (h *WebSocketHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
+	principal, err := h.authenticator.Authenticate(r)
+	if err != nil {
+		h.logger.Warn("websocket authentication failed", "error", err, "remote", r.RemoteAddr)
+		authError(w, err)
+		return
+	}
+
+	if token := r.URL.Query().Get("resume_token"); token != "" {
+		h.resumeConnection(w, r, principal, token)
+		return
+	}
+
 	sessionID := util.NewSessionID()
-	logger := h.logger.With("session_id", sessionID)
+	logger := h.logger.With("session_id", sessionID, "tenant_id", principal.TenantID, "principal", principal.Subject)
 
-	wsConn, err := transport.UpgradeToWebSocket(w, r, logger, sessionID)
+	wsConn, err := h.upgrader.Upgrade(w, r, logger, sessionID)
 	if err != nil {
 		logger.Error("websocket upgrade failed", "error", err)
 		return
 	}
 
-	session := h.sessionManager.Create(sessionID, wsConn, logger)
+	ctx, span := startSessionSpan(r.Context(), sessionID, principal)
+	client := h.clientConn(wsConn, logger)
+	session, err := h.sessionManager.Create(ctx, sessionID, principal, client, logger)
+	if err != nil {
+		logger.Warn("session rejected by tenant quota", "error", err)
+		closeClientConn(client)
+		rejectConnection(wsConn, StageSession, err, logger)
+		endSpan(span, err)
+		return
+	}
 
-	ctx, cancel := context.WithCancel(r.Context())
+	logger.Info("new session started")
+	h.serve(ctx, session, wsConn, client, span, false, logger)
+}
+
+func (h *WebSocketHandler) resumeConnection(w http.ResponseWriter, r *http.Request, principal *auth.Principal, token string) {
+	var lastSeq uint64
+	if v := r.URL.Query().Get("last_seq"); v != "" {
+		seq, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "invalid last_seq")
+			return
+		}
+		lastSeq = seq
+	}
+
+	var sessionID string
+	if session := h.sessionManager.resumable(token, principal); session != nil {
+		sessionID = session.ID
+	}
+	logger := h.logger.With("session_id", sessionID, "tenant_id", principal.TenantID, "principal", principal.Subject)
+
+	wsConn, err := h.upgrader.Upgrade(w, r, logger, sessionID)
+	if err != nil {
+		logger.Error("websocket upgrade failed", "error", err)
+		return
+	}
+
+	ctx, span := startSessionSpan(r.Context(), sessionID, principal)
+	client := h.clientConn(wsConn, logger)
+	session, previous, err := h.sessionManager.Resume(token, principal, client, lastSeq)
+	if errors.Is(err, ErrSessionNotFound) {
+		logger.Warn("session resume rejected", "error", err)
+		closeClientConn(client)
+		rejectConnection(wsConn, StageSession, err, logger)
+		endSpan(span, err)
+		return
+	}
+	if previous, ok := previous.(interface{ CloseWithCode(int, string) error }); ok {
+		previous.CloseWithCode(CloseSessionResumed, "session resumed on another connection")
+	}
+	if err != nil {
+		logger.Warn("failed to replay missed events", "error", err)
+	}
+
+	logger.Info("session resumed", "last_seq", lastSeq)
+	h.serve(ctx, session, wsConn, client, span, true, logger)
+}
+
+func (h *WebSocketHandler) clientConn(wsConn *transport.WSConn, logger *slog.Logger) Conn {
+	if h.sendQueue.MaxBytes <= 0 {
+		return wsConn
+	}
+	return newSendQueue(wsConn, h.sendQueue, logger)
+}
+
+func closeClientConn(client Conn) {
+	if q, ok := client.(*sendQueue); ok {
+		q.close()
+	}
+}
+
+func (h *WebSocketHandler) serve(ctx context.Context, session *Session, wsConn *transport.WSConn, client Conn, span trace.Span, configReceived bool, logger *slog.Logger) {
+	ctx, cancel := context.WithCancel(ctx)
+	detach := false
 	defer func() {
 		cancel()
-		h.sessionManager.Remove(sessionID)
+		if detach {
+			h.sessionManager.Detach(session.ID, client)
+		} else {
+			h.sessionManager.Remove(session.ID)
+		}
+		closeClientConn(client)
 		wsConn.Close()
-		logger.Info("session ended")
+		span.End()
+		logger.Info("connection closed", "session_ended", !detach)
 	}()
 
-	logger.Info("new session started")
-
-	configReceived := false
 	var config ClientConfig
 
 	for {
 		msgType, data, err := wsConn.ReadMessage()
 		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				logger.Warn("websocket idle timeout", "error", err)
+			} else if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
 				logger.Error("websocket read error", "error", err)
 			}
+			detach = !websocket.IsCloseError(err, websocket.CloseNormalClosure)
 			return
 		}
 
@@ -69,12 +221,28 @@ func (h *WebSocketHandler) HandleConnection(w http.ResponseWriter, r *http.Reque
 				logger.Warn("invalid config message", "error", err)
 				continue
 			}
+			style, err := config.TranslationStyle()
+			if err != nil {
+				logger.Warn("invalid config message", "error", err)
+				continue
+			}
+			recordMode := config.RecordMode
+			if recordMode == "" {
+				recordMode = h.recordMode
+			}
+			if _, err := recording.ParseMode(recordMode); err != nil {
+				logger.Warn("invalid config message", "error", err)
+				continue
+			}
 			session.SetLanguages(config.SourceLanguage, config.TargetLanguage)
+			session.SetStyle(style)
+			recorded := session.SetRecording(config.Record, recordMode)
 			configReceived = true
-			logger.Info("config received", "source", config.SourceLanguage, "target", config.TargetLanguage)
+			logger.Info("config received", "source", config.SourceLanguage, "target", config.TargetLanguage, "formality", config.Formality, "domain", config.Domain, "recording", recorded)
 
-			if err := wsConn.WriteText(`{"status":"ready"}`); err != nil {
+			if err := writeEvent(client, session.readyEvent("ready")); err != nil {
 				logger.Error("failed to send ready status", "error", err)
+				detach = true
 				return
 			}
 
@@ -85,6 +253,11 @@ func (h *WebSocketHandler) HandleConnection(w http.ResponseWriter, r *http.Reque
 			}
 
 			if err := session.ProcessAudio(ctx, data); err != nil {
+				if quota.IsQuotaExhausted(err) {
+					logger.Warn("audio rejected by tenant quota", "error", err)
+					rejectConnection(wsConn, StageRecognition, err, logger)
+					return
+				}
 				logger.Error("audio processing error", "error", err)
 			}
 		}
@@ -0,0 +1,36 @@
+package gateway
+
+import (
+	"testing"
+
+	pb "ai-translator/api/proto"
+)
+
+func TestClientConfigTranslationStyle(t *testing.T) {
+	style, err := ClientConfig{Formality: "Formal", Domain: "customer-support", Audience: "retail customers"}.TranslationStyle()
+	if err != nil {
+		t.Fatalf("TranslationStyle: %v", err)
+	}
+	if style.Formality != pb.Formality_FORMALITY_FORMAL || style.Domain != pb.Domain_DOMAIN_CUSTOMER_SUPPORT || style.Audience != "retail customers" {
+		t.Fatalf("style = %+v", style)
+	}
+
+	style, err = ClientConfig{}.TranslationStyle()
+	if err != nil {
+		t.Fatalf("TranslationStyle of an empty config: %v", err)
+	}
+	if style.Formality != pb.Formality_FORMALITY_AUTO || style.Domain != pb.Domain_DOMAIN_GENERAL {
+		t.Fatalf("default style = %+v", style)
+	}
+}
+
+func TestClientConfigRejectsUnknownStyle(t *testing.T) {
+	for _, config := range []ClientConfig{
+		{Formality: "polite"},
+		{Domain: "finance"},
+	} {
+		if _, err := config.TranslationStyle(); err == nil {
+			t.Errorf("TranslationStyle(%+v) accepted an unknown value", config)
+		}
+	}
+}
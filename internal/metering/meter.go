@@ -0,0 +1,308 @@
+package metering
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-translator/internal/metrics"
+)
+
+const (
+	MetricAudioSeconds     = "audio_seconds"
+	MetricTranslationChars = "translation_chars"
+	MetricTTSChars         = "tts_chars"
+)
+
+const (
+	DefaultFlushInterval = time.Minute
+	sequenceRetention    = time.Hour
+)
+
+type Record struct {
+	ID          string    `json:"id"`
+	TenantID    string    `json:"tenant_id"`
+	SessionID   string    `json:"session_id"`
+	Metric      string    `json:"metric"`
+	Dimension   string    `json:"dimension,omitempty"`
+	Quantity    float64   `json:"quantity"`
+	Sequence    uint64    `json:"sequence"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+}
+
+type Batch struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Records   []Record  `json:"records"`
+}
+
+type Sink interface {
+	Write(ctx context.Context, batch Batch) error
+	Close() error
+}
+
+type counterKey struct {
+	tenant    string
+	session   string
+	metric    string
+	dimension string
+}
+
+type Meter struct {
+	mu          sync.Mutex
+	flushMu     sync.Mutex
+	counts      map[counterKey]float64
+	sequences   map[string]uint64
+	ended       map[string]time.Time
+	periodStart time.Time
+	pending     []Batch
+	sink        Sink
+	spoolDir    string
+	logger      *slog.Logger
+}
+
+func NewMeter(sink Sink, spoolDir string, logger *slog.Logger) (*Meter, error) {
+	m := &Meter{
+		counts:      make(map[counterKey]float64),
+		sequences:   make(map[string]uint64),
+		ended:       make(map[string]time.Time),
+		periodStart: time.Now().UTC(),
+		sink:        sink,
+		spoolDir:    spoolDir,
+		logger:      logger,
+	}
+
+	if spoolDir != "" {
+		if err := os.MkdirAll(spoolDir, 0o750); err != nil {
+			return nil, fmt.Errorf("create metering spool: %w", err)
+		}
+		if err := m.loadSpool(); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+func (m *Meter) add(tenant, session, metric, dimension string, quantity float64) {
+	if m == nil || quantity <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counts[counterKey{tenant: tenant, session: session, metric: metric, dimension: dimension}] += quantity
+}
+
+func (m *Meter) AddAudio(tenant, session string, d time.Duration) {
+	m.add(tenant, session, MetricAudioSeconds, "", d.Seconds())
+}
+
+func (m *Meter) AddTranslation(tenant, session, engine string, chars int) {
+	m.add(tenant, session, MetricTranslationChars, engine, float64(chars))
+}
+
+func (m *Meter) AddSynthesis(tenant, session, voiceTier string, chars int) {
+	m.add(tenant, session, MetricTTSChars, voiceTier, float64(chars))
+}
+
+func (m *Meter) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Flush(ctx); err != nil {
+				m.logger.Warn("metering flush failed, will retry", "error", err)
+			}
+		}
+	}
+}
+
+func (m *Meter) Flush(ctx context.Context) error {
+	m.flushMu.Lock()
+	defer m.flushMu.Unlock()
+
+	if batch, ok := m.cut(); ok {
+		if err := m.spool(batch); err != nil {
+			m.logger.Error("failed to spool metering batch", "batch_id", batch.ID, "error", err)
+		}
+		m.pending = append(m.pending, batch)
+	}
+
+	for len(m.pending) > 0 {
+		batch := m.pending[0]
+		if err := m.sink.Write(ctx, batch); err != nil {
+			metrics.MeteringFlushes.WithLabelValues("error").Inc()
+			return fmt.Errorf("batch %s: %w", batch.ID, err)
+		}
+		metrics.MeteringFlushes.WithLabelValues("ok").Inc()
+		m.unspool(batch)
+		m.pending = m.pending[1:]
+	}
+	return nil
+}
+
+func (m *Meter) cut() (Batch, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now().UTC()
+	start := m.periodStart
+	m.periodStart = now
+
+	if len(m.counts) == 0 {
+		return Batch{}, false
+	}
+
+	keys := make([]counterKey, 0, len(m.counts))
+	for k := range m.counts {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b counterKey) int {
+		return cmp.Or(
+			strings.Compare(a.session, b.session),
+			strings.Compare(a.metric, b.metric),
+			strings.Compare(a.dimension, b.dimension),
+		)
+	})
+
+	flushed := make(map[string]bool)
+	records := make([]Record, 0, len(keys))
+	for _, k := range keys {
+		if !flushed[k.session] {
+			m.sequences[k.session]++
+			flushed[k.session] = true
+		}
+		seq := m.sequences[k.session]
+
+		records = append(records, Record{
+			ID:          recordID(k, seq),
+			TenantID:    k.tenant,
+			SessionID:   k.session,
+			Metric:      k.metric,
+			Dimension:   k.dimension,
+			Quantity:    m.counts[k],
+			Sequence:    seq,
+			PeriodStart: start,
+			PeriodEnd:   now,
+		})
+	}
+	m.counts = make(map[counterKey]float64)
+
+	for session, endedAt := range m.ended {
+		if now.Sub(endedAt) >= sequenceRetention {
+			delete(m.sequences, session)
+			delete(m.ended, session)
+		}
+	}
+
+	return Batch{ID: batchID(now), CreatedAt: now, Records: records}, true
+}
+
+func (m *Meter) EndSession(session string) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ended[session] = time.Now().UTC()
+}
+
+func recordID(k counterKey, seq uint64) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%d", k.tenant, k.session, k.metric, k.dimension, seq)))
+	return hex.EncodeToString(sum[:16])
+}
+
+func batchID(now time.Time) string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("%d-%s", now.UnixNano(), hex.EncodeToString(suffix))
+}
+
+func (m *Meter) spoolPath(batch Batch) string {
+	return filepath.Join(m.spoolDir, batch.ID+".json")
+}
+
+func (m *Meter) spool(batch Batch) error {
+	if m.spoolDir == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	tmp := m.spoolPath(batch) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.spoolPath(batch))
+}
+
+func (m *Meter) unspool(batch Batch) {
+	if m.spoolDir == "" {
+		return
+	}
+	if err := os.Remove(m.spoolPath(batch)); err != nil && !os.IsNotExist(err) {
+		m.logger.Warn("failed to remove spooled metering batch", "batch_id", batch.ID, "error", err)
+	}
+}
+
+func (m *Meter) loadSpool() error {
+	files, err := filepath.Glob(filepath.Join(m.spoolDir, "*.json"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var batch Batch
+		if err := json.Unmarshal(data, &batch); err != nil {
+			m.logger.Error("skipping unreadable metering batch", "file", file, "error", err)
+			continue
+		}
+		m.pending = append(m.pending, batch)
+	}
+
+	if len(m.pending) > 0 {
+		m.logger.Info("recovered unsent metering batches", "count", len(m.pending))
+	}
+	return nil
+}
+
+func (m *Meter) Close(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	err := m.Flush(ctx)
+	if closeErr := m.sink.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
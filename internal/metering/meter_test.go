@@ -0,0 +1,208 @@
+package metering
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memorySink struct {
+	mu      sync.Mutex
+	batches []Batch
+	err     error
+}
+
+func (s *memorySink) Write(ctx context.Context, batch Batch) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.batches = append(s.batches, batch)
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+func (s *memorySink) records() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var records []Record
+	for _, b := range s.batches {
+		records = append(records, b.Records...)
+	}
+	return records
+}
+
+func newTestMeter(t *testing.T, sink Sink, spoolDir string) *Meter {
+	t.Helper()
+	m, err := NewMeter(sink, spoolDir, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("NewMeter: %v", err)
+	}
+	return m
+}
+
+func TestNilMeterIsSafe(t *testing.T) {
+	var m *Meter
+	m.AddAudio("acme", "s1", time.Second)
+	m.AddTranslation("acme", "s1", "gemini", 10)
+	m.EndSession("s1")
+	if err := m.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFlushAggregatesRecords(t *testing.T) {
+	sink := &memorySink{}
+	m := newTestMeter(t, sink, "")
+
+	m.AddTranslation("acme", "s1", "gemini", 10)
+	m.AddTranslation("acme", "s1", "gemini", 5)
+	m.AddTranslation("acme", "s1", "google", 3)
+	m.AddTranslation("acme", "s1", "gemini", 0)
+	m.AddAudio("acme", "s1", 1500*time.Millisecond)
+	m.AddSynthesis("acme", "s2", "neural2", 7)
+
+	if err := m.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]float64{
+		"s1/translation_chars/gemini": 15,
+		"s1/translation_chars/google": 3,
+		"s1/audio_seconds/":           1.5,
+		"s2/tts_chars/neural2":        7,
+	}
+	records := sink.records()
+	if len(records) != len(want) {
+		t.Fatalf("%d records, want %d: %+v", len(records), len(want), records)
+	}
+	for _, r := range records {
+		key := r.SessionID + "/" + r.Metric + "/" + r.Dimension
+		if r.Quantity != want[key] {
+			t.Errorf("%s = %v, want %v", key, r.Quantity, want[key])
+		}
+		if r.Sequence != 1 || r.TenantID != "acme" || r.ID == "" {
+			t.Errorf("record %+v", r)
+		}
+	}
+
+	if err := m.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.batches) != 1 {
+		t.Errorf("%d batches, want no batch for an empty period", len(sink.batches))
+	}
+}
+
+func TestSequenceContinuesAfterSessionEnds(t *testing.T) {
+	sink := &memorySink{}
+	m := newTestMeter(t, sink, "")
+	ctx := context.Background()
+
+	m.AddTranslation("acme", "s1", "gemini", 10)
+	m.Flush(ctx)
+	m.AddTranslation("acme", "s1", "gemini", 10)
+	m.EndSession("s1")
+	m.Flush(ctx)
+	m.AddTranslation("acme", "s1", "gemini", 4)
+	m.Flush(ctx)
+
+	records := sink.records()
+	if len(records) != 3 {
+		t.Fatalf("%d records, want 3", len(records))
+	}
+	ids := make(map[string]bool)
+	for i, r := range records {
+		if r.Sequence != uint64(i+1) {
+			t.Errorf("record %d has sequence %d, want %d", i, r.Sequence, i+1)
+		}
+		if ids[r.ID] {
+			t.Errorf("record %d reuses id %s", i, r.ID)
+		}
+		ids[r.ID] = true
+	}
+}
+
+func TestEndedSessionsAreForgottenAfterRetention(t *testing.T) {
+	m := newTestMeter(t, &memorySink{}, "")
+	ctx := context.Background()
+
+	m.AddTranslation("acme", "s1", "gemini", 10)
+	m.EndSession("s1")
+	m.Flush(ctx)
+
+	m.mu.Lock()
+	if m.sequences["s1"] != 1 {
+		t.Errorf("sequence = %d right after the session ended, want 1", m.sequences["s1"])
+	}
+	m.ended["s1"] = time.Now().Add(-sequenceRetention)
+	m.mu.Unlock()
+
+	m.AddAudio("acme", "s2", time.Second)
+	m.Flush(ctx)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sequences["s1"]; ok {
+		t.Error("sequence kept after retention")
+	}
+	if len(m.ended) != 0 {
+		t.Errorf("ended = %v, want empty", m.ended)
+	}
+}
+
+func TestFailedBatchesAreRetriedWithTheSameIDs(t *testing.T) {
+	dir := t.TempDir()
+	sink := &memorySink{err: errors.New("unavailable")}
+	m := newTestMeter(t, sink, dir)
+	ctx := context.Background()
+
+	m.AddTranslation("acme", "s1", "gemini", 10)
+	if err := m.Flush(ctx); err == nil {
+		t.Fatal("expected the flush to fail")
+	}
+	spooled, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(spooled) != 1 {
+		t.Fatalf("%d spooled batches, want 1", len(spooled))
+	}
+	first := m.pending[0].Records[0].ID
+
+	restarted := &memorySink{}
+	recovered := newTestMeter(t, restarted, dir)
+	if err := recovered.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	records := restarted.records()
+	if len(records) != 1 || records[0].ID != first {
+		t.Fatalf("recovered records %+v, want id %s", records, first)
+	}
+	if spooled, _ := filepath.Glob(filepath.Join(dir, "*.json")); len(spooled) != 0 {
+		t.Errorf("%d batches left in the spool", len(spooled))
+	}
+
+	sink.err = nil
+	if err := m.Flush(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := sink.records(); len(got) != 1 || got[0].ID != first {
+		t.Errorf("retried records %+v, want id %s", got, first)
+	}
+}
+
+func TestUnreadableSpoolFilesAreSkipped(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o640)
+
+	m := newTestMeter(t, &memorySink{}, dir)
+	if len(m.pending) != 0 {
+		t.Errorf("%d pending batches, want 0", len(m.pending))
+	}
+}
@@ -0,0 +1,101 @@
+package metering
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testBatch() Batch {
+	return Batch{ID: "b1", CreatedAt: time.Now().UTC(), Records: []Record{
+		{ID: "r1", TenantID: "acme", SessionID: "s1", Metric: MetricAudioSeconds, Quantity: 1.5, Sequence: 1},
+		{ID: "r2", TenantID: "acme", SessionID: "s1", Metric: MetricTranslationChars, Dimension: "gemini", Quantity: 12, Sequence: 1},
+	}}
+}
+
+func TestJSONLinesSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "usage.jsonl")
+	sink, err := NewJSONLinesSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(context.Background(), testBatch()); err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Write(context.Background(), testBatch()); err != nil {
+		t.Fatal(err)
+	}
+	sink.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("line %d: %v", lines, err)
+		}
+		lines++
+	}
+	if lines != 4 {
+		t.Errorf("%d lines, want 4", lines)
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	var gotKey, gotSignature string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get("Idempotency-Key")
+		gotSignature = r.Header.Get("X-Signature")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink(server.URL, "secret", time.Second)
+	if err := sink.Write(context.Background(), testBatch()); err != nil {
+		t.Fatal(err)
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); gotSignature != want {
+		t.Errorf("signature = %q, want %q", gotSignature, want)
+	}
+	if gotKey != "b1" {
+		t.Errorf("Idempotency-Key = %q, want b1", gotKey)
+	}
+	var batch Batch
+	if err := json.Unmarshal(body, &batch); err != nil || len(batch.Records) != 2 {
+		t.Errorf("body = %s, %v", body, err)
+	}
+}
+
+func TestWebhookSinkRejectsNon2xx(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Signature") != "" {
+			t.Error("unsigned sink sent a signature")
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if err := NewWebhookSink(server.URL, "", time.Second).Write(context.Background(), testBatch()); err == nil {
+		t.Fatal("expected an error for a 503 response")
+	}
+}
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSplitMethod(t *testing.T) {
+	tests := []struct {
+		in, service, method string
+	}{
+		{"/translator.TranslatorService/Translate", "translator.TranslatorService", "Translate"},
+		{"grpc.health.v1.Health/Check", "grpc.health.v1.Health", "Check"},
+		{"Translate", "unknown", "Translate"},
+	}
+	for _, tt := range tests {
+		service, method := splitMethod(tt.in)
+		if service != tt.service || method != tt.method {
+			t.Errorf("splitMethod(%q) = %q, %q, want %q, %q", tt.in, service, method, tt.service, tt.method)
+		}
+	}
+}
+
+func TestUnaryServerInterceptorCountsCodes(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Unary/Call"}
+	ok := grpcServerHandled.WithLabelValues("test.Unary", "Call", "OK")
+	failed := grpcServerHandled.WithLabelValues("test.Unary", "Call", "InvalidArgument")
+	okBefore, failedBefore := testutil.ToFloat64(ok), testutil.ToFloat64(failed)
+
+	interceptor := UnaryServerInterceptor()
+	interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) { return "done", nil })
+	if _, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+		return nil, status.Error(codes.InvalidArgument, "bad")
+	}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("interceptor changed the error to %v", err)
+	}
+
+	if got := testutil.ToFloat64(ok) - okBefore; got != 1 {
+		t.Errorf("OK count grew by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(failed) - failedBefore; got != 1 {
+		t.Errorf("InvalidArgument count grew by %v, want 1", got)
+	}
+}
+
+type fakeClientStream struct {
+	grpc.ClientStream
+	errs []error
+}
+
+func (s *fakeClientStream) RecvMsg(m any) error {
+	err := s.errs[0]
+	if len(s.errs) > 1 {
+		s.errs = s.errs[1:]
+	}
+	return err
+}
+
+func streamWith(errs ...error) grpc.Streamer {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &fakeClientStream{errs: errs}, nil
+	}
+}
+
+func TestStreamClientInterceptorObservesOnce(t *testing.T) {
+	ok := grpcClientHandled.WithLabelValues("test.Stream", "Recv", "OK")
+	unavailable := grpcClientHandled.WithLabelValues("test.Stream", "Recv", "Unavailable")
+	okBefore, unavailableBefore := testutil.ToFloat64(ok), testutil.ToFloat64(unavailable)
+
+	interceptor := StreamClientInterceptor()
+	stream, err := interceptor(context.Background(), &grpc.StreamDesc{}, nil, "/test.Stream/Recv", streamWith(nil, io.EOF))
+	if err != nil {
+		t.Fatalf("open stream: %v", err)
+	}
+	stream.RecvMsg(nil)
+	if got := testutil.ToFloat64(ok) - okBefore; got != 0 {
+		t.Fatalf("stream counted after a successful message")
+	}
+	stream.RecvMsg(nil)
+	stream.RecvMsg(nil)
+	if got := testutil.ToFloat64(ok) - okBefore; got != 1 {
+		t.Fatalf("OK count grew by %v after io.EOF, want 1", got)
+	}
+
+	stream, _ = interceptor(context.Background(), &grpc.StreamDesc{}, nil, "/test.Stream/Recv", streamWith(status.Error(codes.Unavailable, "down")))
+	stream.RecvMsg(nil)
+	stream.RecvMsg(nil)
+	if got := testutil.ToFloat64(unavailable) - unavailableBefore; got != 1 {
+		t.Fatalf("Unavailable count grew by %v, want 1", got)
+	}
+}
+
+func TestStreamClientInterceptorCountsFailedOpen(t *testing.T) {
+	failed := grpcClientHandled.WithLabelValues("test.Stream", "Open", "PermissionDenied")
+	before := testutil.ToFloat64(failed)
+
+	_, err := StreamClientInterceptor()(context.Background(), &grpc.StreamDesc{}, nil, "/test.Stream/Open",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			return nil, status.Error(codes.PermissionDenied, "no")
+		})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("open error = %v", err)
+	}
+	if got := testutil.ToFloat64(failed) - before; got != 1 {
+		t.Fatalf("PermissionDenied count grew by %v, want 1", got)
+	}
+}
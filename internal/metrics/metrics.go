@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "ai_translator"
+
+var (
+	ActiveSessions = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "gateway",
+		Name:      "active_sessions",
+		Help:      "Number of WebSocket sessions currently open.",
+	})
+
+	CaptionViewers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "gateway",
+		Name:      "caption_viewers",
+		Help:      "Number of Server-Sent Events caption streams currently open.",
+	})
+
+	OutboundAudioDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "gateway",
+		Name:      "outbound_audio_dropped_bytes_total",
+		Help:      "Translated audio dropped from the send queue of clients that did not keep up.",
+	})
+
+	SlowClientDisconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "gateway",
+		Name:      "slow_client_disconnects_total",
+		Help:      "WebSocket connections closed because their send queue overflowed.",
+	})
+
+	SessionResumes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "gateway",
+		Name:      "session_resumes_total",
+		Help:      "Attempts to reattach a disconnected WebSocket session with a resume token.",
+	}, []string{"outcome"})
+
+	AudioBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "gateway",
+		Name:      "audio_bytes_total",
+		Help:      "Audio bytes received from (in) and sent to (out) clients.",
+	}, []string{"direction"})
+
+	AudioChunksDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "gateway",
+		Name:      "audio_chunks_dropped_total",
+		Help:      "Inbound audio chunks dropped because the session ingest buffer was full.",
+	})
+
+	FlowControlEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "gateway",
+		Name:      "flow_control_events_total",
+		Help:      "Flow control messages sent to clients as the ingest buffer crosses its watermarks.",
+	}, []string{"action"})
+
+	UtteranceLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "gateway",
+		Name:      "utterance_latency_seconds",
+		Help:      "Time from the first audio of an utterance until each pipeline milestone was reached.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 0.75, 1, 1.5, 2, 3, 5, 7.5, 10, 15, 30},
+	}, []string{"milestone"})
+
+	QuotaRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "gateway",
+		Name:      "quota_rejections_total",
+		Help:      "Requests rejected by tenant quotas and rate limits, by resource.",
+	}, []string{"resource"})
+
+	MeteringFlushes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "gateway",
+		Name:      "metering_flushes_total",
+		Help:      "Usage record batches delivered to the metering sink, by outcome.",
+	}, []string{"outcome"})
+
+	BatchJobs = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "gateway",
+		Name:      "batch_jobs_total",
+		Help:      "Batch translation jobs finished, by final status.",
+	}, []string{"status"})
+
+	BatchQueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "gateway",
+		Name:      "batch_queue_depth",
+		Help:      "Batch translation jobs waiting for a worker.",
+	})
+
+	TextSegments = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "gateway",
+		Name:      "text_segments_total",
+		Help:      "Segments translated through the REST text endpoint, by outcome.",
+	}, []string{"outcome"})
+
+	SpeechRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "gateway",
+		Name:      "speech_requests_total",
+		Help:      "Requests to the REST speech endpoint, by audio format and outcome.",
+	}, []string{"format", "outcome"})
+
+	ASRResults = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "asr",
+		Name:      "results_total",
+		Help:      "Recognition results emitted, by type (partial or final).",
+	}, []string{"type"})
+
+	TranslationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "translator",
+		Name:      "translation_duration_seconds",
+		Help:      "Latency of translation requests by language pair and serving engine.",
+		Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 0.75, 1, 1.5, 2, 3, 5, 10},
+	}, []string{"source_language", "target_language", "engine", "outcome"})
+
+	TTSCharacters = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "tts",
+		Name:      "characters_synthesized_total",
+		Help:      "Characters of text sent to the speech synthesizer, by language and voice.",
+	}, []string{"language", "voice"})
+)
+
+func ResultType(isFinal bool) string {
+	if isFinal {
+		return "final"
+	}
+	return "partial"
+}
+
+func Outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResultType(t *testing.T) {
+	if got := ResultType(true); got != "final" {
+		t.Errorf("ResultType(true) = %q", got)
+	}
+	if got := ResultType(false); got != "partial" {
+		t.Errorf("ResultType(false) = %q", got)
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	if got := Outcome(nil); got != "ok" {
+		t.Errorf("Outcome(nil) = %q", got)
+	}
+	if got := Outcome(errors.New("boom")); got != "error" {
+		t.Errorf("Outcome(err) = %q", got)
+	}
+}
+
+func TestHandlerExposesMetrics(t *testing.T) {
+	ActiveSessions.Set(0)
+	AudioBytes.WithLabelValues("in").Add(0)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/metrics = %d", rec.Code)
+	}
+	body, _ := io.ReadAll(rec.Body)
+	for _, name := range []string{
+		"ai_translator_gateway_active_sessions",
+		`ai_translator_gateway_audio_bytes_total{direction="in"}`,
+		"go_goroutines",
+	} {
+		if !strings.Contains(string(body), name) {
+			t.Errorf("/metrics is missing %s", name)
+		}
+	}
+}
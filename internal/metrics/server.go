@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+type Server struct {
+	server *http.Server
+	logger *slog.Logger
+}
+
+func NewServer(addr string, logger *slog.Logger) *Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+
+	return &Server{
+		server: &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+		logger: logger,
+	}
+}
+
+func (s *Server) Start() error {
+	s.logger.Info("metrics server starting", "address", s.server.Addr)
+	return s.server.ListenAndServe()
+}
+
+func (s *Server) Stop(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
@@ -0,0 +1,341 @@
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"ai-translator/internal/metrics"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	ResourceSessions         = "sessions"
+	ResourceSessionRate      = "session_rate"
+	ResourceAudio            = "audio"
+	ResourceTranslationChars = "translation_chars"
+	ResourceTTSChars         = "tts_chars"
+	ResourceCharRate         = "char_rate"
+)
+
+const (
+	charBurstSeconds = 10
+	minCharBurst     = 5000
+	tenantIdleTTL    = 24 * time.Hour
+)
+
+var (
+	ErrConcurrentSessions = errors.New("concurrent session limit reached")
+	ErrSessionRate        = errors.New("session rate limit reached")
+	ErrAudioQuota         = errors.New("daily audio quota exhausted")
+	ErrTranslationQuota   = errors.New("daily translation character quota exhausted")
+	ErrTTSQuota           = errors.New("daily synthesis character quota exhausted")
+	ErrCharRate           = errors.New("character rate limit reached")
+	ErrTextTooLarge       = errors.New("text exceeds the character rate burst")
+)
+
+func IsRateLimit(err error) bool {
+	return errors.Is(err, ErrConcurrentSessions) || errors.Is(err, ErrSessionRate) || errors.Is(err, ErrCharRate)
+}
+
+func IsQuotaExhausted(err error) bool {
+	return errors.Is(err, ErrAudioQuota) || errors.Is(err, ErrTranslationQuota) || errors.Is(err, ErrTTSQuota)
+}
+
+func IsTooLarge(err error) bool {
+	return errors.Is(err, ErrTextTooLarge)
+}
+
+type Limits struct {
+	MaxSessions            int     `json:"max_sessions"`
+	SessionsPerMinute      float64 `json:"sessions_per_minute"`
+	AudioMinutesPerDay     float64 `json:"audio_minutes_per_day"`
+	TranslationCharsPerDay int64   `json:"translation_chars_per_day"`
+	TTSCharsPerDay         int64   `json:"tts_chars_per_day"`
+	CharsPerSecond         float64 `json:"chars_per_second"`
+}
+
+func (l Limits) Validate() error {
+	if l.MaxSessions < 0 || l.SessionsPerMinute < 0 || l.AudioMinutesPerDay < 0 ||
+		l.TranslationCharsPerDay < 0 || l.TTSCharsPerDay < 0 || l.CharsPerSecond < 0 {
+		return errors.New("limits must not be negative")
+	}
+	return nil
+}
+
+type Usage struct {
+	Day              string  `json:"day"`
+	ActiveSessions   int     `json:"active_sessions"`
+	AudioMinutes     float64 `json:"audio_minutes"`
+	TranslationChars int64   `json:"translation_chars"`
+	TTSChars         int64   `json:"tts_chars"`
+}
+
+type TenantStatus struct {
+	TenantID string `json:"tenant_id"`
+	Limits   Limits `json:"limits"`
+	Override bool   `json:"override"`
+	Usage    Usage  `json:"usage"`
+}
+
+type tenant struct {
+	limits           Limits
+	override         bool
+	day              string
+	sessions         int
+	audio            time.Duration
+	translationChars int64
+	ttsChars         int64
+	lastUsed         time.Time
+	sessionBucket    *rate.Limiter
+	translationRate  *rate.Limiter
+	ttsRate          *rate.Limiter
+}
+
+type Manager struct {
+	mu       sync.Mutex
+	defaults Limits
+	tenants  map[string]*tenant
+	now      func() time.Time
+}
+
+func NewManager(defaults Limits) *Manager {
+	return &Manager{
+		defaults: defaults,
+		tenants:  make(map[string]*tenant),
+		now:      time.Now,
+	}
+}
+
+func (m *Manager) tenant(id string) *tenant {
+	t, ok := m.tenants[id]
+	if !ok {
+		t = &tenant{}
+		t.apply(m.defaults)
+		m.tenants[id] = t
+	}
+
+	if day := m.now().UTC().Format(time.DateOnly); t.day != day {
+		t.day = day
+		t.audio = 0
+		t.translationChars = 0
+		t.ttsChars = 0
+	}
+	return t
+}
+
+func (m *Manager) use(id string) *tenant {
+	t := m.tenant(id)
+	t.lastUsed = m.now()
+	return t
+}
+
+func (t *tenant) apply(limits Limits) {
+	t.limits = limits
+	t.sessionBucket = newLimiter(limits.SessionsPerMinute/60, int(math.Ceil(limits.SessionsPerMinute)))
+	charBurst := max(int(limits.CharsPerSecond*charBurstSeconds), minCharBurst)
+	t.translationRate = newLimiter(limits.CharsPerSecond, charBurst)
+	t.ttsRate = newLimiter(limits.CharsPerSecond, charBurst)
+}
+
+func newLimiter(perSecond float64, burst int) *rate.Limiter {
+	if perSecond <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), max(burst, 1))
+}
+
+func (t *tenant) usage() Usage {
+	return Usage{
+		Day:              t.day,
+		ActiveSessions:   t.sessions,
+		AudioMinutes:     t.audio.Minutes(),
+		TranslationChars: t.translationChars,
+		TTSChars:         t.ttsChars,
+	}
+}
+
+func reject(resource string, err error) error {
+	metrics.QuotaRejections.WithLabelValues(resource).Inc()
+	return err
+}
+
+func allowChars(limiter *rate.Limiter, now time.Time, chars int) error {
+	if limiter.Limit() != rate.Inf && chars > limiter.Burst() {
+		return reject(ResourceCharRate, fmt.Errorf("%w: %d characters, at most %d at once", ErrTextTooLarge, chars, limiter.Burst()))
+	}
+	if !limiter.AllowN(now, chars) {
+		return reject(ResourceCharRate, ErrCharRate)
+	}
+	return nil
+}
+
+func (m *Manager) AcquireSession(tenantID string) (func(), error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.use(tenantID)
+	if t.limits.MaxSessions > 0 && t.sessions >= t.limits.MaxSessions {
+		return nil, reject(ResourceSessions, ErrConcurrentSessions)
+	}
+	if t.limits.AudioMinutesPerDay > 0 && t.audio.Minutes() >= t.limits.AudioMinutesPerDay {
+		return nil, reject(ResourceAudio, ErrAudioQuota)
+	}
+	if !t.sessionBucket.AllowN(m.now(), 1) {
+		return nil, reject(ResourceSessionRate, ErrSessionRate)
+	}
+
+	t.sessions++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			m.tenants[tenantID].sessions--
+		})
+	}, nil
+}
+
+func (m *Manager) ConsumeAudio(tenantID string, d time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.use(tenantID)
+	if t.limits.AudioMinutesPerDay > 0 && t.audio.Minutes() >= t.limits.AudioMinutesPerDay {
+		return reject(ResourceAudio, ErrAudioQuota)
+	}
+	t.audio += d
+	return nil
+}
+
+func (m *Manager) ConsumeTranslation(tenantID string, chars int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.use(tenantID)
+	if t.limits.TranslationCharsPerDay > 0 && t.translationChars+int64(chars) > t.limits.TranslationCharsPerDay {
+		return reject(ResourceTranslationChars, ErrTranslationQuota)
+	}
+	if err := allowChars(t.translationRate, m.now(), chars); err != nil {
+		return err
+	}
+	t.translationChars += int64(chars)
+	return nil
+}
+
+func (m *Manager) ConsumeTTS(tenantID string, chars int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.use(tenantID)
+	if t.limits.TTSCharsPerDay > 0 && t.ttsChars+int64(chars) > t.limits.TTSCharsPerDay {
+		return reject(ResourceTTSChars, ErrTTSQuota)
+	}
+	if err := allowChars(t.ttsRate, m.now(), chars); err != nil {
+		return err
+	}
+	t.ttsChars += int64(chars)
+	return nil
+}
+
+func (m *Manager) Defaults() Limits {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.defaults
+}
+
+func (m *Manager) SetDefaults(limits Limits) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.defaults = limits
+	for _, t := range m.tenants {
+		if !t.override {
+			t.apply(limits)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) SetLimits(tenantID string, limits Limits) error {
+	if err := limits.Validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.tenant(tenantID)
+	t.apply(limits)
+	t.override = true
+	return nil
+}
+
+func (m *Manager) ResetLimits(tenantID string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.tenant(tenantID)
+	t.apply(m.defaults)
+	t.override = false
+}
+
+func (m *Manager) Status(tenantID string) TenantStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t := m.tenant(tenantID)
+	return TenantStatus{TenantID: tenantID, Limits: t.limits, Override: t.override, Usage: t.usage()}
+}
+
+func (m *Manager) Statuses() []TenantStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]TenantStatus, 0, len(m.tenants))
+	for id := range m.tenants {
+		t := m.tenant(id)
+		statuses = append(statuses, TenantStatus{TenantID: id, Limits: t.limits, Override: t.override, Usage: t.usage()})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].TenantID < statuses[j].TenantID })
+	return statuses
+}
+
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Prune()
+		}
+	}
+}
+
+func (m *Manager) Prune() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	pruned := 0
+	for id, t := range m.tenants {
+		if !t.override && t.sessions == 0 && now.Sub(t.lastUsed) >= tenantIdleTTL {
+			delete(m.tenants, id)
+			pruned++
+		}
+	}
+	return pruned
+}
@@ -0,0 +1,238 @@
+package quota
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time { return c.now }
+
+func newTestManager(limits Limits) (*Manager, *clock) {
+	c := &clock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	m := NewManager(limits)
+	m.now = c.Now
+	return m, c
+}
+
+func TestConcurrentSessions(t *testing.T) {
+	m, _ := newTestManager(Limits{MaxSessions: 2})
+
+	release1, err := m.AcquireSession("acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AcquireSession("acme"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.AcquireSession("acme"); !errors.Is(err, ErrConcurrentSessions) {
+		t.Fatalf("third session: error = %v, want ErrConcurrentSessions", err)
+	}
+	if _, err := m.AcquireSession("globex"); err != nil {
+		t.Fatalf("other tenant: %v", err)
+	}
+
+	release1()
+	release1()
+	if got := m.Status("acme").Usage.ActiveSessions; got != 1 {
+		t.Fatalf("active sessions = %d after a double release, want 1", got)
+	}
+	if _, err := m.AcquireSession("acme"); err != nil {
+		t.Fatalf("after release: %v", err)
+	}
+}
+
+func TestSessionRate(t *testing.T) {
+	m, c := newTestManager(Limits{SessionsPerMinute: 2})
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.AcquireSession("acme"); err != nil {
+			t.Fatalf("session %d: %v", i, err)
+		}
+	}
+	if _, err := m.AcquireSession("acme"); !errors.Is(err, ErrSessionRate) || !IsRateLimit(err) {
+		t.Fatalf("error = %v, want ErrSessionRate", err)
+	}
+
+	c.now = c.now.Add(30 * time.Second)
+	if _, err := m.AcquireSession("acme"); err != nil {
+		t.Fatalf("after refill: %v", err)
+	}
+}
+
+func TestDailyAudioQuota(t *testing.T) {
+	m, c := newTestManager(Limits{AudioMinutesPerDay: 1})
+
+	if err := m.ConsumeAudio("acme", 50*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ConsumeAudio("acme", 20*time.Second); err != nil {
+		t.Fatalf("quota is checked before the chunk is added: %v", err)
+	}
+	if err := m.ConsumeAudio("acme", time.Second); !errors.Is(err, ErrAudioQuota) || !IsQuotaExhausted(err) {
+		t.Fatalf("error = %v, want ErrAudioQuota", err)
+	}
+	if _, err := m.AcquireSession("acme"); !errors.Is(err, ErrAudioQuota) {
+		t.Fatalf("new session: error = %v, want ErrAudioQuota", err)
+	}
+
+	c.now = c.now.Add(12 * time.Hour)
+	if err := m.ConsumeAudio("acme", time.Second); err != nil {
+		t.Fatalf("next day: %v", err)
+	}
+	if got := m.Status("acme").Usage; got.Day != "2025-01-02" || got.AudioMinutes != 1.0/60 {
+		t.Errorf("usage = %+v", got)
+	}
+}
+
+func TestCharacterQuotas(t *testing.T) {
+	m, _ := newTestManager(Limits{TranslationCharsPerDay: 10, TTSCharsPerDay: 5})
+
+	if err := m.ConsumeTranslation("acme", 10); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.ConsumeTranslation("acme", 1); !errors.Is(err, ErrTranslationQuota) {
+		t.Fatalf("translation: error = %v, want ErrTranslationQuota", err)
+	}
+	if err := m.ConsumeTranslation("acme", 0); err != nil {
+		t.Fatalf("zero characters: %v", err)
+	}
+	if err := m.ConsumeTTS("acme", 6); !errors.Is(err, ErrTTSQuota) {
+		t.Fatalf("tts: error = %v, want ErrTTSQuota", err)
+	}
+	if err := m.ConsumeTTS("acme", 5); err != nil {
+		t.Fatal(err)
+	}
+
+	usage := m.Status("acme").Usage
+	if usage.TranslationChars != 10 || usage.TTSChars != 5 {
+		t.Errorf("usage = %+v", usage)
+	}
+}
+
+func TestCharacterRate(t *testing.T) {
+	m, c := newTestManager(Limits{CharsPerSecond: 1000})
+
+	if err := m.ConsumeTranslation("acme", 10000); err != nil {
+		t.Fatalf("burst: %v", err)
+	}
+	if err := m.ConsumeTranslation("acme", 100); !errors.Is(err, ErrCharRate) {
+		t.Fatalf("error = %v, want ErrCharRate", err)
+	}
+	if err := m.ConsumeTTS("acme", 100); err != nil {
+		t.Fatalf("synthesis has its own bucket: %v", err)
+	}
+
+	c.now = c.now.Add(time.Second)
+	if err := m.ConsumeTranslation("acme", 1000); err != nil {
+		t.Fatalf("after refill: %v", err)
+	}
+}
+
+func TestTextLargerThanBurst(t *testing.T) {
+	m, c := newTestManager(Limits{CharsPerSecond: 100})
+
+	err := m.ConsumeTranslation("acme", 5001)
+	if !errors.Is(err, ErrTextTooLarge) || !IsTooLarge(err) || IsRateLimit(err) {
+		t.Fatalf("error = %v, want ErrTextTooLarge and not retryable", err)
+	}
+	if err := m.ConsumeTTS("acme", 5001); !IsTooLarge(err) {
+		t.Fatalf("tts error = %v, want ErrTextTooLarge", err)
+	}
+
+	c.now = c.now.Add(time.Hour)
+	if err := m.ConsumeTranslation("acme", 5001); !IsTooLarge(err) {
+		t.Fatalf("after refill: error = %v, want ErrTextTooLarge", err)
+	}
+	if err := m.ConsumeTranslation("acme", 5000); err != nil {
+		t.Fatalf("a full burst: %v", err)
+	}
+	if got := m.Status("acme").Usage.TranslationChars; got != 5000 {
+		t.Fatalf("translation chars = %d, want only the accepted text counted", got)
+	}
+
+	unlimited, _ := newTestManager(Limits{})
+	if err := unlimited.ConsumeTranslation("acme", 1_000_000); err != nil {
+		t.Fatalf("without a rate limit: %v", err)
+	}
+}
+
+func TestPruneIdleTenants(t *testing.T) {
+	m, c := newTestManager(Limits{MaxSessions: 5})
+
+	if err := m.ConsumeTranslation("idle", 10); err != nil {
+		t.Fatal(err)
+	}
+	release, err := m.AcquireSession("connected")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetLimits("override", Limits{MaxSessions: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	c.now = c.now.Add(tenantIdleTTL - time.Minute)
+	if err := m.ConsumeTTS("recent", 10); err != nil {
+		t.Fatal(err)
+	}
+	if n := m.Prune(); n != 0 {
+		t.Fatalf("pruned %d tenants before they were idle for a day", n)
+	}
+
+	c.now = c.now.Add(time.Minute)
+	if n := m.Prune(); n != 1 {
+		t.Fatalf("pruned %d tenants, want only the idle one", n)
+	}
+	var ids []string
+	for _, s := range m.Statuses() {
+		ids = append(ids, s.TenantID)
+	}
+	if got := strings.Join(ids, ","); got != "connected,override,recent" {
+		t.Fatalf("tenants = %s", got)
+	}
+
+	release()
+	c.now = c.now.Add(tenantIdleTTL)
+	if n := m.Prune(); n != 2 {
+		t.Fatalf("pruned %d tenants after the session ended, want 2", n)
+	}
+	if got := m.Statuses(); len(got) != 1 || got[0].TenantID != "override" {
+		t.Fatalf("tenants = %+v, want only the override kept", got)
+	}
+}
+
+func TestLimitOverrides(t *testing.T) {
+	m, _ := newTestManager(Limits{MaxSessions: 1})
+
+	if err := m.SetLimits("acme", Limits{MaxSessions: -1}); err == nil {
+		t.Fatal("negative limits accepted")
+	}
+	if err := m.SetLimits("acme", Limits{MaxSessions: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.SetDefaults(Limits{MaxSessions: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := m.Status("acme"); !got.Override || got.Limits.MaxSessions != 3 {
+		t.Errorf("acme = %+v, want its override kept", got)
+	}
+	if got := m.Status("globex"); got.Override || got.Limits.MaxSessions != 2 {
+		t.Errorf("globex = %+v, want the new defaults", got)
+	}
+
+	m.ResetLimits("acme")
+	if got := m.Status("acme"); got.Override || got.Limits.MaxSessions != 2 {
+		t.Errorf("acme after reset = %+v", got)
+	}
+
+	statuses := m.Statuses()
+	if len(statuses) != 2 || statuses[0].TenantID != "acme" || statuses[1].TenantID != "globex" {
+		t.Errorf("Statuses = %+v", statuses)
+	}
+}
@@ -0,0 +1,108 @@
+package recording
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+const EventsFile = "events.jsonl"
+
+const (
+	EventConfig      = "config"
+	EventRecognition = "recognition"
+	EventTranslation = "translation"
+)
+
+type SessionConfig struct {
+	SourceLanguage string `json:"source_language"`
+	TargetLanguage string `json:"target_language"`
+	Formality      string `json:"formality,omitempty"`
+	Domain         string `json:"domain,omitempty"`
+	Audience       string `json:"audience,omitempty"`
+}
+
+type Recognition struct {
+	Transcript       string  `json:"transcript"`
+	IsFinal          bool    `json:"is_final"`
+	Stability        float32 `json:"stability,omitempty"`
+	DetectedLanguage string  `json:"detected_language,omitempty"`
+}
+
+type Translation struct {
+	Text           string `json:"text"`
+	IsFinal        bool   `json:"is_final"`
+	SourceLanguage string `json:"source_language,omitempty"`
+	TargetLanguage string `json:"target_language,omitempty"`
+	TranslatedText string `json:"translated_text"`
+	Engine         string `json:"engine,omitempty"`
+}
+
+type Event struct {
+	OffsetMs    int64          `json:"offset_ms"`
+	Kind        string         `json:"kind"`
+	Config      *SessionConfig `json:"config,omitempty"`
+	Recognition *Recognition   `json:"recognition,omitempty"`
+	Translation *Translation   `json:"translation,omitempty"`
+}
+
+func (r *Recorder) CaptureConfig(c SessionConfig) {
+	r.capture(Event{Kind: EventConfig, Config: &c})
+}
+
+func (r *Recorder) CaptureRecognition(rec Recognition) {
+	r.capture(Event{Kind: EventRecognition, Recognition: &rec})
+}
+
+func (r *Recorder) CaptureTranslation(t Translation) {
+	r.capture(Event{Kind: EventTranslation, Translation: &t})
+}
+
+func (r *Recorder) capture(e Event) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed || r.events == nil {
+		return
+	}
+
+	e.OffsetMs = time.Since(r.startedAt).Milliseconds()
+	if err := r.eventsEnc.Encode(e); err != nil {
+		r.logger.Error("failed to capture recording event", "error", err, "kind", e.Kind)
+	}
+}
+
+func ReadEvents(r io.Reader) ([]Event, error) {
+	var events []Event
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		events = append(events, e)
+	}
+	return events, scanner.Err()
+}
+
+func (r *Recorder) openEvents(ctx context.Context) error {
+	f, err := r.storage.Create(ctx, r.manifest.SessionID+"/"+EventsFile)
+	if err != nil {
+		return err
+	}
+	r.events = f
+	r.eventsEnc = json.NewEncoder(f)
+	r.manifest.Events = r.manifest.SessionID + "/" + EventsFile
+	return nil
+}
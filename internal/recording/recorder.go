@@ -0,0 +1,409 @@
+package recording
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ai-translator/internal/audio"
+)
+
+const (
+	ModeTracks = "tracks"
+	ModeStereo = "stereo"
+)
+
+const (
+	TrackInbound  = "inbound"
+	TrackOutbound = "outbound"
+)
+
+const ManifestFile = "manifest.json"
+
+func ParseMode(mode string) (string, error) {
+	switch mode {
+	case ModeTracks, ModeStereo:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown recording mode %q, expected tracks or stereo", mode)
+}
+
+type Entry struct {
+	Key        string   `json:"key"`
+	Channels   int      `json:"channels"`
+	Tracks     []string `json:"tracks"`
+	DurationMs int64    `json:"duration_ms"`
+	Bytes      int64    `json:"bytes"`
+}
+
+type Manifest struct {
+	SessionID  string     `json:"session_id"`
+	TenantID   string     `json:"tenant_id"`
+	Mode       string     `json:"mode"`
+	SampleRate int        `json:"sample_rate"`
+	StartedAt  time.Time  `json:"started_at"`
+	EndedAt    time.Time  `json:"ended_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	Transcript string     `json:"transcript,omitempty"`
+	Events     string     `json:"events,omitempty"`
+	Files      []Entry    `json:"files"`
+}
+
+type Manager struct {
+	storage   Storage
+	retention time.Duration
+	logger    *slog.Logger
+}
+
+func NewManager(storage Storage, retention time.Duration, logger *slog.Logger) *Manager {
+	return &Manager{storage: storage, retention: retention, logger: logger}
+}
+
+func (m *Manager) Start(ctx context.Context, sessionID, tenantID, mode, transcript string, startedAt time.Time) (*Recorder, error) {
+	r := &Recorder{
+		storage:   m.storage,
+		retention: m.retention,
+		startedAt: startedAt,
+		manifest: Manifest{
+			SessionID:  sessionID,
+			TenantID:   tenantID,
+			Mode:       mode,
+			SampleRate: audio.SampleRate,
+			StartedAt:  startedAt.UTC(),
+			Transcript: transcript,
+		},
+		logger: m.logger.With("session_id", sessionID),
+	}
+
+	var err error
+	switch mode {
+	case ModeTracks:
+		r.tracks[0], err = r.open(ctx, TrackInbound, 1, TrackInbound)
+		if err == nil {
+			r.tracks[1], err = r.open(ctx, TrackOutbound, 1, TrackOutbound)
+		}
+	case ModeStereo:
+		r.tracks[0], r.tracks[1] = &track{}, &track{}
+		r.mix, err = r.open(ctx, "mix", 2, TrackInbound, TrackOutbound)
+	default:
+		_, err = ParseMode(mode)
+	}
+	if err == nil {
+		err = r.openEvents(ctx)
+	}
+	if err != nil {
+		r.closeFiles()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (m *Manager) Run(ctx context.Context, interval time.Duration) {
+	if m.retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if deleted, err := m.Sweep(ctx, time.Now()); err != nil {
+			m.logger.Error("recording retention sweep failed", "error", err)
+		} else if deleted > 0 {
+			m.logger.Info("expired recordings deleted", "sessions", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) Sweep(ctx context.Context, now time.Time) (int, error) {
+	if m.retention <= 0 {
+		return 0, nil
+	}
+
+	objects, err := m.storage.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sessions := make(map[string][]Object)
+	latest := make(map[string]time.Time)
+	for _, obj := range objects {
+		session, _, ok := strings.Cut(obj.Key, "/")
+		if !ok {
+			continue
+		}
+		sessions[session] = append(sessions[session], obj)
+		if obj.ModTime.After(latest[session]) {
+			latest[session] = obj.ModTime
+		}
+	}
+
+	cutoff := now.Add(-m.retention)
+	deleted := 0
+	for session, objs := range sessions {
+		if latest[session].After(cutoff) {
+			continue
+		}
+		for _, obj := range objs {
+			if err := m.storage.Delete(ctx, obj.Key); err != nil {
+				return deleted, err
+			}
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+type track struct {
+	entry   *Entry
+	object  File
+	wav     *audio.WAVWriter
+	end     int64
+	pending []byte
+}
+
+type Recorder struct {
+	mu        sync.Mutex
+	storage   Storage
+	retention time.Duration
+	startedAt time.Time
+	manifest  Manifest
+	tracks    [2]*track
+	mix       *track
+	events    File
+	eventsEnc *json.Encoder
+	closed    bool
+	failed    bool
+	logger    *slog.Logger
+}
+
+func (r *Recorder) open(ctx context.Context, name string, channels int, tracks ...string) (*track, error) {
+	key := path.Join(r.manifest.SessionID, name+".wav")
+	f, err := r.storage.Create(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	wav, err := audio.NewWAVWriter(f, audio.SampleRate, channels)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &track{
+		entry:  &Entry{Key: key, Channels: channels, Tracks: tracks},
+		object: f,
+		wav:    wav,
+	}, nil
+}
+
+func (r *Recorder) WriteInbound(data []byte) {
+	r.write(0, data, time.Now())
+}
+
+func (r *Recorder) WriteOutbound(data []byte) {
+	r.write(1, data, time.Now())
+}
+
+func (r *Recorder) write(i int, data []byte, now time.Time) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed || r.failed {
+		return
+	}
+
+	pos := r.offset(now)
+	var err error
+	if r.mix != nil {
+		err = r.writeMix(i, data, pos)
+	} else {
+		err = r.writeTrack(r.tracks[i], data, pos)
+	}
+	if err != nil {
+		r.failed = true
+		r.logger.Error("recording write failed, recording stopped", "error", err)
+	}
+}
+
+func (r *Recorder) offset(now time.Time) int64 {
+	elapsed := now.Sub(r.startedAt)
+	if elapsed <= 0 {
+		return 0
+	}
+	return int64(elapsed) * audio.SampleRate / int64(time.Second) * audio.BytesPerSample
+}
+
+func (r *Recorder) writeTrack(t *track, data []byte, pos int64) error {
+	if gap := pos - t.end; gap > 0 {
+		if err := writeSilence(t.wav, gap); err != nil {
+			return err
+		}
+		t.end = pos
+	}
+	if _, err := t.wav.Write(data); err != nil {
+		return err
+	}
+	t.end += int64(len(data))
+	return nil
+}
+
+func (r *Recorder) writeMix(i int, data []byte, pos int64) error {
+	lead := max(r.tracks[0].end, r.tracks[1].end)
+	if err := r.padMix(min(pos, lead)); err != nil {
+		return err
+	}
+	if pos > lead {
+		if err := writeSilence(r.mix.wav, (pos-lead)*2); err != nil {
+			return err
+		}
+		r.tracks[0].end, r.tracks[1].end = pos, pos
+	}
+
+	t := r.tracks[i]
+	t.pending = append(t.pending, data...)
+	t.end += int64(len(data))
+	return r.flushMix()
+}
+
+func (r *Recorder) padMix(pos int64) error {
+	for _, t := range r.tracks {
+		if gap := pos - t.end; gap > 0 {
+			t.pending = append(t.pending, make([]byte, gap)...)
+			t.end = pos
+		}
+	}
+	return r.flushMix()
+}
+
+func (r *Recorder) flushMix() error {
+	in, out := r.tracks[0], r.tracks[1]
+	n := min(len(in.pending), len(out.pending)) &^ (audio.BytesPerSample - 1)
+	if n == 0 {
+		return nil
+	}
+
+	frames := make([]byte, 0, n*2)
+	for i := 0; i < n; i += audio.BytesPerSample {
+		frames = append(frames, in.pending[i:i+audio.BytesPerSample]...)
+		frames = append(frames, out.pending[i:i+audio.BytesPerSample]...)
+	}
+	in.pending = append(in.pending[:0], in.pending[n:]...)
+	out.pending = append(out.pending[:0], out.pending[n:]...)
+
+	_, err := r.mix.wav.Write(frames)
+	return err
+}
+
+var silence = make([]byte, 4096)
+
+func writeSilence(w *audio.WAVWriter, n int64) error {
+	for n > 0 {
+		chunk := min(n, int64(len(silence)))
+		if _, err := w.Write(silence[:chunk]); err != nil {
+			return err
+		}
+		n -= chunk
+	}
+	return nil
+}
+
+func (r *Recorder) Close(ctx context.Context, endedAt time.Time) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+
+	if r.mix != nil && !r.failed {
+		if err := r.padMix(max(r.tracks[0].end, r.tracks[1].end)); err != nil {
+			r.failed = true
+			r.logger.Error("recording write failed", "error", err)
+		}
+	}
+	if err := r.closeFiles(); err != nil {
+		return err
+	}
+
+	r.manifest.EndedAt = endedAt.UTC()
+	if r.retention > 0 {
+		expiresAt := r.manifest.EndedAt.Add(r.retention)
+		r.manifest.ExpiresAt = &expiresAt
+	}
+	for _, t := range r.files() {
+		bytesPerFrame := int64(t.entry.Channels * audio.BytesPerSample)
+		t.entry.Bytes = t.wav.DataBytes()
+		t.entry.DurationMs = t.entry.Bytes / bytesPerFrame * 1000 / audio.SampleRate
+		r.manifest.Files = append(r.manifest.Files, *t.entry)
+	}
+	sort.Slice(r.manifest.Files, func(i, j int) bool { return r.manifest.Files[i].Key < r.manifest.Files[j].Key })
+
+	return r.writeManifest(ctx)
+}
+
+func (r *Recorder) files() []*track {
+	if r.mix != nil {
+		return []*track{r.mix}
+	}
+	var tracks []*track
+	for _, t := range r.tracks {
+		if t != nil {
+			tracks = append(tracks, t)
+		}
+	}
+	return tracks
+}
+
+func (r *Recorder) closeFiles() error {
+	var firstErr error
+	if r.events != nil {
+		firstErr = r.events.Close()
+	}
+	for _, t := range r.files() {
+		if t == nil {
+			continue
+		}
+		if err := t.wav.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if err := t.object.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (r *Recorder) writeManifest(ctx context.Context) error {
+	f, err := r.storage.Create(ctx, path.Join(r.manifest.SessionID, ManifestFile))
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r.manifest); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
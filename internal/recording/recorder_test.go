@@ -0,0 +1,373 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ai-translator/internal/audio"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func newTestManager(t *testing.T, retention time.Duration) (*Manager, string) {
+	t.Helper()
+	dir := t.TempDir()
+	storage, err := NewLocalStorage(dir)
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	return NewManager(storage, retention, discardLogger()), dir
+}
+
+func samples(value int16, n int) []byte {
+	out := make([]byte, 0, n*audio.BytesPerSample)
+	for range n {
+		out = binary.LittleEndian.AppendUint16(out, uint16(value))
+	}
+	return out
+}
+
+func readWAV(t *testing.T, path string) (*audio.WAVReader, []byte) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+	r, err := audio.NewWAVReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewWAVReader(%s): %v", path, err)
+	}
+	pcm, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("read %s data: %v", path, err)
+	}
+	return r, pcm
+}
+
+func readManifest(t *testing.T, dir, sessionID string) Manifest {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, sessionID, ManifestFile))
+	if err != nil {
+		t.Fatalf("read manifest: %v", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+	return m
+}
+
+func TestParseMode(t *testing.T) {
+	for _, mode := range []string{ModeTracks, ModeStereo} {
+		if got, err := ParseMode(mode); err != nil || got != mode {
+			t.Errorf("ParseMode(%q) = %q, %v", mode, got, err)
+		}
+	}
+	for _, mode := range []string{"", "mono", "Stereo"} {
+		if _, err := ParseMode(mode); err == nil {
+			t.Errorf("ParseMode(%q) succeeded", mode)
+		}
+	}
+}
+
+func TestStartRejectsUnknownMode(t *testing.T) {
+	m, _ := newTestManager(t, 0)
+	if _, err := m.Start(context.Background(), "sess_1", "acme", "mono", "", time.Now()); err == nil {
+		t.Fatal("Start accepted an unknown mode")
+	}
+}
+
+func TestTracksRecording(t *testing.T) {
+	m, dir := newTestManager(t, 0)
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	r, err := m.Start(context.Background(), "sess_1", "acme", ModeTracks, "/v1/sessions/sess_1/transcript", start)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	r.write(0, samples(100, 160), start)
+	r.write(1, samples(200, 160), start.Add(100*time.Millisecond))
+	r.write(0, samples(300, 160), start.Add(50*time.Millisecond))
+	if err := r.Close(context.Background(), start.Add(time.Second)); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	in, inPCM := readWAV(t, filepath.Join(dir, "sess_1", "inbound.wav"))
+	if in.Channels != 1 || in.SampleRate != audio.SampleRate {
+		t.Fatalf("inbound format = %+v", in.WAVFormat)
+	}
+	gap := 50 * audio.SampleRate / 1000 * audio.BytesPerSample
+	want := append(append(samples(100, 160), make([]byte, gap-320)...), samples(300, 160)...)
+	if !bytes.Equal(inPCM, want) {
+		t.Fatalf("inbound = %d bytes, want %d bytes with silence up to the 50ms offset", len(inPCM), len(want))
+	}
+
+	_, outPCM := readWAV(t, filepath.Join(dir, "sess_1", "outbound.wav"))
+	gap = 100 * audio.SampleRate / 1000 * audio.BytesPerSample
+	if want := append(make([]byte, gap), samples(200, 160)...); !bytes.Equal(outPCM, want) {
+		t.Fatalf("outbound = %d bytes, want %d bytes aligned to the 100ms offset", len(outPCM), len(want))
+	}
+
+	manifest := readManifest(t, dir, "sess_1")
+	if manifest.SessionID != "sess_1" || manifest.TenantID != "acme" || manifest.Mode != ModeTracks {
+		t.Fatalf("manifest = %+v", manifest)
+	}
+	if manifest.Transcript != "/v1/sessions/sess_1/transcript" || manifest.Events != "sess_1/"+EventsFile {
+		t.Fatalf("manifest links = %q, %q", manifest.Transcript, manifest.Events)
+	}
+	if !manifest.StartedAt.Equal(start) || !manifest.EndedAt.Equal(start.Add(time.Second)) || manifest.ExpiresAt != nil {
+		t.Fatalf("manifest times = %v, %v, %v", manifest.StartedAt, manifest.EndedAt, manifest.ExpiresAt)
+	}
+	if len(manifest.Files) != 2 {
+		t.Fatalf("manifest files = %+v", manifest.Files)
+	}
+	inbound, outbound := manifest.Files[0], manifest.Files[1]
+	if inbound.Key != "sess_1/inbound.wav" || inbound.Channels != 1 || inbound.Tracks[0] != TrackInbound {
+		t.Fatalf("inbound entry = %+v", inbound)
+	}
+	if inbound.Bytes != int64(len(inPCM)) || inbound.DurationMs != 60 {
+		t.Fatalf("inbound entry size = %d bytes, %d ms", inbound.Bytes, inbound.DurationMs)
+	}
+	if outbound.Key != "sess_1/outbound.wav" || outbound.DurationMs != 110 {
+		t.Fatalf("outbound entry = %+v", outbound)
+	}
+}
+
+func TestStereoRecording(t *testing.T) {
+	m, dir := newTestManager(t, 0)
+	start := time.Now()
+	r, err := m.Start(context.Background(), "sess_1", "acme", ModeStereo, "", start)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	r.write(0, samples(100, 320), start)
+	r.write(1, samples(200, 160), start.Add(10*time.Millisecond))
+	if err := r.Close(context.Background(), start.Add(time.Second)); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "sess_1", "inbound.wav")); !os.IsNotExist(err) {
+		t.Fatalf("stereo mode wrote a separate inbound track: %v", err)
+	}
+	mix, pcm := readWAV(t, filepath.Join(dir, "sess_1", "mix.wav"))
+	if mix.Channels != 2 {
+		t.Fatalf("mix channels = %d, want 2", mix.Channels)
+	}
+	if len(pcm) != 320*2*audio.BytesPerSample {
+		t.Fatalf("mix = %d bytes, want %d", len(pcm), 320*2*audio.BytesPerSample)
+	}
+	for i := 0; i < len(pcm); i += 2 * audio.BytesPerSample {
+		frame := i / (2 * audio.BytesPerSample)
+		left := int16(binary.LittleEndian.Uint16(pcm[i:]))
+		right := int16(binary.LittleEndian.Uint16(pcm[i+audio.BytesPerSample:]))
+		wantRight := int16(0)
+		if frame >= 160 {
+			wantRight = 200
+		}
+		if left != 100 || right != wantRight {
+			t.Fatalf("frame %d = (%d, %d), want (100, %d)", frame, left, right, wantRight)
+		}
+	}
+
+	manifest := readManifest(t, dir, "sess_1")
+	if len(manifest.Files) != 1 {
+		t.Fatalf("manifest files = %+v", manifest.Files)
+	}
+	entry := manifest.Files[0]
+	if entry.Key != "sess_1/mix.wav" || entry.Channels != 2 || len(entry.Tracks) != 2 || entry.DurationMs != 20 {
+		t.Fatalf("mix entry = %+v", entry)
+	}
+}
+
+func TestStereoRecordingPadsUnevenTracksOnClose(t *testing.T) {
+	m, dir := newTestManager(t, 0)
+	start := time.Now()
+	r, err := m.Start(context.Background(), "sess_1", "acme", ModeStereo, "", start)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	r.write(0, samples(100, 160), start)
+	if err := r.Close(context.Background(), start); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	_, pcm := readWAV(t, filepath.Join(dir, "sess_1", "mix.wav"))
+	if len(pcm) != 160*2*audio.BytesPerSample {
+		t.Fatalf("mix = %d bytes, want the inbound audio with a silent right channel", len(pcm))
+	}
+}
+
+func TestRecorderIgnoresWritesAfterClose(t *testing.T) {
+	m, dir := newTestManager(t, 0)
+	start := time.Now()
+	r, err := m.Start(context.Background(), "sess_1", "acme", ModeTracks, "", start)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := r.Close(context.Background(), start); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	r.WriteInbound(samples(1, 160))
+	r.CaptureConfig(SessionConfig{SourceLanguage: "en-US"})
+	if err := r.Close(context.Background(), start); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+
+	_, pcm := readWAV(t, filepath.Join(dir, "sess_1", "inbound.wav"))
+	if len(pcm) != 0 {
+		t.Fatalf("inbound = %d bytes after close", len(pcm))
+	}
+}
+
+func TestNilRecorder(t *testing.T) {
+	var r *Recorder
+	r.WriteInbound([]byte{1, 2})
+	r.WriteOutbound([]byte{1, 2})
+	r.CaptureRecognition(Recognition{Transcript: "hello"})
+	if err := r.Close(context.Background(), time.Now()); err != nil {
+		t.Fatalf("Close on nil recorder: %v", err)
+	}
+}
+
+func TestRecorderCapturesEvents(t *testing.T) {
+	m, dir := newTestManager(t, 0)
+	r, err := m.Start(context.Background(), "sess_1", "acme", ModeTracks, "", time.Now())
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	r.CaptureConfig(SessionConfig{SourceLanguage: "en-US", TargetLanguage: "es-ES", Formality: "formal"})
+	r.CaptureRecognition(Recognition{Transcript: "hello", IsFinal: true, DetectedLanguage: "en-US"})
+	r.CaptureTranslation(Translation{Text: "hello", IsFinal: true, TranslatedText: "hola", Engine: "gemini"})
+	if err := r.Close(context.Background(), time.Now()); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "sess_1", EventsFile))
+	if err != nil {
+		t.Fatalf("open events: %v", err)
+	}
+	defer f.Close()
+	events, err := ReadEvents(f)
+	if err != nil {
+		t.Fatalf("ReadEvents: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("events = %+v", events)
+	}
+	if events[0].Kind != EventConfig || events[0].Config.Formality != "formal" {
+		t.Fatalf("config event = %+v", events[0])
+	}
+	if events[1].Kind != EventRecognition || events[1].Recognition.Transcript != "hello" || !events[1].Recognition.IsFinal {
+		t.Fatalf("recognition event = %+v", events[1])
+	}
+	if events[2].Kind != EventTranslation || events[2].Translation.TranslatedText != "hola" {
+		t.Fatalf("translation event = %+v", events[2])
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].OffsetMs < events[i-1].OffsetMs {
+			t.Fatalf("event offsets went backwards: %+v", events)
+		}
+	}
+}
+
+func TestReadEventsReportsBadLine(t *testing.T) {
+	in := "{\"kind\":\"config\"}\n\n{not json}\n"
+	if _, err := ReadEvents(bytes.NewBufferString(in)); err == nil || !bytes.Contains([]byte(err.Error()), []byte("line 3")) {
+		t.Fatalf("ReadEvents error = %v, want line 3", err)
+	}
+}
+
+func TestManifestExpiry(t *testing.T) {
+	m, dir := newTestManager(t, 24*time.Hour)
+	start := time.Now()
+	r, err := m.Start(context.Background(), "sess_1", "acme", ModeTracks, "", start)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	end := start.Add(time.Minute)
+	if err := r.Close(context.Background(), end); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	manifest := readManifest(t, dir, "sess_1")
+	if manifest.ExpiresAt == nil || !manifest.ExpiresAt.Equal(end.UTC().Add(24*time.Hour)) {
+		t.Fatalf("expires_at = %v, want %v", manifest.ExpiresAt, end.Add(24*time.Hour))
+	}
+}
+
+func recordSession(t *testing.T, m *Manager, dir, sessionID string, modTime time.Time) {
+	t.Helper()
+	r, err := m.Start(context.Background(), sessionID, "acme", ModeTracks, "", modTime)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := r.Close(context.Background(), modTime); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	entries, err := os.ReadDir(filepath.Join(dir, sessionID))
+	if err != nil {
+		t.Fatalf("read session dir: %v", err)
+	}
+	for _, e := range entries {
+		if err := os.Chtimes(filepath.Join(dir, sessionID, e.Name()), modTime, modTime); err != nil {
+			t.Fatalf("Chtimes: %v", err)
+		}
+	}
+}
+
+func TestSweepDeletesExpiredSessions(t *testing.T) {
+	m, dir := newTestManager(t, time.Hour)
+	now := time.Now()
+	recordSession(t, m, dir, "old", now.Add(-2*time.Hour))
+	recordSession(t, m, dir, "new", now.Add(-time.Minute))
+
+	deleted, err := m.Sweep(context.Background(), now)
+	if err != nil {
+		t.Fatalf("Sweep: %v", err)
+	}
+	if deleted != 1 {
+		t.Fatalf("deleted = %d, want 1", deleted)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old")); !os.IsNotExist(err) {
+		t.Fatalf("expired session directory still exists: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new", ManifestFile)); err != nil {
+		t.Fatalf("recent session was deleted: %v", err)
+	}
+}
+
+func TestSweepKeepsSessionWithRecentFile(t *testing.T) {
+	m, dir := newTestManager(t, time.Hour)
+	now := time.Now()
+	recordSession(t, m, dir, "sess_1", now.Add(-2*time.Hour))
+	if err := os.Chtimes(filepath.Join(dir, "sess_1", ManifestFile), now, now); err != nil {
+		t.Fatalf("Chtimes: %v", err)
+	}
+
+	if deleted, err := m.Sweep(context.Background(), now); err != nil || deleted != 0 {
+		t.Fatalf("Sweep = %d, %v, want the session kept", deleted, err)
+	}
+}
+
+func TestSweepWithoutRetention(t *testing.T) {
+	m, dir := newTestManager(t, 0)
+	recordSession(t, m, dir, "sess_1", time.Now().Add(-365*24*time.Hour))
+
+	if deleted, err := m.Sweep(context.Background(), time.Now()); err != nil || deleted != 0 {
+		t.Fatalf("Sweep = %d, %v, want nothing deleted", deleted, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sess_1", ManifestFile)); err != nil {
+		t.Fatalf("session was deleted: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package recording
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalStorageRejectsUnsafeKeys(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	for _, key := range []string{"", "../escape.wav", "/abs.wav", `sess\mix.wav`, "sess/../../x"} {
+		if _, err := s.Create(context.Background(), key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Create(%q) error = %v, want ErrInvalidKey", key, err)
+		}
+		if err := s.Delete(context.Background(), key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Delete(%q) error = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
+
+func TestLocalStorageCreateListDelete(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "recordings")
+	s, err := NewLocalStorage(dir)
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+
+	f, err := s.Create(context.Background(), "sess_1/inbound.wav")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := f.Write([]byte("abcd")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	objects, err := s.List(context.Background())
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(objects) != 1 || objects[0].Key != "sess_1/inbound.wav" || objects[0].Size != 4 || objects[0].ModTime.IsZero() {
+		t.Fatalf("objects = %+v", objects)
+	}
+
+	if err := s.Delete(context.Background(), "sess_1/inbound.wav"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sess_1")); !os.IsNotExist(err) {
+		t.Fatalf("empty session directory was kept: %v", err)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		t.Fatalf("storage root was removed: %v", err)
+	}
+	if err := s.Delete(context.Background(), "sess_1/inbound.wav"); err != nil {
+		t.Fatalf("Delete of a missing key: %v", err)
+	}
+}
+
+func TestLocalStorageDeleteKeepsNonEmptyDirectory(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewLocalStorage(dir)
+	if err != nil {
+		t.Fatalf("NewLocalStorage: %v", err)
+	}
+	for _, key := range []string{"sess_1/a.wav", "sess_1/b.wav"} {
+		f, err := s.Create(context.Background(), key)
+		if err != nil {
+			t.Fatalf("Create(%q): %v", key, err)
+		}
+		f.Close()
+	}
+
+	if err := s.Delete(context.Background(), "sess_1/a.wav"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sess_1", "b.wav")); err != nil {
+		t.Fatalf("sibling recording was removed: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestSetupDisabled(t *testing.T) {
+	for _, exporter := range []string{"", ExporterNone} {
+		previous := otel.GetTracerProvider()
+		shutdown, err := Setup(context.Background(), "gateway", Config{Exporter: exporter}, discardLogger())
+		if err != nil {
+			t.Fatalf("Setup(%q): %v", exporter, err)
+		}
+		if err := shutdown(context.Background()); err != nil {
+			t.Fatalf("shutdown: %v", err)
+		}
+		if otel.GetTracerProvider() != previous {
+			t.Fatalf("Setup(%q) replaced the tracer provider", exporter)
+		}
+	}
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), "gateway", Config{Exporter: "zipkin"}, discardLogger()); err == nil {
+		t.Fatal("Setup accepted an unknown exporter")
+	}
+}
+
+func TestSetupInstallsProvider(t *testing.T) {
+	for _, cfg := range []Config{
+		{Exporter: ExporterStdout, SampleRatio: 0},
+		{Exporter: ExporterOTLP, OTLPEndpoint: "localhost:4317", OTLPInsecure: true, SampleRatio: 0},
+	} {
+		t.Run(cfg.Exporter, func(t *testing.T) {
+			previous := otel.GetTracerProvider()
+			t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+			shutdown, err := Setup(context.Background(), "translator", cfg, discardLogger())
+			if err != nil {
+				t.Fatalf("Setup: %v", err)
+			}
+			provider, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider)
+			if !ok {
+				t.Fatalf("tracer provider = %T, want the SDK provider", otel.GetTracerProvider())
+			}
+
+			_, span := provider.Tracer("test").Start(context.Background(), "unsampled")
+			if span.SpanContext().IsSampled() {
+				t.Error("span sampled with a sample ratio of 0")
+			}
+			span.End()
+
+			if err := shutdown(context.Background()); err != nil {
+				t.Fatalf("shutdown: %v", err)
+			}
+		})
+	}
+}
+
+func TestSetupInstallsPropagator(t *testing.T) {
+	if _, err := Setup(context.Background(), "asr", Config{}, discardLogger()); err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	fields := otel.GetTextMapPropagator().Fields()
+	want := map[string]bool{"traceparent": false, "baggage": false}
+	for _, field := range fields {
+		if _, ok := want[field]; ok {
+			want[field] = true
+		}
+	}
+	for field, found := range want {
+		if !found {
+			t.Errorf("propagator does not carry %s", field)
+		}
+	}
+}
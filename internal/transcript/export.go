@@ -0,0 +1,107 @@
+package transcript
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	FormatJSON   = "json"
+	FormatSRT    = "srt"
+	FormatWebVTT = "vtt"
+)
+
+const (
+	LanguageSource    = "source"
+	LanguageTarget    = "target"
+	LanguageBilingual = "bilingual"
+)
+
+const minCueDuration = time.Second
+
+func ParseLanguageMode(mode string) (string, error) {
+	switch mode {
+	case "", LanguageBilingual:
+		return LanguageBilingual, nil
+	case LanguageSource, LanguageTarget:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown language %q, expected source, target or bilingual", mode)
+}
+
+type cue struct {
+	start time.Duration
+	end   time.Duration
+	lines []string
+}
+
+func cues(t *Transcript, mode string) []cue {
+	var out []cue
+	for _, u := range t.Utterances {
+		var lines []string
+		if mode != LanguageTarget && u.SourceText != "" {
+			lines = append(lines, singleLine(u.SourceText))
+		}
+		if mode != LanguageSource && u.TranslatedText != "" {
+			lines = append(lines, singleLine(u.TranslatedText))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		start := time.Duration(u.StartMs) * time.Millisecond
+		end := time.Duration(u.EndMs) * time.Millisecond
+		if end < start+minCueDuration {
+			end = start + minCueDuration
+		}
+		out = append(out, cue{start: start, end: end, lines: lines})
+	}
+	return out
+}
+
+func singleLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func timestamp(d time.Duration, sep string) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+func WriteSRT(w io.Writer, t *Transcript, mode string) error {
+	for i, c := range cues(t, mode) {
+		_, err := fmt.Fprintf(w, "%d\n%s --> %s\n%s\n\n", i+1, timestamp(c.start, ","), timestamp(c.end, ","), strings.Join(c.lines, "\n"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+func WriteWebVTT(w io.Writer, t *Transcript, mode string) error {
+	if _, err := io.WriteString(w, "WEBVTT\n\n"); err != nil {
+		return err
+	}
+	for i, c := range cues(t, mode) {
+		lines := make([]string, len(c.lines))
+		for j, line := range c.lines {
+			lines[j] = vttEscaper.Replace(line)
+		}
+		_, err := fmt.Fprintf(w, "%d\n%s --> %s\n%s\n\n", i+1, timestamp(c.start, "."), timestamp(c.end, "."), strings.Join(lines, "\n"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func WriteJSON(w io.Writer, t *Transcript) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(t)
+}
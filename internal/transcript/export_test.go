@@ -0,0 +1,84 @@
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func sampleTranscript() *Transcript {
+	return &Transcript{
+		SessionID: "sess-1",
+		TenantID:  "acme",
+		StartedAt: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC),
+		Utterances: []Utterance{
+			{Index: 0, StartMs: 500, EndMs: 2750, SourceText: "Hello  there,\nfriends", SourceLanguage: "en-US", TranslatedText: "Hola, amigos", TargetLanguage: "es-ES"},
+			{Index: 1, StartMs: 3_723_004, EndMs: 3_723_100, SourceText: "x < y & z", TranslatedText: ""},
+			{Index: 2, StartMs: 4_000_000, EndMs: 4_002_000},
+		},
+	}
+}
+
+func TestParseLanguageMode(t *testing.T) {
+	for in, want := range map[string]string{"": LanguageBilingual, "bilingual": LanguageBilingual, "source": LanguageSource, "target": LanguageTarget} {
+		got, err := ParseLanguageMode(in)
+		if err != nil || got != want {
+			t.Errorf("ParseLanguageMode(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseLanguageMode("es"); err == nil {
+		t.Error("ParseLanguageMode accepted a language code")
+	}
+}
+
+func TestWriteSRT(t *testing.T) {
+	tests := map[string]string{
+		LanguageBilingual: "1\n00:00:00,500 --> 00:00:02,750\nHello there, friends\nHola, amigos\n\n" +
+			"2\n01:02:03,004 --> 01:02:04,004\nx < y & z\n\n",
+		LanguageSource: "1\n00:00:00,500 --> 00:00:02,750\nHello there, friends\n\n" +
+			"2\n01:02:03,004 --> 01:02:04,004\nx < y & z\n\n",
+		LanguageTarget: "1\n00:00:00,500 --> 00:00:02,750\nHola, amigos\n\n",
+	}
+	for mode, want := range tests {
+		var buf bytes.Buffer
+		if err := WriteSRT(&buf, sampleTranscript(), mode); err != nil {
+			t.Fatalf("WriteSRT(%s): %v", mode, err)
+		}
+		if buf.String() != want {
+			t.Errorf("WriteSRT(%s) =\n%q\nwant\n%q", mode, buf.String(), want)
+		}
+	}
+}
+
+func TestWriteWebVTT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteWebVTT(&buf, sampleTranscript(), LanguageSource); err != nil {
+		t.Fatalf("WriteWebVTT: %v", err)
+	}
+	want := "WEBVTT\n\n" +
+		"1\n00:00:00.500 --> 00:00:02.750\nHello there, friends\n\n" +
+		"2\n01:02:03.004 --> 01:02:04.004\nx &lt; y &amp; z\n\n"
+	if buf.String() != want {
+		t.Fatalf("WriteWebVTT =\n%q\nwant\n%q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := WriteWebVTT(&buf, &Transcript{}, LanguageBilingual); err != nil || buf.String() != "WEBVTT\n\n" {
+		t.Fatalf("empty WebVTT = %q, %v", buf.String(), err)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, sampleTranscript()); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	var got Transcript
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.SessionID != "sess-1" || len(got.Utterances) != 3 || got.Utterances[0].TranslatedText != "Hola, amigos" || got.EndedAt != nil {
+		t.Fatalf("round trip = %+v", got)
+	}
+}
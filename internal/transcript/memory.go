@@ -0,0 +1,57 @@
+package transcript
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type MemoryStore struct {
+	mu          sync.RWMutex
+	transcripts map[string]*Transcript
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{transcripts: make(map[string]*Transcript)}
+}
+
+func (s *MemoryStore) Begin(ctx context.Context, sessionID, tenantID string, startedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transcripts[sessionID] = &Transcript{SessionID: sessionID, TenantID: tenantID, StartedAt: startedAt, Utterances: []Utterance{}}
+	return nil
+}
+
+func (s *MemoryStore) Append(ctx context.Context, sessionID string, u Utterance) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.transcripts[sessionID]
+	if !ok {
+		return ErrNotFound
+	}
+	t.Utterances = append(t.Utterances, u)
+	return nil
+}
+
+func (s *MemoryStore) End(ctx context.Context, sessionID string, endedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.transcripts[sessionID]
+	if !ok {
+		return ErrNotFound
+	}
+	t.EndedAt = &endedAt
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, sessionID string) (*Transcript, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.transcripts[sessionID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	copied := *t
+	copied.Utterances = append([]Utterance(nil), t.Utterances...)
+	return &copied, nil
+}
@@ -0,0 +1,159 @@
+package transcript
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	StoreNone = "none"
+	StoreFile = "file"
+)
+
+var (
+	ErrNotFound         = errors.New("transcript not found")
+	ErrInvalidSessionID = errors.New("invalid session id")
+)
+
+var sessionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,128}$`)
+
+type Utterance struct {
+	Index          int       `json:"index"`
+	StartMs        int64     `json:"start_ms"`
+	EndMs          int64     `json:"end_ms"`
+	SourceText     string    `json:"source_text"`
+	SourceLanguage string    `json:"source_language,omitempty"`
+	TranslatedText string    `json:"translated_text"`
+	TargetLanguage string    `json:"target_language,omitempty"`
+	RecordedAt     time.Time `json:"recorded_at"`
+}
+
+type Transcript struct {
+	SessionID  string      `json:"session_id"`
+	TenantID   string      `json:"tenant_id"`
+	StartedAt  time.Time   `json:"started_at"`
+	EndedAt    *time.Time  `json:"ended_at,omitempty"`
+	Utterances []Utterance `json:"utterances"`
+}
+
+type Store interface {
+	Begin(ctx context.Context, sessionID, tenantID string, startedAt time.Time) error
+	Append(ctx context.Context, sessionID string, u Utterance) error
+	End(ctx context.Context, sessionID string, endedAt time.Time) error
+	Get(ctx context.Context, sessionID string) (*Transcript, error)
+}
+
+type entry struct {
+	Kind      string     `json:"kind"`
+	SessionID string     `json:"session_id,omitempty"`
+	TenantID  string     `json:"tenant_id,omitempty"`
+	Time      time.Time  `json:"time"`
+	Utterance *Utterance `json:"utterance,omitempty"`
+}
+
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create transcript directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) path(sessionID string) (string, error) {
+	if !sessionIDPattern.MatchString(sessionID) {
+		return "", ErrInvalidSessionID
+	}
+	return filepath.Join(s.dir, sessionID+".jsonl"), nil
+}
+
+func (s *FileStore) write(sessionID string, e entry, flags int) error {
+	path, err := s.path(sessionID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(path, flags|os.O_WRONLY, 0o640)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func (s *FileStore) Begin(ctx context.Context, sessionID, tenantID string, startedAt time.Time) error {
+	return s.write(sessionID, entry{Kind: "begin", SessionID: sessionID, TenantID: tenantID, Time: startedAt}, os.O_CREATE|os.O_EXCL)
+}
+
+func (s *FileStore) Append(ctx context.Context, sessionID string, u Utterance) error {
+	return s.write(sessionID, entry{Kind: "utterance", Time: u.RecordedAt, Utterance: &u}, os.O_APPEND)
+}
+
+func (s *FileStore) End(ctx context.Context, sessionID string, endedAt time.Time) error {
+	return s.write(sessionID, entry{Kind: "end", Time: endedAt}, os.O_APPEND)
+}
+
+func (s *FileStore) Get(ctx context.Context, sessionID string) (*Transcript, error) {
+	path, err := s.path(sessionID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	t := &Transcript{SessionID: sessionID, Utterances: []Utterance{}}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		switch e.Kind {
+		case "begin":
+			t.TenantID = e.TenantID
+			t.StartedAt = e.Time
+		case "utterance":
+			if e.Utterance != nil {
+				t.Utterances = append(t.Utterances, *e.Utterance)
+			}
+		case "end":
+			endedAt := e.Time
+			t.EndedAt = &endedAt
+		}
+	}
+	return t, scanner.Err()
+}
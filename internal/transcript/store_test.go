@@ -0,0 +1,135 @@
+package transcript
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func testStores(t *testing.T) map[string]Store {
+	t.Helper()
+	files, err := NewFileStore(filepath.Join(t.TempDir(), "transcripts"))
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+	return map[string]Store{"file": files, "memory": NewMemoryStore()}
+}
+
+func TestStoreRecordsSession(t *testing.T) {
+	ctx := context.Background()
+	started := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := store.Begin(ctx, "sess-1", "acme", started); err != nil {
+				t.Fatalf("Begin: %v", err)
+			}
+			for i, text := range []string{"hello", "goodbye"} {
+				u := Utterance{Index: i, StartMs: int64(i) * 1000, EndMs: int64(i)*1000 + 800, SourceText: text, TranslatedText: "es:" + text, RecordedAt: started.Add(time.Second)}
+				if err := store.Append(ctx, "sess-1", u); err != nil {
+					t.Fatalf("Append: %v", err)
+				}
+			}
+
+			got, err := store.Get(ctx, "sess-1")
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			if got.TenantID != "acme" || !got.StartedAt.Equal(started) || got.EndedAt != nil {
+				t.Fatalf("transcript = %+v", got)
+			}
+			if len(got.Utterances) != 2 || got.Utterances[1].SourceText != "goodbye" || got.Utterances[1].StartMs != 1000 {
+				t.Fatalf("utterances = %+v", got.Utterances)
+			}
+
+			ended := started.Add(time.Minute)
+			if err := store.End(ctx, "sess-1", ended); err != nil {
+				t.Fatalf("End: %v", err)
+			}
+			got, _ = store.Get(ctx, "sess-1")
+			if got.EndedAt == nil || !got.EndedAt.Equal(ended) {
+				t.Fatalf("ended at = %v, want %v", got.EndedAt, ended)
+			}
+		})
+	}
+}
+
+func TestStoreUnknownSession(t *testing.T) {
+	ctx := context.Background()
+	for name, store := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := store.Append(ctx, "missing", Utterance{}); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Append = %v, want ErrNotFound", err)
+			}
+			if err := store.End(ctx, "missing", time.Now()); !errors.Is(err, ErrNotFound) {
+				t.Errorf("End = %v, want ErrNotFound", err)
+			}
+			if _, err := store.Get(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("Get = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	store.Begin(ctx, "sess-1", "acme", time.Now())
+	store.Append(ctx, "sess-1", Utterance{SourceText: "hello"})
+
+	got, _ := store.Get(ctx, "sess-1")
+	got.Utterances[0].SourceText = "changed"
+	got.Utterances = append(got.Utterances, Utterance{})
+
+	again, _ := store.Get(ctx, "sess-1")
+	if len(again.Utterances) != 1 || again.Utterances[0].SourceText != "hello" {
+		t.Fatalf("stored utterances = %+v, want them unaffected by the caller", again.Utterances)
+	}
+}
+
+func TestFileStoreRejectsUnsafeSessionIDs(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, _ := NewFileStore(dir)
+
+	for _, id := range []string{"", "../escape", "a/b", "sess.1", string(make([]byte, 129))} {
+		if err := store.Begin(ctx, id, "acme", time.Now()); !errors.Is(err, ErrInvalidSessionID) {
+			t.Errorf("Begin(%q) = %v, want ErrInvalidSessionID", id, err)
+		}
+		if _, err := store.Get(ctx, id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get(%q) = %v, want ErrNotFound", id, err)
+		}
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(dir)); len(entries) != 1 {
+		t.Fatalf("files written outside the store: %v", entries)
+	}
+}
+
+func TestFileStorePersistsAcrossInstances(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	first, _ := NewFileStore(dir)
+	first.Begin(ctx, "sess-1", "acme", time.Now())
+	first.Append(ctx, "sess-1", Utterance{SourceText: "hello"})
+
+	if err := first.Begin(ctx, "sess-1", "acme", time.Now()); err == nil {
+		t.Fatal("Begin replaced an existing transcript")
+	}
+
+	f, _ := os.OpenFile(filepath.Join(dir, "sess-1.jsonl"), os.O_APPEND|os.O_WRONLY, 0)
+	f.WriteString("{not json\n")
+	f.Close()
+
+	second, _ := NewFileStore(dir)
+	second.Append(ctx, "sess-1", Utterance{SourceText: "goodbye"})
+	got, err := second.Get(ctx, "sess-1")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.TenantID != "acme" || len(got.Utterances) != 2 || got.Utterances[1].SourceText != "goodbye" {
+		t.Fatalf("transcript = %+v, want both utterances with the corrupt line skipped", got)
+	}
+}
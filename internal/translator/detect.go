@@ -0,0 +1,72 @@
+package translator
+
+import (
+	"strings"
+	"unicode"
+)
+
+var scriptLanguages = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Han, "zh"},
+	{unicode.Hebrew, "he"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+	{unicode.Greek, "el"},
+	{unicode.Arabic, "ar"},
+	{unicode.Cyrillic, "ru"},
+}
+
+func DetectLanguage(text string) string {
+	counts := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.table, r) {
+				counts[s.lang]++
+				break
+			}
+		}
+		if unicode.Is(unicode.Latin, r) {
+			counts[""]++
+		}
+	}
+
+	lang, best := "", 0
+	for _, s := range scriptLanguages {
+		if counts[s.lang] > best {
+			lang, best = s.lang, counts[s.lang]
+		}
+	}
+	if best == 0 || best < counts[""] {
+		return ""
+	}
+
+	switch lang {
+	case "zh":
+		if counts["ja"] > 0 {
+			return "ja"
+		}
+		if counts["ko"] > 0 {
+			return "ko"
+		}
+	case "ar":
+		if strings.ContainsAny(text, "پچژگ") {
+			return "fa"
+		}
+	case "ru":
+		if strings.ContainsAny(text, "іїєґІЇЄҐ") {
+			return "uk"
+		}
+		if !strings.ContainsAny(text, "ыэЫЭёЁ") {
+			return ""
+		}
+	}
+	return lang
+}
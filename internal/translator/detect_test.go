@@ -0,0 +1,33 @@
+package translator
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"", ""},
+		{"1234 !?", ""},
+		{"Hello, how are you?", ""},
+		{"こんにちは、元気ですか", "ja"},
+		{"カタカナ", "ja"},
+		{"안녕하세요", "ko"},
+		{"你好，你好吗", "zh"},
+		{"שלום מה שלומך", "he"},
+		{"नमस्ते आप कैसे हैं", "hi"},
+		{"สวัสดีครับ", "th"},
+		{"Καλημέρα σας", "el"},
+		{"مرحبا كيف حالك", "ar"},
+		{"سلام، چطوری؟", "fa"},
+		{"Привет, как ты? Мы здесь", "ru"},
+		{"Привіт, як справи? Їжак", "uk"},
+		{"Привет", ""},
+		{"Hello everybody, мы", ""},
+	}
+	for _, tt := range tests {
+		if got := DetectLanguage(tt.text); got != tt.want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"ai-translator/internal/util"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+)
+
+const DefaultEngineTimeout = 5 * time.Second
+
+var ErrNoEngineAvailable = errors.New("no translation engine available")
+
+var tracer = otel.Tracer("ai-translator/internal/translator")
+
+type Engine interface {
+	Name() string
+	Translate(ctx context.Context, text, sourceLang, targetLang string, style Style, conversationContext []string) (string, error)
+	Close() error
+}
+
+const (
+	ProviderGemini = "gemini"
+	ProviderGoogle = "google"
+)
+
+var defaultCredentials = map[string]string{
+	ProviderGemini: "GEMINI_API_KEY",
+	ProviderGoogle: "GOOGLE_TRANSLATE_API_KEY",
+}
+
+type EngineSpec struct {
+	Provider   string
+	Model      string
+	Timeout    time.Duration
+	Credential string
+}
+
+func ParseEngineSpecs(s string) ([]EngineSpec, error) {
+	var specs []EngineSpec
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		spec, err := parseEngineSpec(item)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("no translation engines configured")
+	}
+	return specs, nil
+}
+
+func parseEngineSpec(item string) (EngineSpec, error) {
+	spec := EngineSpec{Provider: ProviderGemini, Timeout: DefaultEngineTimeout}
+
+	if rest, credential, ok := strings.Cut(item, "@"); ok {
+		credential = strings.TrimSpace(credential)
+		if credential == "" {
+			return EngineSpec{}, fmt.Errorf("empty credential variable for engine %q", item)
+		}
+		item, spec.Credential = rest, credential
+	}
+
+	if rest, timeout, ok := strings.Cut(item, "="); ok {
+		d, err := time.ParseDuration(strings.TrimSpace(timeout))
+		if err != nil || d <= 0 {
+			return EngineSpec{}, fmt.Errorf("invalid timeout for engine %q: %q", rest, timeout)
+		}
+		item, spec.Timeout = rest, d
+	}
+
+	item = strings.TrimSpace(item)
+	if provider, model, ok := strings.Cut(item, ":"); ok {
+		spec.Provider = strings.ToLower(strings.TrimSpace(provider))
+		spec.Model = strings.TrimSpace(model)
+	} else if _, ok := defaultCredentials[strings.ToLower(item)]; ok {
+		spec.Provider = strings.ToLower(item)
+	} else {
+		spec.Model = item
+	}
+
+	if _, ok := defaultCredentials[spec.Provider]; !ok {
+		return EngineSpec{}, fmt.Errorf("unknown translation provider %q, expected gemini or google", spec.Provider)
+	}
+	if spec.Model == "" && spec.Provider == ProviderGemini {
+		return EngineSpec{}, fmt.Errorf("missing model for gemini engine")
+	}
+	if spec.Credential == "" {
+		spec.Credential = defaultCredentials[spec.Provider]
+	}
+	return spec, nil
+}
+
+func NewEngine(ctx context.Context, spec EngineSpec, credential string, logger *slog.Logger) (Engine, error) {
+	switch spec.Provider {
+	case ProviderGemini:
+		if credential == "" {
+			return nil, fmt.Errorf("engine %s:%s needs an API key in %s", spec.Provider, spec.Model, spec.Credential)
+		}
+		return NewGeminiClient(ctx, credential, spec.Model, logger)
+	case ProviderGoogle:
+		return NewGoogleTranslateClient(ctx, credential, spec.Model, logger)
+	}
+	return nil, fmt.Errorf("unknown translation provider %q", spec.Provider)
+}
+
+type chainEntry struct {
+	engine  Engine
+	timeout time.Duration
+	breaker *util.CircuitBreaker
+}
+
+type Chain struct {
+	entries []chainEntry
+	logger  *slog.Logger
+}
+
+func NewChain(logger *slog.Logger) *Chain {
+	return &Chain{logger: logger}
+}
+
+func (c *Chain) Add(engine Engine, timeout time.Duration, breakerCfg util.BreakerConfig) {
+	c.entries = append(c.entries, chainEntry{
+		engine:  engine,
+		timeout: timeout,
+		breaker: util.NewCircuitBreaker(breakerCfg),
+	})
+}
+
+func (c *Chain) Translate(ctx context.Context, text, sourceLang, targetLang string, style Style, conversationContext []string) (string, string, error) {
+	lastErr := ErrNoEngineAvailable
+
+	for _, entry := range c.entries {
+		name := entry.engine.Name()
+
+		if !entry.breaker.Allow() {
+			c.logger.Debug("skipping engine with open circuit", "engine", name)
+			continue
+		}
+
+		engineCtx, cancel := context.WithTimeout(ctx, entry.timeout)
+		engineCtx, span := tracer.Start(engineCtx, "translate.engine", trace.WithAttributes(
+			attribute.String("translation.engine", name),
+			attribute.String("translation.source_language", LanguageLabel(sourceLang)),
+			attribute.String("translation.target_language", LanguageLabel(targetLang)),
+		))
+		translated, err := entry.engine.Translate(engineCtx, text, sourceLang, targetLang, style, conversationContext)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(otelcodes.Error, err.Error())
+		}
+		span.End()
+		cancel()
+
+		if err == nil {
+			entry.breaker.Success()
+			return translated, name, nil
+		}
+
+		if ctx.Err() != nil {
+			entry.breaker.Release()
+			return "", name, ctx.Err()
+		}
+
+		if !isFailoverError(err) {
+			entry.breaker.Success()
+			return "", name, err
+		}
+
+		entry.breaker.Failure()
+		c.logger.Warn("translation engine failed, falling over", "engine", name, "error", err, "breaker", entry.breaker.State().String())
+		lastErr = fmt.Errorf("%s: %w", name, err)
+	}
+
+	return "", "", lastErr
+}
+
+func (c *Chain) Healthy(ctx context.Context) error {
+	for _, entry := range c.entries {
+		if entry.breaker.State() != util.BreakerOpen {
+			return nil
+		}
+	}
+	return ErrNoEngineAvailable
+}
+
+func (c *Chain) Close() error {
+	var errs []error
+	for _, entry := range c.entries {
+		if err := entry.engine.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func isFailoverError(err error) bool {
+	code, _ := classify(err)
+	switch code {
+	case codes.ResourceExhausted, codes.Unavailable, codes.DeadlineExceeded, codes.Internal:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,285 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"ai-translator/internal/util"
+
+	"go.opentelemetry.io/otel"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/api/option"
+	translate "google.golang.org/api/translate/v2"
+)
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestParseEngineSpecs(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    []EngineSpec
+		wantErr bool
+	}{
+		{
+			name: "bare model is gemini",
+			in:   "gemini-1.5-flash",
+			want: []EngineSpec{{Provider: ProviderGemini, Model: "gemini-1.5-flash", Timeout: DefaultEngineTimeout, Credential: "GEMINI_API_KEY"}},
+		},
+		{
+			name: "provider model and timeout",
+			in:   "gemini:gemini-1.5-flash=5s, google:nmt=3s",
+			want: []EngineSpec{
+				{Provider: ProviderGemini, Model: "gemini-1.5-flash", Timeout: 5 * time.Second, Credential: "GEMINI_API_KEY"},
+				{Provider: ProviderGoogle, Model: "nmt", Timeout: 3 * time.Second, Credential: "GOOGLE_TRANSLATE_API_KEY"},
+			},
+		},
+		{
+			name: "per engine credential",
+			in:   "gemini:gemini-1.5-pro=8s@GEMINI_BACKUP_API_KEY",
+			want: []EngineSpec{{Provider: ProviderGemini, Model: "gemini-1.5-pro", Timeout: 8 * time.Second, Credential: "GEMINI_BACKUP_API_KEY"}},
+		},
+		{
+			name: "provider without model",
+			in:   "google=2s",
+			want: []EngineSpec{{Provider: ProviderGoogle, Timeout: 2 * time.Second, Credential: "GOOGLE_TRANSLATE_API_KEY"}},
+		},
+		{
+			name: "credential without timeout",
+			in:   "google:nmt@TRANSLATE_KEY",
+			want: []EngineSpec{{Provider: ProviderGoogle, Model: "nmt", Timeout: DefaultEngineTimeout, Credential: "TRANSLATE_KEY"}},
+		},
+		{name: "unknown provider", in: "deepl:default=2s", wantErr: true},
+		{name: "gemini without model", in: "gemini:=2s", wantErr: true},
+		{name: "bad timeout", in: "gemini:gemini-1.5-flash=soon", wantErr: true},
+		{name: "negative timeout", in: "gemini:gemini-1.5-flash=-1s", wantErr: true},
+		{name: "empty credential", in: "google:nmt@", wantErr: true},
+		{name: "empty", in: " , ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseEngineSpecs(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseEngineSpecs(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("ParseEngineSpecs(%q) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewEngineRequiresGeminiKey(t *testing.T) {
+	spec := EngineSpec{Provider: ProviderGemini, Model: "gemini-1.5-flash", Credential: "GEMINI_BACKUP_API_KEY"}
+	if _, err := NewEngine(context.Background(), spec, "", testLogger()); err == nil {
+		t.Fatal("expected an error for a gemini engine without a key")
+	}
+}
+
+type fakeEngine struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (f *fakeEngine) Name() string { return f.name }
+
+func (f *fakeEngine) Translate(ctx context.Context, text, sourceLang, targetLang string, style Style, conversationContext []string) (string, error) {
+	f.calls++
+	if f.err != nil {
+		return "", f.err
+	}
+	return f.name + ": " + text, nil
+}
+
+func (f *fakeEngine) Close() error { return nil }
+
+func TestChainFailsOverToNextEngine(t *testing.T) {
+	primary := &fakeEngine{name: "primary", err: ErrWrongScript}
+	fallback := &fakeEngine{name: "fallback"}
+
+	chain := NewChain(testLogger())
+	chain.Add(primary, time.Second, util.BreakerConfig{FailureThreshold: 2, Cooldown: time.Hour})
+	chain.Add(fallback, time.Second, util.BreakerConfig{FailureThreshold: 2, Cooldown: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		got, engine, err := chain.Translate(context.Background(), "hello", "en", "es", Style{}, nil)
+		if err != nil || engine != "fallback" || got != "fallback: hello" {
+			t.Fatalf("Translate = %q, %q, %v", got, engine, err)
+		}
+	}
+	if primary.calls != 2 {
+		t.Errorf("primary called %d times, want 2 before its breaker opened", primary.calls)
+	}
+	if err := chain.Healthy(context.Background()); err != nil {
+		t.Errorf("Healthy = %v, want nil while the fallback is closed", err)
+	}
+}
+
+func TestChainStopsOnNonFailoverError(t *testing.T) {
+	primary := &fakeEngine{name: "primary", err: ErrSafetyBlocked}
+	fallback := &fakeEngine{name: "fallback"}
+
+	chain := NewChain(testLogger())
+	chain.Add(primary, time.Second, util.DefaultBreakerConfig())
+	chain.Add(fallback, time.Second, util.DefaultBreakerConfig())
+
+	_, engine, err := chain.Translate(context.Background(), "hello", "en", "es", Style{}, nil)
+	if !errors.Is(err, ErrSafetyBlocked) || engine != "primary" {
+		t.Fatalf("Translate = %q, %v; want the safety error from primary", engine, err)
+	}
+	if fallback.calls != 0 {
+		t.Errorf("fallback called %d times, want 0", fallback.calls)
+	}
+}
+
+func TestChainAllEnginesDown(t *testing.T) {
+	chain := NewChain(testLogger())
+	chain.Add(&fakeEngine{name: "a", err: ErrTruncated}, time.Second, util.BreakerConfig{FailureThreshold: 1, Cooldown: time.Hour})
+
+	if _, _, err := chain.Translate(context.Background(), "hello", "en", "es", Style{}, nil); !errors.Is(err, ErrTruncated) {
+		t.Fatalf("first call error = %v, want ErrTruncated", err)
+	}
+	if _, _, err := chain.Translate(context.Background(), "hello", "en", "es", Style{}, nil); !errors.Is(err, ErrNoEngineAvailable) {
+		t.Fatalf("second call error = %v, want ErrNoEngineAvailable", err)
+	}
+	if err := chain.Healthy(context.Background()); !errors.Is(err, ErrNoEngineAvailable) {
+		t.Errorf("Healthy = %v, want ErrNoEngineAvailable", err)
+	}
+}
+
+func TestChainHealthyWhileAnyEngineIsUp(t *testing.T) {
+	chain := NewChain(testLogger())
+	if err := chain.Healthy(context.Background()); !errors.Is(err, ErrNoEngineAvailable) {
+		t.Fatalf("empty chain Healthy = %v, want ErrNoEngineAvailable", err)
+	}
+
+	chain.Add(&fakeEngine{name: "primary", err: ErrTruncated}, time.Second, util.BreakerConfig{FailureThreshold: 1, Cooldown: time.Hour})
+	chain.Add(&fakeEngine{name: "fallback"}, time.Second, util.BreakerConfig{FailureThreshold: 1, Cooldown: time.Hour})
+	if _, engine, err := chain.Translate(context.Background(), "hello", "en", "es", Style{}, nil); err != nil || engine != "fallback" {
+		t.Fatalf("Translate = %q, %v, want the fallback", engine, err)
+	}
+	if err := chain.Healthy(context.Background()); err != nil {
+		t.Fatalf("Healthy with the primary down = %v, want nil", err)
+	}
+}
+
+func TestChainRecordsEngineSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	chain := NewChain(testLogger())
+	chain.Add(&fakeEngine{name: "primary", err: ErrWrongScript}, time.Second, util.DefaultBreakerConfig())
+	chain.Add(&fakeEngine{name: "fallback"}, time.Second, util.DefaultBreakerConfig())
+	if _, _, err := chain.Translate(context.Background(), "hello", "en-US", "es-ES", Style{}, nil); err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans, want one per engine tried", len(spans))
+	}
+	want := []struct {
+		engine string
+		code   otelcodes.Code
+	}{{"primary", otelcodes.Error}, {"fallback", otelcodes.Unset}}
+	for i, span := range spans {
+		attrs := map[string]string{}
+		for _, kv := range span.Attributes() {
+			attrs[string(kv.Key)] = kv.Value.AsString()
+		}
+		if span.Name() != "translate.engine" || attrs["translation.engine"] != want[i].engine || span.Status().Code != want[i].code {
+			t.Errorf("span %d = %s %v %v, want %s with status %v", i, span.Name(), attrs, span.Status(), want[i].engine, want[i].code)
+		}
+		if attrs["translation.source_language"] != "en" || attrs["translation.target_language"] != "es" {
+			t.Errorf("span %d languages = %v", i, attrs)
+		}
+	}
+}
+
+func newTestGoogleClient(t *testing.T, handler http.HandlerFunc) *GoogleTranslateClient {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	service, err := translate.NewService(context.Background(), option.WithEndpoint(server.URL), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatalf("create service: %v", err)
+	}
+	return &GoogleTranslateClient{service: service, model: "nmt", logger: testLogger()}
+}
+
+func TestGoogleTranslateClient(t *testing.T) {
+	var query map[string][]string
+	client := newTestGoogleClient(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		query = r.Form
+		json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"translations": []map[string]string{{"translatedText": "Buenos días a todos"}},
+			},
+		})
+	})
+
+	got, err := client.Translate(context.Background(), "Good morning everyone", "en-US", "es-ES", Style{}, nil)
+	if err != nil {
+		t.Fatalf("Translate: %v", err)
+	}
+	if got != "Buenos días a todos" {
+		t.Errorf("got %q", got)
+	}
+	for key, want := range map[string]string{"source": "en", "target": "es", "format": "text", "model": "nmt"} {
+		if v := query[key]; len(v) != 1 || v[0] != want {
+			t.Errorf("query %s = %v, want %q", key, v, want)
+		}
+	}
+	if client.Name() != "google/nmt" {
+		t.Errorf("Name() = %q", client.Name())
+	}
+}
+
+func TestGoogleTranslateClientQuotaFailsOver(t *testing.T) {
+	client := newTestGoogleClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"error":{"code":429,"message":"quota exceeded"}}`))
+	})
+
+	_, err := client.Translate(context.Background(), "Good morning", "en-US", "es-ES", Style{}, nil)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if !isFailoverError(err) {
+		t.Errorf("error %v does not fail over", err)
+	}
+}
+
+func TestGoogleLanguageCode(t *testing.T) {
+	for in, want := range map[string]string{
+		"es-ES": "es",
+		"pt-BR": "pt",
+		"zh-CN": "zh-CN",
+		"zh_TW": "zh-TW",
+		"zh":    "zh-CN",
+		"ja":    "ja",
+	} {
+		if got := googleLanguageCode(in); got != want {
+			t.Errorf("googleLanguageCode(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/googleapis/gax-go/v2/apierror"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const ErrorDomain = "translator.ai-translator"
+
+const (
+	ReasonSafetyBlocked       = "SAFETY_BLOCKED"
+	ReasonQuotaExhausted      = "QUOTA_EXHAUSTED"
+	ReasonDeadlineExceeded    = "DEADLINE_EXCEEDED"
+	ReasonCanceled            = "CANCELED"
+	ReasonInvalidArgument     = "INVALID_ARGUMENT"
+	ReasonInvalidOutput       = "INVALID_OUTPUT"
+	ReasonProviderUnavailable = "PROVIDER_UNAVAILABLE"
+	ReasonInternal            = "INTERNAL"
+)
+
+func ToStatus(err error, provider string) error {
+	if err == nil {
+		return nil
+	}
+
+	code, reason := classify(err)
+
+	st := status.New(code, err.Error())
+	detailed, detailErr := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   reason,
+		Domain:   ErrorDomain,
+		Metadata: map[string]string{"provider": provider},
+	})
+	if detailErr != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+func classify(err error) (codes.Code, string) {
+	switch {
+	case errors.Is(err, ErrNoEngineAvailable):
+		return codes.Unavailable, ReasonProviderUnavailable
+	case errors.Is(err, ErrSafetyBlocked):
+		return codes.FailedPrecondition, ReasonSafetyBlocked
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded, ReasonDeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled, ReasonCanceled
+	case IsCorrectable(err):
+		return codes.Internal, ReasonInvalidOutput
+	}
+
+	var apiErr *apierror.APIError
+	if !errors.As(err, &apiErr) {
+		return codes.Internal, ReasonInternal
+	}
+
+	switch apiErr.HTTPCode() {
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted, ReasonQuotaExhausted
+	case http.StatusBadRequest:
+		return codes.InvalidArgument, ReasonInvalidArgument
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded, ReasonDeadlineExceeded
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return codes.Unavailable, ReasonProviderUnavailable
+	}
+
+	if st := apiErr.GRPCStatus(); st != nil {
+		switch st.Code() {
+		case codes.ResourceExhausted:
+			return codes.ResourceExhausted, ReasonQuotaExhausted
+		case codes.InvalidArgument:
+			return codes.InvalidArgument, ReasonInvalidArgument
+		case codes.DeadlineExceeded:
+			return codes.DeadlineExceeded, ReasonDeadlineExceeded
+		case codes.Unavailable, codes.Internal:
+			return codes.Unavailable, ReasonProviderUnavailable
+		}
+	}
+
+	return codes.Internal, ReasonInternal
+}
@@ -0,0 +1,87 @@
+package translator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/googleapis/gax-go/v2/apierror"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func apiError(t *testing.T, err error) error {
+	t.Helper()
+	apiErr, ok := apierror.FromError(err)
+	if !ok {
+		t.Fatalf("apierror.FromError(%v) failed", err)
+	}
+	return fmt.Errorf("gemini generation failed: %w", apiErr)
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   codes.Code
+		reason string
+	}{
+		{"no engine", ErrNoEngineAvailable, codes.Unavailable, ReasonProviderUnavailable},
+		{"safety", fmt.Errorf("%w: SAFETY", ErrSafetyBlocked), codes.FailedPrecondition, ReasonSafetyBlocked},
+		{"deadline", fmt.Errorf("call: %w", context.DeadlineExceeded), codes.DeadlineExceeded, ReasonDeadlineExceeded},
+		{"canceled", context.Canceled, codes.Canceled, ReasonCanceled},
+		{"empty", ErrEmptyTranslation, codes.Internal, ReasonInvalidOutput},
+		{"truncated", ErrTruncated, codes.Internal, ReasonInvalidOutput},
+		{"wrong script", fmt.Errorf("%w: want Cyrillic", ErrWrongScript), codes.Internal, ReasonInvalidOutput},
+		{"unknown", errors.New("boom"), codes.Internal, ReasonInternal},
+		{"http 429", apiError(t, &googleapi.Error{Code: http.StatusTooManyRequests}), codes.ResourceExhausted, ReasonQuotaExhausted},
+		{"http 400", apiError(t, &googleapi.Error{Code: http.StatusBadRequest}), codes.InvalidArgument, ReasonInvalidArgument},
+		{"http 504", apiError(t, &googleapi.Error{Code: http.StatusGatewayTimeout}), codes.DeadlineExceeded, ReasonDeadlineExceeded},
+		{"http 503", apiError(t, &googleapi.Error{Code: http.StatusServiceUnavailable}), codes.Unavailable, ReasonProviderUnavailable},
+		{"http 403", apiError(t, &googleapi.Error{Code: http.StatusForbidden}), codes.Internal, ReasonInternal},
+		{"grpc exhausted", apiError(t, status.Error(codes.ResourceExhausted, "quota")), codes.ResourceExhausted, ReasonQuotaExhausted},
+		{"grpc invalid", apiError(t, status.Error(codes.InvalidArgument, "bad")), codes.InvalidArgument, ReasonInvalidArgument},
+		{"grpc deadline", apiError(t, status.Error(codes.DeadlineExceeded, "slow")), codes.DeadlineExceeded, ReasonDeadlineExceeded},
+		{"grpc internal", apiError(t, status.Error(codes.Internal, "oops")), codes.Unavailable, ReasonProviderUnavailable},
+		{"grpc denied", apiError(t, status.Error(codes.PermissionDenied, "no")), codes.Internal, ReasonInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, reason := classify(tt.err)
+			if code != tt.code || reason != tt.reason {
+				t.Fatalf("classify = %v, %s, want %v, %s", code, reason, tt.code, tt.reason)
+			}
+		})
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	if err := ToStatus(nil, "gemini"); err != nil {
+		t.Fatalf("ToStatus(nil) = %v", err)
+	}
+
+	err := ToStatus(fmt.Errorf("%w: SAFETY", ErrSafetyBlocked), "gemini")
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.FailedPrecondition {
+		t.Fatalf("status = %v, want FailedPrecondition", err)
+	}
+	if st.Message() != "translation blocked by safety filters: SAFETY" {
+		t.Fatalf("message = %q", st.Message())
+	}
+
+	details := st.Details()
+	if len(details) != 1 {
+		t.Fatalf("details = %v, want one ErrorInfo", details)
+	}
+	info, ok := details[0].(*errdetails.ErrorInfo)
+	if !ok {
+		t.Fatalf("detail = %T, want ErrorInfo", details[0])
+	}
+	if info.Reason != ReasonSafetyBlocked || info.Domain != ErrorDomain || info.Metadata["provider"] != "gemini" {
+		t.Fatalf("ErrorInfo = %+v", info)
+	}
+}
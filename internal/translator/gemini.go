@@ -2,6 +2,7 @@ package translator
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -10,19 +11,26 @@ import (
 	"google.golang.org/api/option"
 )
 
+const DefaultGeminiModel = "gemini-1.5-flash"
+
 type GeminiClient struct {
-	client *genai.Client
-	model  *genai.GenerativeModel
-	logger *slog.Logger
+	client    *genai.Client
+	model     *genai.GenerativeModel
+	modelName string
+	logger    *slog.Logger
 }
 
-func NewGeminiClient(ctx context.Context, apiKey string, logger *slog.Logger) (*GeminiClient, error) {
+func NewGeminiClient(ctx context.Context, apiKey, modelName string, logger *slog.Logger) (*GeminiClient, error) {
 	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create gemini client: %w", err)
 	}
 
-	model := client.GenerativeModel("gemini-1.5-flash")
+	if modelName == "" {
+		modelName = DefaultGeminiModel
+	}
+
+	model := client.GenerativeModel(modelName)
 	model.SetTemperature(0.3)
 	model.SetTopP(0.8)
 	model.SetTopK(40)
@@ -35,76 +43,78 @@ func NewGeminiClient(ctx context.Context, apiKey string, logger *slog.Logger) (*
 	}
 
 	return &GeminiClient{
-		client: client,
-		model:  model,
-		logger: logger,
+		client:    client,
+		model:     model,
+		modelName: modelName,
+		logger:    logger,
 	}, nil
 }
 
+func (g *GeminiClient) Name() string {
+	return "gemini/" + g.modelName
+}
+
 func (g *GeminiClient) Close() error {
 	return g.client.Close()
 }
 
-func (g *GeminiClient) Translate(ctx context.Context, text, sourceLang, targetLang string, conversationContext []string) (string, error) {
-	prompt := BuildTranslationPrompt(text, sourceLang, targetLang, conversationContext)
-
-	resp, err := g.model.GenerateContent(ctx, genai.Text(prompt))
-	if err != nil {
-		return "", fmt.Errorf("gemini generation failed: %w", err)
+func (p TranslationPrompt) Parts() []genai.Part {
+	return []genai.Part{
+		genai.Text(p.Instructions),
+		genai.Text(p.Data),
 	}
+}
 
-	if len(resp.Candidates) == 0 || len(resp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("no translation generated")
-	}
+func (g *GeminiClient) Translate(ctx context.Context, text, sourceLang, targetLang string, style Style, conversationContext []string) (string, error) {
+	prompt := BuildTranslationPrompt(text, sourceLang, targetLang, style, conversationContext)
 
-	var result strings.Builder
-	for _, part := range resp.Candidates[0].Content.Parts {
-		if text, ok := part.(genai.Text); ok {
-			result.WriteString(string(text))
-		}
+	translated, err := g.generate(ctx, prompt, text, sourceLang, targetLang)
+	if err == nil || !IsCorrectable(err) {
+		return translated, err
 	}
 
-	return strings.TrimSpace(result.String()), nil
-}
+	g.logger.Warn("retrying rejected translation", "error", err, "target", targetLang)
 
-func (g *GeminiClient) TranslateStream(ctx context.Context, text, sourceLang, targetLang string, conversationContext []string) (<-chan string, <-chan error) {
-	textCh := make(chan string, 10)
-	errCh := make(chan error, 1)
+	translated, err = g.generate(ctx, prompt.WithCorrection(err, targetLang), text, sourceLang, targetLang)
+	if err != nil {
+		g.logger.Warn("rejected translation", "error", err, "target", targetLang)
+		return "", err
+	}
 
-	go func() {
-		defer close(textCh)
-		defer close(errCh)
+	return translated, nil
+}
 
-		prompt := BuildTranslationPrompt(text, sourceLang, targetLang, conversationContext)
+func (g *GeminiClient) generate(ctx context.Context, prompt TranslationPrompt, text, sourceLang, targetLang string) (string, error) {
+	resp, err := g.model.GenerateContent(ctx, prompt.Parts()...)
+	if err != nil {
+		var blocked *genai.BlockedError
+		if errors.As(err, &blocked) {
+			return "", fmt.Errorf("%w: %v", ErrSafetyBlocked, blocked)
+		}
+		return "", fmt.Errorf("gemini generation failed: %w", err)
+	}
 
-		iter := g.model.GenerateContentStream(ctx, genai.Text(prompt))
+	if len(resp.Candidates) == 0 {
+		return "", ErrEmptyTranslation
+	}
 
-		for {
-			resp, err := iter.Next()
-			if err != nil {
-				if err.Error() != "iterator done" {
-					errCh <- err
-				}
-				return
-			}
+	cand := resp.Candidates[0]
 
-			for _, cand := range resp.Candidates {
-				if cand.Content == nil {
-					continue
-				}
-				for _, part := range cand.Content.Parts {
-					if text, ok := part.(genai.Text); ok {
-						select {
-						case textCh <- string(text):
-						case <-ctx.Done():
-							errCh <- ctx.Err()
-							return
-						}
-					}
-				}
+	var result strings.Builder
+	if cand.Content != nil {
+		for _, part := range cand.Content.Parts {
+			if text, ok := part.(genai.Text); ok {
+				result.WriteString(string(text))
 			}
 		}
-	}()
+	}
+
+	switch cand.FinishReason {
+	case genai.FinishReasonSafety, genai.FinishReasonRecitation:
+		return "", fmt.Errorf("%w: %s", ErrSafetyBlocked, cand.FinishReason)
+	case genai.FinishReasonMaxTokens:
+		return "", ErrTruncated
+	}
 
-	return textCh, errCh
+	return FinalizeTranslation(text, result.String(), sourceLang, targetLang)
 }
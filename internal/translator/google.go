@@ -0,0 +1,80 @@
+package translator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/api/option"
+	translate "google.golang.org/api/translate/v2"
+)
+
+type GoogleTranslateClient struct {
+	service *translate.Service
+	model   string
+	logger  *slog.Logger
+}
+
+func NewGoogleTranslateClient(ctx context.Context, apiKey, model string, logger *slog.Logger) (*GoogleTranslateClient, error) {
+	var opts []option.ClientOption
+	if apiKey != "" {
+		opts = append(opts, option.WithAPIKey(apiKey))
+	}
+
+	service, err := translate.NewService(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create google translate client: %w", err)
+	}
+
+	return &GoogleTranslateClient{
+		service: service,
+		model:   model,
+		logger:  logger,
+	}, nil
+}
+
+func (g *GoogleTranslateClient) Name() string {
+	if g.model == "" {
+		return "google"
+	}
+	return "google/" + g.model
+}
+
+func (g *GoogleTranslateClient) Close() error {
+	return nil
+}
+
+func (g *GoogleTranslateClient) Translate(ctx context.Context, text, sourceLang, targetLang string, style Style, conversationContext []string) (string, error) {
+	call := g.service.Translations.List([]string{text}, googleLanguageCode(targetLang)).Format("text").Context(ctx)
+	if sourceLang != "" {
+		call = call.Source(googleLanguageCode(sourceLang))
+	}
+	if g.model != "" {
+		call = call.Model(g.model)
+	}
+
+	resp, err := call.Do()
+	if err != nil {
+		return "", fmt.Errorf("google translate request failed: %w", err)
+	}
+	if len(resp.Translations) == 0 {
+		return "", ErrEmptyTranslation
+	}
+
+	translated := strings.TrimSpace(resp.Translations[0].TranslatedText)
+	if err := ValidateTranslation(text, translated, sourceLang, targetLang); err != nil {
+		return "", err
+	}
+	return translated, nil
+}
+
+func googleLanguageCode(code string) string {
+	switch strings.ReplaceAll(strings.ToLower(code), "_", "-") {
+	case "zh-tw", "zh-hk", "zh-hant":
+		return "zh-TW"
+	case "zh", "zh-cn", "zh-hans":
+		return "zh-CN"
+	}
+	return primaryLanguage(code)
+}
@@ -0,0 +1,77 @@
+package translator
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+var preambles = []string{
+	"here is the translation",
+	"here's the translation",
+	"here is the translated text",
+	"here's the translated text",
+	"sure, here is the translation",
+	"sure, here's the translation",
+	"sure! here is the translation",
+	"sure! here's the translation",
+	"the translation is",
+	"translated text",
+	"translation",
+}
+
+var quotePairs = map[rune]rune{
+	'"':  '"',
+	'\'': '\'',
+	'`':  '`',
+	'“':  '”',
+	'‘':  '’',
+	'«':  '»',
+	'„':  '“',
+	'「':  '」',
+	'『':  '』',
+}
+
+func CleanTranslation(s string) string {
+	s = strings.TrimSpace(s)
+	s = stripCodeFence(s)
+	s = stripPreamble(s)
+	s = stripWrappingQuotes(s)
+	return strings.TrimSpace(s)
+}
+
+func stripCodeFence(s string) string {
+	if !strings.HasPrefix(s, "```") || !strings.HasSuffix(s, "```") || len(s) < 6 {
+		return s
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "```"), "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 && !strings.ContainsAny(s[:i], " \t") {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(s)
+}
+
+func stripPreamble(s string) string {
+	for _, p := range preambles {
+		label := p + ":"
+		if len(s) >= len(label) && strings.EqualFold(s[:len(label)], label) {
+			return strings.TrimSpace(s[len(label):])
+		}
+	}
+	return s
+}
+
+func stripWrappingQuotes(s string) string {
+	for {
+		first, size := utf8.DecodeRuneInString(s)
+		last, lastSize := utf8.DecodeLastRuneInString(s)
+		closing, ok := quotePairs[first]
+		if !ok || last != closing || len(s) <= size+lastSize {
+			return s
+		}
+		inner := s[size : len(s)-lastSize]
+		if strings.ContainsRune(inner, first) && first == closing {
+			return s
+		}
+		s = strings.TrimSpace(inner)
+	}
+}
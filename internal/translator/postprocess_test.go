@@ -0,0 +1,32 @@
+package translator
+
+import "testing"
+
+func TestCleanTranslation(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "plain", in: "Hola a todos", want: "Hola a todos"},
+		{name: "label", in: "Translation: Hola a todos", want: "Hola a todos"},
+		{name: "label without space", in: "translation:Hola", want: "Hola"},
+		{name: "translated text label", in: "Translated text:\nHola a todos", want: "Hola a todos"},
+		{name: "here is label", in: "Here's the translation: Hola a todos", want: "Hola a todos"},
+		{name: "sentence starting with translation", in: "Translation services are closed today: come back tomorrow.", want: "Translation services are closed today: come back tomorrow."},
+		{name: "sentence starting with translation and newline", in: "Translation is hard\nbut worth it", want: "Translation is hard\nbut worth it"},
+		{name: "sentence starting with translated text", in: "Translated text must be reviewed: always.", want: "Translated text must be reviewed: always."},
+		{name: "wrapping quotes", in: "\"Hola a todos\"", want: "Hola a todos"},
+		{name: "label and quotes", in: "Translation: «Bonjour à tous»", want: "Bonjour à tous"},
+		{name: "inner quotes kept", in: "\"Hola\" y \"adiós\"", want: "\"Hola\" y \"adiós\""},
+		{name: "code fence", in: "```text\nHola a todos\n```", want: "Hola a todos"},
+		{name: "code fence without language", in: "```Hola a todos```", want: "Hola a todos"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CleanTranslation(tt.in); got != tt.want {
+				t.Errorf("CleanTranslation(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
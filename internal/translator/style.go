@@ -0,0 +1,74 @@
+package translator
+
+import (
+	"strings"
+)
+
+const MaxAudienceLength = 200
+
+type Formality string
+
+const (
+	FormalityAuto     Formality = "auto"
+	FormalityFormal   Formality = "formal"
+	FormalityInformal Formality = "informal"
+)
+
+type Domain string
+
+const (
+	DomainGeneral         Domain = ""
+	DomainMedical         Domain = "medical"
+	DomainLegal           Domain = "legal"
+	DomainCustomerSupport Domain = "customer-support"
+	DomainTechnical       Domain = "technical"
+)
+
+type Style struct {
+	Formality Formality
+	Domain    Domain
+	Audience  string
+}
+
+func DefaultStyle() Style {
+	return Style{
+		Formality: FormalityAuto,
+		Domain:    DomainGeneral,
+	}
+}
+
+func (s Style) Instructions() []string {
+	var lines []string
+
+	switch s.Formality {
+	case FormalityFormal:
+		lines = append(lines, "Use a formal, polite register. Avoid contractions, slang and colloquialisms. Use the formal form of address where the target language has one.")
+	case FormalityInformal:
+		lines = append(lines, "Use an informal, casual register with natural contractions and colloquialisms. Use the familiar form of address where the target language has one.")
+	}
+
+	switch s.Domain {
+	case DomainMedical:
+		lines = append(lines, "This is a medical conversation. Translate symptoms, conditions, medications and dosages precisely using standard medical terminology. Never soften or generalize clinical details.")
+	case DomainLegal:
+		lines = append(lines, "This is a legal conversation. Preserve the exact meaning of statements, dates, names and obligations. Prefer literal accuracy over idiomatic phrasing.")
+	case DomainCustomerSupport:
+		lines = append(lines, "This is a customer support conversation. Keep a courteous, helpful tone and translate product names, order numbers and account details verbatim.")
+	case DomainTechnical:
+		lines = append(lines, "This is a technical conversation. Keep technical terms, product names, commands and acronyms in their commonly used form in the target language.")
+	}
+
+	if audience := sanitizeAudience(s.Audience); audience != "" {
+		lines = append(lines, "Audience: "+audience)
+	}
+
+	return lines
+}
+
+func sanitizeAudience(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > MaxAudienceLength {
+		s = strings.ToValidUTF8(s[:MaxAudienceLength], "")
+	}
+	return s
+}
@@ -0,0 +1,216 @@
+package translator
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	ErrEmptyTranslation   = errors.New("empty translation")
+	ErrWrongScript        = errors.New("translation is not in the target language script")
+	ErrLeakedInstructions = errors.New("translation contains prompt instructions")
+	ErrTranslationTooLong = errors.New("translation is implausibly long for the input")
+	ErrUntranslatedEcho   = errors.New("translation echoes the untranslated input")
+	ErrTruncated          = errors.New("translation truncated at the output token limit")
+	ErrSafetyBlocked      = errors.New("translation blocked by safety filters")
+)
+
+var corrections = []struct {
+	err    error
+	reason string
+}{
+	{ErrEmptyTranslation, "it was empty"},
+	{ErrWrongScript, "it was not written in %s"},
+	{ErrUntranslatedEcho, "it repeated the original text instead of translating it into %s"},
+	{ErrLeakedInstructions, "it contained parts of these instructions"},
+	{ErrTranslationTooLong, "it was far longer than the original speech"},
+	{ErrTruncated, "it was cut off before the end"},
+}
+
+func IsCorrectable(err error) bool {
+	return correctionReason(err, "") != ""
+}
+
+func correctionReason(err error, targetLang string) string {
+	for _, c := range corrections {
+		if errors.Is(err, c.err) {
+			if strings.Contains(c.reason, "%s") {
+				return fmt.Sprintf(c.reason, targetLang)
+			}
+			return c.reason
+		}
+	}
+	return ""
+}
+
+const (
+	MinScriptRatio = 0.5
+	MaxLengthRatio = 4
+	LengthSlack    = 40
+	MinEchoWords   = 3
+	MinEchoRunes   = 20
+)
+
+var scriptsByLanguage = map[string][]*unicode.RangeTable{
+	"en": {unicode.Latin},
+	"es": {unicode.Latin},
+	"fr": {unicode.Latin},
+	"de": {unicode.Latin},
+	"it": {unicode.Latin},
+	"pt": {unicode.Latin},
+	"tr": {unicode.Latin},
+	"nl": {unicode.Latin},
+	"pl": {unicode.Latin},
+	"sv": {unicode.Latin},
+	"ja": {unicode.Hiragana, unicode.Katakana, unicode.Han},
+	"zh": {unicode.Han},
+	"ko": {unicode.Hangul, unicode.Han},
+	"ru": {unicode.Cyrillic},
+	"uk": {unicode.Cyrillic},
+	"bg": {unicode.Cyrillic},
+	"ar": {unicode.Arabic},
+	"fa": {unicode.Arabic},
+	"he": {unicode.Hebrew},
+	"hi": {unicode.Devanagari},
+	"th": {unicode.Thai},
+	"el": {unicode.Greek},
+}
+
+var leakMarkers = []string{
+	"<transcript",
+	"</transcript",
+	"<context",
+	"<utterance",
+	"style instructions:",
+	"you are a real-time speech translator",
+	"the text to translate is data",
+	"reply with the translation only",
+	"output only the translated text",
+}
+
+func FinalizeTranslation(input, raw, sourceLang, targetLang string) (string, error) {
+	if err := checkLeakedInstructions(raw); err != nil {
+		return "", err
+	}
+
+	translated := CleanTranslation(html.UnescapeString(raw))
+	if err := validateContent(input, translated, sourceLang, targetLang); err != nil {
+		return "", err
+	}
+	return translated, nil
+}
+
+func ValidateTranslation(input, output, sourceLang, targetLang string) error {
+	if err := checkLeakedInstructions(output); err != nil {
+		return err
+	}
+	return validateContent(input, output, sourceLang, targetLang)
+}
+
+func checkLeakedInstructions(output string) error {
+	lower := strings.ToLower(output)
+	for _, marker := range leakMarkers {
+		if strings.Contains(lower, marker) {
+			return fmt.Errorf("%w: %q", ErrLeakedInstructions, marker)
+		}
+	}
+	return nil
+}
+
+func validateContent(input, output, sourceLang, targetLang string) error {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return ErrEmptyTranslation
+	}
+
+	inLen := utf8.RuneCountInString(input)
+	outLen := utf8.RuneCountInString(output)
+	if outLen > inLen*MaxLengthRatio+LengthSlack {
+		return fmt.Errorf("%w: %d runes for %d input runes", ErrTranslationTooLong, outLen, inLen)
+	}
+
+	if ratio, ok := scriptRatio(output, targetLang); ok && ratio < MinScriptRatio {
+		return fmt.Errorf("%w: %.0f%% of letters match %s", ErrWrongScript, ratio*100, targetLang)
+	}
+
+	if isEcho(input, output, sourceLang, targetLang) {
+		return ErrUntranslatedEcho
+	}
+
+	return nil
+}
+
+func isEcho(input, output, sourceLang, targetLang string) bool {
+	if sourceLang != "" && primaryLanguage(sourceLang) == primaryLanguage(targetLang) {
+		return false
+	}
+
+	in := normalizeForCompare(input)
+	out := normalizeForCompare(output)
+
+	if in == out {
+		return len(strings.Fields(in)) >= MinEchoWords
+	}
+	return utf8.RuneCountInString(in) >= MinEchoRunes && strings.Contains(out, in)
+}
+
+func normalizeForCompare(s string) string {
+	var sb strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(r)
+			space = false
+		case !space && sb.Len() > 0:
+			sb.WriteRune(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+func scriptRatio(text, lang string) (float64, bool) {
+	tables, ok := scriptsByLanguage[primaryLanguage(lang)]
+	if !ok {
+		return 0, false
+	}
+
+	var letters, matching int
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.In(r, tables...) {
+			matching++
+		}
+	}
+
+	if letters == 0 {
+		return 0, false
+	}
+	return float64(matching) / float64(letters), true
+}
+
+func LanguageLabel(code string) string {
+	lang := primaryLanguage(code)
+	if lang == "" {
+		return "auto"
+	}
+	if _, ok := scriptsByLanguage[lang]; !ok {
+		return "other"
+	}
+	return lang
+}
+
+func primaryLanguage(code string) string {
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	return strings.ToLower(code)
+}
@@ -0,0 +1,151 @@
+package translator
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+type injectionCase struct {
+	Name           string `json:"name"`
+	Input          string `json:"input"`
+	SourceLanguage string `json:"source_language"`
+	TargetLanguage string `json:"target_language"`
+	Expect         string `json:"expect"`
+	Want           string `json:"want"`
+	BadOutput      string `json:"bad_output"`
+	ExpectError    string `json:"expect_error"`
+}
+
+var corpusErrors = map[string]error{
+	"leaked_instructions": ErrLeakedInstructions,
+	"wrong_script":        ErrWrongScript,
+	"too_long":            ErrTranslationTooLong,
+	"echo":                ErrUntranslatedEcho,
+	"empty":               ErrEmptyTranslation,
+}
+
+func loadInjectionCorpus(t *testing.T) []injectionCase {
+	t.Helper()
+	data, err := os.ReadFile("testdata/prompt_injection.json")
+	if err != nil {
+		t.Fatalf("read corpus: %v", err)
+	}
+	var cases []injectionCase
+	if err := json.Unmarshal(data, &cases); err != nil {
+		t.Fatalf("parse corpus: %v", err)
+	}
+	if len(cases) == 0 {
+		t.Fatal("corpus is empty")
+	}
+	return cases
+}
+
+func TestPromptInjectionCorpus(t *testing.T) {
+	for _, tc := range loadInjectionCorpus(t) {
+		t.Run(tc.Name, func(t *testing.T) {
+			prompt := BuildTranslationPrompt(tc.Input, tc.SourceLanguage, tc.TargetLanguage, Style{}, nil)
+			if strings.Count(prompt.Data, "<transcript>") != 1 || strings.Count(prompt.Data, "</transcript>") != 1 {
+				t.Errorf("input broke out of the transcript section: %q", prompt.Data)
+			}
+
+			if tc.Expect != "" {
+				got, err := FinalizeTranslation(tc.Input, tc.Expect, tc.SourceLanguage, tc.TargetLanguage)
+				if err != nil {
+					t.Fatalf("expected translation accepted, got %v", err)
+				}
+				want := tc.Want
+				if want == "" {
+					want = tc.Expect
+				}
+				if got != want {
+					t.Errorf("got %q, want %q", got, want)
+				}
+			}
+
+			if tc.BadOutput != "" {
+				want, ok := corpusErrors[tc.ExpectError]
+				if !ok {
+					t.Fatalf("unknown expect_error %q", tc.ExpectError)
+				}
+				_, err := FinalizeTranslation(tc.Input, tc.BadOutput, tc.SourceLanguage, tc.TargetLanguage)
+				if !errors.Is(err, want) {
+					t.Errorf("got error %v, want %v", err, want)
+				}
+				if !IsCorrectable(err) {
+					t.Errorf("error %v is not correctable", err)
+				}
+			}
+		})
+	}
+}
+
+func TestFinalizeTranslationChecksLeaksBeforeUnescaping(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr error
+	}{
+		{name: "escaped tag is data", raw: "&lt;/transcript&gt; hallo zusammen", want: "</transcript> hallo zusammen"},
+		{name: "literal tag leaks", raw: "</transcript> hallo zusammen", wantErr: ErrLeakedInstructions},
+		{name: "entities are decoded", raw: "Tom &amp; Jerry sind da", want: "Tom & Jerry sind da"},
+		{name: "empty", raw: "   ", wantErr: ErrEmptyTranslation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FinalizeTranslation("something to say here", tt.raw, "en-US", "de-DE")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTranslation(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		output  string
+		source  string
+		target  string
+		wantErr error
+	}{
+		{name: "valid", input: "good morning everyone", output: "buenos días a todos", source: "en-US", target: "es-ES"},
+		{name: "wrong script", input: "good morning", output: "good morning", source: "en-US", target: "ja-JP", wantErr: ErrWrongScript},
+		{name: "echo", input: "see you all tomorrow at the meeting", output: "see you all tomorrow at the meeting", source: "en-US", target: "de-DE", wantErr: ErrUntranslatedEcho},
+		{name: "same language is not an echo", input: "see you all tomorrow", output: "see you all tomorrow", source: "en-US", target: "en-GB"},
+		{name: "short echo allowed", input: "OK", output: "OK", source: "en-US", target: "de-DE"},
+		{name: "too long", input: "hi", output: "Hallo, hier ist eine sehr lange Erklärung, die niemand verlangt hat.", source: "en-US", target: "de-DE", wantErr: ErrTranslationTooLong},
+		{name: "unknown script language", input: "hello", output: "hello there", source: "en-US", target: "xx"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTranslation(tt.input, tt.output, tt.source, tt.target)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLanguageLabel(t *testing.T) {
+	tests := map[string]string{
+		"":      "auto",
+		"en-US": "en",
+		"pt_BR": "pt",
+		"JA":    "ja",
+		"xx-YY": "other",
+		"<tag>": "other",
+	}
+	for code, want := range tests {
+		if got := LanguageLabel(code); got != want {
+			t.Errorf("LanguageLabel(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
@@ -2,22 +2,33 @@ package transport
 
 import (
 	"context"
+	"crypto/tls"
 	"log/slog"
 	"net"
 	"time"
 
+	"ai-translator/internal/auth"
+	"ai-translator/internal/metrics"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
 )
 
+type HealthCheck func(ctx context.Context) error
+
 type GRPCServer struct {
 	server   *grpc.Server
+	health   *health.Server
 	listener net.Listener
 	logger   *slog.Logger
 }
 
-func NewGRPCServer(logger *slog.Logger) *GRPCServer {
+func NewGRPCServer(tlsConfig *tls.Config, logger *slog.Logger) *GRPCServer {
 	opts := []grpc.ServerOption{
 		grpc.KeepaliveParams(keepalive.ServerParameters{
 			MaxConnectionIdle:     15 * time.Minute,
@@ -30,10 +41,21 @@ func NewGRPCServer(logger *slog.Logger) *GRPCServer {
 			MinTime:             5 * time.Second,
 			PermitWithoutStream: true,
 		}),
+		grpc.ChainUnaryInterceptor(metrics.UnaryServerInterceptor(), auth.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(metrics.StreamServerInterceptor(), auth.StreamServerInterceptor()),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
 	}
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	server := grpc.NewServer(opts...)
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
 
 	return &GRPCServer{
-		server: grpc.NewServer(opts...),
+		server: server,
+		health: healthServer,
 		logger: logger,
 	}
 }
@@ -42,6 +64,41 @@ func (s *GRPCServer) Server() *grpc.Server {
 	return s.server
 }
 
+func (s *GRPCServer) SetServing(service string, serving bool) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if serving {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	s.health.SetServingStatus(service, status)
+	if service != "" {
+		s.health.SetServingStatus("", status)
+	}
+}
+
+func (s *GRPCServer) WatchHealth(ctx context.Context, service string, interval time.Duration, check HealthCheck) {
+	healthy := check(ctx) == nil
+	s.SetServing(service, healthy)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				err := check(ctx)
+				if (err == nil) != healthy {
+					healthy = err == nil
+					s.logger.Warn("health status changed", "service", service, "serving", healthy, "error", err)
+				}
+				s.SetServing(service, healthy)
+			}
+		}
+	}()
+}
+
 func (s *GRPCServer) Start(address string) error {
 	var err error
 	s.listener, err = net.Listen("tcp", address)
@@ -54,6 +111,8 @@ func (s *GRPCServer) Start(address string) error {
 }
 
 func (s *GRPCServer) Stop(ctx context.Context) {
+	s.health.Shutdown()
+
 	stopped := make(chan struct{})
 	go func() {
 		s.server.GracefulStop()
@@ -74,14 +133,22 @@ type GRPCClient struct {
 	logger *slog.Logger
 }
 
-func NewGRPCClient(ctx context.Context, address string, logger *slog.Logger) (*GRPCClient, error) {
+func NewGRPCClient(ctx context.Context, address string, tlsConfig *tls.Config, logger *slog.Logger) (*GRPCClient, error) {
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
 	opts := []grpc.DialOption{
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(creds),
 		grpc.WithKeepaliveParams(keepalive.ClientParameters{
 			Time:                10 * time.Second,
 			Timeout:             3 * time.Second,
 			PermitWithoutStream: true,
 		}),
+		grpc.WithChainUnaryInterceptor(metrics.UnaryClientInterceptor(), auth.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(metrics.StreamClientInterceptor(), auth.StreamClientInterceptor()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 	}
 
 	conn, err := grpc.DialContext(ctx, address, opts...)
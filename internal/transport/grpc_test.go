@@ -0,0 +1,137 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testService = "translator.TranslatorService"
+
+func startHealthServer(t *testing.T) (*GRPCServer, healthpb.HealthClient) {
+	t.Helper()
+	s := NewGRPCServer(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	listener := bufconn.Listen(1 << 20)
+	go s.server.Serve(listener)
+	t.Cleanup(s.server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return s, healthpb.NewHealthClient(conn)
+}
+
+func checkHealth(t *testing.T, client healthpb.HealthClient, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		t.Fatalf("Check(%q): %v", service, err)
+	}
+	return resp.Status
+}
+
+func waitForHealth(t *testing.T, client healthpb.HealthClient, service string, want healthpb.HealthCheckResponse_ServingStatus) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for checkHealth(t, client, service) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("%q never became %v", service, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestGRPCServerRegistersHealth(t *testing.T) {
+	s, client := startHealthServer(t)
+
+	if got := checkHealth(t, client, ""); got != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("overall status = %v, want SERVING before any check", got)
+	}
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: testService}); status.Code(err) != codes.NotFound {
+		t.Fatalf("unregistered service error = %v, want NotFound", err)
+	}
+
+	s.SetServing(testService, false)
+	if got := checkHealth(t, client, testService); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("service status = %v, want NOT_SERVING", got)
+	}
+	if got := checkHealth(t, client, ""); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("overall status = %v, want it to follow the service", got)
+	}
+
+	s.SetServing(testService, true)
+	if got := checkHealth(t, client, testService); got != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("service status = %v, want SERVING", got)
+	}
+}
+
+func TestWatchHealthFollowsCheck(t *testing.T) {
+	s, client := startHealthServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var failing atomic.Bool
+	failing.Store(true)
+	s.WatchHealth(ctx, testService, 10*time.Millisecond, func(context.Context) error {
+		if failing.Load() {
+			return errors.New("provider down")
+		}
+		return nil
+	})
+
+	if got := checkHealth(t, client, testService); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("status after first check = %v, want NOT_SERVING", got)
+	}
+	failing.Store(false)
+	waitForHealth(t, client, testService, healthpb.HealthCheckResponse_SERVING)
+	failing.Store(true)
+	waitForHealth(t, client, testService, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+func TestStopReportsNotServingWhileDraining(t *testing.T) {
+	s, client := startHealthServer(t)
+	s.SetServing(testService, true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watch, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: testService})
+	if err != nil {
+		t.Fatalf("Watch: %v", err)
+	}
+	if resp, err := watch.Recv(); err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("first watch update = %v, %v, want SERVING", resp, err)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s.Stop(context.Background())
+	}()
+
+	if resp, err := watch.Recv(); err != nil || resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("watch update while stopping = %v, %v, want NOT_SERVING", resp, err)
+	}
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop did not return after the last stream ended")
+	}
+}
@@ -0,0 +1,396 @@
+package transport
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+type tlsFiles struct {
+	cert, key, ca string
+}
+
+func writeTLSFiles(t *testing.T, dir string, ca *testCA, name string, usage x509.ExtKeyUsage) tlsFiles {
+	t.Helper()
+	certPEM, keyPEM := ca.issue(t, name, usage)
+	files := tlsFiles{
+		cert: filepath.Join(dir, name+".crt"),
+		key:  filepath.Join(dir, name+".key"),
+		ca:   filepath.Join(dir, name+"-ca.crt"),
+	}
+	for path, data := range map[string][]byte{files.cert: certPEM, files.key: keyPEM, files.ca: ca.pem} {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return files
+}
+
+func bumpModTime(t *testing.T, paths ...string) {
+	t.Helper()
+	later := time.Now().Add(time.Minute)
+	for _, path := range paths {
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func testLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func newReloader(t *testing.T, certFile, keyFile, caFile string) *CertReloader {
+	t.Helper()
+	r, err := NewCertReloader(certFile, keyFile, caFile, testLogger())
+	if err != nil {
+		t.Fatalf("NewCertReloader: %v", err)
+	}
+	return r
+}
+
+func handshake(t *testing.T, server, client *tls.Config) error {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		raw, err := listener.Accept()
+		if err != nil {
+			serverErr <- err
+			return
+		}
+		defer raw.Close()
+		raw.SetDeadline(time.Now().Add(2 * time.Second))
+		conn := tls.Server(raw, server)
+		if err = conn.Handshake(); err == nil {
+			_, err = conn.Read(make([]byte, 1))
+		}
+		serverErr <- err
+	}()
+
+	raw, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	raw.SetDeadline(time.Now().Add(2 * time.Second))
+	conn := tls.Client(raw, client)
+	if err = conn.Handshake(); err == nil {
+		_, err = conn.Write([]byte{0})
+	}
+	if serverErr := <-serverErr; err == nil {
+		err = serverErr
+	}
+	return err
+}
+
+func TestNewCertReloaderValidation(t *testing.T) {
+	dir := t.TempDir()
+	files := writeTLSFiles(t, dir, newTestCA(t, "ca"), "server", x509.ExtKeyUsageServerAuth)
+
+	if _, err := NewCertReloader(files.cert, "", "", testLogger()); err == nil {
+		t.Error("accepted a certificate without a key")
+	}
+	if _, err := NewCertReloader(filepath.Join(dir, "missing.crt"), files.key, "", testLogger()); err == nil {
+		t.Error("accepted a missing certificate")
+	}
+	if _, err := NewCertReloader(files.cert, files.cert, "", testLogger()); err == nil {
+		t.Error("accepted a certificate as its own key")
+	}
+
+	empty := filepath.Join(dir, "empty.pem")
+	os.WriteFile(empty, []byte("not a certificate"), 0o600)
+	if _, err := NewCertReloader("", "", empty, testLogger()); err == nil {
+		t.Error("accepted a CA bundle without certificates")
+	}
+}
+
+func TestServerConfigValidation(t *testing.T) {
+	files := writeTLSFiles(t, t.TempDir(), newTestCA(t, "ca"), "server", x509.ExtKeyUsageServerAuth)
+
+	if _, err := newReloader(t, "", "", files.ca).ServerConfig(false); err == nil {
+		t.Error("ServerConfig without a certificate succeeded")
+	}
+	if _, err := newReloader(t, files.cert, files.key, "").ServerConfig(true); err == nil {
+		t.Error("ServerConfig requiring client certificates without a CA succeeded")
+	}
+}
+
+func TestMutualTLSHandshake(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "ca")
+	serverFiles := writeTLSFiles(t, dir, ca, "translator", x509.ExtKeyUsageServerAuth)
+	clientFiles := writeTLSFiles(t, dir, ca, "gateway", x509.ExtKeyUsageClientAuth)
+
+	serverConfig, err := newReloader(t, serverFiles.cert, serverFiles.key, serverFiles.ca).ServerConfig(true)
+	if err != nil {
+		t.Fatalf("ServerConfig: %v", err)
+	}
+	client := newReloader(t, clientFiles.cert, clientFiles.key, clientFiles.ca)
+
+	if err := handshake(t, serverConfig, client.ClientConfig("translator")); err != nil {
+		t.Fatalf("mTLS handshake: %v", err)
+	}
+	if err := handshake(t, serverConfig, client.ClientConfig("tts")); err == nil {
+		t.Fatal("handshake succeeded with the wrong server name")
+	}
+
+	anonymous := newReloader(t, "", "", clientFiles.ca)
+	if err := handshake(t, serverConfig, anonymous.ClientConfig("translator")); err == nil {
+		t.Fatal("server accepted a client without a certificate")
+	}
+
+	otherFiles := writeTLSFiles(t, dir, newTestCA(t, "other"), "gateway-other", x509.ExtKeyUsageClientAuth)
+	stranger := newReloader(t, otherFiles.cert, otherFiles.key, otherFiles.ca)
+	if err := handshake(t, serverConfig, stranger.ClientConfig("translator")); err == nil {
+		t.Fatal("handshake succeeded with certificates from an untrusted CA")
+	}
+}
+
+func TestCertReloaderPicksUpRotatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	oldCA, newCA := newTestCA(t, "old"), newTestCA(t, "new")
+	serverFiles := writeTLSFiles(t, dir, oldCA, "translator", x509.ExtKeyUsageServerAuth)
+	server := newReloader(t, serverFiles.cert, serverFiles.key, "")
+	serverConfig, err := server.ServerConfig(false)
+	if err != nil {
+		t.Fatalf("ServerConfig: %v", err)
+	}
+
+	client := newReloader(t, "", "", serverFiles.ca)
+	if err := handshake(t, serverConfig, client.ClientConfig("translator")); err != nil {
+		t.Fatalf("handshake before rotation: %v", err)
+	}
+	if server.changed() {
+		t.Fatal("reloader reports a change before the files were touched")
+	}
+
+	rotated := writeTLSFiles(t, t.TempDir(), newCA, "translator", x509.ExtKeyUsageServerAuth)
+	for src, dst := range map[string]string{rotated.cert: serverFiles.cert, rotated.key: serverFiles.key} {
+		data, _ := os.ReadFile(src)
+		os.WriteFile(dst, data, 0o600)
+	}
+	bumpModTime(t, serverFiles.cert, serverFiles.key)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go server.Watch(ctx, 5*time.Millisecond)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for handshake(t, serverConfig, client.ClientConfig("translator")) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("server kept the old certificate after rotation")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	os.WriteFile(serverFiles.ca, newCA.pem, 0o600)
+	bumpModTime(t, serverFiles.ca)
+	if !client.changed() {
+		t.Fatal("client did not notice the rotated CA bundle")
+	}
+	if err := client.reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if err := handshake(t, serverConfig, client.ClientConfig("translator")); err != nil {
+		t.Fatalf("handshake after rotating both sides: %v", err)
+	}
+}
+
+func TestCertReloaderKeepsCertificateOnBadReload(t *testing.T) {
+	files := writeTLSFiles(t, t.TempDir(), newTestCA(t, "ca"), "translator", x509.ExtKeyUsageServerAuth)
+	r := newReloader(t, files.cert, files.key, files.ca)
+	before, _ := r.certificate()
+
+	os.WriteFile(files.key, []byte("garbage"), 0o600)
+	bumpModTime(t, files.key)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Watch(ctx, 5*time.Millisecond)
+	}()
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	<-done
+
+	after, _ := r.certificate()
+	if after != before {
+		t.Fatal("a failed reload replaced the certificate")
+	}
+	if !r.changed() {
+		t.Fatal("a failed reload forgot that the files changed")
+	}
+}
+
+func TestGRPCOverMutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "ca")
+	serverFiles := writeTLSFiles(t, dir, ca, "translator", x509.ExtKeyUsageServerAuth)
+	clientFiles := writeTLSFiles(t, dir, ca, "gateway", x509.ExtKeyUsageClientAuth)
+
+	serverTLS, err := newReloader(t, serverFiles.cert, serverFiles.key, serverFiles.ca).ServerConfig(true)
+	if err != nil {
+		t.Fatalf("ServerConfig: %v", err)
+	}
+	server := NewGRPCServer(serverTLS, testLogger())
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.server.Serve(listener)
+	t.Cleanup(server.server.Stop)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clientTLS := newReloader(t, clientFiles.cert, clientFiles.key, clientFiles.ca).ClientConfig("translator")
+	client, err := NewGRPCClient(ctx, listener.Addr().String(), clientTLS, testLogger())
+	if err != nil {
+		t.Fatalf("NewGRPCClient: %v", err)
+	}
+	defer client.Close()
+	if _, err := healthpb.NewHealthClient(client.Conn()).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("health check over mTLS: %v", err)
+	}
+
+	plain, err := NewGRPCClient(ctx, listener.Addr().String(), nil, testLogger())
+	if err != nil {
+		t.Fatalf("NewGRPCClient: %v", err)
+	}
+	defer plain.Close()
+	if _, err := healthpb.NewHealthClient(plain.Conn()).Check(ctx, &healthpb.HealthCheckRequest{}); err == nil {
+		t.Fatal("plaintext client reached the TLS server")
+	}
+}
+
+func TestWSServerServesTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t, "ca")
+	files := writeTLSFiles(t, dir, ca, "localhost", x509.ExtKeyUsageServerAuth)
+	serverTLS, err := newReloader(t, files.cert, files.key, "").ServerConfig(false)
+	if err != nil {
+		t.Fatalf("ServerConfig: %v", err)
+	}
+
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := probe.Addr().String()
+	probe.Close()
+
+	upgrader := NewWSUpgrader(nil, WSTimeouts{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, testLogger(), "sess-tls")
+		if err != nil {
+			return
+		}
+		conn.WriteText("hello")
+	})
+	server := NewWSServer(addr, handler, serverTLS, testLogger())
+	go server.Start()
+	t.Cleanup(func() { server.Stop(context.Background()) })
+
+	dialer := websocket.Dialer{TLSClientConfig: newReloader(t, "", "", files.ca).ClientConfig("localhost"), HandshakeTimeout: time.Second}
+	var conn *websocket.Conn
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, _, err = dialer.Dial("wss://"+addr+"/ws", nil)
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("dial wss: %v", err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, data, err := conn.ReadMessage(); err != nil || string(data) != "hello" {
+		t.Fatalf("read = %q, %v", data, err)
+	}
+}
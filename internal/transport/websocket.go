@@ -2,44 +2,125 @@ package transport
 
 import (
 	"context"
+	"crypto/tls"
 	"log/slog"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  16384,
-	WriteBufferSize: 16384,
-	CheckOrigin: func(r *http.Request) bool {
-		return true
-	},
+const defaultControlTimeout = time.Second
+
+type WSTimeouts struct {
+	PingInterval time.Duration
+	IdleTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+type WSUpgrader struct {
+	upgrader websocket.Upgrader
+	timeouts WSTimeouts
+}
+
+func NewWSUpgrader(allowedOrigins []string, timeouts WSTimeouts) *WSUpgrader {
+	u := &WSUpgrader{
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  16384,
+			WriteBufferSize: 16384,
+		},
+		timeouts: timeouts,
+	}
+	if len(allowedOrigins) > 0 {
+		u.upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			return origin == "" || OriginAllowed(origin, allowedOrigins)
+		}
+	}
+	return u
+}
+
+func OriginAllowed(origin string, allowed []string) bool {
+	origin = strings.ToLower(origin)
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+		if scheme, host, ok := strings.Cut(pattern, "://*."); ok {
+			prefix := scheme + "://"
+			if strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, "."+host) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 type WSConn struct {
 	conn      *websocket.Conn
 	mu        sync.Mutex
+	timeouts  WSTimeouts
 	logger    *slog.Logger
 	sessionID string
 	closed    bool
+	done      chan struct{}
 }
 
-func UpgradeToWebSocket(w http.ResponseWriter, r *http.Request, logger *slog.Logger, sessionID string) (*WSConn, error) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+func (u *WSUpgrader) Upgrade(w http.ResponseWriter, r *http.Request, logger *slog.Logger, sessionID string) (*WSConn, error) {
+	conn, err := u.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &WSConn{
+	ws := &WSConn{
 		conn:      conn,
+		timeouts:  u.timeouts,
 		logger:    logger,
 		sessionID: sessionID,
-	}, nil
+		done:      make(chan struct{}),
+	}
+	if ws.timeouts.IdleTimeout > 0 {
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(ws.timeouts.IdleTimeout))
+		})
+	}
+	if ws.timeouts.PingInterval > 0 {
+		go ws.keepalive()
+	}
+	return ws, nil
+}
+
+func (ws *WSConn) keepalive() {
+	ticker := time.NewTicker(ws.timeouts.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ws.done:
+			return
+		case <-ticker.C:
+			if err := ws.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(ws.controlTimeout())); err != nil {
+				ws.logger.Debug("websocket ping failed", "error", err)
+				return
+			}
+		}
+	}
+}
+
+func (ws *WSConn) controlTimeout() time.Duration {
+	if ws.timeouts.WriteTimeout > 0 {
+		return ws.timeouts.WriteTimeout
+	}
+	return defaultControlTimeout
 }
 
 func (ws *WSConn) ReadMessage() (int, []byte, error) {
+	if ws.timeouts.IdleTimeout > 0 {
+		ws.conn.SetReadDeadline(time.Now().Add(ws.timeouts.IdleTimeout))
+	}
 	return ws.conn.ReadMessage()
 }
 
@@ -50,6 +131,9 @@ func (ws *WSConn) WriteMessage(messageType int, data []byte) error {
 	if ws.closed {
 		return websocket.ErrCloseSent
 	}
+	if ws.timeouts.WriteTimeout > 0 {
+		ws.conn.SetWriteDeadline(time.Now().Add(ws.timeouts.WriteTimeout))
+	}
 
 	return ws.conn.WriteMessage(messageType, data)
 }
@@ -63,6 +147,10 @@ func (ws *WSConn) WriteText(data string) error {
 }
 
 func (ws *WSConn) Close() error {
+	return ws.CloseWithCode(websocket.CloseNormalClosure, "")
+}
+
+func (ws *WSConn) CloseWithCode(code int, reason string) error {
 	ws.mu.Lock()
 	defer ws.mu.Unlock()
 
@@ -71,10 +159,11 @@ func (ws *WSConn) Close() error {
 	}
 
 	ws.closed = true
+	close(ws.done)
 	ws.conn.WriteControl(
 		websocket.CloseMessage,
-		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
-		time.Now().Add(time.Second),
+		websocket.FormatCloseMessage(code, reason),
+		time.Now().Add(defaultControlTimeout),
 	)
 	return ws.conn.Close()
 }
@@ -96,11 +185,12 @@ type WSServer struct {
 	logger *slog.Logger
 }
 
-func NewWSServer(addr string, handler http.Handler, logger *slog.Logger) *WSServer {
+func NewWSServer(addr string, handler http.Handler, tlsConfig *tls.Config, logger *slog.Logger) *WSServer {
 	return &WSServer{
 		server: &http.Server{
 			Addr:         addr,
 			Handler:      handler,
+			TLSConfig:    tlsConfig,
 			ReadTimeout:  30 * time.Second,
 			WriteTimeout: 30 * time.Second,
 			IdleTimeout:  120 * time.Second,
@@ -110,6 +200,10 @@ func NewWSServer(addr string, handler http.Handler, logger *slog.Logger) *WSServ
 }
 
 func (s *WSServer) Start() error {
+	if s.server.TLSConfig != nil {
+		s.logger.Info("WebSocket server starting", "address", s.server.Addr, "tls", true)
+		return s.server.ListenAndServeTLS("", "")
+	}
 	s.logger.Info("WebSocket server starting", "address", s.server.Addr)
 	return s.server.ListenAndServe()
 }
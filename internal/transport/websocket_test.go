@@ -0,0 +1,194 @@
+package transport
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func wsPair(t *testing.T, origins []string, timeouts WSTimeouts) (*WSConn, *websocket.Conn) {
+	t.Helper()
+	upgrader := NewWSUpgrader(origins, timeouts)
+	conns := make(chan *WSConn, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, discardLogger(), "sess-1")
+		if err != nil {
+			return
+		}
+		conns <- ws
+	}))
+	t.Cleanup(srv.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	server := <-conns
+	t.Cleanup(func() { server.Close() })
+	return server, client
+}
+
+func TestOriginAllowed(t *testing.T) {
+	allowed := []string{"https://app.example.com", "https://*.example.org"}
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"https://app.example.com", true},
+		{"HTTPS://APP.EXAMPLE.COM", true},
+		{"https://evil.example.com", false},
+		{"https://eu.example.org", true},
+		{"https://example.org", false},
+		{"http://eu.example.org", false},
+		{"https://eu.example.org.evil.com", false},
+	}
+	for _, tt := range tests {
+		if got := OriginAllowed(tt.origin, allowed); got != tt.want {
+			t.Errorf("OriginAllowed(%q) = %v, want %v", tt.origin, got, tt.want)
+		}
+	}
+	if !OriginAllowed("https://anything.test", []string{"*"}) {
+		t.Error("wildcard origin rejected")
+	}
+}
+
+func TestUpgradeRejectsDisallowedOrigin(t *testing.T) {
+	upgrader := NewWSUpgrader([]string{"https://app.example.com"}, WSTimeouts{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upgrader.Upgrade(w, r, discardLogger(), "sess-1")
+	}))
+	defer srv.Close()
+
+	header := http.Header{"Origin": []string{"https://evil.example.com"}}
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), header)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("Dial from a disallowed origin = %v, want 403", err)
+	}
+}
+
+func TestKeepalivePings(t *testing.T) {
+	_, client := wsPair(t, nil, WSTimeouts{PingInterval: 20 * time.Millisecond})
+
+	var pings atomic.Int32
+	client.SetPingHandler(func(string) error {
+		pings.Add(1)
+		return nil
+	})
+	go func() {
+		for {
+			if _, _, err := client.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for pings.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("received %d pings, want at least 3", pings.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestIdleTimeout(t *testing.T) {
+	server, _ := wsPair(t, nil, WSTimeouts{IdleTimeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	_, _, err := server.ReadMessage()
+	var netErr net.Error
+	if !errors.As(err, &netErr) || !netErr.Timeout() {
+		t.Fatalf("ReadMessage from a silent client = %v, want a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("idle timeout took %s", elapsed)
+	}
+}
+
+func TestPongsKeepConnectionAlive(t *testing.T) {
+	server, client := wsPair(t, nil, WSTimeouts{PingInterval: 20 * time.Millisecond, IdleTimeout: 60 * time.Millisecond})
+	go func() {
+		for {
+			if _, _, err := client.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		client.WriteMessage(websocket.TextMessage, []byte("still here"))
+	}()
+	_, data, err := server.ReadMessage()
+	if err != nil || string(data) != "still here" {
+		t.Fatalf("ReadMessage = %q, %v, want the message sent after several idle timeouts", data, err)
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	server, _ := wsPair(t, nil, WSTimeouts{WriteTimeout: 50 * time.Millisecond})
+
+	chunk := make([]byte, 1<<20)
+	done := make(chan error, 1)
+	go func() {
+		for {
+			if err := server.WriteBinary(chunk); err != nil {
+				done <- err
+				return
+			}
+		}
+	}()
+
+	select {
+	case err := <-done:
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Fatalf("write to a client that never reads = %v, want a timeout", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("writes to a client that never reads did not time out")
+	}
+}
+
+func TestCloseWithCode(t *testing.T) {
+	server, client := wsPair(t, nil, WSTimeouts{PingInterval: 10 * time.Millisecond})
+
+	if err := server.WriteText("bye"); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	if err := server.CloseWithCode(4408, "too slow"); err != nil {
+		t.Fatalf("CloseWithCode: %v", err)
+	}
+	if err := server.CloseWithCode(websocket.CloseNormalClosure, ""); err != nil {
+		t.Fatalf("second CloseWithCode: %v", err)
+	}
+	if err := server.WriteText("late"); !errors.Is(err, websocket.ErrCloseSent) {
+		t.Fatalf("WriteText after close = %v, want ErrCloseSent", err)
+	}
+
+	if _, data, err := client.ReadMessage(); err != nil || string(data) != "bye" {
+		t.Fatalf("ReadMessage = %q, %v", data, err)
+	}
+	_, _, err := client.ReadMessage()
+	if !websocket.IsCloseError(err, 4408) || !strings.Contains(err.Error(), "too slow") {
+		t.Fatalf("close = %v, want code 4408 with the reason", err)
+	}
+	if server.SessionID() != "sess-1" {
+		t.Fatalf("SessionID = %q", server.SessionID())
+	}
+}
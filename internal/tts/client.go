@@ -1,16 +1,25 @@
 package tts
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 
+	"ai-translator/internal/audio"
+	"ai-translator/internal/util"
+
 	texttospeech "cloud.google.com/go/texttospeech/apiv1"
 	tspb "cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
 )
 
+var ErrProviderUnhealthy = errors.New("text-to-speech provider unhealthy")
+
 type Client struct {
 	client *texttospeech.Client
+	health *util.CircuitBreaker
 	logger *slog.Logger
 }
 
@@ -22,6 +31,7 @@ func NewClient(ctx context.Context, logger *slog.Logger) (*Client, error) {
 
 	return &Client{
 		client: client,
+		health: util.NewCircuitBreaker(util.DefaultBreakerConfig()),
 		logger: logger,
 	}, nil
 }
@@ -30,12 +40,29 @@ func (c *Client) Close() error {
 	return c.client.Close()
 }
 
+func (c *Client) Healthy(ctx context.Context) error {
+	if c.health.State() == util.BreakerOpen {
+		return ErrProviderUnhealthy
+	}
+	return nil
+}
+
+func (c *Client) report(ctx context.Context, err error) {
+	switch {
+	case err == nil:
+		c.health.Success()
+	case ctx.Err() == nil && util.IsProviderFailure(err):
+		c.health.Failure()
+	}
+}
+
 type SynthesizeConfig struct {
 	LanguageCode string
 	VoiceName    string
 	SpeakingRate float64
 	Pitch        float64
 	SampleRate   int32
+	Encoding     tspb.AudioEncoding
 }
 
 func DefaultSynthesizeConfig(languageCode string) SynthesizeConfig {
@@ -45,6 +72,7 @@ func DefaultSynthesizeConfig(languageCode string) SynthesizeConfig {
 		SpeakingRate: 1.0,
 		Pitch:        0.0,
 		SampleRate:   16000,
+		Encoding:     tspb.AudioEncoding_LINEAR16,
 	}
 }
 
@@ -66,7 +94,7 @@ func (c *Client) Synthesize(ctx context.Context, text string, cfg SynthesizeConf
 		},
 		Voice: voice,
 		AudioConfig: &tspb.AudioConfig{
-			AudioEncoding:   tspb.AudioEncoding_LINEAR16,
+			AudioEncoding:   cfg.Encoding,
 			SampleRateHertz: cfg.SampleRate,
 			SpeakingRate:    cfg.SpeakingRate,
 			Pitch:           cfg.Pitch,
@@ -74,11 +102,12 @@ func (c *Client) Synthesize(ctx context.Context, text string, cfg SynthesizeConf
 	}
 
 	resp, err := c.client.SynthesizeSpeech(ctx, req)
+	c.report(ctx, err)
 	if err != nil {
 		return nil, fmt.Errorf("TTS synthesis failed: %w", err)
 	}
 
-	return resp.AudioContent, nil
+	return stripWAVHeader(resp.AudioContent, cfg.Encoding), nil
 }
 
 func (c *Client) SynthesizeSSML(ctx context.Context, ssml string, cfg SynthesizeConfig) ([]byte, error) {
@@ -99,7 +128,7 @@ func (c *Client) SynthesizeSSML(ctx context.Context, ssml string, cfg Synthesize
 		},
 		Voice: voice,
 		AudioConfig: &tspb.AudioConfig{
-			AudioEncoding:   tspb.AudioEncoding_LINEAR16,
+			AudioEncoding:   cfg.Encoding,
 			SampleRateHertz: cfg.SampleRate,
 			SpeakingRate:    cfg.SpeakingRate,
 			Pitch:           cfg.Pitch,
@@ -107,9 +136,25 @@ func (c *Client) SynthesizeSSML(ctx context.Context, ssml string, cfg Synthesize
 	}
 
 	resp, err := c.client.SynthesizeSpeech(ctx, req)
+	c.report(ctx, err)
 	if err != nil {
 		return nil, fmt.Errorf("TTS SSML synthesis failed: %w", err)
 	}
 
-	return resp.AudioContent, nil
+	return stripWAVHeader(resp.AudioContent, cfg.Encoding), nil
+}
+
+func stripWAVHeader(data []byte, encoding tspb.AudioEncoding) []byte {
+	if encoding != tspb.AudioEncoding_LINEAR16 || !bytes.HasPrefix(data, []byte("RIFF")) {
+		return data
+	}
+	wav, err := audio.NewWAVReader(bytes.NewReader(data))
+	if err != nil || !wav.IsPCM16() {
+		return data
+	}
+	pcm, err := io.ReadAll(wav)
+	if err != nil {
+		return data
+	}
+	return pcm
 }
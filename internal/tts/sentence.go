@@ -0,0 +1,44 @@
+package tts
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const minSentenceRunes = 24
+
+func SplitSentences(text string) []string {
+	var sentences []string
+	start := 0
+	for i, r := range text {
+		next := i + utf8.RuneLen(r)
+		if !endsSentence(r, text[next:]) {
+			continue
+		}
+		if sentence := strings.TrimSpace(text[start:next]); utf8.RuneCountInString(sentence) >= minSentenceRunes {
+			sentences = append(sentences, sentence)
+			start = next
+		}
+	}
+
+	if rest := strings.TrimSpace(text[start:]); rest != "" {
+		if n := len(sentences); n > 0 && utf8.RuneCountInString(rest) < minSentenceRunes {
+			sentences[n-1] = strings.TrimSpace(sentences[n-1] + " " + rest)
+		} else {
+			sentences = append(sentences, rest)
+		}
+	}
+	return sentences
+}
+
+func endsSentence(r rune, rest string) bool {
+	switch r {
+	case '。', '！', '？', '\n':
+		return true
+	case '.', '!', '?', '…', ';':
+		next, _ := utf8.DecodeRuneInString(rest)
+		return rest == "" || unicode.IsSpace(next)
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package tts
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"single", "Hola, ¿en qué puedo ayudarte?", []string{"Hola, ¿en qué puedo ayudarte?"}},
+		{"two sentences", "The meeting starts at nine today. Please bring the quarterly report with you!", []string{"The meeting starts at nine today.", "Please bring the quarterly report with you!"}},
+		{"short sentence joins the next", "Yes. The meeting starts at nine o'clock today.", []string{"Yes. The meeting starts at nine o'clock today."}},
+		{"abbreviation", "Dr. Smith will join the call at three o'clock.", []string{"Dr. Smith will join the call at three o'clock."}},
+		{"decimal", "The price went up by 3.5 percent over the last year.", []string{"The price went up by 3.5 percent over the last year."}},
+		{"short tail joins the previous", "The meeting starts at nine o'clock today. Thanks!", []string{"The meeting starts at nine o'clock today. Thanks!"}},
+		{"cjk", "会議は今日の午前九時から始まりますのでよろしくお願いします。報告書を必ず持ってきてください、よろしくお願いいたします。", []string{"会議は今日の午前九時から始まりますのでよろしくお願いします。", "報告書を必ず持ってきてください、よろしくお願いいたします。"}},
+		{"newline", "First line of the announcement here\nSecond line of the announcement here", []string{"First line of the announcement here", "Second line of the announcement here"}},
+		{"blank", "   ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitSentences(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("SplitSentences(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package tts
 
+import "strings"
+
 var voiceMap = map[string]string{
 	"en-US": "en-US-Neural2-J",
 	"en-GB": "en-GB-Neural2-B",
@@ -72,3 +74,16 @@ func GetSupportedVoices() map[string]string {
 	}
 	return result
 }
+
+var voiceTiers = []string{"Standard", "Wavenet", "Neural2", "Studio", "Polyglot", "News", "Journey", "Chirp3", "Chirp"}
+
+func VoiceTier(voiceName string) string {
+	for _, part := range strings.Split(voiceName, "-") {
+		for _, tier := range voiceTiers {
+			if strings.EqualFold(part, tier) {
+				return strings.ToLower(tier)
+			}
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,94 @@
+package util
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	b := NewCircuitBreaker(BreakerConfig{FailureThreshold: 2, Cooldown: time.Hour})
+
+	b.Failure()
+	if !b.Allow() || b.State() != BreakerClosed {
+		t.Fatalf("state %s after one failure, want closed", b.State())
+	}
+	b.Failure()
+	if b.Allow() || b.State() != BreakerOpen {
+		t.Fatalf("state %s after two failures, want open", b.State())
+	}
+}
+
+func TestCircuitBreakerSuccessResetsFailures(t *testing.T) {
+	b := NewCircuitBreaker(BreakerConfig{FailureThreshold: 2, Cooldown: time.Hour})
+
+	b.Failure()
+	b.Success()
+	b.Failure()
+	if b.State() != BreakerClosed {
+		t.Fatalf("state %s, want closed", b.State())
+	}
+}
+
+func TestCircuitBreakerHalfOpenAllowsOneProbe(t *testing.T) {
+	b := NewCircuitBreaker(BreakerConfig{FailureThreshold: 1, Cooldown: 10 * time.Millisecond})
+	b.Failure()
+	time.Sleep(20 * time.Millisecond)
+
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("state %s after cooldown, want half_open", b.State())
+	}
+	if !b.Allow() {
+		t.Fatal("first probe rejected")
+	}
+	if b.Allow() {
+		t.Fatal("second concurrent probe allowed")
+	}
+
+	b.Release()
+	if !b.Allow() {
+		t.Fatal("probe rejected after release")
+	}
+	b.Failure()
+	if b.State() != BreakerOpen {
+		t.Fatalf("state %s after failed probe, want open", b.State())
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	b.Allow()
+	b.Success()
+	if b.State() != BreakerClosed || !b.Allow() {
+		t.Fatalf("state %s after successful probe, want closed", b.State())
+	}
+}
+
+func TestCircuitBreakerZeroThreshold(t *testing.T) {
+	b := NewCircuitBreaker(BreakerConfig{Cooldown: time.Hour})
+	b.Failure()
+	if b.State() != BreakerOpen {
+		t.Fatalf("state %s, want open after one failure", b.State())
+	}
+}
+
+func TestIsProviderFailure(t *testing.T) {
+	failures := []codes.Code{codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown, codes.Unauthenticated, codes.PermissionDenied}
+	for _, code := range failures {
+		if !IsProviderFailure(status.Error(code, "")) {
+			t.Errorf("%v is not a provider failure", code)
+		}
+	}
+	for _, code := range []codes.Code{codes.InvalidArgument, codes.NotFound, codes.Canceled, codes.FailedPrecondition} {
+		if IsProviderFailure(status.Error(code, "")) {
+			t.Errorf("%v is a provider failure", code)
+		}
+	}
+	if IsProviderFailure(nil) {
+		t.Error("nil is a provider failure")
+	}
+	if !IsProviderFailure(errors.New("plain")) {
+		t.Error("an untyped error is not a provider failure")
+	}
+}
@@ -23,3 +23,11 @@ func NewRequestID() string {
 	rand.Read(b)
 	return hex.EncodeToString(b)
 }
+
+// NewToken returns 128 random bits in hex for bearer secrets such as resume
+// tokens. Use NewRequestID for identifiers that only need to be unique.
+func NewToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,21 @@
+package util
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestNewTokenCarries128Bits(t *testing.T) {
+	seen := make(map[string]bool)
+	for range 100 {
+		token := NewToken()
+		b, err := hex.DecodeString(token)
+		if err != nil || len(b) != 16 {
+			t.Fatalf("token %q decodes to %d bytes, %v, want 16", token, len(b), err)
+		}
+		if seen[token] {
+			t.Fatalf("token %q repeated", token)
+		}
+		seen[token] = true
+	}
+}
@@ -3,6 +3,7 @@ package util
 import (
 	"context"
 	"math"
+	"math/rand/v2"
 	"time"
 )
 
@@ -10,6 +11,8 @@ type RetryConfig struct {
 	MaxAttempts int
 	BaseDelay   time.Duration
 	MaxDelay    time.Duration
+	Jitter      float64
+	ShouldRetry func(error) bool
 }
 
 func DefaultRetryConfig() RetryConfig {
@@ -17,6 +20,7 @@ func DefaultRetryConfig() RetryConfig {
 		MaxAttempts: 3,
 		BaseDelay:   100 * time.Millisecond,
 		MaxDelay:    5 * time.Second,
+		Jitter:      0.2,
 	}
 }
 
@@ -31,11 +35,18 @@ func Retry(ctx context.Context, cfg RetryConfig, fn func() error) error {
 			return nil
 		}
 
+		if cfg.ShouldRetry != nil && !cfg.ShouldRetry(lastErr) {
+			return lastErr
+		}
+
 		if attempt < cfg.MaxAttempts-1 {
 			delay := time.Duration(float64(cfg.BaseDelay) * math.Pow(2, float64(attempt)))
 			if delay > cfg.MaxDelay {
 				delay = cfg.MaxDelay
 			}
+			if cfg.Jitter > 0 {
+				delay += time.Duration((rand.Float64()*2 - 1) * cfg.Jitter * float64(delay))
+			}
 			select {
 			case <-time.After(delay):
 			case <-ctx.Done():
@@ -7,7 +7,6 @@ import (
 	pb "ai-translator/api/proto"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type ASRClient struct {
@@ -15,8 +14,8 @@ type ASRClient struct {
 	client pb.ASRServiceClient
 }
 
-func NewASRClient(ctx context.Context, address string) (*ASRClient, error) {
-	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func NewASRClient(ctx context.Context, address string, opts ...Option) (*ASRClient, error) {
+	conn, err := dial(ctx, address, opts)
 	if err != nil {
 		return nil, err
 	}
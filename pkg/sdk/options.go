@@ -0,0 +1,70 @@
+package sdk
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type Option func(*dialOptions)
+
+type dialOptions struct {
+	creds credentials.TransportCredentials
+	extra []grpc.DialOption
+}
+
+func WithTLS(cfg *tls.Config) Option {
+	return func(o *dialOptions) {
+		o.creds = credentials.NewTLS(cfg)
+	}
+}
+
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *dialOptions) {
+		o.extra = append(o.extra, opts...)
+	}
+}
+
+func LoadTLSConfig(caFile, certFile, keyFile, serverName string) (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+	}
+
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA bundle %s contains no certificates", caFile)
+		}
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+func dial(ctx context.Context, address string, opts []Option) (*grpc.ClientConn, error) {
+	o := dialOptions{creds: insecure.NewCredentials()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	dialOpts := append([]grpc.DialOption{grpc.WithTransportCredentials(o.creds)}, o.extra...)
+	return grpc.DialContext(ctx, address, dialOpts...)
+}
@@ -0,0 +1,169 @@
+package sdk
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pb "ai-translator/api/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+type certFiles struct {
+	ca, cert, key string
+}
+
+func writeCert(t *testing.T, dir, name string) certFiles {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := certFiles{
+		ca:   filepath.Join(dir, name+"-ca.pem"),
+		cert: filepath.Join(dir, name+".pem"),
+		key:  filepath.Join(dir, name+"-key.pem"),
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	for path, data := range map[string][]byte{
+		files.ca:   certPEM,
+		files.cert: certPEM,
+		files.key:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	} {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return files
+}
+
+func TestLoadTLSConfig(t *testing.T) {
+	files := writeCert(t, t.TempDir(), "translator")
+
+	cfg, err := LoadTLSConfig(files.ca, files.cert, files.key, "translator")
+	if err != nil {
+		t.Fatalf("LoadTLSConfig: %v", err)
+	}
+	if cfg.ServerName != "translator" || cfg.MinVersion != tls.VersionTLS12 || cfg.RootCAs == nil || len(cfg.Certificates) != 1 {
+		t.Fatalf("config = %+v", cfg)
+	}
+
+	cfg, err = LoadTLSConfig("", "", "", "")
+	if err != nil || cfg.RootCAs != nil || len(cfg.Certificates) != 0 {
+		t.Fatalf("empty LoadTLSConfig = %+v, %v, want system roots and no client certificate", cfg, err)
+	}
+}
+
+func TestLoadTLSConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	files := writeCert(t, dir, "translator")
+	garbage := filepath.Join(dir, "garbage.pem")
+	os.WriteFile(garbage, []byte("not pem"), 0o600)
+
+	for name, args := range map[string][3]string{
+		"missing CA":     {filepath.Join(dir, "missing.pem"), "", ""},
+		"CA without PEM": {garbage, "", ""},
+		"cert alone":     {"", files.cert, ""},
+		"key mismatch":   {"", files.cert, files.cert},
+	} {
+		if _, err := LoadTLSConfig(args[0], args[1], args[2], ""); err == nil {
+			t.Errorf("%s: LoadTLSConfig succeeded", name)
+		}
+	}
+}
+
+func TestDialOptions(t *testing.T) {
+	var o dialOptions
+	WithTLS(&tls.Config{})(&o)
+	WithDialOptions(grpc.WithUserAgent("test"))(&o)
+	if o.creds == nil || o.creds.Info().SecurityProtocol != "tls" {
+		t.Fatalf("credentials = %v, want TLS", o.creds)
+	}
+	if len(o.extra) != 1 {
+		t.Fatalf("extra dial options = %d, want 1", len(o.extra))
+	}
+}
+
+type echoTranslator struct {
+	pb.UnimplementedTranslatorServiceServer
+}
+
+func (echoTranslator) Translate(ctx context.Context, req *pb.TranslateRequest) (*pb.TranslateResponse, error) {
+	return &pb.TranslateResponse{TranslatedText: "hola " + req.Text}, nil
+}
+
+func TestClientDialsOverTLS(t *testing.T) {
+	files := writeCert(t, t.TempDir(), "translator")
+	cert, err := tls.LoadX509KeyPair(files.cert, files.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}})))
+	pb.RegisterTranslatorServiceServer(server, echoTranslator{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	cfg, err := LoadTLSConfig(files.ca, "", "", "translator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := NewTranslatorClient(ctx, listener.Addr().String(), WithTLS(cfg))
+	if err != nil {
+		t.Fatalf("NewTranslatorClient: %v", err)
+	}
+	defer client.Close()
+	got, err := client.Translate(ctx, "s1", "world", "en", "es", true, nil)
+	if err != nil || got != "hola world" {
+		t.Fatalf("Translate over TLS = %q, %v", got, err)
+	}
+
+	plain, err := NewTranslatorClient(ctx, listener.Addr().String())
+	if err != nil {
+		t.Fatalf("NewTranslatorClient: %v", err)
+	}
+	defer plain.Close()
+	if _, err := plain.Translate(ctx, "s1", "world", "en", "es", true, nil); err == nil {
+		t.Fatal("plaintext client reached a TLS server")
+	}
+}
@@ -6,7 +6,6 @@ import (
 	pb "ai-translator/api/proto"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type TranslatorClient struct {
@@ -14,8 +13,8 @@ type TranslatorClient struct {
 	client pb.TranslatorServiceClient
 }
 
-func NewTranslatorClient(ctx context.Context, address string) (*TranslatorClient, error) {
-	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func NewTranslatorClient(ctx context.Context, address string, opts ...Option) (*TranslatorClient, error) {
+	conn, err := dial(ctx, address, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -30,13 +29,31 @@ func (c *TranslatorClient) Close() error {
 	return c.conn.Close()
 }
 
-func (c *TranslatorClient) Translate(ctx context.Context, sessionID, text, sourceLang, targetLang string, isFinal bool) (string, error) {
+type TranslationStyle struct {
+	Formality pb.Formality
+	Domain    pb.Domain
+	Audience  string
+}
+
+func (s *TranslationStyle) toProto() *pb.TranslationStyle {
+	if s == nil {
+		return nil
+	}
+	return &pb.TranslationStyle{
+		Formality: s.Formality,
+		Domain:    s.Domain,
+		Audience:  s.Audience,
+	}
+}
+
+func (c *TranslatorClient) Translate(ctx context.Context, sessionID, text, sourceLang, targetLang string, isFinal bool, style *TranslationStyle) (string, error) {
 	resp, err := c.client.Translate(ctx, &pb.TranslateRequest{
 		SessionId:      sessionID,
 		Text:           text,
 		SourceLanguage: sourceLang,
 		TargetLanguage: targetLang,
 		IsFinal:        isFinal,
+		Style:          style.toProto(),
 	})
 	if err != nil {
 		return "", err
@@ -58,13 +75,14 @@ func (c *TranslatorClient) StartStream(ctx context.Context) (*TranslateStream, e
 	return &TranslateStream{stream: stream}, nil
 }
 
-func (s *TranslateStream) Send(sessionID, text, sourceLang, targetLang string, isFinal bool) error {
+func (s *TranslateStream) Send(sessionID, text, sourceLang, targetLang string, isFinal bool, style *TranslationStyle) error {
 	return s.stream.Send(&pb.TranslateRequest{
 		SessionId:      sessionID,
 		Text:           text,
 		SourceLanguage: sourceLang,
 		TargetLanguage: targetLang,
 		IsFinal:        isFinal,
+		Style:          style.toProto(),
 	})
 }
 
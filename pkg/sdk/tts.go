@@ -7,7 +7,6 @@ import (
 	pb "ai-translator/api/proto"
 
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 )
 
 type TTSClient struct {
@@ -15,8 +14,8 @@ type TTSClient struct {
 	client pb.TTSServiceClient
 }
 
-func NewTTSClient(ctx context.Context, address string) (*TTSClient, error) {
-	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func NewTTSClient(ctx context.Context, address string, opts ...Option) (*TTSClient, error) {
+	conn, err := dial(ctx, address, opts)
 	if err != nil {
 		return nil, err
 	}